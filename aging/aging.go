@@ -0,0 +1,177 @@
+/*
+Descrição: relatório de aging dos boletos em aberto (recebíveis)
+Os boletos emitidos, não pagos e não cancelados são distribuídos em faixas pelos dias em
+atraso na data de referência: a vencer (vencimento na data de referência ou depois),
+1 a 30, 31 a 60, 61 a 90 e mais de 90 dias. Os boletos sem data de vencimento ficam em
+uma faixa própria. O relatório agrupa os boletos por beneficiário e por pagador e é
+calculado da mesma forma pelo chaincode (query agingRecebiveis) e pela projeção.
+*/
+
+// Package aging calcula o relatório de aging dos recebíveis a partir dos boletos em
+// aberto, em JSON ou CSV.
+package aging
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/oracle"
+)
+
+// Formatos do relatório
+const (
+	FormatoJSON = "json"
+	FormatoCSV  = "csv"
+)
+
+// Titulo - boleto em aberto considerado no relatório
+type Titulo struct {
+	IDProposta     string
+	Beneficiario   string // CPF ou CNPJ (vazio: não informado na emissão)
+	CpfPagador     string
+	Valor          int64  // em centavos
+	DataVencimento string // AAAA-MM-DD (vazio: não informada na emissão)
+}
+
+// Faixa - quantidade e valor, em centavos, dos boletos de uma faixa
+type Faixa struct {
+	Quantidade int   `json:"quantidade"`
+	Valor      int64 `json:"valor"`
+}
+
+// Faixas - boletos em aberto distribuídos pelos dias em atraso
+type Faixas struct {
+	AVencer       Faixa `json:"a_vencer"`
+	Dias1a30      Faixa `json:"dias_1_30"`
+	Dias31a60     Faixa `json:"dias_31_60"`
+	Dias61a90     Faixa `json:"dias_61_90"`
+	Dias90Mais    Faixa `json:"dias_90_mais"`
+	SemVencimento Faixa `json:"sem_vencimento"`
+	Total         Faixa `json:"total"`
+}
+
+// Grupo - faixas dos boletos de um beneficiário ou de um pagador
+type Grupo struct {
+	Chave string `json:"chave"`
+	Faixas
+}
+
+// Relatorio - aging dos boletos em aberto na data de referência
+type Relatorio struct {
+	DataReferencia  string  `json:"data_referencia"`
+	PorBeneficiario []Grupo `json:"por_beneficiario"`
+	PorPagador      []Grupo `json:"por_pagador"`
+	Total           Faixas  `json:"total"`
+}
+
+// EmAberto: indica se o boleto da proposta entra no relatório (emitido, não pago e não cancelado)
+func EmAberto(nossoNumero string, pago, cancelada bool) bool {
+	return nossoNumero != "" && !pago && !cancelada
+}
+
+// DiasEmAtraso: dias entre o vencimento e a data de referência (zero ou negativo: a vencer).
+// Retorna false se o vencimento não for uma data AAAA-MM-DD.
+func DiasEmAtraso(vencimento string, referencia time.Time) (int, bool) {
+	v, err := time.Parse(oracle.LayoutData, vencimento)
+	if err != nil {
+		return 0, false
+	}
+	ref := time.Date(referencia.Year(), referencia.Month(), referencia.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ref.Sub(v).Hours() / 24), true
+}
+
+// incluir: soma o título à faixa correspondente e ao total
+func (f *Faixas) incluir(t Titulo, referencia time.Time) {
+	faixa := &f.SemVencimento
+	if dias, ok := DiasEmAtraso(t.DataVencimento, referencia); ok {
+		switch {
+		case dias <= 0:
+			faixa = &f.AVencer
+		case dias <= 30:
+			faixa = &f.Dias1a30
+		case dias <= 60:
+			faixa = &f.Dias31a60
+		case dias <= 90:
+			faixa = &f.Dias61a90
+		default:
+			faixa = &f.Dias90Mais
+		}
+	}
+	for _, fx := range []*Faixa{faixa, &f.Total} {
+		fx.Quantidade++
+		fx.Valor += t.Valor
+	}
+}
+
+// Calcular: relatório dos títulos na data de referência (considerada em UTC). Os grupos
+// são ordenados pela chave.
+func Calcular(titulos []Titulo, referencia time.Time) Relatorio {
+	referencia = referencia.UTC()
+	r := Relatorio{DataReferencia: referencia.Format(oracle.LayoutData)}
+	beneficiarios := make(map[string]*Faixas)
+	pagadores := make(map[string]*Faixas)
+	for _, t := range titulos {
+		r.Total.incluir(t, referencia)
+		faixasDe(beneficiarios, t.Beneficiario).incluir(t, referencia)
+		faixasDe(pagadores, t.CpfPagador).incluir(t, referencia)
+	}
+	r.PorBeneficiario = grupos(beneficiarios)
+	r.PorPagador = grupos(pagadores)
+	return r
+}
+
+// faixasDe: faixas do grupo com a chave informada, criadas na primeira consulta
+func faixasDe(m map[string]*Faixas, chave string) *Faixas {
+	f, ok := m[chave]
+	if !ok {
+		f = &Faixas{}
+		m[chave] = f
+	}
+	return f
+}
+
+// grupos: grupos ordenados pela chave
+func grupos(m map[string]*Faixas) []Grupo {
+	lista := []Grupo{}
+	for chave, f := range m {
+		lista = append(lista, Grupo{Chave: chave, Faixas: *f})
+	}
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Chave < lista[j].Chave })
+	return lista
+}
+
+// colunasCSV: cabeçalho do relatório em CSV; os valores das faixas são em centavos
+var colunasCSV = []string{
+	"agrupamento", "chave", "quantidade",
+	"a_vencer", "dias_1_30", "dias_31_60", "dias_61_90", "dias_90_mais", "sem_vencimento", "total",
+}
+
+// CSV: relatório em CSV, com uma linha por beneficiário, uma por pagador e a linha do total
+func (r Relatorio) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	linhas := [][]string{colunasCSV}
+	for _, g := range r.PorBeneficiario {
+		linhas = append(linhas, linhaCSV("beneficiario", g.Chave, g.Faixas))
+	}
+	for _, g := range r.PorPagador {
+		linhas = append(linhas, linhaCSV("pagador", g.Chave, g.Faixas))
+	}
+	linhas = append(linhas, linhaCSV("total", r.DataReferencia, r.Total))
+	if err := w.WriteAll(linhas); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// linhaCSV: quantidade de boletos do grupo e valor de cada faixa
+func linhaCSV(agrupamento, chave string, f Faixas) []string {
+	linha := []string{agrupamento, chave, strconv.Itoa(f.Total.Quantidade)}
+	for _, fx := range []Faixa{f.AVencer, f.Dias1a30, f.Dias31a60, f.Dias61a90, f.Dias90Mais, f.SemVencimento, f.Total} {
+		linha = append(linha, strconv.FormatInt(fx.Valor, 10))
+	}
+	return linha
+}
@@ -0,0 +1,168 @@
+package aging
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// referencia: data de referência dos testes, no fim do dia em São Paulo (dia seguinte em UTC)
+var referencia = time.Date(2026, 11, 30, 22, 30, 0, 0, time.FixedZone("BRT", -3*60*60))
+
+// faixaDoTitulo: tag JSON da faixa em que o título foi incluído ("" se em nenhuma ou em mais de uma)
+func faixaDoTitulo(t *testing.T, f Faixas) string {
+	t.Helper()
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var faixas map[string]Faixa
+	if err := json.Unmarshal(b, &faixas); err != nil {
+		t.Fatal(err)
+	}
+	nome := ""
+	for n, fx := range faixas {
+		if n == "total" || fx.Quantidade == 0 {
+			continue
+		}
+		if nome != "" || fx.Quantidade != 1 || fx.Valor != f.Total.Valor {
+			return ""
+		}
+		nome = n
+	}
+	return nome
+}
+
+func TestFaixas(t *testing.T) {
+	// a data de referência é 2026-12-01 em UTC
+	casos := []struct {
+		vencimento string
+		dias       int
+		faixa      string
+	}{
+		{"2027-01-15", -45, "a_vencer"},
+		{"2026-12-02", -1, "a_vencer"},
+		{"2026-12-01", 0, "a_vencer"},
+		{"2026-11-30", 1, "dias_1_30"},
+		{"2026-11-01", 30, "dias_1_30"},
+		{"2026-10-31", 31, "dias_31_60"},
+		{"2026-10-02", 60, "dias_31_60"},
+		{"2026-10-01", 61, "dias_61_90"},
+		{"2026-09-02", 90, "dias_61_90"},
+		{"2026-09-01", 91, "dias_90_mais"},
+		{"2025-12-01", 365, "dias_90_mais"},
+		{"", 0, "sem_vencimento"},
+		{"01/12/2026", 0, "sem_vencimento"},
+	}
+	for _, c := range casos {
+		t.Run(c.faixa+" "+c.vencimento, func(t *testing.T) {
+			dias, ok := DiasEmAtraso(c.vencimento, referencia.UTC())
+			if ok != (c.faixa != "sem_vencimento") || dias != c.dias {
+				t.Fatalf("DiasEmAtraso = %d, %v; esperado %d", dias, ok, c.dias)
+			}
+			var f Faixas
+			f.incluir(Titulo{Valor: 1500, DataVencimento: c.vencimento}, referencia.UTC())
+			if got := faixaDoTitulo(t, f); got != c.faixa {
+				t.Fatalf("faixa %q, esperada %q", got, c.faixa)
+			}
+			if f.Total != (Faixa{Quantidade: 1, Valor: 1500}) {
+				t.Fatalf("total %+v", f.Total)
+			}
+		})
+	}
+}
+
+func TestEmAberto(t *testing.T) {
+	casos := []struct {
+		nossoNumero     string
+		pago, cancelada bool
+		emAberto        bool
+	}{
+		{"00000000001", false, false, true},
+		{"", false, false, false},
+		{"00000000001", true, false, false},
+		{"00000000001", false, true, false},
+	}
+	for _, c := range casos {
+		if got := EmAberto(c.nossoNumero, c.pago, c.cancelada); got != c.emAberto {
+			t.Fatalf("EmAberto(%q, %v, %v) = %v", c.nossoNumero, c.pago, c.cancelada, got)
+		}
+	}
+}
+
+func TestCalcular(t *testing.T) {
+	titulos := []Titulo{
+		{IDProposta: "p1", Beneficiario: "B2", CpfPagador: "111", Valor: 1000, DataVencimento: "2026-12-10"},
+		{IDProposta: "p2", Beneficiario: "B1", CpfPagador: "111", Valor: 2000, DataVencimento: "2026-11-20"},
+		{IDProposta: "p3", Beneficiario: "B2", CpfPagador: "222", Valor: 4000, DataVencimento: "2026-08-01"},
+		{IDProposta: "p4", CpfPagador: "222", Valor: 8000},
+	}
+	r := Calcular(titulos, referencia)
+	if r.DataReferencia != "2026-12-01" {
+		t.Fatalf("data de referência %s, esperada 2026-12-01 (UTC)", r.DataReferencia)
+	}
+	total := Faixas{
+		AVencer:       Faixa{1, 1000},
+		Dias1a30:      Faixa{1, 2000},
+		Dias90Mais:    Faixa{1, 4000},
+		SemVencimento: Faixa{1, 8000},
+		Total:         Faixa{4, 15000},
+	}
+	if r.Total != total {
+		t.Fatalf("total %+v, esperado %+v", r.Total, total)
+	}
+
+	casos := []struct {
+		agrupamento string
+		grupos      []Grupo
+		chaves      string
+		totais      []Faixa
+	}{
+		// o beneficiário não informado é agrupado na chave vazia, a primeira na ordem
+		{"beneficiário", r.PorBeneficiario, ",B1,B2", []Faixa{{1, 8000}, {1, 2000}, {2, 5000}}},
+		{"pagador", r.PorPagador, "111,222", []Faixa{{2, 3000}, {2, 12000}}},
+	}
+	for _, c := range casos {
+		var chaves []string
+		for i, g := range c.grupos {
+			chaves = append(chaves, g.Chave)
+			if i < len(c.totais) && g.Total != c.totais[i] {
+				t.Fatalf("%s %q: total %+v, esperado %+v", c.agrupamento, g.Chave, g.Total, c.totais[i])
+			}
+		}
+		if strings.Join(chaves, ",") != c.chaves {
+			t.Fatalf("grupos por %s %v, esperados %s", c.agrupamento, chaves, c.chaves)
+		}
+	}
+
+	// sem títulos, os grupos são listas vazias (e não null no JSON)
+	b, err := json.Marshal(Calcular(nil, referencia))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"por_beneficiario":[],"por_pagador":[]`) {
+		t.Fatalf("relatório vazio %s", b)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	r := Calcular([]Titulo{
+		{IDProposta: "p1", Beneficiario: "12.345.678/0001-90", CpfPagador: "111", Valor: 1000, DataVencimento: "2026-11-20"},
+		{IDProposta: "p2", Beneficiario: "12.345.678/0001-90", CpfPagador: "222", Valor: 2000},
+	}, referencia)
+	b, err := r.CSV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperado := strings.Join([]string{
+		"agrupamento,chave,quantidade,a_vencer,dias_1_30,dias_31_60,dias_61_90,dias_90_mais,sem_vencimento,total",
+		"beneficiario,12.345.678/0001-90,2,0,1000,0,0,0,2000,3000",
+		"pagador,111,1,0,1000,0,0,0,0,1000",
+		"pagador,222,1,0,0,0,0,0,2000,2000",
+		"total,2026-12-01,2,0,1000,0,0,0,2000,3000",
+	}, "\n") + "\n"
+	if string(b) != esperado {
+		t.Fatalf("CSV\n%s\nesperado\n%s", b, esperado)
+	}
+}
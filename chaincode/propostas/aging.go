@@ -0,0 +1,75 @@
+/*
+Descrição: relatório de aging dos boletos em aberto (query agingRecebiveis)
+As faixas de atraso são calculadas pelo pacote aging a partir do vencimento informado
+em emitirBoleto, na data da transação ou na data de referência informada. A projeção
+calcula o mesmo relatório fora da rede (projecao -aging).
+*/
+
+package propostas
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/aging"
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// agingRecebiveis: função Query que retorna o aging dos boletos em aberto por beneficiário e
+// por pagador, recebendo os seguintes argumentos
+// args[0]: formato. json (padrão) ou csv (opcional)
+// args[1]: dataReferencia. Data de referência AAAA-MM-DD; padrão: data da transação (opcional)
+func (t *BoletoPropostaChaincode) agingRecebiveis(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	parametros, err := validation.AgingRecebiveis(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var referencia time.Time
+	if parametros.DataReferencia != "" {
+		referencia, _ = time.Parse(oracle.LayoutData, parametros.DataReferencia)
+	} else {
+		horario := horarioTransacao(stub)
+		if horario == 0 {
+			// sem o horário da transação, a data de referência deve ser informada
+			return nil, envelope.Novo(envelope.CampoObrigatorio, "campo", "dataReferencia")
+		}
+		referencia = time.Unix(horario, 0)
+	}
+
+	rows, err := stub.GetRows(nomeTabelaProposta, []shim.Column{})
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao listar as Propostas: %s", err)
+	}
+	var titulos []aging.Titulo
+	for row := range rows {
+		p, encontrada := propostaDaLinha(row)
+		if !encontrada || !aging.EmAberto(p.NossoNumero, p.BoletoPago, p.Cancelada) {
+			continue
+		}
+		titulos = append(titulos, aging.Titulo{
+			IDProposta:     p.ID,
+			Beneficiario:   p.Beneficiario,
+			CpfPagador:     p.CpfPagador,
+			Valor:          p.Valor,
+			DataVencimento: p.DataVencimento,
+		})
+	}
+
+	relatorio := aging.Calcular(titulos, referencia)
+	log.Debug("Aging calculado", "data_referencia", relatorio.DataReferencia, "boletos", relatorio.Total.Total.Quantidade)
+	if parametros.Formato == aging.FormatoCSV {
+		return relatorio.CSV()
+	}
+	b, err := json.Marshal(relatorio)
+	if err != nil {
+		return nil, envelope.Interno(err)
+	}
+	return b, nil
+}
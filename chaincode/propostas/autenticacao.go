@@ -0,0 +1,122 @@
+/*
+Descrição: verificação do chamador das funções protegidas (ver Configuracao.Autenticacao)
+*/
+
+package propostas
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/logging"
+)
+
+// registrarAdministrador: grava no Init a identidade de quem executou o deploy, utilizada
+// pelos modos metadata (metadata do chamador) e assinatura (certificado do chamador).
+// Sem autenticação, o metadata do deploy identifica o administrador das funções
+// restritas. Nos modos nenhuma e metadata, um deploy sem metadata não registra
+// administrador: as funções protegidas ficam indisponíveis até um novo deploy.
+func registrarAdministrador(stub shim.ChaincodeStubInterface, cfg Configuracao, log *logging.Logger) error {
+	var admin []byte
+	var err error
+	switch cfg.Autenticacao.Modo {
+	case AutenticacaoNenhuma, AutenticacaoMetadata:
+		// The metadata will contain the certificate of the administrator
+		admin, err = stub.GetCallerMetadata()
+		if err != nil {
+			return errors.New("Failed getting metadata")
+		}
+		if len(admin) == 0 {
+			log.Aviso("Deploy sem metadata: as funções protegidas ficam indisponíveis", "modo", cfg.Autenticacao.Modo)
+			return stub.DelState(chaveAdmin)
+		}
+	case AutenticacaoAssinatura:
+		admin, err = stub.GetCallerCertificate()
+		if err != nil {
+			return errors.New("Failed getting caller certificate")
+		}
+	default:
+		return nil
+	}
+	if len(admin) == 0 {
+		return errors.New("Invalid admin certificate. Empty.")
+	}
+	log.Info("Administrador registrado", "modo", cfg.Autenticacao.Modo, "admin", logging.Bytes(admin))
+	return stub.PutState(chaveAdmin, admin)
+}
+
+// verificarChamador: verifica se o chamador pode executar a função protegida. Sem
+// autenticação configurada, apenas as funções restritas chegam aqui, e o metadata do
+// chamador é comparado com o do deploy, como no modo metadata.
+func verificarChamador(stub shim.ChaincodeStubInterface, cfg Configuracao, funcao string, log *logging.Logger) error {
+	log.Debug("Verificando o chamador", "modo", cfg.Autenticacao.Modo)
+
+	switch cfg.Autenticacao.Modo {
+	case AutenticacaoNenhuma, AutenticacaoMetadata:
+		admin, err := stub.GetState(chaveAdmin)
+		if err != nil {
+			return errors.New("Failed fetching admin identity")
+		}
+		metadata, err := stub.GetCallerMetadata()
+		if err != nil {
+			return errors.New("Failed getting metadata")
+		}
+		if len(admin) == 0 || !bytes.Equal(admin, metadata) {
+			log.Aviso("Metadata do chamador diferente do administrador", "metadata", logging.Bytes(metadata))
+			return envelope.Novo(envelope.NaoAutorizado, "funcao", funcao)
+		}
+
+	case AutenticacaoAssinatura:
+		// Verify \sigma=Sign(certificate.sk, tx.Payload||tx.Binding) against certificate.vk
+		// \sigma is in the metadata
+		certificado, err := stub.GetState(chaveAdmin)
+		if err != nil {
+			return errors.New("Failed fetching admin identity")
+		}
+		sigma, err := stub.GetCallerMetadata()
+		if err != nil {
+			return errors.New("Failed getting metadata")
+		}
+		payload, err := stub.GetPayload()
+		if err != nil {
+			return errors.New("Failed getting payload")
+		}
+		binding, err := stub.GetBinding()
+		if err != nil {
+			return errors.New("Failed getting binding")
+		}
+		ok, err := stub.VerifySignature(certificado, sigma, append(payload, binding...))
+		if err != nil {
+			return fmt.Errorf("Failed checking signature [%s]", err)
+		}
+		if !ok {
+			log.Aviso("Assinatura do chamador inválida", "assinatura", logging.Bytes(sigma))
+			return envelope.Novo(envelope.NaoAutorizado, "funcao", funcao)
+		}
+
+	case AutenticacaoAtributos:
+		valor, err := stub.ReadCertAttribute(cfg.Autenticacao.Atributo)
+		if err != nil {
+			log.Aviso("Falha ao ler o atributo do chamador", "atributo", cfg.Autenticacao.Atributo, "erro", err)
+			return envelope.Novo(envelope.NaoAutorizado, "funcao", funcao)
+		}
+		autorizado := false
+		for _, v := range cfg.Autenticacao.Valores {
+			if string(valor) == v {
+				autorizado = true
+				break
+			}
+		}
+		if !autorizado {
+			log.Aviso("Atributo do chamador não autorizado", "atributo", cfg.Autenticacao.Atributo, "valor", string(valor))
+			return envelope.Novo(envelope.NaoAutorizado, "funcao", funcao)
+		}
+	}
+
+	log.Debug("Chamador verificado")
+	return nil
+}
@@ -0,0 +1,264 @@
+/*
+Descrição: registro da conciliação dos boletos com os extratos bancários
+A conciliação é calculada fora da rede (pacote reconciliation, cmd/conciliacao) e
+gravada pelo invoke registrarConciliacao: o registro completo, pelo ID da conciliação,
+e a situação de cada boleto conciliado, pelo ID da proposta. Os dados do ledger de cada
+item são preenchidos pelo chaincode a partir da proposta, e as situações que afirmam o
+pagamento no ledger são conferidas com o estado. Os auditores consultam uma conciliação
+com consultarConciliacao e a situação de todos os boletos com listarStatusConciliacao.
+*/
+
+package propostas
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/reconciliation"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// prefixos das chaves de estado das conciliações e da situação de cada boleto. Os IDs das
+// conciliações não contêm '~', que encerra o intervalo das chaves na exclusão.
+const (
+	prefixoConciliacao       = "conciliacao_"
+	prefixoStatusConciliacao = "statusconciliacao_"
+)
+
+// Conciliacao - conciliação gravada no estado
+type Conciliacao struct {
+	ID       string                `json:"id_conciliacao"`
+	Inicio   string                `json:"inicio,omitempty"`
+	Fim      string                `json:"fim,omitempty"`
+	Extratos []string              `json:"extratos,omitempty"`
+	Itens    []reconciliation.Item `json:"itens"`
+	Resumo   map[string]int        `json:"resumo"` // quantidade de itens por situação
+	TxID     string                `json:"tx_id"`
+	Horario  int64                 `json:"horario"` // horário da transação, em segundos (0: não informado pelo peer)
+}
+
+// StatusConciliacao - situação da conciliação de um boleto: a do item mais grave da última
+// conciliação que o incluiu (ver reconciliation.Situacoes)
+type StatusConciliacao struct {
+	IDProposta    string `json:"id_proposta"`
+	NossoNumero   string `json:"nosso_numero"`
+	EndToEndID    string `json:"end_to_end_id,omitempty"` // pagamento PIX
+	Valor         int64  `json:"valor"`
+	Status        string `json:"status"` // status atual da proposta
+	Situacao      string `json:"situacao"`
+	Detalhe       string `json:"detalhe,omitempty"`
+	IDConciliacao string `json:"id_conciliacao,omitempty"`
+	TxID          string `json:"tx_id,omitempty"`
+	Horario       int64  `json:"horario,omitempty"`
+}
+
+// RespostaConciliacao - resposta do invoke registrarConciliacao
+type RespostaConciliacao struct {
+	Operacao      string         `json:"operacao"`
+	IDConciliacao string         `json:"id_conciliacao"`
+	Boletos       int            `json:"boletos"` // propostas com a situação atualizada
+	Resumo        map[string]int `json:"resumo"`
+}
+
+// registrarConciliacao: função Invoke que grava a conciliação com os extratos, recebendo o
+// documento da conciliação (ver validation.RegistrarConciliacao)
+func (t *BoletoPropostaChaincode) registrarConciliacao(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	doc, err := validation.RegistrarConciliacao(args)
+	if err != nil {
+		return nil, err
+	}
+	log = log.Com("id_conciliacao", doc.ID)
+
+	anterior, err := stub.GetState(prefixoConciliacao + doc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao obter a conciliação %s: %s", doc.ID, err)
+	}
+	if len(anterior) > 0 {
+		return nil, envelope.Novo(envelope.ConciliacaoJaRegistrada, "id_conciliacao", doc.ID)
+	}
+
+	c := Conciliacao{
+		ID:       doc.ID,
+		Inicio:   doc.Inicio,
+		Fim:      doc.Fim,
+		Extratos: doc.Extratos,
+		Itens:    doc.Itens,
+		Resumo:   make(map[string]int),
+		TxID:     stub.GetTxID(),
+		Horario:  horarioTransacao(stub),
+	}
+	if c.Itens == nil {
+		c.Itens = []reconciliation.Item{}
+	}
+
+	// situação de cada boleto: o item mais grave, na ordem dos itens
+	status := make(map[string]*StatusConciliacao)
+	var ordem []string
+	for i := range c.Itens {
+		item := &c.Itens[i]
+		c.Resumo[item.Situacao]++
+		if item.IDProposta == "" {
+			continue
+		}
+		p, encontrada, err := obterProposta(stub, item.IDProposta)
+		if err != nil {
+			return nil, err
+		}
+		if !encontrada {
+			return nil, envelope.Novo(envelope.PropostaNaoEncontrada, "id", item.IDProposta)
+		}
+		// conciliado, somente_ledger e as divergências afirmam o pagamento no ledger
+		if afirmaPagamento(item.Situacao) && !p.BoletoPago {
+			return nil, envelope.Novo(envelope.ArgumentoInvalido,
+				"campo", fmt.Sprintf("itens[%d].situacao", i), "valor", item.Situacao+" (proposta com status "+p.Status+")")
+		}
+		item.NossoNumero, item.EndToEndID = p.NossoNumero, p.EndToEndID
+		item.ValorLedger, item.DataLedger = p.Valor, p.DataPagamento
+
+		s, ok := status[p.ID]
+		if !ok {
+			s = &StatusConciliacao{IDProposta: p.ID, Situacao: item.Situacao, Detalhe: item.Detalhe}
+			status[p.ID] = s
+			ordem = append(ordem, p.ID)
+		} else if reconciliation.Gravidade(item.Situacao) < reconciliation.Gravidade(s.Situacao) {
+			s.Situacao, s.Detalhe = item.Situacao, item.Detalhe
+		}
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, envelope.Interno(err)
+	}
+	if err := stub.PutState(prefixoConciliacao+c.ID, b); err != nil {
+		return nil, fmt.Errorf("Falha ao gravar a conciliação %s: %s", c.ID, err)
+	}
+	for _, id := range ordem {
+		s := status[id]
+		s.IDConciliacao, s.TxID, s.Horario = c.ID, c.TxID, c.Horario
+		b, err := json.Marshal(s)
+		if err != nil {
+			return nil, envelope.Interno(err)
+		}
+		if err := stub.PutState(prefixoStatusConciliacao+id, b); err != nil {
+			return nil, fmt.Errorf("Falha ao gravar a situação da conciliação da Proposta [%s]: %s", id, err)
+		}
+	}
+	log.Info("Conciliação registrada", "itens", len(c.Itens), "boletos", len(ordem))
+
+	b, err = json.Marshal(RespostaConciliacao{
+		Operacao:      envelope.OperacaoConciliacaoRegistrada,
+		IDConciliacao: c.ID,
+		Boletos:       len(ordem),
+		Resumo:        c.Resumo,
+	})
+	if err != nil {
+		return nil, envelope.Interno(err)
+	}
+	return b, nil
+}
+
+// afirmaPagamento: indica se a situação pressupõe o boleto pago no ledger
+func afirmaPagamento(situacao string) bool {
+	switch situacao {
+	case reconciliation.SituacaoConciliado, reconciliation.SituacaoSomenteLedger,
+		reconciliation.SituacaoValorDivergente, reconciliation.SituacaoDataDivergente:
+		return true
+	}
+	return false
+}
+
+// consultarConciliacao: função Query que retorna a conciliação gravada, recebendo o seguinte argumento
+// args[0]: idConciliacao. Identificador da conciliação
+func (t *BoletoPropostaChaincode) consultarConciliacao(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	id, err := validation.ConsultarConciliacao(args)
+	if err != nil {
+		return nil, err
+	}
+	b, err := stub.GetState(prefixoConciliacao + id)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao obter a conciliação %s: %s", id, err)
+	}
+	if len(b) == 0 {
+		return nil, envelope.Novo(envelope.ConciliacaoNaoEncontrada, "id_conciliacao", id)
+	}
+	return b, nil
+}
+
+// listarStatusConciliacao: função Query que retorna a situação da conciliação de cada boleto
+// emitido e de cada pagamento PIX (nao_conciliado se nenhuma conciliação o incluiu),
+// recebendo o seguinte argumento
+// args[0]: situacao. Lista apenas os boletos na situação informada (opcional)
+func (t *BoletoPropostaChaincode) listarStatusConciliacao(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	filtro, err := validation.ListarStatusConciliacao(args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stub.GetRows(nomeTabelaProposta, []shim.Column{})
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao listar as Propostas: %s", err)
+	}
+	var propostas []Proposta
+	for row := range rows {
+		if p, encontrada := propostaDaLinha(row); encontrada && (p.NossoNumero != "" || p.EndToEndID != "") {
+			propostas = append(propostas, p)
+		}
+	}
+
+	lista := []StatusConciliacao{}
+	for _, p := range propostas {
+		s := StatusConciliacao{Situacao: reconciliation.SituacaoNaoConciliado}
+		b, err := stub.GetState(prefixoStatusConciliacao + p.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Falha ao obter a situação da conciliação da Proposta [%s]: %s", p.ID, err)
+		}
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &s); err != nil {
+				return nil, fmt.Errorf("Situação da conciliação da Proposta [%s] gravada inválida: %s", p.ID, err)
+			}
+		}
+		s.IDProposta, s.NossoNumero, s.EndToEndID, s.Valor, s.Status = p.ID, p.NossoNumero, p.EndToEndID, p.Valor, p.Status
+		if filtro == "" || s.Situacao == filtro {
+			lista = append(lista, s)
+		}
+	}
+	log.Debug("Situação da conciliação listada", "boletos", len(lista), "situacao", filtro)
+
+	b, err := json.Marshal(lista)
+	if err != nil {
+		return nil, envelope.Interno(err)
+	}
+	return b, nil
+}
+
+// excluirConciliacoes: exclui do estado as conciliações e a situação dos boletos
+// (no Init, junto com as propostas). Retorna a quantidade de chaves excluídas.
+func excluirConciliacoes(stub shim.ChaincodeStubInterface) (int, error) {
+	var chaves []string
+	for _, prefixo := range []string{prefixoConciliacao, prefixoStatusConciliacao} {
+		iter, err := stub.RangeQueryState(prefixo, prefixo+"~")
+		if err != nil {
+			return 0, fmt.Errorf("Falha ao listar as conciliações: %s", err)
+		}
+		for iter.HasNext() {
+			chave, _, err := iter.Next()
+			if err != nil {
+				iter.Close()
+				return 0, fmt.Errorf("Falha ao listar as conciliações: %s", err)
+			}
+			chaves = append(chaves, chave)
+		}
+		iter.Close()
+	}
+
+	for _, chave := range chaves {
+		if err := stub.DelState(chave); err != nil {
+			return 0, fmt.Errorf("Falha ao excluir a conciliação %s: %s", chave, err)
+		}
+	}
+	return len(chaves), nil
+}
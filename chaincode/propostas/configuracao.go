@@ -0,0 +1,288 @@
+/*
+Descrição: configuração do chaincode de propostas, recebida em JSON no Init
+Substitui as cópias do chaincode (start, finished, cert e apicall): cada comportamento
+das variantes é selecionado por uma opção da configuração.
+*/
+
+package propostas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/pix"
+)
+
+// Modos de autenticação do chamador das funções protegidas
+const (
+	// AutenticacaoNenhuma: qualquer chamador (variante finished)
+	AutenticacaoNenhuma = "nenhuma"
+	// AutenticacaoMetadata: o metadata do chamador deve ser igual ao metadata enviado
+	// no deploy (variantes cert e apicall)
+	AutenticacaoMetadata = "metadata"
+	// AutenticacaoAssinatura: o metadata deve conter a assinatura de payload||binding
+	// da transação, verificada com o certificado de quem executou o deploy
+	AutenticacaoAssinatura = "assinatura"
+	// AutenticacaoAtributos: um atributo do certificado (TCert) do chamador deve ter
+	// um dos valores autorizados
+	AutenticacaoAtributos = "atributos"
+)
+
+// Modos de notificação externa
+const (
+	NotificacaoNenhuma = "nenhuma"
+	// NotificacaoHTTP: POST da proposta na API externa ao atualizar uma proposta (variante apicall)
+	NotificacaoHTTP = "http"
+)
+
+// Layouts da tabela 'Proposta'
+const (
+	// TabelaCompleta: colunas do boleto, do pagamento e do cancelamento (variante finished)
+	TabelaCompleta = "completa"
+	// TabelaSimples: apenas Id, cpfPagador, pagadorAceitou, beneficiarioAceitou e boletoPago
+	// (variantes start, cert e apicall). Sem emitirBoleto, confirmarPagamento e cancelarProposta.
+	TabelaSimples = "simples"
+)
+
+// URLAtualizar - API externa notificada pela variante apicall
+const URLAtualizar = "http://bc-desafio.mybluemix.net/atualizar"
+
+// chave de estado da configuração gravada no Init
+const chaveConfiguracao = "configuracao"
+
+// chave de estado do administrador (metadata ou certificado de quem executou o deploy)
+const chaveAdmin = "admin"
+
+// Configuracao - opções do chaincode, recebidas em JSON como primeiro argumento do Init
+type Configuracao struct {
+	Autenticacao Autenticacao `json:"autenticacao"`
+	Notificacao  Notificacao  `json:"notificacao"`
+	Tabela       string       `json:"tabela"`
+	// Idioma das mensagens de erro: pt-BR (padrão) ou en (ver pacote envelope)
+	Idioma string `json:"idioma,omitempty"`
+	// Nível do log do chaincode: debug, info (padrão), aviso ou erro (ver pacote logging)
+	NivelLog string `json:"nivel_log,omitempty"`
+	// Retenção das respostas das requisições idempotentes, no formato de time.ParseDuration
+	// (padrão: 24h; ver requisicoes.go)
+	RetencaoRequisicoes string `json:"retencao_requisicoes,omitempty"`
+	// Máximo de operações por lote de executarLote (padrão: 100)
+	LoteMaximo int `json:"lote_maximo,omitempty"`
+	// Oraculos: chaves públicas (PEM) dos oráculos dos bancos, por código do banco.
+	// Também podem ser informadas no Init em pares (codigoBanco, chavePublica).
+	Oraculos map[string]string `json:"oraculos,omitempty"`
+	// Pix: conta que recebe os pagamentos PIX das propostas, utilizada no BR Code gerado
+	// por gerarBRCode (nil: cobrança PIX desabilitada; ver pix.go)
+	Pix *pix.Recebedor `json:"pix,omitempty"`
+}
+
+// Autenticacao - verificação do chamador das funções protegidas
+type Autenticacao struct {
+	Modo string `json:"modo"`
+	// Funcoes protegidas (invokes ou queries). Padrão: as funções com o papel administrador
+	// no registro (registrarProposta, aceitarProposta, emitirBoleto, cancelarProposta...).
+	Funcoes []string `json:"funcoes,omitempty"`
+	// Atributo do certificado verificado no modo atributos (padrão: role)
+	Atributo string `json:"atributo,omitempty"`
+	// Valores do atributo autorizados (padrão: admin)
+	Valores []string `json:"valores,omitempty"`
+}
+
+// Notificacao - chamada da API externa
+type Notificacao struct {
+	Modo string `json:"modo"`
+	URL  string `json:"url,omitempty"` // padrão: URLAtualizar
+	// Segredo: se informado, o corpo é assinado com HMAC-SHA256 no cabeçalho X-Assinatura.
+	// Recusado no JSON do Init e nunca gravado no estado (ver segredoNotificacao).
+	Segredo string `json:"segredo,omitempty"`
+	// NaCriacao: notifica também o registro de novas propostas (a variante apicall
+	// notifica apenas as atualizações)
+	NaCriacao bool `json:"na_criacao,omitempty"`
+}
+
+// ConfiguracaoPadrao - configuração utilizada quando o Init não recebe nenhuma:
+// o comportamento da variante finished
+func ConfiguracaoPadrao() Configuracao {
+	return Configuracao{
+		Autenticacao: Autenticacao{Modo: AutenticacaoNenhuma},
+		Notificacao:  Notificacao{Modo: NotificacaoNenhuma},
+		Tabela:       TabelaCompleta,
+		Idioma:       envelope.IdiomaPadrao,
+		NivelLog:     logging.NivelPadrao.String(),
+
+		RetencaoRequisicoes: RetencaoRequisicoesPadrao,
+		LoteMaximo:          LoteMaximoPadrao,
+	}
+}
+
+// DecodificarConfiguracao: converte o JSON da configuração, preenchendo os valores padrão
+// e rejeitando campos e modos desconhecidos
+func DecodificarConfiguracao(configuracaoJSON []byte) (Configuracao, error) {
+	cfg := ConfiguracaoPadrao()
+	dec := json.NewDecoder(bytes.NewReader(configuracaoJSON))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", err.Error())
+	}
+	cfg.preencherPadroes()
+	return cfg, cfg.Validar()
+}
+
+// Validar: verifica os modos e as opções de cada modo
+func (cfg Configuracao) Validar() error {
+	switch cfg.Autenticacao.Modo {
+	case AutenticacaoNenhuma, AutenticacaoMetadata, AutenticacaoAssinatura, AutenticacaoAtributos:
+	default:
+		return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "modo de autenticação desconhecido ["+cfg.Autenticacao.Modo+"]")
+	}
+	for _, funcao := range cfg.Autenticacao.Funcoes {
+		_, invoke := buscarFuncao(funcao, TipoInvoke)
+		_, query := buscarFuncao(funcao, TipoQuery)
+		if !invoke && !query {
+			return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "função protegida desconhecida ["+funcao+"]")
+		}
+	}
+	switch cfg.Notificacao.Modo {
+	case NotificacaoNenhuma:
+	case NotificacaoHTTP:
+		if !strings.HasPrefix(cfg.Notificacao.URL, "http://") && !strings.HasPrefix(cfg.Notificacao.URL, "https://") {
+			return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "URL de notificação inválida ["+cfg.Notificacao.URL+"]")
+		}
+	default:
+		return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "modo de notificação desconhecido ["+cfg.Notificacao.Modo+"]")
+	}
+	switch cfg.Tabela {
+	case TabelaCompleta, TabelaSimples:
+	default:
+		return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "layout de tabela desconhecido ["+cfg.Tabela+"]")
+	}
+	if cfg.Tabela == TabelaSimples && len(cfg.Oraculos) > 0 {
+		return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "oráculos exigem a tabela completa")
+	}
+	if cfg.Pix != nil {
+		if cfg.Tabela == TabelaSimples {
+			return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "a cobrança PIX exige a tabela completa")
+		}
+		if err := cfg.Pix.Validar(); err != nil {
+			return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "pix: "+err.Error())
+		}
+	}
+	if !envelope.IdiomaSuportado(cfg.Idioma) {
+		return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "idioma não suportado ["+cfg.Idioma+"]")
+	}
+	if _, err := logging.ConverterNivel(cfg.NivelLog); err != nil {
+		return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", err.Error())
+	}
+	if retencao, err := time.ParseDuration(cfg.RetencaoRequisicoes); err != nil || retencao <= 0 {
+		return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "retenção das requisições inválida ["+cfg.RetencaoRequisicoes+"]")
+	}
+	if cfg.LoteMaximo < 1 {
+		return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "máximo de operações por lote inválido ["+strconv.Itoa(cfg.LoteMaximo)+"]")
+	}
+	return nil
+}
+
+// preencherPadroes: valores padrão das opções não informadas
+func (cfg *Configuracao) preencherPadroes() {
+	if cfg.Autenticacao.Modo == "" {
+		cfg.Autenticacao.Modo = AutenticacaoNenhuma
+	}
+	if len(cfg.Autenticacao.Funcoes) == 0 {
+		cfg.Autenticacao.Funcoes = funcoesAdministrador()
+	}
+	if cfg.Autenticacao.Modo == AutenticacaoAtributos {
+		if cfg.Autenticacao.Atributo == "" {
+			cfg.Autenticacao.Atributo = "role"
+		}
+		if len(cfg.Autenticacao.Valores) == 0 {
+			cfg.Autenticacao.Valores = []string{"admin"}
+		}
+	}
+	if cfg.Notificacao.Modo == "" {
+		cfg.Notificacao.Modo = NotificacaoNenhuma
+	}
+	if cfg.Notificacao.Modo == NotificacaoHTTP && cfg.Notificacao.URL == "" {
+		cfg.Notificacao.URL = URLAtualizar
+	}
+	if cfg.Tabela == "" {
+		cfg.Tabela = TabelaCompleta
+	}
+	if cfg.Idioma == "" {
+		cfg.Idioma = envelope.IdiomaPadrao
+	}
+	if cfg.NivelLog == "" {
+		cfg.NivelLog = logging.NivelPadrao.String()
+	}
+	if cfg.RetencaoRequisicoes == "" {
+		cfg.RetencaoRequisicoes = RetencaoRequisicoesPadrao
+	}
+	if cfg.LoteMaximo == 0 {
+		cfg.LoteMaximo = LoteMaximoPadrao
+	}
+}
+
+// logger: log da transação atual no nível configurado
+func (cfg Configuracao) logger(stub shim.ChaincodeStubInterface, funcao string) *logging.Logger {
+	log := logging.Novo(stub.GetTxID(), funcao)
+	if nivel, err := logging.ConverterNivel(cfg.NivelLog); err == nil {
+		log.Nivel = nivel
+	}
+	return log
+}
+
+// protegida: indica se a função exige a autenticação do chamador. As funções restritas
+// (init e registrarOraculo) são sempre protegidas.
+func (cfg Configuracao) protegida(funcao string) bool {
+	if f, ok := buscarFuncao(funcao, TipoInvoke); ok && f.Restrita {
+		return true
+	}
+	if cfg.Autenticacao.Modo == AutenticacaoNenhuma {
+		return false
+	}
+	for _, f := range cfg.Autenticacao.Funcoes {
+		if f == funcao {
+			return true
+		}
+	}
+	return false
+}
+
+// gravarConfiguracao: grava a configuração no estado, sem os oráculos (registrados
+// em chaves próprias) e sem o segredo da notificação (obtido a cada transação)
+func gravarConfiguracao(stub shim.ChaincodeStubInterface, cfg Configuracao) error {
+	cfg.Oraculos = nil
+	cfg.Notificacao.Segredo = ""
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := stub.PutState(chaveConfiguracao, b); err != nil {
+		return fmt.Errorf("Falha ao gravar a configuração: %s", err)
+	}
+	return nil
+}
+
+// carregarConfiguracao: configuração gravada no Init. Um chaincode implantado antes da
+// configuração (sem a chave no estado) utiliza a configuração padrão.
+func carregarConfiguracao(stub shim.ChaincodeStubInterface) (Configuracao, error) {
+	b, err := stub.GetState(chaveConfiguracao)
+	if err != nil {
+		return Configuracao{}, fmt.Errorf("Falha ao obter a configuração: %s", err)
+	}
+	if len(b) == 0 {
+		return ConfiguracaoPadrao(), nil
+	}
+	var cfg Configuracao
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("Configuração gravada inválida: %s", err)
+	}
+	cfg.preencherPadroes()
+	return cfg, nil
+}
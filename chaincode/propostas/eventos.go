@@ -0,0 +1,96 @@
+/*
+Descrição: emissão dos eventos de mudança de estado das propostas (ver pacote events)
+*/
+
+package propostas
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/logging"
+)
+
+// emitirEvento: publica o evento da transação atual. O fabric v0.6 entrega apenas
+// um evento por transação, portanto cada função deve chamá-la no máximo uma vez.
+func emitirEvento(stub shim.ChaincodeStubInterface, tipo events.Tipo, idProposta string, alterados events.Campos, log *logging.Logger) error {
+	return publicarEvento(stub, events.Evento{
+		Tipo:       tipo,
+		IDProposta: idProposta,
+		Alterados:  alterados,
+	}, log)
+}
+
+// publicarEvento: preenche o ID e o horário da transação e publica o evento
+func publicarEvento(stub shim.ChaincodeStubInterface, evento events.Evento, log *logging.Logger) error {
+	evento.TxID = stub.GetTxID()
+	if ts, err := stub.GetTxTimestamp(); err == nil && ts != nil {
+		evento.Horario = time.Unix(ts.Seconds, int64(ts.Nanos)).UTC().Format(time.RFC3339Nano)
+	}
+
+	payload, err := events.Codificar(evento)
+	if err != nil {
+		return fmt.Errorf("Falha ao codificar o evento %s: %s", evento.Tipo, err)
+	}
+	if err := stub.SetEvent(string(evento.Tipo), payload); err != nil {
+		return fmt.Errorf("Falha ao emitir o evento %s: %s", evento.Tipo, err)
+	}
+	log.Debug("Evento emitido", "evento", string(evento.Tipo))
+	return nil
+}
+
+// statusProposta: status derivado dos campos da proposta
+func statusProposta(p Proposta) string {
+	return events.DerivarStatus(p.PagadorAceitou, p.BeneficiarioAceitou, p.NossoNumero, p.BoletoPago, p.Cancelada)
+}
+
+// camposAlterados: compara a proposta antes e depois de registrarProposta e escolhe o
+// tipo do evento pela alteração mais relevante (pagamento > boleto > aceite > demais)
+func camposAlterados(antes, depois Proposta) (events.Tipo, events.Campos) {
+	var c events.Campos
+	tipo := events.PropostaAtualizada
+
+	if antes.CpfPagador != depois.CpfPagador {
+		c.CpfPagador = events.String(depois.CpfPagador)
+	}
+	if antes.PagadorAceitou != depois.PagadorAceitou {
+		c.PagadorAceitou = events.Bool(depois.PagadorAceitou)
+		if depois.PagadorAceitou {
+			tipo = events.PropostaAceita
+		}
+	}
+	if antes.BeneficiarioAceitou != depois.BeneficiarioAceitou {
+		c.BeneficiarioAceitou = events.Bool(depois.BeneficiarioAceitou)
+		if depois.BeneficiarioAceitou {
+			tipo = events.PropostaAceita
+		}
+	}
+	if antes.NossoNumero != depois.NossoNumero || antes.Valor != depois.Valor {
+		c.NossoNumero = events.String(depois.NossoNumero)
+		c.Valor = events.Int64(depois.Valor)
+		if depois.NossoNumero != "" {
+			tipo = events.BoletoEmitido
+		}
+	}
+	if antes.DataVencimento != depois.DataVencimento {
+		c.DataVencimento = events.String(depois.DataVencimento)
+	}
+	if antes.Beneficiario != depois.Beneficiario {
+		c.Beneficiario = events.String(depois.Beneficiario)
+	}
+	if antes.BoletoPago != depois.BoletoPago {
+		c.BoletoPago = events.Bool(depois.BoletoPago)
+		if depois.BoletoPago {
+			tipo = events.PagamentoRegistrado
+		}
+	}
+
+	statusAntes, statusDepois := statusProposta(antes), statusProposta(depois)
+	if statusAntes != statusDepois {
+		c.Status = events.String(statusDepois)
+	}
+	return tipo, c
+}
@@ -0,0 +1,47 @@
+package propostas
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/CaueP/BlockchainDojo/events"
+)
+
+func TestCamposAlterados(t *testing.T) {
+	antes := Proposta{ID: "p1", CpfPagador: "111.111.111-11", PagadorAceitou: true, BeneficiarioAceitou: true,
+		NossoNumero: "00000000001", Valor: 15000, DataVencimento: "2026-11-30", Beneficiario: "12.345.678/0001-90"}
+	casos := []struct {
+		nome      string
+		alterar   func(p *Proposta)
+		tipo      events.Tipo
+		alterados string
+	}{
+		{"sem alteração", func(p *Proposta) {}, events.PropostaAtualizada, `{}`},
+		{"CPF", func(p *Proposta) { p.CpfPagador = "222.222.222-22" }, events.PropostaAtualizada,
+			`{"cpf_pagador":"222.222.222-22"}`},
+		{"vencimento", func(p *Proposta) { p.DataVencimento = "2026-12-15" }, events.PropostaAtualizada,
+			`{"data_vencimento":"2026-12-15"}`},
+		{"vencimento removido", func(p *Proposta) { p.DataVencimento = "" }, events.PropostaAtualizada,
+			`{"data_vencimento":""}`},
+		{"beneficiário", func(p *Proposta) { p.Beneficiario = "98.765.432/0001-10" }, events.PropostaAtualizada,
+			`{"beneficiario":"98.765.432/0001-10"}`},
+		{"boleto", func(p *Proposta) { p.NossoNumero, p.Valor, p.DataVencimento = "00000000002", 9000, "" }, events.BoletoEmitido,
+			`{"nosso_numero":"00000000002","valor":9000,"data_vencimento":""}`},
+		{"aceite do pagador desfeito", func(p *Proposta) { p.PagadorAceitou = false }, events.PropostaAtualizada,
+			`{"pagador_aceitou":false}`},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			depois := antes
+			c.alterar(&depois)
+			tipo, alterados := camposAlterados(antes, depois)
+			b, err := json.Marshal(alterados)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tipo != c.tipo || string(b) != c.alterados {
+				t.Fatalf("evento %s %s, esperado %s %s", tipo, b, c.tipo, c.alterados)
+			}
+		})
+	}
+}
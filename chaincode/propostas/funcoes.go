@@ -0,0 +1,336 @@
+/*
+Descrição: registro das funções do chaincode de propostas
+Cada função declara o nome, o tipo (invoke ou query), os argumentos, o papel exigido do
+chamador e uma descrição. O Invoke e o Query despacham as chamadas pelo registro, e a
+query listarFuncoes publica o catálogo em JSON para a geração de clientes.
+*/
+
+package propostas
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// Tipos de função
+const (
+	TipoInvoke = "invoke"
+	TipoQuery  = "query"
+)
+
+// Papéis exigidos do chamador
+const (
+	// PapelQualquer: qualquer chamador
+	PapelQualquer = ""
+	// PapelAdministrador: o chamador deve passar pela autenticação configurada (ver
+	// Configuracao.Autenticacao). As funções com este papel são as protegidas por padrão,
+	// e as restritas exigem o papel em qualquer configuração. Sem autenticação (modo
+	// nenhuma), o papel das funções não restritas não é verificado.
+	PapelAdministrador = "administrador"
+)
+
+// Funcao - função do chaincode no registro e no catálogo de listarFuncoes
+type Funcao struct {
+	Nome       string      `json:"nome"`
+	Tipo       string      `json:"tipo"`
+	Descricao  string      `json:"descricao"`
+	Argumentos []Argumento `json:"argumentos"`
+	// Documento: esquema JSON do documento aceito no lugar dos argumentos posicionais
+	// (ver pacote validation)
+	Documento json.RawMessage `json:"documento,omitempty"`
+	// Papel exigido do chamador; no catálogo, o papel efetivo com a configuração do Init
+	Papel string `json:"papel,omitempty"`
+	// Restrita: exige o papel administrador mesmo sem autenticação configurada ou fora
+	// das funções protegidas da configuração (ver autenticacao.go)
+	Restrita bool `json:"-"`
+	// TabelaCompleta: indisponível com a tabela simples
+	TabelaCompleta bool `json:"tabela_completa,omitempty"`
+	// Disponivel: no catálogo, indica se a função pode ser chamada com a configuração do Init
+	Disponivel bool `json:"disponivel"`
+	// Idempotente: aceita o ID da requisição do cliente (ver requisicoes.go)
+	Idempotente bool `json:"idempotente,omitempty"`
+
+	executar execucao
+}
+
+// Argumento - argumento posicional de uma função
+type Argumento struct {
+	Nome      string `json:"nome"`
+	Tipo      string `json:"tipo"` // string, boolean, integer ou json
+	Descricao string `json:"descricao"`
+	Opcional  bool   `json:"opcional,omitempty"`
+}
+
+// execucao: implementação da função, com a configuração e o log da transação
+type execucao func(t *BoletoPropostaChaincode, stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error)
+
+// funcoes: registro das funções, na ordem do catálogo (preenchido no init do pacote,
+// pois listarFuncoes consulta o próprio registro)
+var funcoes []*Funcao
+
+func init() {
+	funcoes = []*Funcao{
+		{
+			Nome:      "init",
+			Tipo:      TipoInvoke,
+			Descricao: "Reinicia o estado, recriando a tabela de propostas, com a configuração informada; os oráculos do deploy são mantidos",
+			Argumentos: []Argumento{
+				{Nome: "configuracao", Tipo: "json", Descricao: "Configuração do chaincode (ver Configuracao)", Opcional: true},
+			},
+			Papel:    PapelAdministrador,
+			Restrita: true,
+			executar: func(t *BoletoPropostaChaincode, stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+				return t.iniciar(stub, args, true)
+			},
+		},
+		{
+			Nome:      "registrarProposta",
+			Tipo:      TipoInvoke,
+			Descricao: "Registra uma nova proposta ou atualiza uma já existente, ainda não paga nem cancelada",
+			Argumentos: []Argumento{
+				{Nome: "id_proposta", Tipo: "string", Descricao: "Hash que identifica a proposta"},
+				{Nome: "cpf_pagador", Tipo: "string", Descricao: "CPF do pagador"},
+				{Nome: "pagador_aceitou", Tipo: "boolean", Descricao: "Aceite do pagador"},
+				{Nome: "beneficiario_aceitou", Tipo: "boolean", Descricao: "Aceite do beneficiário"},
+				{Nome: "boleto_pago", Tipo: "boolean", Descricao: "Pagamento do boleto (false com a tabela completa: ver confirmarPagamento)"},
+				{Nome: "nosso_numero", Tipo: "string", Descricao: "Nosso número do boleto, junto com o valor", Opcional: true},
+				{Nome: "valor", Tipo: "integer", Descricao: "Valor do boleto em centavos, junto com o nosso número", Opcional: true},
+			},
+			Papel:    PapelAdministrador,
+			executar: (*BoletoPropostaChaincode).registrarProposta,
+		},
+		{
+			Nome:      "aceitarProposta",
+			Tipo:      TipoInvoke,
+			Descricao: "Registra o aceite do pagador ou do beneficiário",
+			Argumentos: []Argumento{
+				{Nome: "id_proposta", Tipo: "string", Descricao: "Hash da proposta"},
+				{Nome: "parte", Tipo: "string", Descricao: "pagador ou beneficiario"},
+			},
+			Papel:    PapelAdministrador,
+			executar: (*BoletoPropostaChaincode).aceitarProposta,
+		},
+		{
+			Nome:      "emitirBoleto",
+			Tipo:      TipoInvoke,
+			Descricao: "Registra o boleto emitido para uma proposta aceita pelas duas partes, ainda sem boleto",
+			Argumentos: []Argumento{
+				{Nome: "id_proposta", Tipo: "string", Descricao: "Hash da proposta"},
+				{Nome: "nosso_numero", Tipo: "string", Descricao: "Nosso número do boleto"},
+				{Nome: "valor", Tipo: "integer", Descricao: "Valor do boleto em centavos"},
+				{Nome: "data_vencimento", Tipo: "string", Descricao: "Data de vencimento do boleto (AAAA-MM-DD)", Opcional: true},
+				{Nome: "beneficiario", Tipo: "string", Descricao: "CPF ou CNPJ do beneficiário", Opcional: true},
+			},
+			Papel:          PapelAdministrador,
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).emitirBoleto,
+		},
+		{
+			Nome:      "confirmarPagamento",
+			Tipo:      TipoInvoke,
+			Descricao: "Liquida a proposta a partir do atestado de pagamento (boleto ou PIX) assinado pelo oráculo do banco",
+			Argumentos: []Argumento{
+				{Nome: "id_proposta", Tipo: "string", Descricao: "Hash da proposta"},
+				{Nome: "atestado", Tipo: "json", Descricao: "Atestado com codigo_banco, nosso_numero (boleto) ou end_to_end_id e txid (PIX), valor, data_pagamento e assinatura"},
+			},
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).confirmarPagamento,
+		},
+		{
+			Nome:      "registrarCobrancaPix",
+			Tipo:      TipoInvoke,
+			Descricao: "Registra uma única vez a cobrança PIX de uma proposta aceita, com o valor da proposta, e retorna o BR Code, com o ID da proposta como txid",
+			Argumentos: []Argumento{
+				{Nome: "id_proposta", Tipo: "string", Descricao: "Hash da proposta"},
+				{Nome: "valor", Tipo: "integer", Descricao: "Valor em centavos, conferido com o valor da proposta", Opcional: true},
+			},
+			Papel:          PapelAdministrador,
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).registrarCobrancaPix,
+		},
+		{
+			Nome:      "cancelarProposta",
+			Tipo:      TipoInvoke,
+			Descricao: "Cancela uma proposta ainda não paga",
+			Argumentos: []Argumento{
+				{Nome: "id_proposta", Tipo: "string", Descricao: "Hash da proposta"},
+				{Nome: "motivo", Tipo: "string", Descricao: "Motivo do cancelamento"},
+			},
+			Papel:          PapelAdministrador,
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).cancelarProposta,
+		},
+		{
+			Nome:      "executarLote",
+			Tipo:      TipoInvoke,
+			Descricao: "Executa em uma transação uma lista de operações (registrarProposta, aceitarProposta, confirmarPagamento e cancelarProposta): todas são aplicadas ou nenhuma",
+			Argumentos: []Argumento{
+				{Nome: "lote", Tipo: "json", Descricao: "Documento com as operações, cada uma com a funcao e o documento da função"},
+			},
+			Papel:    PapelAdministrador,
+			executar: (*BoletoPropostaChaincode).executarLote,
+		},
+		{
+			Nome:      "registrarConciliacao",
+			Tipo:      TipoInvoke,
+			Descricao: "Registra a conciliação dos boletos pagos com os extratos bancários e a situação de cada boleto conciliado",
+			Argumentos: []Argumento{
+				{Nome: "conciliacao", Tipo: "json", Descricao: "Documento com o id_conciliacao, o período, os extratos e os itens da conciliação"},
+			},
+			Papel:          PapelAdministrador,
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).registrarConciliacao,
+		},
+		{
+			Nome:      "registrarOraculo",
+			Tipo:      TipoInvoke,
+			Descricao: "Registra ou substitui a chave pública do oráculo de um banco depois do deploy",
+			Argumentos: []Argumento{
+				{Nome: "codigo_banco", Tipo: "string", Descricao: "Código do banco (3 dígitos)"},
+				{Nome: "chave_publica", Tipo: "string", Descricao: "Chave pública ECDSA do oráculo em PEM"},
+			},
+			Papel:          PapelAdministrador,
+			Restrita:       true,
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).registrarOraculo,
+		},
+		{
+			Nome:       "expurgarRequisicoes",
+			Tipo:       TipoInvoke,
+			Descricao:  "Exclui do estado as respostas das requisições idempotentes fora da retenção",
+			Argumentos: []Argumento{},
+			Papel:      PapelAdministrador,
+			executar:   (*BoletoPropostaChaincode).expurgarRequisicoes,
+		},
+		{
+			Nome:      "consultarProposta",
+			Tipo:      TipoQuery,
+			Descricao: "Consulta uma proposta existente",
+			Argumentos: []Argumento{
+				{Nome: "id_proposta", Tipo: "string", Descricao: "Hash da proposta"},
+			},
+			executar: (*BoletoPropostaChaincode).consultarProposta,
+		},
+		{
+			Nome:       "listarPropostas",
+			Tipo:       TipoQuery,
+			Descricao:  "Lista todas as propostas registradas",
+			Argumentos: []Argumento{},
+			executar:   (*BoletoPropostaChaincode).listarPropostas,
+		},
+		{
+			Nome:      "agingRecebiveis",
+			Tipo:      TipoQuery,
+			Descricao: "Aging dos boletos em aberto por beneficiário e por pagador (a vencer, 1-30, 31-60, 61-90 e mais de 90 dias em atraso)",
+			Argumentos: []Argumento{
+				{Nome: "formato", Tipo: "string", Descricao: "json (padrão) ou csv", Opcional: true},
+				{Nome: "data_referencia", Tipo: "string", Descricao: "Data de referência (AAAA-MM-DD); padrão: data da transação", Opcional: true},
+			},
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).agingRecebiveis,
+		},
+		{
+			Nome:      "gerarBRCode",
+			Tipo:      TipoQuery,
+			Descricao: "Gera o BR Code (PIX copia e cola) da cobrança registrada por registrarCobrancaPix, com o ID da proposta como txid",
+			Argumentos: []Argumento{
+				{Nome: "id_proposta", Tipo: "string", Descricao: "Hash da proposta"},
+			},
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).gerarBRCode,
+		},
+		{
+			Nome:      "consultarConciliacao",
+			Tipo:      TipoQuery,
+			Descricao: "Consulta uma conciliação registrada, com os itens e a quantidade por situação",
+			Argumentos: []Argumento{
+				{Nome: "id_conciliacao", Tipo: "string", Descricao: "Identificador da conciliação"},
+			},
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).consultarConciliacao,
+		},
+		{
+			Nome:      "listarStatusConciliacao",
+			Tipo:      TipoQuery,
+			Descricao: "Lista a situação da conciliação de cada boleto emitido (nao_conciliado se nenhuma conciliação o incluiu)",
+			Argumentos: []Argumento{
+				{Nome: "situacao", Tipo: "string", Descricao: "Lista apenas os boletos na situação informada", Opcional: true},
+			},
+			TabelaCompleta: true,
+			executar:       (*BoletoPropostaChaincode).listarStatusConciliacao,
+		},
+		{
+			Nome:       "listarFuncoes",
+			Tipo:       TipoQuery,
+			Descricao:  "Lista as funções do chaincode, com os argumentos e o papel exigido",
+			Argumentos: []Argumento{},
+			executar:   (*BoletoPropostaChaincode).listarFuncoes,
+		},
+		{
+			Nome:       "versao",
+			Tipo:       TipoQuery,
+			Descricao:  "Retorna a versão do chaincode, o commit do build, a versão do esquema e as funcionalidades habilitadas",
+			Argumentos: []Argumento{},
+			executar:   (*BoletoPropostaChaincode).versao,
+		},
+	}
+	for _, f := range funcoes {
+		if esquema, ok := validation.EsquemaJSON(f.Nome); ok {
+			f.Documento = json.RawMessage(esquema)
+			// as funções Invoke com documento aceitam o ID da requisição
+			f.Idempotente = f.Tipo == TipoInvoke
+		}
+	}
+}
+
+// buscarFuncao: função registrada com o nome e o tipo informados
+func buscarFuncao(nome, tipo string) (*Funcao, bool) {
+	for _, f := range funcoes {
+		if f.Nome == nome && f.Tipo == tipo {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// funcoesAdministrador: funções protegidas por padrão (papel administrador)
+func funcoesAdministrador() []string {
+	var nomes []string
+	for _, f := range funcoes {
+		if f.Papel == PapelAdministrador {
+			nomes = append(nomes, f.Nome)
+		}
+	}
+	return nomes
+}
+
+// disponivel: indica se a função pode ser executada com a tabela configurada
+func (f *Funcao) disponivel(cfg Configuracao) bool {
+	return !f.TabelaCompleta || cfg.Tabela == TabelaCompleta
+}
+
+// listarFuncoes: função Query que retorna o catálogo das funções em JSON, sem argumentos.
+// O papel e a disponibilidade de cada função refletem a configuração do Init.
+func (t *BoletoPropostaChaincode) listarFuncoes(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	if err := validation.ListarFuncoes(args); err != nil {
+		return nil, err
+	}
+
+	catalogo := make([]Funcao, 0, len(funcoes))
+	for _, f := range funcoes {
+		c := *f
+		c.Papel = PapelQualquer
+		if cfg.protegida(f.Nome) {
+			c.Papel = PapelAdministrador
+		}
+		c.Disponivel = f.disponivel(cfg)
+		catalogo = append(catalogo, c)
+	}
+	log.Debug("Funções listadas", "quantidade", len(catalogo))
+
+	return json.Marshal(catalogo)
+}
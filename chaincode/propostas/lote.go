@@ -0,0 +1,127 @@
+/*
+Descrição: lote de operações de propostas (executarLote)
+As operações são executadas em ordem na mesma transação; se uma delas falhar, a função
+retorna o erro LOTE_REJEITADO com a posição da operação e a transação não é confirmada,
+portanto nenhuma operação é aplicada. Como o fabric v0.6 mantém apenas um evento por
+transação, os eventos das operações são publicados em um único evento LoteExecutado, e a
+API externa é notificada apenas depois que todas as operações forem concluídas.
+*/
+
+package propostas
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// LoteMaximoPadrao - máximo de operações por lote quando a configuração não informa nenhum
+const LoteMaximoPadrao = 100
+
+// stubLote - stub repassado às operações do lote, que acumula os eventos emitidos
+// em vez de publicá-los
+type stubLote struct {
+	shim.ChaincodeStubInterface
+	eventos []events.Evento
+}
+
+// SetEvent - acumula o evento da operação para o evento LoteExecutado
+func (s *stubLote) SetEvent(nome string, payload []byte) error {
+	evento, err := events.Decodificar(payload)
+	if err != nil {
+		return err
+	}
+	s.eventos = append(s.eventos, evento)
+	return nil
+}
+
+// executarLote: função Invoke que executa as operações do documento recebido (registrarProposta,
+// aceitarProposta, confirmarPagamento e cancelarProposta) e retorna a resposta de cada uma (ver envelope.RespostaLote)
+func (t *BoletoPropostaChaincode) executarLote(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	lote, err := validation.ExecutarLote(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(lote.Operacoes) > cfg.LoteMaximo {
+		return nil, envelope.Novo(envelope.LoteExcedido,
+			"quantidade", strconv.Itoa(len(lote.Operacoes)), "maximo", strconv.Itoa(cfg.LoteMaximo))
+	}
+
+	// As notificações das operações são adiadas para o fim do lote
+	cfgOperacao := cfg
+	cfgOperacao.Notificacao.Modo = NotificacaoNenhuma
+
+	sl := &stubLote{ChaincodeStubInterface: stub}
+	resposta := envelope.RespostaLote{Operacao: envelope.OperacaoLoteExecutado}
+	verificado := false
+	var notificacoes []int
+	for i, op := range lote.Operacoes {
+		f, ok := buscarFuncao(op.Funcao, TipoInvoke)
+		if !ok {
+			return nil, envelope.Lote(i, op.Funcao, envelope.Novo(envelope.FuncaoDesconhecida, "funcao", op.Funcao))
+		}
+		if cfg.protegida(f.Nome) && !verificado {
+			if err := verificarChamador(stub, cfg, f.Nome, log); err != nil {
+				return nil, envelope.Lote(i, f.Nome, err)
+			}
+			verificado = true
+		}
+		if !f.disponivel(cfg) {
+			return nil, envelope.Lote(i, f.Nome, envelope.Novo(envelope.FuncaoIndisponivel, "funcao", f.Nome))
+		}
+
+		b, err := f.executar(t, sl, op.Argumentos, cfgOperacao, log.Com("indice", i))
+		if err != nil {
+			return nil, envelope.Lote(i, f.Nome, err)
+		}
+		r, err := envelope.DecodificarResposta(b)
+		if err != nil {
+			return nil, envelope.Lote(i, f.Nome, err)
+		}
+		resposta.Resultados = append(resposta.Resultados, envelope.ResultadoLote{Indice: i, Funcao: f.Nome, Resposta: r})
+
+		// registrarProposta notifica as atualizações e, com na_criacao, os registros
+		if f.Nome == "registrarProposta" &&
+			(r.Operacao == envelope.OperacaoAtualizada || cfg.Notificacao.NaCriacao) {
+			notificacoes = append(notificacoes, i)
+		}
+	}
+
+	if len(sl.eventos) > 0 {
+		if err := publicarEvento(stub, events.Evento{Tipo: events.LoteExecutado, Itens: sl.eventos}, log); err != nil {
+			return nil, err
+		}
+	}
+
+	// Notifica a API externa uma vez por proposta, com o estado final do lote
+	if cfg.Notificacao.Modo == NotificacaoHTTP {
+		notificadas := make(map[string]bool)
+		for _, i := range notificacoes {
+			id := resposta.Resultados[i].Resposta.IDProposta
+			if notificadas[id] {
+				continue
+			}
+			notificadas[id] = true
+			proposta, _, err := obterProposta(stub, id)
+			if err != nil {
+				return nil, envelope.Lote(i, "registrarProposta", err)
+			}
+			if err := notificar(cfg.Notificacao, proposta, log); err != nil {
+				return nil, envelope.Lote(i, "registrarProposta", err)
+			}
+		}
+	}
+
+	log.Info("Lote executado", "operacoes", len(resposta.Resultados), "eventos", len(sl.eventos))
+	b, err := json.Marshal(resposta)
+	if err != nil {
+		return nil, envelope.Interno(err)
+	}
+	return b, nil
+}
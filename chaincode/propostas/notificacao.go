@@ -0,0 +1,92 @@
+/*
+Descrição: chamada da API externa /atualizar ao registrar uma proposta (ver Configuracao.Notificacao)
+A chamada HTTP é executada por cada peer que executa a transação; a API deve, portanto,
+tolerar notificações repetidas da mesma atualização.
+*/
+
+package propostas
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/logging"
+)
+
+// propostaNotificada - JSON enviado à API externa, com os campos da variante apicall
+type propostaNotificada struct {
+	ID                  string `json:"id_proposta"`
+	CpfPagador          string `json:"cpf_pagador"`
+	PagadorAceitou      bool   `json:"pagador_aceitou"`
+	BeneficiarioAceitou bool   `json:"beneficiario_aceitou"`
+	BoletoPago          bool   `json:"boleto_pago"`
+}
+
+// VariavelSegredoNotificacao - variável de ambiente do processo do chaincode com o segredo
+// HMAC da notificação, utilizada quando a configuração do chaincode não o informa
+const VariavelSegredoNotificacao = "DOJO_SEGREDO_NOTIFICACAO"
+
+// segredoNotificacao: segredo HMAC da notificação, obtido a cada transação. Os argumentos
+// do Init ficam registrados no ledger e a configuração gravada é legível por qualquer
+// query ao estado; por isso o segredo vem da Configuracao do chaincode (main de cada
+// variante) ou, se não informado nela, da variável de ambiente VariavelSegredoNotificacao.
+func (t *BoletoPropostaChaincode) segredoNotificacao() string {
+	if t.Configuracao != nil && t.Configuracao.Notificacao.Segredo != "" {
+		return t.Configuracao.Notificacao.Segredo
+	}
+	return os.Getenv(VariavelSegredoNotificacao)
+}
+
+// clienteNotificacao: cliente HTTP com timeout, para que uma API indisponível não
+// bloqueie a execução da transação
+var clienteNotificacao = &http.Client{Timeout: 10 * time.Second}
+
+// notificar: POST da proposta na URL configurada. Uma resposta diferente de 2xx
+// é tratada como falha, e a transação não é confirmada.
+func notificar(cfg Notificacao, p Proposta, log *logging.Logger) error {
+	log.Debug("Notificando a API externa", "url", cfg.URL, "id_proposta", p.ID)
+
+	corpo, err := json.Marshal(propostaNotificada{
+		ID:                  p.ID,
+		CpfPagador:          p.CpfPagador,
+		PagadorAceitou:      p.PagadorAceitou,
+		BeneficiarioAceitou: p.BeneficiarioAceitou,
+		BoletoPago:          p.BoletoPago,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", cfg.URL, bytes.NewBuffer(corpo))
+	if err != nil {
+		return envelope.Novo(envelope.NotificacaoFalhou, "detalhe", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Segredo != "" {
+		mac := hmac.New(sha256.New, []byte(cfg.Segredo))
+		mac.Write(corpo)
+		req.Header.Set("X-Assinatura", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := clienteNotificacao.Do(req)
+	if err != nil {
+		return envelope.Novo(envelope.NotificacaoFalhou, "detalhe", err.Error())
+	}
+	defer resp.Body.Close()
+
+	// logs de resposta
+	body, _ := ioutil.ReadAll(resp.Body)
+	log.Debug("Resposta da API externa", "status", resp.Status, "corpo", string(body))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return envelope.Novo(envelope.NotificacaoFalhou, "detalhe", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Descrição: registro das chaves públicas dos oráculos dos bancos
+As chaves são recebidas no deploy (configuração ou pares do Init) e, depois dele,
+apenas pelo invoke registrarOraculo, restrito ao administrador: o invoke init não
+altera os oráculos, que são a raiz de confiança de confirmarPagamento.
+*/
+
+package propostas
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// RespostaOraculo - resposta do invoke registrarOraculo
+type RespostaOraculo struct {
+	CodigoBanco string `json:"codigo_banco"`
+	Substituido bool   `json:"substituido"` // o banco já tinha uma chave registrada
+}
+
+// gravarOraculo: grava a chave pública (PEM) do oráculo do banco, indicando se
+// substituiu uma chave já registrada
+func gravarOraculo(stub shim.ChaincodeStubInterface, codigoBanco, chavePublica string, log *logging.Logger) (bool, error) {
+	if _, err := oracle.DecodificarChavePublica([]byte(chavePublica)); err != nil {
+		return false, envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "chave do oráculo do banco ["+codigoBanco+"]: "+err.Error())
+	}
+	anterior, err := stub.GetState(prefixoOraculo + codigoBanco)
+	if err != nil {
+		return false, fmt.Errorf("Falha ao obter o oráculo do banco [%s]: %s", codigoBanco, err)
+	}
+	if err := stub.PutState(prefixoOraculo+codigoBanco, []byte(chavePublica)); err != nil {
+		return false, fmt.Errorf("Falha ao registrar o oráculo do banco [%s]: %s", codigoBanco, err)
+	}
+	log.Info("Oráculo registrado", "codigo_banco", codigoBanco, "chave_publica", chavePublica, "substituido", len(anterior) > 0)
+	return len(anterior) > 0, nil
+}
+
+// registrarOraculo: função Invoke, restrita ao administrador, que registra (ou substitui)
+// a chave pública do oráculo de um banco depois do deploy, recebendo os seguintes argumentos
+// args[0]: codigoBanco. Código do banco (3 dígitos)
+// args[1]: chavePublica. Chave pública ECDSA do oráculo em PEM
+func (t *BoletoPropostaChaincode) registrarOraculo(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	o, err := validation.RegistrarOraculo(args)
+	if err != nil {
+		return nil, err
+	}
+	substituido, err := gravarOraculo(stub, o.CodigoBanco, o.ChavePublica, log)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(RespostaOraculo{CodigoBanco: o.CodigoBanco, Substituido: substituido})
+	if err != nil {
+		return nil, envelope.Interno(err)
+	}
+	return b, nil
+}
@@ -0,0 +1,147 @@
+/*
+Descrição: cobrança PIX das propostas (invoke registrarCobrancaPix e query gerarBRCode)
+A cobrança é registrada uma única vez para as propostas aceitas pelas duas partes, ainda
+não pagas e com valor (do boleto emitido ou de registrarProposta), que fica gravado no
+estado; o valor informado pelo chamador apenas confere o da proposta. O BR Code
+é gerado pelo pacote pix com a conta do recebedor da configuração, o ID da proposta como
+txid e o valor registrado. O pagamento é confirmado por confirmarPagamento, com o
+atestado PIX do oráculo do banco (ID fim a fim e txid), como alternativa ao boleto, e o
+valor atestado deve ser o da cobrança registrada. A imagem do QR Code é gerada fora da
+rede (gateway e cmd/pix, com o pacote pix/qrcode).
+*/
+
+package propostas
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/pix"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// prefixo das chaves de estado com o valor (em centavos) das cobranças PIX registradas
+const prefixoCobrancaPix = "cobranca_pix_"
+
+// RespostaBRCode - resposta de registrarCobrancaPix e da query gerarBRCode
+type RespostaBRCode struct {
+	IDProposta string `json:"id_proposta"`
+	TxID       string `json:"txid"`
+	Valor      int64  `json:"valor"` // em centavos
+	BRCode     string `json:"brcode"`
+}
+
+// registrarCobrancaPix: função Invoke que registra a cobrança PIX da proposta e retorna o
+// seu BR Code (PIX copia e cola), recebendo os seguintes argumentos
+// args[0]: Id. Hash da proposta, utilizado como txid
+// args[1]: valor. Valor em centavos, que deve ser o valor da proposta (opcional)
+// A cobrança já registrada não é substituída (COBRANCA_PIX_JA_REGISTRADA).
+func (t *BoletoPropostaChaincode) registrarCobrancaPix(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	cobranca, err := validation.RegistrarCobrancaPix(args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Pix == nil {
+		return nil, envelope.Novo(envelope.PixNaoConfigurado)
+	}
+	idProposta := cobranca.ID
+	log = log.Com("id_proposta", idProposta)
+
+	proposta, err := obterPropostaAberta(stub, idProposta)
+	if err != nil {
+		return nil, err
+	}
+	if !proposta.PagadorAceitou || !proposta.BeneficiarioAceitou {
+		return nil, envelope.Novo(envelope.PropostaNaoAceita, "id", idProposta)
+	}
+
+	registrada, err := stub.GetState(prefixoCobrancaPix + idProposta)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao obter a cobrança PIX da Proposta [%s]: %s", idProposta, err)
+	}
+	if len(registrada) > 0 {
+		return nil, envelope.Novo(envelope.CobrancaPixJaRegistrada, "id", idProposta)
+	}
+
+	// a cobrança é do valor da proposta; o valor informado apenas o confere
+	valor := proposta.Valor
+	switch {
+	case valor == 0:
+		return nil, envelope.Novo(envelope.CampoObrigatorio, "campo", "valor")
+	case cobranca.Valor != 0 && cobranca.Valor != valor:
+		return nil, envelope.Novo(envelope.ArgumentoInvalido, "campo", "valor",
+			"valor", strconv.FormatInt(cobranca.Valor, 10)+" (valor da proposta: "+strconv.FormatInt(valor, 10)+")")
+	}
+
+	resposta, err := respostaBRCode(cfg, idProposta, valor)
+	if err != nil {
+		return nil, err
+	}
+	if err := stub.PutState(prefixoCobrancaPix+idProposta, []byte(strconv.FormatInt(valor, 10))); err != nil {
+		return nil, fmt.Errorf("Falha ao registrar a cobrança PIX da Proposta [%s]: %s", idProposta, err)
+	}
+	log.Info("Cobrança PIX registrada", "valor", valor)
+	return resposta, nil
+}
+
+// gerarBRCode: função Query que retorna o BR Code (PIX copia e cola) da cobrança registrada
+// por registrarCobrancaPix, recebendo os seguintes argumentos
+// args[0]: Id. Hash da proposta, utilizado como txid
+func (t *BoletoPropostaChaincode) gerarBRCode(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	idProposta, err := validation.GerarBRCode(args)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Pix == nil {
+		return nil, envelope.Novo(envelope.PixNaoConfigurado)
+	}
+	log = log.Com("id_proposta", idProposta)
+
+	if _, err := obterPropostaAberta(stub, idProposta); err != nil {
+		return nil, err
+	}
+	valor, err := obterCobrancaPix(stub, idProposta)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("BR Code gerado", "valor", valor)
+	return respostaBRCode(cfg, idProposta, valor)
+}
+
+// obterCobrancaPix: valor da cobrança PIX registrada para a proposta
+// (COBRANCA_PIX_NAO_REGISTRADA se não houver)
+func obterCobrancaPix(stub shim.ChaincodeStubInterface, idProposta string) (int64, error) {
+	b, err := stub.GetState(prefixoCobrancaPix + idProposta)
+	if err != nil {
+		return 0, fmt.Errorf("Falha ao obter a cobrança PIX da Proposta [%s]: %s", idProposta, err)
+	}
+	if len(b) == 0 {
+		return 0, envelope.Novo(envelope.CobrancaPixNaoRegistrada, "id", idProposta)
+	}
+	valor, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Cobrança PIX da Proposta [%s] gravada inválida: %s", idProposta, err)
+	}
+	return valor, nil
+}
+
+// respostaBRCode: BR Code da cobrança da proposta com a conta PIX da configuração
+func respostaBRCode(cfg Configuracao, idProposta string, valor int64) ([]byte, error) {
+	if err := pix.ValidarTxID(idProposta, cfg.Pix.Localizacao != ""); err != nil {
+		return nil, envelope.Novo(envelope.ArgumentoInvalido, "campo", "txid", "valor", idProposta)
+	}
+	brcode, err := pix.Cobranca{Recebedor: *cfg.Pix, TxID: idProposta, Valor: valor}.BRCode()
+	if err != nil {
+		return nil, envelope.Interno(err)
+	}
+	b, err := json.Marshal(RespostaBRCode{IDProposta: idProposta, TxID: idProposta, Valor: valor, BRCode: brcode})
+	if err != nil {
+		return nil, envelope.Interno(err)
+	}
+	return b, nil
+}
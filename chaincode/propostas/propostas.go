@@ -0,0 +1,993 @@
+/*
+Copyright IBM Corp 2016 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+		 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Descrição: com init, invoke e query finalizados
+Implementação iniciada por Caue Garcia Polimanti e Vitor Diego dos Santos de Sousa
+Separado em um package para ser utilizado tanto pelo chaincode (chaincode/finished)
+quanto pelo ledger embutido das ferramentas (ver pacote simulator)
+Os comportamentos das variantes cert e apicall são selecionados pela configuração
+recebida no Init (ver configuracao.go)
+As respostas das funções Invoke e os erros seguem o modelo do pacote envelope
+O log é escrito em JSON pelo pacote logging, com o ID da transação em todas as linhas
+*/
+
+// Package propostas implementa o chaincode de propostas de boleto.
+package propostas
+
+// lista de imports
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// BoletoPropostaChaincode - implementacao do chaincode
+type BoletoPropostaChaincode struct {
+	// Configuracao utilizada quando o Init não recebe uma configuração em JSON
+	// (nil: ConfiguracaoPadrao)
+	Configuracao *Configuracao
+}
+
+// Definição da Struct Proposta e parametros para exportação para JSON
+type Proposta struct {
+	ID                  string `json:"id_proposta"`
+	CpfPagador          string `json:"cpf_pagador"`
+	PagadorAceitou      bool   `json:"pagador_aceitou"`
+	BeneficiarioAceitou bool   `json:"beneficiario_aceitou"`
+	BoletoPago          bool   `json:"boleto_pago"`
+	NossoNumero         string `json:"nosso_numero"`
+	Valor               int64  `json:"valor"` // em centavos
+	DataPagamento       string `json:"data_pagamento"`
+	Cancelada           bool   `json:"cancelada"`
+	DataVencimento      string `json:"data_vencimento"` // AAAA-MM-DD, informada em emitirBoleto
+	Beneficiario        string `json:"beneficiario"`    // CPF ou CNPJ, informado em emitirBoleto
+	FormaPagamento      string `json:"forma_pagamento"` // boleto ou pix, informada em confirmarPagamento
+	EndToEndID          string `json:"end_to_end_id"`   // ID fim a fim do pagamento PIX
+	Status              string `json:"status"`          // derivado dos demais campos (ver events.DerivarStatus)
+}
+
+// consts associadas à tabela de Propostas
+const (
+	nomeTabelaProposta     = "Proposta"
+	colCpfPagador          = "cpfPagador"
+	colPagadorAceitou      = "pagadorAceitou"
+	colBeneficiarioAceitou = "beneficiarioAceitou"
+	colBoletoPago          = "boletoPago"
+	colNossoNumero         = "nossoNumero"
+	colValor               = "valor"
+	colDataPagamento       = "dataPagamento"
+	colCancelada           = "cancelada"
+	colDataVencimento      = "dataVencimento"
+	colBeneficiario        = "beneficiario"
+	colFormaPagamento      = "formaPagamento"
+	colEndToEndID          = "endToEndId"
+)
+
+// prefixo das chaves de estado com as chaves públicas dos oráculos dos bancos
+const prefixoOraculo = "oraculo_"
+
+// ============================================================================================================================
+// Init
+//
+//	Inicia a tabela de propostas ou, sobre o estado de uma versão anterior, executa
+//	as migrações até a versão atual (ver versao.go)
+//	Recebe opcionalmente, como primeiro argumento, a configuração em JSON (ver Configuracao)
+//	e pares de argumentos (codigoBanco, chavePublica) com as
+//	chaves públicas (PEM) dos oráculos autorizados a confirmar pagamentos.
+//	Os oráculos são aceitos apenas no deploy; depois dele, por registrarOraculo (ver oraculos.go)
+//
+// ============================================================================================================================
+func (t *BoletoPropostaChaincode) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return t.iniciar(stub, args, false)
+}
+
+// iniciar: Init do deploy (reiniciar false) e do invoke init (reiniciar true), que
+// exclui e recria a tabela de propostas mesmo sobre o estado de uma versão anterior.
+// O invoke init é restrito ao administrador e não recebe oráculos: os registrados no
+// deploy são mantidos.
+func (t *BoletoPropostaChaincode) iniciar(stub shim.ChaincodeStubInterface, args []string, reiniciar bool) (resposta []byte, err error) {
+	// Configuração recebida no primeiro argumento ou, se não informada, a do chaincode
+	cfg := ConfiguracaoPadrao()
+	log := cfg.logger(stub, "init")
+	defer func() {
+		registrarErro(log, err)
+		err = erroEnvelope(err, cfg.Idioma)
+	}()
+	if t.Configuracao != nil {
+		cfg = *t.Configuracao
+		cfg.preencherPadroes()
+	}
+	if len(args) > 0 && strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
+		cfg, err = DecodificarConfiguracao([]byte(args[0]))
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Notificacao.Segredo != "" {
+			return nil, envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "notificacao.segredo não é aceito no Init, registrado no ledger; informe-o na configuração do chaincode ou em "+VariavelSegredoNotificacao)
+		}
+		args = args[1:]
+	}
+	if err := cfg.Validar(); err != nil {
+		return nil, err
+	}
+	log = cfg.logger(stub, "init")
+	log.Debug("Init Chaincode...", "reiniciar", reiniciar)
+
+	// Versão do esquema do estado anterior, identificada antes de gravar a configuração
+	anterior, err := versaoEsquemaEstado(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verificação da quantidade de argumentos recebidos
+	if len(args)%2 != 0 {
+		return nil, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "pares (codigoBanco, chavePublica)")
+	}
+
+	// Oráculos da configuração e dos pares de argumentos
+	oraculos := make(map[string]string)
+	for codigoBanco, chavePublica := range cfg.Oraculos {
+		oraculos[codigoBanco] = chavePublica
+	}
+	for i := 0; i < len(args); i += 2 {
+		oraculos[args[i]] = args[i+1]
+	}
+	if reiniciar && len(oraculos) > 0 {
+		return nil, envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "os oráculos são recebidos apenas no deploy; utilize registrarOraculo")
+	}
+	if cfg.Tabela == TabelaSimples && len(oraculos) > 0 {
+		return nil, envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe", "oráculos exigem a tabela completa")
+	}
+	var bancos []string
+	for codigoBanco := range oraculos {
+		bancos = append(bancos, codigoBanco)
+	}
+	sort.Strings(bancos)
+
+	// Registra as chaves públicas dos oráculos dos bancos
+	for _, codigoBanco := range bancos {
+		if _, err := gravarOraculo(stub, codigoBanco, oraculos[codigoBanco], log); err != nil {
+			return nil, err
+		}
+	}
+
+	// Grava a configuração e, nos modos metadata e assinatura, o administrador
+	if err := gravarConfiguracao(stub, cfg); err != nil {
+		return nil, err
+	}
+	if err := registrarAdministrador(stub, cfg, log); err != nil {
+		return nil, err
+	}
+	log.Info("Configuração gravada", "autenticacao", cfg.Autenticacao.Modo, "notificacao", cfg.Notificacao.Modo,
+		"tabela", cfg.Tabela, "idioma", cfg.Idioma, "nivel_log", cfg.NivelLog)
+
+	// Novo deploy sobre o estado de uma versão anterior: migra o estado, mantendo as propostas
+	if anterior > 0 && !reiniciar {
+		log.Info("Estado anterior encontrado", "versao_esquema", anterior)
+		if err := migrar(stub, anterior, cfg, log); err != nil {
+			return nil, err
+		}
+		if err := gravarVersao(stub); err != nil {
+			return nil, err
+		}
+		log.Info("Estado migrado", "versao", Versao, "commit", Commit, "versao_esquema", VersaoEsquema)
+		return nil, nil
+	}
+
+	// Verifica se a tabela 'Proposta' existe
+	log.Debug("Verificando se a tabela existe", "tabela", nomeTabelaProposta)
+	tbProposta, err := stub.GetTable(nomeTabelaProposta)
+	if err != nil {
+		log.Debug("Falha ao executar stub.GetTable", "tabela", nomeTabelaProposta, "erro", err)
+	}
+	// Se a tabela 'Proposta' já existir, excluir a tabela
+	if tbProposta != nil {
+		err = stub.DeleteTable(nomeTabelaProposta)
+		if err != nil {
+			return nil, fmt.Errorf("Falha ao excluir a tabela "+nomeTabelaProposta+". [%v]", err)
+		}
+		log.Info("Tabela excluída", "tabela", nomeTabelaProposta)
+
+		// As respostas gravadas se referem às propostas excluídas
+		excluidas, err := excluirRequisicoes(stub, nil)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Requisições excluídas", "quantidade", excluidas.Expurgadas)
+
+		// assim como as conciliações dos boletos
+		conciliacoes, err := excluirConciliacoes(stub)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Conciliações excluídas", "chaves", conciliacoes)
+	}
+
+	// Criar tabela de Propostas
+	log.Debug("Criando a tabela", "tabela", nomeTabelaProposta)
+	colunas := colunasTabelaProposta(cfg.Tabela)
+	err = stub.CreateTable(nomeTabelaProposta, colunas)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao criar a tabela "+nomeTabelaProposta+". [%v]", err)
+	}
+	log.Info("Tabela criada", "tabela", nomeTabelaProposta, "colunas", len(colunas))
+
+	if err := gravarVersao(stub); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// ============================================================================================================================
+// Invoke Functions
+// ============================================================================================================================
+
+// Invoke - Ponto de entrada para chamadas do tipo Invoke, despachadas pelo registro
+// de funções (ver funcoes.go).
+// Funções suportadas:
+// "init": reinicia o estado do chaincode (reset), recriando a tabela de propostas.
+// Restrita ao administrador, qualquer que seja a autenticação configurada.
+// "registrarProposta(Id, cpfPagador, pagadorAceitou,
+// beneficiarioAceitou, boletoPago[, nossoNumero, valor])": para registrar uma nova proposta ou atualizar uma já existente.
+// Only an administrator can call this function.
+// "aceitarProposta(Id, parte)": para registrar o aceite do pagador ou do beneficiário.
+// "emitirBoleto(Id, nossoNumero, valor)": para registrar o boleto emitido para a proposta.
+// "confirmarPagamento(Id, atestado)": para liquidar a proposta a partir de um atestado
+// de pagamento assinado pelo oráculo de um banco registrado.
+// "cancelarProposta(Id, motivo)": para cancelar uma proposta ainda não paga.
+// "registrarCobrancaPix(Id[, valor])": para registrar a cobrança PIX da proposta e obter o BR Code.
+// "registrarConciliacao(documento)": para registrar a conciliação dos boletos pagos com os extratos bancários.
+// "registrarOraculo(codigoBanco, chavePublica)": para registrar o oráculo de um banco depois
+// do deploy (restrita, como o init).
+// Cada função que altera uma proposta emite um evento (ver pacote events).
+// As funções protegidas pela configuração verificam o chamador antes de executar,
+// e com a tabela simples apenas init, registrarProposta e aceitarProposta estão disponíveis.
+// "consultarProposta(Id)": para consultar uma Proposta existente.
+// Only the owner of the specific asset can call this function.
+// An asset is any string to identify it. An owner is representated by one of his ECert/TCert.
+func (t *BoletoPropostaChaincode) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) (resposta []byte, err error) {
+	// Configuração gravada no Init; os erros são registrados no log e convertidos
+	// no envelope, no idioma configurado
+	var cfg Configuracao
+	log := logging.Novo(stub.GetTxID(), function)
+	defer func() {
+		if function != "init" { // o Init registra os próprios erros
+			registrarErro(log, err)
+		}
+		err = erroEnvelope(err, cfg.Idioma)
+	}()
+	cfg, err = carregarConfiguracao(stub)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Notificacao.Segredo = t.segredoNotificacao()
+	log = cfg.logger(stub, function)
+	log.Debug("Invoke Chaincode...", "argumentos", args)
+
+	return t.executar(stub, TipoInvoke, function, args, cfg, log)
+}
+
+// executar: despacha a chamada para a função registrada com o nome e o tipo informados,
+// verificando o chamador das funções protegidas e a disponibilidade na tabela configurada
+func (t *BoletoPropostaChaincode) executar(stub shim.ChaincodeStubInterface, tipo, function string, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	f, ok := buscarFuncao(function, tipo)
+	if !ok {
+		return nil, envelope.Novo(envelope.FuncaoDesconhecida, "funcao", function)
+	}
+	if cfg.protegida(function) {
+		if err := verificarChamador(stub, cfg, function, log); err != nil {
+			return nil, err
+		}
+	}
+	if !f.disponivel(cfg) {
+		return nil, envelope.Novo(envelope.FuncaoIndisponivel, "funcao", function)
+	}
+	if f.Idempotente {
+		return t.executarIdempotente(stub, f, args, cfg, log)
+	}
+	return f.executar(t, stub, args, cfg, log)
+}
+
+// registrarProposta: função Invoke para registrar uma nova proposta, recebendo os seguintes argumentos:
+// args[0]: Id. Hash que identificará a proposta
+// args[1]: cpfPagador. CPF do Pagador
+// args[2]: pagadorAceitou. Status de aceite do Pagador da proposta
+// args[3]: beneficiarioAceitou. Status de aceite do Beneficiario da proposta
+// args[4]: boletoPago. Status do Pagamento do Boleto (false com a tabela completa)
+// args[5]: nossoNumero. Nosso número do boleto (opcional, junto com o valor)
+// args[6]: valor. Valor do boleto em centavos (opcional, junto com o nosso número)
+// Ao atualizar uma proposta sem informar nossoNumero e valor, os valores já registrados são mantidos;
+// com o boleto já emitido, nossoNumero e valor não são aceitos (BOLETO_JA_EMITIDO).
+// Com a tabela simples, nossoNumero e valor não são aceitos. Com a tabela completa, o pagamento
+// é registrado apenas por confirmarPagamento, com o atestado do oráculo do banco.
+// As propostas pagas ou canceladas não são atualizadas.
+func (t *BoletoPropostaChaincode) registrarProposta(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+
+	// Verifica a quantidade de argumentos recebidos e os converte no tipo
+	// necessário para salvar na tabela 'Proposta' (ver pacote validation)
+	registro, err := validation.RegistrarProposta(args)
+	if err != nil {
+		return nil, err
+	}
+	if registro.InformouBoleto && cfg.Tabela == TabelaSimples {
+		return nil, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "5")
+	}
+	if registro.BoletoPago && cfg.Tabela == TabelaCompleta {
+		return nil, envelope.Novo(envelope.ArgumentoInvalido, "campo", "boletoPago", "valor", "true (utilize confirmarPagamento)")
+	}
+	proposta := Proposta{
+		ID:                  registro.ID,
+		CpfPagador:          registro.CpfPagador,
+		PagadorAceitou:      registro.PagadorAceitou,
+		BeneficiarioAceitou: registro.BeneficiarioAceitou,
+		BoletoPago:          registro.BoletoPago,
+		NossoNumero:         registro.NossoNumero,
+		Valor:               registro.Valor,
+	}
+
+	// [To do] verificar identidade
+
+	// Registra a proposta na tabela 'Proposta'
+	log = log.Com("id_proposta", proposta.ID)
+	log.Debug("Registrando Proposta", "cpf_pagador", proposta.CpfPagador, "pagador_aceitou", proposta.PagadorAceitou,
+		"beneficiario_aceitou", proposta.BeneficiarioAceitou, "boleto_pago", proposta.BoletoPago)
+
+	ok, err := stub.InsertRow(nomeTabelaProposta, linhaProposta(proposta, cfg.Tabela))
+
+	// Caso a proposta já exista (false and no error if a row already exists for the given key).
+	if !ok && err == nil {
+		// Apenas retornar que a proposta existe
+		//return nil, errors.New("Proposta já existente.")
+		//jsonResp = "{\"registrado\":\"" + "False" + "\"}"
+		//return []byte(jsonResp), errors.New("Proposta já existente.")
+
+		// /*
+		// Trecho para atualizar uma proposta existente
+		//	mantém os dados do boleto já registrados quando não forem informados
+		existente, encontrada, err := obterProposta(stub, proposta.ID)
+		if err != nil {
+			return nil, err
+		}
+		if encontrada {
+			// o pagamento e o cancelamento encerram a proposta
+			if existente.BoletoPago {
+				return nil, envelope.Novo(envelope.PropostaJaPaga, "id", proposta.ID)
+			}
+			if existente.Cancelada {
+				return nil, envelope.Novo(envelope.PropostaCancelada, "id", proposta.ID)
+			}
+			// o boleto emitido não é substituído, como em emitirBoleto
+			if registro.InformouBoleto && existente.NossoNumero != "" {
+				return nil, envelope.Novo(envelope.BoletoJaEmitido, "id", proposta.ID, "nosso_numero", existente.NossoNumero)
+			}
+			if !registro.InformouBoleto {
+				proposta.NossoNumero = existente.NossoNumero
+				proposta.Valor = existente.Valor
+			}
+			// o vencimento e o beneficiário são informados apenas em emitirBoleto
+			proposta.DataVencimento = existente.DataVencimento
+			proposta.Beneficiario = existente.Beneficiario
+			proposta.DataPagamento = existente.DataPagamento
+			proposta.Cancelada = existente.Cancelada
+			proposta.FormaPagamento = existente.FormaPagamento
+			proposta.EndToEndID = existente.EndToEndID
+		}
+
+		//	substitui um registro existente em uma linha com o registro associado ao idProposta recebido nos argumentos
+		//	(uma falha do ReplaceRow interrompe a atualização antes do evento e da notificação)
+		if err := atualizarProposta(stub, proposta, cfg.Tabela); err != nil {
+			return nil, err
+		}
+
+		// Emite o evento correspondente aos campos alterados
+		tipo, alterados := camposAlterados(existente, proposta)
+		if !alterados.Vazio() {
+			if err := emitirEvento(stub, tipo, proposta.ID, alterados, log); err != nil {
+				return nil, err
+			}
+		}
+
+		// Notifica a API externa da atualização (variante apicall)
+		if cfg.Notificacao.Modo == NotificacaoHTTP {
+			if err := notificar(cfg.Notificacao, proposta, log); err != nil {
+				return nil, err
+			}
+		}
+
+		log.Info("Proposta atualizada", "status", statusProposta(proposta))
+		return envelope.Resposta{Operacao: envelope.OperacaoAtualizada, IDProposta: proposta.ID, Status: statusProposta(proposta)}.Codificar()
+		//*/
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao criar a Proposta [%s]: %s", proposta.ID, err)
+	}
+
+	// Emite o evento de criação com todos os campos da proposta
+	proposta.Status = statusProposta(proposta)
+	alterados := events.Campos{
+		CpfPagador:          events.String(proposta.CpfPagador),
+		PagadorAceitou:      events.Bool(proposta.PagadorAceitou),
+		BeneficiarioAceitou: events.Bool(proposta.BeneficiarioAceitou),
+		BoletoPago:          events.Bool(proposta.BoletoPago),
+		Status:              events.String(proposta.Status),
+	}
+	if proposta.NossoNumero != "" {
+		alterados.NossoNumero = events.String(proposta.NossoNumero)
+		alterados.Valor = events.Int64(proposta.Valor)
+	}
+	if err := emitirEvento(stub, events.PropostaCriada, proposta.ID, alterados, log); err != nil {
+		return nil, err
+	}
+	if cfg.Notificacao.Modo == NotificacaoHTTP && cfg.Notificacao.NaCriacao {
+		if err := notificar(cfg.Notificacao, proposta, log); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info("Proposta criada", "status", proposta.Status)
+
+	return envelope.Resposta{Operacao: envelope.OperacaoRegistrada, IDProposta: proposta.ID, Status: proposta.Status}.Codificar()
+}
+
+// confirmarPagamento: função Invoke para liquidar uma proposta a partir do atestado de pagamento
+// assinado pelo oráculo do banco, recebendo os seguintes argumentos:
+// args[0]: Id. Hash da proposta
+// args[1]: atestado. JSON com codigo_banco, nosso_numero (boleto) ou end_to_end_id e txid (PIX),
+// valor, data_pagamento e assinatura
+// O atestado só é aceito se a assinatura for válida para a chave do banco registrada no Init,
+// se o nosso número (ou, no PIX, o txid) e o valor corresponderem aos da proposta e se a
+// proposta ainda não estiver paga. O pagamento PIX exige o aceite das duas partes e a
+// cobrança de registrarCobrancaPix, cujo valor deve ser o do atestado.
+func (t *BoletoPropostaChaincode) confirmarPagamento(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+
+	// Verifica os argumentos recebidos e decodifica o atestado
+	pagamento, err := validation.ConfirmarPagamento(args)
+	if err != nil {
+		return nil, err
+	}
+	idProposta := pagamento.ID
+	atestado := pagamento.Atestado
+	log = log.Com("id_proposta", idProposta, "codigo_banco", atestado.CodigoBanco)
+
+	// Obtem a chave pública do oráculo do banco que emitiu o atestado
+	chavePublica, err := stub.GetState(prefixoOraculo + atestado.CodigoBanco)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao obter o oráculo do banco [%s]: %s", atestado.CodigoBanco, err)
+	}
+	if len(chavePublica) == 0 {
+		return nil, envelope.Novo(envelope.OraculoNaoRegistrado, "banco", atestado.CodigoBanco)
+	}
+
+	// Verifica a assinatura do atestado
+	if err := oracle.Verificar(chavePublica, atestado); err != nil {
+		log.Aviso("Atestado recusado", "erro", err)
+		return nil, envelope.Novo(envelope.AssinaturaInvalida, "banco", atestado.CodigoBanco)
+	}
+
+	// Verifica se o atestado corresponde à proposta
+	proposta, encontrada, err := obterProposta(stub, idProposta)
+	if err != nil {
+		return nil, err
+	}
+	if !encontrada {
+		return nil, envelope.Novo(envelope.PropostaNaoEncontrada, "id", idProposta)
+	}
+	if proposta.BoletoPago {
+		return nil, envelope.Novo(envelope.PropostaJaPaga, "id", idProposta)
+	}
+	if proposta.Cancelada {
+		return nil, envelope.Novo(envelope.PropostaCancelada, "id", idProposta)
+	}
+	alterados := events.Campos{
+		BoletoPago:    events.Bool(true),
+		DataPagamento: events.String(atestado.DataPagamento),
+		CodigoBanco:   events.String(atestado.CodigoBanco),
+	}
+	if atestado.Pix() {
+		if !proposta.PagadorAceitou || !proposta.BeneficiarioAceitou {
+			return nil, envelope.Novo(envelope.PropostaNaoAceita, "id", idProposta)
+		}
+		if atestado.TxID != idProposta {
+			return nil, envelope.Novo(envelope.AtestadoDivergente, "id", idProposta, "campo", "txid",
+				"recebido", atestado.TxID, "esperado", idProposta)
+		}
+		valorCobranca, err := obterCobrancaPix(stub, idProposta)
+		if err != nil {
+			return nil, err
+		}
+		if valorCobranca != atestado.Valor {
+			return nil, envelope.Novo(envelope.AtestadoDivergente, "id", idProposta, "campo", "valor",
+				"recebido", strconv.FormatInt(atestado.Valor, 10), "esperado", strconv.FormatInt(valorCobranca, 10))
+		}
+		proposta.FormaPagamento = events.FormaPagamentoPix
+		proposta.EndToEndID = atestado.EndToEndID
+		alterados.EndToEndID = events.String(atestado.EndToEndID)
+	} else {
+		if proposta.NossoNumero == "" || proposta.NossoNumero != atestado.NossoNumero {
+			return nil, envelope.Novo(envelope.AtestadoDivergente, "id", idProposta, "campo", "nosso_numero",
+				"recebido", atestado.NossoNumero, "esperado", proposta.NossoNumero)
+		}
+		proposta.FormaPagamento = events.FormaPagamentoBoleto
+	}
+	if proposta.Valor != atestado.Valor {
+		return nil, envelope.Novo(envelope.AtestadoDivergente, "id", idProposta, "campo", "valor",
+			"recebido", strconv.FormatInt(atestado.Valor, 10), "esperado", strconv.FormatInt(proposta.Valor, 10))
+	}
+
+	// Liquida a proposta
+	proposta.BoletoPago = true
+	proposta.DataPagamento = atestado.DataPagamento
+	if err := atualizarProposta(stub, proposta, TabelaCompleta); err != nil {
+		return nil, err
+	}
+
+	alterados.FormaPagamento = events.String(proposta.FormaPagamento)
+	alterados.Status = events.String(statusProposta(proposta))
+	if err := emitirEvento(stub, events.PagamentoRegistrado, idProposta, alterados, log); err != nil {
+		return nil, err
+	}
+
+	log.Info("Pagamento confirmado", "forma_pagamento", proposta.FormaPagamento, "data_pagamento", atestado.DataPagamento, "valor", atestado.Valor)
+
+	return envelope.Resposta{Operacao: envelope.OperacaoPaga, IDProposta: idProposta, Status: statusProposta(proposta)}.Codificar()
+}
+
+// aceitarProposta: função Invoke para registrar o aceite de uma das partes da proposta,
+// recebendo os seguintes argumentos:
+// args[0]: Id. Hash da proposta
+// args[1]: parte. "pagador" ou "beneficiario"
+func (t *BoletoPropostaChaincode) aceitarProposta(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+
+	// Verifica os argumentos recebidos
+	aceite, err := validation.AceitarProposta(args)
+	if err != nil {
+		return nil, err
+	}
+	idProposta := aceite.ID
+	parte := aceite.Parte
+	log = log.Com("id_proposta", idProposta)
+
+	proposta, err := obterPropostaAberta(stub, idProposta)
+	if err != nil {
+		return nil, err
+	}
+
+	var alterados events.Campos
+	if parte == "pagador" {
+		if proposta.PagadorAceitou {
+			return nil, envelope.Novo(envelope.AceiteJaRegistrado, "id", idProposta, "parte", parte)
+		}
+		proposta.PagadorAceitou = true
+		alterados.PagadorAceitou = events.Bool(true)
+	} else {
+		if proposta.BeneficiarioAceitou {
+			return nil, envelope.Novo(envelope.AceiteJaRegistrado, "id", idProposta, "parte", parte)
+		}
+		proposta.BeneficiarioAceitou = true
+		alterados.BeneficiarioAceitou = events.Bool(true)
+	}
+
+	if err := atualizarProposta(stub, proposta, cfg.Tabela); err != nil {
+		return nil, err
+	}
+
+	alterados.Status = events.String(statusProposta(proposta))
+	if err := emitirEvento(stub, events.PropostaAceita, idProposta, alterados, log); err != nil {
+		return nil, err
+	}
+
+	log.Info("Aceite registrado", "parte", parte, "status", statusProposta(proposta))
+
+	return envelope.Resposta{Operacao: envelope.OperacaoAceita, IDProposta: idProposta, Status: statusProposta(proposta)}.Codificar()
+}
+
+// emitirBoleto: função Invoke para registrar o boleto emitido para a proposta,
+// recebendo os seguintes argumentos:
+// args[0]: Id. Hash da proposta
+// args[1]: nossoNumero. Nosso número do boleto
+// args[2]: valor. Valor do boleto em centavos
+// args[3]: dataVencimento. Data de vencimento do boleto, AAAA-MM-DD (opcional)
+// args[4]: beneficiario. CPF ou CNPJ do beneficiário (opcional)
+// O vencimento e o beneficiário são utilizados no relatório de aging (ver aging.go).
+// Uma proposta que já possui boleto é recusada com BOLETO_JA_EMITIDO.
+func (t *BoletoPropostaChaincode) emitirBoleto(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+
+	// Verifica os argumentos recebidos
+	boleto, err := validation.EmitirBoleto(args)
+	if err != nil {
+		return nil, err
+	}
+	idProposta := boleto.ID
+	nossoNumero := boleto.NossoNumero
+	valor := boleto.Valor
+	log = log.Com("id_proposta", idProposta)
+
+	proposta, err := obterPropostaAberta(stub, idProposta)
+	if err != nil {
+		return nil, err
+	}
+	if !proposta.PagadorAceitou || !proposta.BeneficiarioAceitou {
+		return nil, envelope.Novo(envelope.PropostaNaoAceita, "id", idProposta)
+	}
+	if proposta.NossoNumero != "" {
+		return nil, envelope.Novo(envelope.BoletoJaEmitido, "id", idProposta, "nosso_numero", proposta.NossoNumero)
+	}
+
+	proposta.NossoNumero = nossoNumero
+	proposta.Valor = valor
+	proposta.DataVencimento = boleto.DataVencimento
+	if boleto.Beneficiario != "" {
+		proposta.Beneficiario = boleto.Beneficiario
+	}
+	if err := atualizarProposta(stub, proposta, TabelaCompleta); err != nil {
+		return nil, err
+	}
+
+	alterados := events.Campos{
+		NossoNumero: events.String(nossoNumero),
+		Valor:       events.Int64(valor),
+		Status:      events.String(statusProposta(proposta)),
+	}
+	if proposta.DataVencimento != "" {
+		alterados.DataVencimento = events.String(proposta.DataVencimento)
+	}
+	if proposta.Beneficiario != "" {
+		alterados.Beneficiario = events.String(proposta.Beneficiario)
+	}
+	err = emitirEvento(stub, events.BoletoEmitido, idProposta, alterados, log)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Boleto emitido", "nosso_numero", nossoNumero, "valor", valor)
+
+	return envelope.Resposta{Operacao: envelope.OperacaoBoletoEmitido, IDProposta: idProposta, Status: statusProposta(proposta)}.Codificar()
+}
+
+// cancelarProposta: função Invoke para cancelar uma proposta ainda não paga,
+// recebendo os seguintes argumentos:
+// args[0]: Id. Hash da proposta
+// args[1]: motivo. Motivo do cancelamento
+func (t *BoletoPropostaChaincode) cancelarProposta(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+
+	// Verifica os argumentos recebidos
+	cancelamento, err := validation.CancelarProposta(args)
+	if err != nil {
+		return nil, err
+	}
+	idProposta := cancelamento.ID
+	motivo := cancelamento.Motivo
+	log = log.Com("id_proposta", idProposta)
+
+	proposta, err := obterPropostaAberta(stub, idProposta)
+	if err != nil {
+		return nil, err
+	}
+
+	proposta.Cancelada = true
+	if err := atualizarProposta(stub, proposta, TabelaCompleta); err != nil {
+		return nil, err
+	}
+
+	err = emitirEvento(stub, events.PropostaCancelada, idProposta, events.Campos{
+		Cancelada: events.Bool(true),
+		Status:    events.String(statusProposta(proposta)),
+		Motivo:    events.String(motivo),
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Proposta cancelada", "motivo", motivo)
+
+	return envelope.Resposta{Operacao: envelope.OperacaoCancelada, IDProposta: idProposta, Status: statusProposta(proposta)}.Codificar()
+}
+
+// ============================================================================================================================
+// Query
+// ============================================================================================================================
+
+// Query is our entry point for queries
+
+// Query - Ponto de entrada para chamadas do tipo Query, despachadas pelo registro
+// de funções (ver funcoes.go).
+// Funções suportadas:
+// "consultarProposta(Id)": para consultar uma proposta existente
+// "listarPropostas()": para listar todas as propostas registradas
+// "consultarConciliacao(idConciliacao)": para consultar uma conciliação registrada
+// "listarStatusConciliacao([situacao])": para listar a situação da conciliação de cada boleto
+// "listarFuncoes()": para listar as funções do chaincode (catálogo em JSON)
+// "versao()": para consultar a versão do chaincode e do esquema do estado
+func (t *BoletoPropostaChaincode) Query(stub shim.ChaincodeStubInterface, function string, args []string) (resposta []byte, err error) {
+	var cfg Configuracao
+	log := logging.Novo(stub.GetTxID(), function)
+	defer func() {
+		registrarErro(log, err)
+		err = erroEnvelope(err, cfg.Idioma)
+	}()
+	cfg, err = carregarConfiguracao(stub)
+	if err != nil {
+		return nil, err
+	}
+	log = cfg.logger(stub, function)
+	log.Debug("Query Chaincode...", "argumentos", args)
+
+	return t.executar(stub, TipoQuery, function, args, cfg, log)
+}
+
+// consultarProposta: função Query para consultar uma proposta existente, recebendo os seguintes argumentos
+// args[0]: Id. Hash da proposta
+func (t *BoletoPropostaChaincode) consultarProposta(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	var propostaAsBytes []byte // retorno do json em bytes
+
+	// Verifica se a quantidade de argumentos recebidas corresponde a esperada
+	idProposta, err := validation.ConsultarProposta(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// [To do] verificar identidade
+
+	// Consultar a proposta na tabela 'Proposta'
+	resProposta, encontrada, err := obterProposta(stub, idProposta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tratamento para o caso de não encontrar nenhuma proposta correspondente
+	if !encontrada {
+		return nil, envelope.Novo(envelope.PropostaNaoEncontrada, "id", idProposta) // retorno do erro para o json
+	}
+
+	log.Debug("Proposta encontrada", "id_proposta", resProposta.ID, "cpf_pagador", resProposta.CpfPagador, "status", resProposta.Status)
+
+	// Converter o objeto da Proposta para Bytes, para retorná-lo em formato JSON
+	propostaAsBytes, err = json.Marshal(resProposta)
+	if err != nil {
+		return nil, fmt.Errorf("Query operation failed. Error marshaling JSON: %s", err)
+	}
+	// retorna o objeto em bytes
+	return propostaAsBytes, nil
+}
+
+// listarPropostas: função Query para listar todas as propostas registradas, sem argumentos
+func (t *BoletoPropostaChaincode) listarPropostas(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+
+	// Verifica se a quantidade de argumentos recebidas corresponde a esperada
+	if err := validation.ListarPropostas(args); err != nil {
+		return nil, err
+	}
+
+	// Chave parcial vazia: todas as linhas da tabela 'Proposta'
+	rows, err := stub.GetRows(nomeTabelaProposta, []shim.Column{})
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao listar as Propostas: %s", err)
+	}
+
+	lista := []Proposta{}
+	for row := range rows {
+		if proposta, encontrada := propostaDaLinha(row); encontrada {
+			lista = append(lista, proposta)
+		}
+	}
+	log.Debug("Propostas encontradas", "quantidade", len(lista))
+
+	// Converter a lista de Propostas para Bytes, para retorná-la em formato JSON
+	listaAsBytes, err := json.Marshal(lista)
+	if err != nil {
+		return nil, fmt.Errorf("Query operation failed. Error marshaling JSON: %s", err)
+	}
+	return listaAsBytes, nil
+}
+
+// ============================================================================================================================
+// Tabela Proposta
+// ============================================================================================================================
+
+// obterProposta: consulta a proposta na tabela 'Proposta'.
+// Retorna false se nenhuma proposta corresponder ao Id informado.
+func obterProposta(stub shim.ChaincodeStubInterface, idProposta string) (Proposta, bool, error) {
+	var resProposta Proposta
+
+	// Define o valor de coluna do registro a ser buscado
+	var columns []shim.Column
+	col1 := shim.Column{Value: &shim.Column_String_{String_: idProposta}}
+	columns = append(columns, col1)
+
+	row, err := stub.GetRow(nomeTabelaProposta, columns)
+	if err != nil {
+		return resProposta, false, fmt.Errorf("Erro ao obter Proposta [%s]: [%s]", string(idProposta), err)
+	}
+
+	resProposta, encontrada := propostaDaLinha(row)
+	return resProposta, encontrada, nil
+}
+
+// propostaDaLinha: converte uma linha da tabela 'Proposta' no objeto Proposta.
+// Retorna false se a linha estiver vazia.
+func propostaDaLinha(row shim.Row) (Proposta, bool) {
+	var resProposta Proposta
+
+	if len(row.Columns) == 0 || row.Columns[2] == nil {
+		return resProposta, false
+	}
+
+	// Criação do objeto Proposta
+	resProposta.ID = row.Columns[0].GetString_()
+	resProposta.CpfPagador = row.Columns[1].GetString_()
+	resProposta.PagadorAceitou = row.Columns[2].GetBool()
+	resProposta.BeneficiarioAceitou = row.Columns[3].GetBool()
+	resProposta.BoletoPago = row.Columns[4].GetBool()
+	// colunas adicionadas depois da primeira versão da tabela
+	if len(row.Columns) > 7 {
+		resProposta.NossoNumero = row.Columns[5].GetString_()
+		resProposta.Valor = row.Columns[6].GetInt64()
+		resProposta.DataPagamento = row.Columns[7].GetString_()
+	}
+	if len(row.Columns) > 8 {
+		resProposta.Cancelada = row.Columns[8].GetBool()
+	}
+	if len(row.Columns) > 10 {
+		resProposta.DataVencimento = row.Columns[9].GetString_()
+		resProposta.Beneficiario = row.Columns[10].GetString_()
+	}
+	if len(row.Columns) > 12 {
+		resProposta.FormaPagamento = row.Columns[11].GetString_()
+		resProposta.EndToEndID = row.Columns[12].GetString_()
+	}
+	resProposta.Status = statusProposta(resProposta)
+
+	return resProposta, true
+}
+
+// obterPropostaAberta: consulta a proposta e verifica se ela ainda pode ser alterada
+// (existente, não paga e não cancelada)
+func obterPropostaAberta(stub shim.ChaincodeStubInterface, idProposta string) (Proposta, error) {
+	proposta, encontrada, err := obterProposta(stub, idProposta)
+	if err != nil {
+		return proposta, err
+	}
+	if !encontrada {
+		return proposta, envelope.Novo(envelope.PropostaNaoEncontrada, "id", idProposta)
+	}
+	if proposta.Cancelada {
+		return proposta, envelope.Novo(envelope.PropostaCancelada, "id", idProposta)
+	}
+	if proposta.BoletoPago {
+		return proposta, envelope.Novo(envelope.PropostaJaPaga, "id", idProposta)
+	}
+	return proposta, nil
+}
+
+// atualizarProposta: substitui a linha da proposta na tabela 'Proposta', no layout informado
+func atualizarProposta(stub shim.ChaincodeStubInterface, p Proposta, tabela string) error {
+	ok, err := stub.ReplaceRow(nomeTabelaProposta, linhaProposta(p, tabela))
+	if err != nil {
+		return fmt.Errorf("Falha ao atualizar a Proposta [%s]: %s", p.ID, err)
+	}
+	if !ok {
+		return errors.New("Falha ao atualizar a Proposta nº " + p.ID)
+	}
+	return nil
+}
+
+// colunasTabelaProposta: definição das colunas da tabela 'Proposta' no layout informado
+func colunasTabelaProposta(tabela string) []*shim.ColumnDefinition {
+	colunas := []*shim.ColumnDefinition{
+		// Identificador da proposta (hash)
+		&shim.ColumnDefinition{Name: "Id", Type: shim.ColumnDefinition_STRING, Key: true},
+		// CPF do Pagador
+		&shim.ColumnDefinition{Name: colCpfPagador, Type: shim.ColumnDefinition_STRING, Key: false},
+		// Status de aceite do Pagador da proposta
+		&shim.ColumnDefinition{Name: colPagadorAceitou, Type: shim.ColumnDefinition_BOOL, Key: false},
+		// Status de aceite do Beneficiario da proposta
+		&shim.ColumnDefinition{Name: colBeneficiarioAceitou, Type: shim.ColumnDefinition_BOOL, Key: false},
+		// Status do Pagamento do Boleto
+		&shim.ColumnDefinition{Name: colBoletoPago, Type: shim.ColumnDefinition_BOOL, Key: false},
+	}
+	if tabela == TabelaCompleta {
+		colunas = append(colunas,
+			// Nosso número do boleto no banco emissor
+			&shim.ColumnDefinition{Name: colNossoNumero, Type: shim.ColumnDefinition_STRING, Key: false},
+			// Valor do boleto em centavos
+			&shim.ColumnDefinition{Name: colValor, Type: shim.ColumnDefinition_INT64, Key: false},
+			// Data do pagamento confirmado pelo oráculo do banco (AAAA-MM-DD)
+			&shim.ColumnDefinition{Name: colDataPagamento, Type: shim.ColumnDefinition_STRING, Key: false},
+			// Status de cancelamento da proposta
+			&shim.ColumnDefinition{Name: colCancelada, Type: shim.ColumnDefinition_BOOL, Key: false},
+			// Data de vencimento do boleto (AAAA-MM-DD)
+			&shim.ColumnDefinition{Name: colDataVencimento, Type: shim.ColumnDefinition_STRING, Key: false},
+			// CPF ou CNPJ do beneficiário do boleto
+			&shim.ColumnDefinition{Name: colBeneficiario, Type: shim.ColumnDefinition_STRING, Key: false},
+			// Forma do pagamento confirmado (boleto ou pix)
+			&shim.ColumnDefinition{Name: colFormaPagamento, Type: shim.ColumnDefinition_STRING, Key: false},
+			// ID fim a fim do pagamento PIX
+			&shim.ColumnDefinition{Name: colEndToEndID, Type: shim.ColumnDefinition_STRING, Key: false},
+		)
+	}
+	return colunas
+}
+
+// linhaProposta: converte a proposta em uma linha da tabela 'Proposta'.
+// Na tabela simples, apenas as 5 primeiras colunas são gravadas.
+func linhaProposta(p Proposta, tabela string) shim.Row {
+	row := shim.Row{
+		Columns: []*shim.Column{
+			&shim.Column{Value: &shim.Column_String_{String_: p.ID}},
+			&shim.Column{Value: &shim.Column_String_{String_: p.CpfPagador}},
+			&shim.Column{Value: &shim.Column_Bool{Bool: p.PagadorAceitou}},
+			&shim.Column{Value: &shim.Column_Bool{Bool: p.BeneficiarioAceitou}},
+			&shim.Column{Value: &shim.Column_Bool{Bool: p.BoletoPago}},
+			&shim.Column{Value: &shim.Column_String_{String_: p.NossoNumero}},
+			&shim.Column{Value: &shim.Column_Int64{Int64: p.Valor}},
+			&shim.Column{Value: &shim.Column_String_{String_: p.DataPagamento}},
+			&shim.Column{Value: &shim.Column_Bool{Bool: p.Cancelada}},
+			&shim.Column{Value: &shim.Column_String_{String_: p.DataVencimento}},
+			&shim.Column{Value: &shim.Column_String_{String_: p.Beneficiario}},
+			&shim.Column{Value: &shim.Column_String_{String_: p.FormaPagamento}},
+			&shim.Column{Value: &shim.Column_String_{String_: p.EndToEndID}}},
+	}
+	if tabela == TabelaSimples {
+		row.Columns = row.Columns[:5]
+	}
+	return row
+}
+
+// registrarErro: registra no log o erro retornado pela função; as regras de negócio e
+// as permissões como aviso, as falhas inesperadas como erro
+func registrarErro(log *logging.Logger, err error) {
+	if err == nil {
+		return
+	}
+	e := envelope.Interno(err)
+	causa := e
+	if e.Causa != nil {
+		causa = e.Causa
+	}
+	switch causa.Codigo {
+	case envelope.ErroInterno, envelope.NotificacaoFalhou:
+		log.Erro(e.Mensagem, "codigo", string(e.Codigo))
+	default:
+		log.Aviso(e.Mensagem, "codigo", string(e.Codigo))
+	}
+}
+
+// erroEnvelope: converte o erro retornado pelas funções no envelope de erro (erros inesperados
+// viram ERRO_INTERNO), com a mensagem no idioma configurado
+func erroEnvelope(err error, idioma string) error {
+	if err == nil {
+		return nil
+	}
+	e := envelope.Interno(err)
+	if idioma != "" && idioma != envelope.IdiomaPadrao {
+		e = e.Traduzir(idioma)
+	}
+	return e
+}
@@ -0,0 +1,736 @@
+package propostas_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/chaincode/propostas"
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/simulator"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// configuracaoMetadata: tabela completa, com as funções do administrador protegidas
+// pelo metadata do deploy
+const configuracaoMetadata = `{"autenticacao": {"modo": "metadata"}, "tabela": "completa"}`
+
+// inicio: horário da primeira transação dos simuladores de teste
+var inicio = time.Date(2026, 11, 2, 10, 0, 0, 0, time.UTC)
+
+// implantar: simulador com o chaincode implantado pelo administrador e um outro chamador.
+// Os pares (codigoBanco, chavePublica) dos oráculos seguem a configuração no Init.
+func implantar(t *testing.T, configuracao string, oraculos ...string) (sim *simulator.Simulador, admin, outro *simulator.Identidade) {
+	t.Helper()
+	admin, err := simulator.NovaIdentidade("admin", []byte("admin"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outro, err = simulator.NovaIdentidade("pagador", []byte("pagador"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim = simulator.Novo(&propostas.BoletoPropostaChaincode{})
+	sim.Relogio = simulator.RelogioSequencial(inicio, time.Minute)
+	sim.GeradorTxID = simulator.TxIDSequencial("tx")
+	sim.Identidade = admin
+	if _, err := sim.Implantar("init", append([]string{configuracao}, oraculos...)); err != nil {
+		t.Fatal(err)
+	}
+	return sim, admin, outro
+}
+
+// oraculo: chave privada de um oráculo de teste e a chave pública em PEM
+func oraculo(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publica, err := oracle.CodificarChavePublica(&chave.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chave, string(publica)
+}
+
+// atestado: JSON do atestado assinado com a chave do oráculo
+func atestado(t *testing.T, chave *ecdsa.PrivateKey, a oracle.Atestado) string {
+	t.Helper()
+	if err := oracle.Assinar(chave, &a); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// invocar: executa os invokes em ordem, falhando o teste no primeiro erro
+func invocar(t *testing.T, l ledger.Ledger, chamadas ...[]string) {
+	t.Helper()
+	for _, c := range chamadas {
+		if _, err := l.Invoke(c[0], c[1:]); err != nil {
+			t.Fatalf("%s%q: %s", c[0], c[1:], err)
+		}
+	}
+}
+
+// ultimoEvento: evento da última transação confirmada (nil se ela não emitiu nenhum)
+func ultimoEvento(t *testing.T, sim *simulator.Simulador) *events.Evento {
+	t.Helper()
+	blocos := sim.Blocos()
+	txs := blocos[len(blocos)-1].Transacoes
+	tx := txs[len(txs)-1]
+	if tx.Evento == nil {
+		return nil
+	}
+	evento, err := events.Decodificar(tx.Evento.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(evento.Tipo) != tx.Evento.Nome {
+		t.Fatalf("evento %s publicado com o nome %s", evento.Tipo, tx.Evento.Nome)
+	}
+	if evento.TxID != tx.TxID {
+		t.Fatalf("evento com tx_id %s na transação %s", evento.TxID, tx.TxID)
+	}
+	if evento.Horario != tx.Horario.UTC().Format(time.RFC3339Nano) {
+		t.Fatalf("evento com horário %s na transação de %s", evento.Horario, tx.Horario)
+	}
+	return &evento
+}
+
+// alterados: campos alterados do evento em JSON, para comparação
+func alterados(t *testing.T, e *events.Evento) string {
+	t.Helper()
+	b, err := json.Marshal(e.Alterados)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// codigoErro: falha o teste se o erro não contiver o código informado
+func codigoErro(t *testing.T, err error, codigo string) {
+	t.Helper()
+	if err == nil || !strings.Contains(err.Error(), codigo) {
+		t.Fatalf("erro = %v, esperado %s", err, codigo)
+	}
+}
+
+func TestEventosDoCicloDeVida(t *testing.T) {
+	chave, publica := oraculo(t)
+	sim, _, _ := implantar(t, `{}`, "001", publica)
+	pagamento := atestado(t, chave, oracle.Atestado{CodigoBanco: "001", NossoNumero: "00000000001", Valor: 15000, DataPagamento: "2026-11-20"})
+
+	casos := []struct {
+		funcao    string
+		args      []string
+		tipo      events.Tipo
+		alterados string
+	}{
+		{"registrarProposta", []string{"p1", "111.111.111-11", "false", "false", "false"}, events.PropostaCriada,
+			`{"cpf_pagador":"111.111.111-11","pagador_aceitou":false,"beneficiario_aceitou":false,"boleto_pago":false,"status":"criada"}`},
+		{"registrarProposta", []string{"p1", "222.222.222-22", "false", "false", "false"}, events.PropostaAtualizada,
+			`{"cpf_pagador":"222.222.222-22"}`},
+		{"aceitarProposta", []string{"p1", "pagador"}, events.PropostaAceita,
+			`{"pagador_aceitou":true,"status":"criada"}`},
+		{"aceitarProposta", []string{"p1", "beneficiario"}, events.PropostaAceita,
+			`{"beneficiario_aceitou":true,"status":"aceita"}`},
+		{"emitirBoleto", []string{"p1", "00000000001", "15000", "2026-11-30", "12.345.678/0001-90"}, events.BoletoEmitido,
+			`{"nosso_numero":"00000000001","valor":15000,"data_vencimento":"2026-11-30","beneficiario":"12.345.678/0001-90","status":"boleto_emitido"}`},
+		{"confirmarPagamento", []string{"p1", pagamento}, events.PagamentoRegistrado,
+			`{"boleto_pago":true,"data_pagamento":"2026-11-20","status":"paga","forma_pagamento":"boleto","codigo_banco":"001"}`},
+		{"registrarProposta", []string{"p2", "333.333.333-33", "true", "false", "false"}, events.PropostaCriada,
+			`{"cpf_pagador":"333.333.333-33","pagador_aceitou":true,"beneficiario_aceitou":false,"boleto_pago":false,"status":"criada"}`},
+		{"cancelarProposta", []string{"p2", "desistência"}, events.PropostaCancelada,
+			`{"cancelada":true,"status":"cancelada","motivo":"desistência"}`},
+	}
+	for _, c := range casos {
+		if _, err := sim.Invoke(c.funcao, c.args); err != nil {
+			t.Fatalf("%s: %s", c.funcao, err)
+		}
+		evento := ultimoEvento(t, sim)
+		if evento == nil {
+			t.Fatalf("%s sem evento", c.funcao)
+		}
+		if evento.Tipo != c.tipo || evento.Versao != 1 || evento.IDProposta != c.args[0] {
+			t.Fatalf("%s: evento %s v%d da proposta %s, esperado %s v1 de %s", c.funcao, evento.Tipo, evento.Versao, evento.IDProposta, c.tipo, c.args[0])
+		}
+		if got := alterados(t, evento); got != c.alterados {
+			t.Fatalf("%s: alterados %s, esperado %s", c.funcao, got, c.alterados)
+		}
+	}
+}
+
+func TestSemEventoSemAlteracao(t *testing.T) {
+	sim, _, _ := implantar(t, `{}`)
+	registro := []string{"p1", "111.111.111-11", "true", "false", "false"}
+	invocar(t, sim, append([]string{"registrarProposta"}, registro...))
+	transacoes := sim.Transacoes()
+
+	// a atualização sem alterações é confirmada, mas não emite evento
+	invocar(t, sim, append([]string{"registrarProposta"}, registro...))
+	if evento := ultimoEvento(t, sim); evento != nil {
+		t.Fatalf("evento %s sem alteração da proposta", evento.Tipo)
+	}
+
+	// os invokes recusados não chegam ao bloco, portanto também não emitem evento
+	_, err := sim.Invoke("aceitarProposta", []string{"p1", "pagador"})
+	codigoErro(t, err, "ACEITE_JA_REGISTRADO")
+	if sim.Transacoes() != transacoes+1 {
+		t.Fatalf("%d transações, esperadas %d", sim.Transacoes(), transacoes+1)
+	}
+}
+
+func TestEmitirBoletoRepetido(t *testing.T) {
+	sim, _, _ := implantar(t, configuracaoMetadata)
+	invocar(t, sim,
+		[]string{"registrarProposta", "p1", "111.111.111-11", "true", "true", "false"},
+		[]string{"emitirBoleto", "p1", "00000000001", "15000"},
+	)
+
+	_, err := sim.Invoke("emitirBoleto", []string{"p1", "00000000002", "9000"})
+	codigoErro(t, err, "BOLETO_JA_EMITIDO")
+	// o registrarProposta com nosso número e valor também não substitui o boleto
+	transacoes := sim.Transacoes()
+	_, err = sim.Invoke("registrarProposta", []string{"p1", "111.111.111-11", "true", "true", "false", "00000000002", "1"})
+	codigoErro(t, err, "BOLETO_JA_EMITIDO")
+	if sim.Transacoes() != transacoes {
+		t.Fatal("registrarProposta recusado confirmado no ledger")
+	}
+
+	resposta, err := sim.Query("consultarProposta", []string{"p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resposta), `"nosso_numero":"00000000001"`) || !strings.Contains(string(resposta), `"valor":15000`) {
+		t.Fatalf("boleto alterado: %s", resposta)
+	}
+}
+
+func TestDeploySemMetadata(t *testing.T) {
+	for _, modo := range []string{"nenhuma", "metadata"} {
+		t.Run(modo, func(t *testing.T) {
+			sim := simulator.Novo(&propostas.BoletoPropostaChaincode{})
+			if _, err := sim.Implantar("init", []string{`{"autenticacao": {"modo": "` + modo + `"}}`}); err != nil {
+				t.Fatalf("deploy sem metadata recusado: %s", err)
+			}
+			// sem administrador, ninguém executa as funções protegidas, nem sem metadata
+			_, err := sim.Invoke("registrarOraculo", []string{"001", "chave"})
+			codigoErro(t, err, "NAO_AUTORIZADO")
+		})
+	}
+}
+
+func TestSegredoNotificacao(t *testing.T) {
+	var assinaturas, corpos []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		corpo, _ := ioutil.ReadAll(r.Body)
+		assinaturas = append(assinaturas, r.Header.Get("X-Assinatura"))
+		corpos = append(corpos, string(corpo))
+	}))
+	defer api.Close()
+	configuracao := `{"notificacao": {"modo": "http", "url": "` + api.URL + `", "na_criacao": true%s}}`
+
+	// o segredo não é aceito nos argumentos do Init, registrados no ledger
+	sim := simulator.Novo(&propostas.BoletoPropostaChaincode{})
+	_, err := sim.Implantar("init", []string{strings.Replace(configuracao, "%s", `, "segredo": "s1"`, 1)})
+	codigoErro(t, err, "CONFIGURACAO_INVALIDA")
+
+	assinar := func(segredo, corpo string) string {
+		mac := hmac.New(sha256.New, []byte(segredo))
+		mac.Write([]byte(corpo))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	casos := []struct {
+		nome       string
+		chaincode  *propostas.BoletoPropostaChaincode
+		ambiente   string
+		assinatura string // segredo esperado ("" sem assinatura)
+	}{
+		{"sem segredo", &propostas.BoletoPropostaChaincode{}, "", ""},
+		{"variável de ambiente", &propostas.BoletoPropostaChaincode{}, "s2", "s2"},
+		{"configuração do chaincode", &propostas.BoletoPropostaChaincode{Configuracao: &propostas.Configuracao{
+			Notificacao: propostas.Notificacao{Modo: propostas.NotificacaoHTTP, URL: api.URL, Segredo: "s3", NaCriacao: true},
+		}}, "s2", "s3"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			t.Setenv(propostas.VariavelSegredoNotificacao, c.ambiente)
+			assinaturas, corpos = nil, nil
+			sim := simulator.Novo(c.chaincode)
+			args := []string{strings.Replace(configuracao, "%s", "", 1)}
+			if c.chaincode.Configuracao != nil {
+				args = nil
+			}
+			if _, err := sim.Implantar("init", args); err != nil {
+				t.Fatal(err)
+			}
+			invocar(t, sim, []string{"registrarProposta", "p1", "111.111.111-11", "false", "false", "false"})
+
+			if len(corpos) != 1 {
+				t.Fatalf("%d notificações, esperada 1", len(corpos))
+			}
+			esperada := ""
+			if c.assinatura != "" {
+				esperada = assinar(c.assinatura, corpos[0])
+			}
+			if assinaturas[0] != esperada {
+				t.Fatalf("X-Assinatura %q, esperada %q", assinaturas[0], esperada)
+			}
+			for _, e := range sim.Snapshot().Estado {
+				if strings.Contains(string(e.Valor), `"segredo"`) {
+					t.Fatalf("segredo gravado no estado na chave %s: %s", e.Chave, e.Valor)
+				}
+			}
+		})
+	}
+}
+
+func TestFuncoesDoAdministrador(t *testing.T) {
+	sim, _, outro := implantar(t, configuracaoMetadata)
+	invocar(t, sim, []string{"registrarProposta", "p1", "111.111.111-11", "false", "false", "false"})
+
+	chamadas := [][]string{
+		{"aceitarProposta", "p1", "pagador"},
+		{"emitirBoleto", "p1", "00000000001", "15000"},
+		{"cancelarProposta", "p1", "desistência"},
+		{"executarLote", `{"operacoes": [{"funcao": "aceitarProposta", "documento": {"id_proposta": "p1", "parte": "pagador"}}]}`},
+	}
+	for _, c := range chamadas {
+		_, err := sim.Como(outro).Invoke(c[0], c[1:])
+		codigoErro(t, err, "NAO_AUTORIZADO")
+	}
+	invocar(t, sim,
+		[]string{"aceitarProposta", "p1", "pagador"},
+		[]string{"aceitarProposta", "p1", "beneficiario"},
+		chamadas[1], chamadas[2],
+	)
+
+	// sem autenticação, o papel só é verificado nas funções restritas
+	sim, _, outro = implantar(t, `{}`)
+	invocar(t, sim.Como(outro),
+		[]string{"registrarProposta", "p1", "111.111.111-11", "true", "true", "false"},
+		chamadas[1], chamadas[2],
+	)
+	_, err := sim.Como(outro).Invoke("registrarOraculo", []string{"001", "chave"})
+	codigoErro(t, err, "NAO_AUTORIZADO")
+}
+
+func TestListarFuncoes(t *testing.T) {
+	casos := []struct {
+		nome          string
+		configuracao  string
+		administrador []string // funções listadas com o papel administrador
+		indisponiveis []string
+	}{
+		{"sem autenticação", `{}`, []string{"init", "registrarOraculo"}, nil},
+		{"metadata", configuracaoMetadata,
+			[]string{"init", "registrarProposta", "aceitarProposta", "emitirBoleto", "registrarCobrancaPix", "cancelarProposta", "executarLote", "registrarConciliacao", "registrarOraculo", "expurgarRequisicoes"}, nil},
+		{"funções informadas", `{"autenticacao": {"modo": "metadata", "funcoes": ["consultarProposta"]}}`,
+			[]string{"init", "registrarOraculo", "consultarProposta"}, nil},
+		{"tabela simples", `{"autenticacao": {"modo": "metadata", "funcoes": ["registrarProposta"]}, "tabela": "simples"}`,
+			[]string{"init", "registrarProposta", "registrarOraculo"},
+			[]string{"emitirBoleto", "confirmarPagamento", "registrarCobrancaPix", "cancelarProposta", "registrarConciliacao", "registrarOraculo",
+				"agingRecebiveis", "gerarBRCode", "consultarConciliacao", "listarStatusConciliacao"}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			sim, _, outro := implantar(t, c.configuracao)
+			resposta, err := sim.Como(outro).Query("listarFuncoes", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var catalogo []propostas.Funcao
+			if err := json.Unmarshal(resposta, &catalogo); err != nil {
+				t.Fatal(err)
+			}
+			var administrador, indisponiveis []string
+			for _, f := range catalogo {
+				if f.Papel == propostas.PapelAdministrador {
+					administrador = append(administrador, f.Nome)
+				}
+				if !f.Disponivel {
+					indisponiveis = append(indisponiveis, f.Nome)
+				}
+			}
+			if strings.Join(administrador, " ") != strings.Join(c.administrador, " ") {
+				t.Fatalf("papel administrador em %v, esperado em %v", administrador, c.administrador)
+			}
+			if strings.Join(indisponiveis, " ") != strings.Join(c.indisponiveis, " ") {
+				t.Fatalf("indisponíveis %v, esperadas %v", indisponiveis, c.indisponiveis)
+			}
+		})
+	}
+
+	sim, _, _ := implantar(t, `{}`)
+	_, err := sim.Query("listarFuncoes", []string{"todas"})
+	codigoErro(t, err, "ARGUMENTOS_INVALIDOS")
+}
+
+// chaincodeTrocavel: chaincode do simulador substituído entre deploys, como em um upgrade
+type chaincodeTrocavel struct {
+	shim.Chaincode
+}
+
+// legado: chaincode de uma versão anterior, que cria a tabela 'Proposta' com as
+// primeiras colunas do esquema e a proposta p1, aceita pelo pagador
+type legado struct {
+	colunas int
+	versao  string // chave versao gravada no Init (vazia nos estados anteriores ao registro)
+}
+
+func (l legado) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	nomes := []string{"Id", "cpfPagador", "pagadorAceitou", "beneficiarioAceitou", "boletoPago", "nossoNumero", "valor", "dataPagamento"}
+	tipos := []shim.ColumnDefinition_Type{shim.ColumnDefinition_STRING, shim.ColumnDefinition_STRING, shim.ColumnDefinition_BOOL,
+		shim.ColumnDefinition_BOOL, shim.ColumnDefinition_BOOL, shim.ColumnDefinition_STRING, shim.ColumnDefinition_INT64, shim.ColumnDefinition_STRING}
+	colunas := []*shim.Column{
+		{Value: &shim.Column_String_{String_: "p1"}},
+		{Value: &shim.Column_String_{String_: "111.111.111-11"}},
+		{Value: &shim.Column_Bool{Bool: true}},
+		{Value: &shim.Column_Bool{Bool: false}},
+		{Value: &shim.Column_Bool{Bool: false}},
+		{Value: &shim.Column_String_{String_: "00000000001"}},
+		{Value: &shim.Column_Int64{Int64: 15000}},
+		{Value: &shim.Column_String_{String_: ""}},
+	}
+	var definicoes []*shim.ColumnDefinition
+	for i := 0; i < l.colunas; i++ {
+		definicoes = append(definicoes, &shim.ColumnDefinition{Name: nomes[i], Type: tipos[i], Key: i == 0})
+	}
+	if err := stub.CreateTable("Proposta", definicoes); err != nil {
+		return nil, err
+	}
+	if _, err := stub.InsertRow("Proposta", shim.Row{Columns: colunas[:l.colunas]}); err != nil {
+		return nil, err
+	}
+	if l.versao != "" {
+		return nil, stub.PutState("versao", []byte(l.versao))
+	}
+	return nil, nil
+}
+
+func (l legado) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return nil, nil
+}
+
+func (l legado) Query(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return nil, nil
+}
+
+// consultar: proposta retornada por consultarProposta
+func consultar(t *testing.T, l ledger.Ledger, id string) map[string]interface{} {
+	t.Helper()
+	resposta, err := l.Query("consultarProposta", []string{id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var proposta map[string]interface{}
+	if err := json.Unmarshal(resposta, &proposta); err != nil {
+		t.Fatal(err)
+	}
+	return proposta
+}
+
+func TestMigracoes(t *testing.T) {
+	casos := []struct {
+		nome         string
+		legado       legado
+		configuracao string
+		valor        float64 // valor da proposta p1 depois da migração
+		simples      bool
+	}{
+		{"esquema 1", legado{colunas: 5}, `{}`, 0, false},
+		{"esquema 2", legado{colunas: 8}, `{}`, 15000, false},
+		{"esquema 1 com a tabela simples", legado{colunas: 5}, `{"tabela": "simples"}`, 0, true},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			cc := &chaincodeTrocavel{c.legado}
+			sim := simulator.Novo(cc)
+			if _, err := sim.Implantar("init", nil); err != nil {
+				t.Fatal(err)
+			}
+			cc.Chaincode = &propostas.BoletoPropostaChaincode{}
+			if _, err := sim.Implantar("init", []string{c.configuracao}); err != nil {
+				t.Fatalf("migração: %s", err)
+			}
+
+			resposta, err := sim.Query("versao", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var versao propostas.RespostaVersao
+			if err := json.Unmarshal(resposta, &versao); err != nil {
+				t.Fatal(err)
+			}
+			if versao.Estado == nil || versao.Estado.VersaoEsquema != propostas.VersaoEsquema || versao.Estado.Versao != propostas.Versao {
+				t.Fatalf("estado %+v, esperado o esquema %d da versão %s", versao.Estado, propostas.VersaoEsquema, propostas.Versao)
+			}
+
+			// as propostas da versão anterior são mantidas
+			p1 := consultar(t, sim, "p1")
+			if p1["cpf_pagador"] != "111.111.111-11" || p1["pagador_aceitou"] != true || p1["beneficiario_aceitou"] != false {
+				t.Fatalf("proposta migrada %v", p1)
+			}
+			if valor, _ := p1["valor"].(float64); valor != c.valor {
+				t.Fatalf("valor %v, esperado %v", p1["valor"], c.valor)
+			}
+
+			invocar(t, sim, []string{"aceitarProposta", "p1", "beneficiario"})
+			if c.simples {
+				_, err := sim.Invoke("cancelarProposta", []string{"p1", "desistência"})
+				codigoErro(t, err, "FUNCAO_INDISPONIVEL")
+				return
+			}
+			// e as colunas acrescentadas pelas migrações 3 a 5 são gravadas
+			invocar(t, sim,
+				[]string{"registrarProposta", "p2", "222.222.222-22", "true", "true", "false"},
+				[]string{"emitirBoleto", "p2", "00000000002", "9000", "2026-11-30", "12.345.678/0001-90"},
+				[]string{"cancelarProposta", "p2", "desistência"},
+			)
+			if p2 := consultar(t, sim, "p2"); p2["cancelada"] != true || p2["beneficiario"] != "12.345.678/0001-90" {
+				t.Fatalf("proposta após a migração %v", p2)
+			}
+		})
+	}
+
+	// estado gravado por uma versão mais recente do chaincode
+	cc := &chaincodeTrocavel{legado{colunas: 5, versao: `{"versao": "9.0.0", "versao_esquema": 6}`}}
+	sim := simulator.Novo(cc)
+	if _, err := sim.Implantar("init", nil); err != nil {
+		t.Fatal(err)
+	}
+	cc.Chaincode = &propostas.BoletoPropostaChaincode{}
+	_, err := sim.Implantar("init", []string{`{}`})
+	codigoErro(t, err, "ESQUEMA_INCOMPATIVEL")
+}
+
+func TestRequisicoesIdempotentes(t *testing.T) {
+	sim, _, _ := implantar(t, `{"retencao_requisicoes": "10m"}`)
+	agora := inicio
+	sim.Relogio = func() time.Time { return agora }
+
+	registro := []string{"id_requisicao=r1", "p1", "111.111.111-11", "false", "false", "false"}
+	original, err := sim.Invoke("registrarProposta", registro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	documento := `{"id_requisicao": "r2", "id_proposta": "p1", "parte": "pagador"}`
+	invocar(t, sim, []string{"aceitarProposta", documento})
+
+	// a repetição retorna a resposta original sem executar a função: o registro não
+	// desfaz o aceite e nenhum evento é emitido
+	agora = agora.Add(5 * time.Minute)
+	for _, c := range [][]string{append([]string{"registrarProposta"}, registro...), {"aceitarProposta", documento}} {
+		resultado, err := sim.Invoke(c[0], c[1:])
+		if err != nil {
+			t.Fatalf("repetição de %s: %s", c[0], err)
+		}
+		if evento := ultimoEvento(t, sim); evento != nil {
+			t.Fatalf("repetição de %s emitiu o evento %s", c[0], evento.Tipo)
+		}
+		if c[0] == "registrarProposta" && string(resultado.Payload) != string(original.Payload) {
+			t.Fatalf("resposta %s, esperada a original %s", resultado.Payload, original.Payload)
+		}
+	}
+	if p1 := consultar(t, sim, "p1"); p1["pagador_aceitou"] != true {
+		t.Fatalf("proposta alterada pela repetição: %v", p1)
+	}
+
+	// o mesmo ID com outros argumentos ou outra função é recusado
+	for _, c := range [][]string{
+		{"registrarProposta", "id_requisicao=r1", "p1", "222.222.222-22", "false", "false", "false"},
+		{"aceitarProposta", "id_requisicao=r1", "p1", "beneficiario"},
+		{"aceitarProposta", `{"id_requisicao": "r2", "id_proposta": "p1", "parte": "beneficiario"}`},
+	} {
+		_, err := sim.Invoke(c[0], c[1:])
+		codigoErro(t, err, "ID_REQUISICAO_REUTILIZADO")
+	}
+
+	// o expurgo exclui apenas as requisições fora da retenção
+	invocar(t, sim, []string{"registrarProposta", "id_requisicao=r3", "p2", "333.333.333-33", "false", "false", "false"})
+	agora = inicio.Add(10 * time.Minute)
+	resultado, err := sim.Invoke("expurgarRequisicoes", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resultado.Payload) != `{"expurgadas":2,"mantidas":1}` {
+		t.Fatalf("expurgo %s", resultado.Payload)
+	}
+	if resultado, err = sim.Invoke("expurgarRequisicoes", nil); err != nil || string(resultado.Payload) != `{"expurgadas":0,"mantidas":1}` {
+		t.Fatalf("segundo expurgo %s, %v", resultado.Payload, err)
+	}
+
+	// depois da retenção, o ID pode ser reutilizado; dentro dela, a resposta é mantida
+	invocar(t, sim, []string{"registrarProposta", "id_requisicao=r1", "p1", "222.222.222-22", "true", "false", "false"})
+	if p1 := consultar(t, sim, "p1"); p1["cpf_pagador"] != "222.222.222-22" {
+		t.Fatalf("ID expirado não reutilizado: %v", p1)
+	}
+	_, err = sim.Invoke("registrarProposta", []string{"id_requisicao=r3", "p2", "444.444.444-44", "false", "false", "false"})
+	codigoErro(t, err, "ID_REQUISICAO_REUTILIZADO")
+}
+
+func TestLoteRejeitado(t *testing.T) {
+	var notificadas []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p propostas.Proposta
+		json.NewDecoder(r.Body).Decode(&p)
+		notificadas = append(notificadas, p.ID)
+	}))
+	defer api.Close()
+	sim, _, _ := implantar(t, `{"notificacao": {"modo": "http", "url": "`+api.URL+`", "na_criacao": true}}`)
+	invocar(t, sim, []string{"registrarProposta", "p1", "111.111.111-11", "false", "false", "false"})
+	notificadas = nil
+	transacoes := sim.Transacoes()
+
+	operacoes := []string{
+		`{"funcao": "registrarProposta", "documento": {"id_proposta": "p1", "cpf_pagador": "222.222.222-22", "pagador_aceitou": true, "beneficiario_aceitou": false, "boleto_pago": false}}`,
+		`{"funcao": "registrarProposta", "documento": {"id_proposta": "p2", "cpf_pagador": "333.333.333-33", "pagador_aceitou": false, "beneficiario_aceitou": false, "boleto_pago": false}}`,
+		`{"funcao": "aceitarProposta", "documento": {"id_proposta": "p9", "parte": "pagador"}}`,
+	}
+	lote := func(ops ...string) string { return `{"operacoes": [` + strings.Join(ops, ", ") + `]}` }
+
+	_, err := sim.Invoke("executarLote", []string{lote(operacoes...)})
+	e, ok := envelope.Decodificar(err)
+	esperado := envelope.Lote(2, "aceitarProposta", envelope.Novo(envelope.PropostaNaoEncontrada, "id", "p9"))
+	if !ok || e.Error() != esperado.Error() {
+		t.Fatalf("erro = %v, esperado %s", err, esperado)
+	}
+
+	// nenhuma operação é aplicada e nenhuma proposta é notificada
+	if sim.Transacoes() != transacoes || len(notificadas) > 0 {
+		t.Fatalf("%d transações e notificações de %v depois do lote rejeitado", sim.Transacoes()-transacoes, notificadas)
+	}
+	if p1 := consultar(t, sim, "p1"); p1["cpf_pagador"] != "111.111.111-11" || p1["pagador_aceitou"] != false {
+		t.Fatalf("proposta alterada pelo lote rejeitado: %v", p1)
+	}
+	_, err = sim.Query("consultarProposta", []string{"p2"})
+	codigoErro(t, err, "PROPOSTA_NAO_ENCONTRADA")
+
+	// sem a operação recusada, o lote é aplicado e as propostas são notificadas no fim
+	invocar(t, sim, []string{"executarLote", lote(operacoes[:2]...)})
+	if strings.Join(notificadas, " ") != "p1 p2" {
+		t.Fatalf("notificações de %v, esperadas de p1 e p2", notificadas)
+	}
+}
+
+func TestRegistrarCobrancaPix(t *testing.T) {
+	sim, _, outro := implantar(t, `{"autenticacao": {"modo": "metadata"}, "pix": {"chave": "12345678909", "nome": "BLOCKCHAIN DOJO", "cidade": "SAO PAULO"}}`)
+	invocar(t, sim, []string{"registrarProposta", "p1", "111.111.111-11", "true", "true", "false"})
+
+	// sem valor na proposta, o valor informado não é aceito como o da cobrança
+	_, err := sim.Invoke("registrarCobrancaPix", []string{"p1", "15000"})
+	codigoErro(t, err, "CAMPO_OBRIGATORIO")
+	if p1 := consultar(t, sim, "p1"); p1["valor"] != float64(0) {
+		t.Fatalf("valor da proposta alterado pela cobrança: %v", p1)
+	}
+
+	invocar(t, sim, []string{"emitirBoleto", "p1", "00000000001", "15000"})
+	_, err = sim.Como(outro).Invoke("registrarCobrancaPix", []string{"p1"})
+	codigoErro(t, err, "NAO_AUTORIZADO")
+	_, err = sim.Invoke("registrarCobrancaPix", []string{"p1", "14000"})
+	codigoErro(t, err, "ARGUMENTO_INVALIDO")
+
+	resultado, err := sim.Invoke("registrarCobrancaPix", []string{"p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cobranca propostas.RespostaBRCode
+	if err := json.Unmarshal(resultado.Payload, &cobranca); err != nil {
+		t.Fatal(err)
+	}
+	if cobranca.Valor != 15000 || cobranca.TxID != "p1" || cobranca.BRCode == "" {
+		t.Fatalf("cobrança %+v", cobranca)
+	}
+
+	// a cobrança registrada não é substituída, nem com o mesmo valor
+	for _, args := range [][]string{{"p1"}, {"p1", "15000"}} {
+		_, err = sim.Invoke("registrarCobrancaPix", args)
+		codigoErro(t, err, "COBRANCA_PIX_JA_REGISTRADA")
+	}
+	brcode, err := sim.Query("gerarBRCode", []string{"p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(brcode) != string(resultado.Payload) {
+		t.Fatalf("BR Code %s, esperado o da cobrança registrada %s", brcode, resultado.Payload)
+	}
+}
+
+func TestAtualizacaoMantemVencimento(t *testing.T) {
+	sim, _, _ := implantar(t, `{}`)
+	invocar(t, sim,
+		[]string{"registrarProposta", "p1", "111.111.111-11", "true", "true", "false"},
+		[]string{"emitirBoleto", "p1", "00000000001", "15000", "2026-11-30", "12.345.678/0001-90"},
+		[]string{"registrarProposta", "p1", "222.222.222-22", "true", "true", "false"},
+	)
+	if got := alterados(t, ultimoEvento(t, sim)); got != `{"cpf_pagador":"222.222.222-22"}` {
+		t.Fatalf("alterados %s, esperado apenas o CPF", got)
+	}
+	p1 := consultar(t, sim, "p1")
+	if p1["data_vencimento"] != "2026-11-30" || p1["beneficiario"] != "12.345.678/0001-90" || p1["nosso_numero"] != "00000000001" {
+		t.Fatalf("boleto alterado pela atualização: %v", p1)
+	}
+
+	// o aging continua com o vencimento do boleto (31 a 60 dias em atraso)
+	resposta, err := sim.Query("agingRecebiveis", []string{"json", "2027-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resposta), `"dias_31_60":{"quantidade":1,"valor":15000}`) {
+		t.Fatalf("aging %s", resposta)
+	}
+}
+
+func TestRepeticaoComOutraAssinatura(t *testing.T) {
+	chave, publica := oraculo(t)
+	sim, _, _ := implantar(t, `{}`, "001", publica)
+	invocar(t, sim,
+		[]string{"registrarProposta", "p1", "111.111.111-11", "true", "true", "false"},
+		[]string{"emitirBoleto", "p1", "00000000001", "15000"},
+	)
+	pagamento := oracle.Atestado{CodigoBanco: "001", NossoNumero: "00000000001", Valor: 15000, DataPagamento: "2026-11-20"}
+	original, err := sim.Invoke("confirmarPagamento", []string{"id_requisicao=r1", "p1", atestado(t, chave, pagamento)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// o mesmo atestado assinado de novo com um nonce aleatório
+	hash := sha256.Sum256(pagamento.Mensagem())
+	sig, err := ecdsa.SignASN1(rand.Reader, chave, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagamento.Assinatura = base64.StdEncoding.EncodeToString(sig)
+	b, _ := json.Marshal(pagamento)
+	repeticao, err := sim.Invoke("confirmarPagamento", []string{"id_requisicao=r1", "p1", string(b)})
+	if err != nil {
+		t.Fatalf("repetição com outra assinatura: %s", err)
+	}
+	if string(repeticao.Payload) != string(original.Payload) {
+		t.Fatalf("resposta %s, esperada a original %s", repeticao.Payload, original.Payload)
+	}
+
+	// outro atestado com o mesmo ID continua recusado
+	pagamento.DataPagamento = "2026-11-21"
+	_, err = sim.Invoke("confirmarPagamento", []string{"id_requisicao=r1", "p1", atestado(t, chave, pagamento)})
+	codigoErro(t, err, "ID_REQUISICAO_REUTILIZADO")
+}
@@ -0,0 +1,237 @@
+/*
+Descrição: requisições idempotentes
+As funções Invoke aceitam um ID de requisição do cliente (ver validation.IDRequisicao).
+A resposta de cada requisição executada com sucesso é gravada no estado durante a
+retenção configurada; uma repetição com o mesmo ID e os mesmos argumentos retorna a
+resposta original sem executar a função de novo, portanto sem alterar a proposta,
+emitir eventos ou chamar a API externa.
+*/
+
+package propostas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// RetencaoRequisicoesPadrao - retenção das respostas quando a configuração não informa nenhuma
+const RetencaoRequisicoesPadrao = "24h"
+
+// prefixo das chaves de estado das requisições. Os IDs não contêm '~', que encerra o
+// intervalo das chaves no expurgo.
+const prefixoRequisicao = "requisicao_"
+
+// Requisicao - resultado de uma requisição idempotente gravado no estado
+type Requisicao struct {
+	Funcao string `json:"funcao"`
+	// Argumentos: SHA-256 dos argumentos, para recusar o mesmo ID em outra chamada
+	Argumentos string `json:"argumentos"`
+	Resposta   []byte `json:"resposta"`
+	TxID       string `json:"tx_id"`
+	// Horario: horário da transação original, em segundos (0: não informado pelo peer)
+	Horario int64 `json:"horario"`
+}
+
+// RespostaExpurgo - resposta do invoke expurgarRequisicoes
+type RespostaExpurgo struct {
+	Expurgadas int `json:"expurgadas"`
+	Mantidas   int `json:"mantidas"`
+}
+
+// retencao: retenção configurada das respostas (validada no Init)
+func (cfg Configuracao) retencao() time.Duration {
+	d, err := time.ParseDuration(cfg.RetencaoRequisicoes)
+	if err != nil || d <= 0 {
+		d, _ = time.ParseDuration(RetencaoRequisicoesPadrao)
+	}
+	return d
+}
+
+// horarioTransacao: horário da transação em segundos (0 se o peer não o informar)
+func horarioTransacao(stub shim.ChaincodeStubInterface) int64 {
+	if ts, err := stub.GetTxTimestamp(); err == nil && ts != nil {
+		return ts.Seconds
+	}
+	return 0
+}
+
+// expirada: indica se a requisição já passou da retenção no horário informado. Sem o
+// horário da transação, a requisição é mantida.
+func (r Requisicao) expirada(agora int64, retencao time.Duration) bool {
+	if agora == 0 || r.Horario == 0 {
+		return false
+	}
+	return agora-r.Horario >= int64(retencao/time.Second)
+}
+
+// resumoArgumentos: SHA-256 da função e dos argumentos, separados pelo tamanho de cada um.
+// Os documentos JSON entram sem as assinaturas, portanto o mesmo atestado assinado de
+// novo (com outro nonce) é reconhecido como repetição.
+func resumoArgumentos(funcao string, args []string) string {
+	h := sha256.New()
+	for _, s := range append([]string{funcao}, args...) {
+		s = semAssinaturas(s)
+		h.Write([]byte(strconv.Itoa(len(s)) + ":" + s))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// semAssinaturas: documento JSON sem os campos assinatura, em qualquer nível (o argumento
+// é retornado sem alterações se não for um documento válido)
+func semAssinaturas(arg string) string {
+	if !validation.EhDocumento(arg) {
+		return arg
+	}
+	d := json.NewDecoder(strings.NewReader(arg))
+	d.UseNumber()
+	var documento interface{}
+	if d.Decode(&documento) != nil {
+		return arg
+	}
+	removerAssinaturas(documento)
+	b, err := json.Marshal(documento)
+	if err != nil {
+		return arg
+	}
+	return string(b)
+}
+
+func removerAssinaturas(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		delete(v, "assinatura")
+		for _, campo := range v {
+			removerAssinaturas(campo)
+		}
+	case []interface{}:
+		for _, item := range v {
+			removerAssinaturas(item)
+		}
+	}
+}
+
+// obterRequisicao: requisição gravada com o ID informado (nil se não houver)
+func obterRequisicao(stub shim.ChaincodeStubInterface, id string) (*Requisicao, error) {
+	b, err := stub.GetState(prefixoRequisicao + id)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao obter a requisição %s: %s", id, err)
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var r Requisicao
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("Requisição %s gravada inválida: %s", id, err)
+	}
+	return &r, nil
+}
+
+// executarIdempotente: executa a função Invoke, retornando a resposta original quando o
+// ID da requisição já foi utilizado dentro da retenção
+func (t *BoletoPropostaChaincode) executarIdempotente(stub shim.ChaincodeStubInterface, f *Funcao, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	id, args, err := validation.IDRequisicao(args)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return f.executar(t, stub, args, cfg, log)
+	}
+	log = log.Com("id_requisicao", id)
+
+	agora := horarioTransacao(stub)
+	resumo := resumoArgumentos(f.Nome, args)
+	anterior, err := obterRequisicao(stub, id)
+	if err != nil {
+		return nil, err
+	}
+	if anterior != nil && !anterior.expirada(agora, cfg.retencao()) {
+		if anterior.Funcao != f.Nome || anterior.Argumentos != resumo {
+			return nil, envelope.Novo(envelope.IDRequisicaoReutilizado, "id_requisicao", id, "funcao", anterior.Funcao)
+		}
+		log.Info("Requisição repetida; retornando a resposta original", "tx_id_original", anterior.TxID)
+		return anterior.Resposta, nil
+	}
+
+	resposta, err := f.executar(t, stub, args, cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(Requisicao{
+		Funcao:     f.Nome,
+		Argumentos: resumo,
+		Resposta:   resposta,
+		TxID:       stub.GetTxID(),
+		Horario:    agora,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := stub.PutState(prefixoRequisicao+id, b); err != nil {
+		return nil, fmt.Errorf("Falha ao gravar a requisição %s: %s", id, err)
+	}
+	log.Debug("Resposta da requisição gravada")
+	return resposta, nil
+}
+
+// expurgarRequisicoes: função Invoke que exclui do estado as requisições fora da retenção,
+// sem argumentos. As requisições expiradas são ignoradas mesmo antes do expurgo; a função
+// apenas libera o estado.
+func (t *BoletoPropostaChaincode) expurgarRequisicoes(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	if err := validation.ExpurgarRequisicoes(args); err != nil {
+		return nil, err
+	}
+
+	agora := horarioTransacao(stub)
+	retencao := cfg.retencao()
+	resposta, err := excluirRequisicoes(stub, func(r Requisicao) bool { return r.expirada(agora, retencao) })
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Requisições expurgadas", "expurgadas", resposta.Expurgadas, "mantidas", resposta.Mantidas)
+
+	return json.Marshal(resposta)
+}
+
+// excluirRequisicoes: exclui do estado as requisições selecionadas (todas, com selecionar nil).
+// Registros ilegíveis também são excluídos.
+func excluirRequisicoes(stub shim.ChaincodeStubInterface, selecionar func(Requisicao) bool) (RespostaExpurgo, error) {
+	var resposta RespostaExpurgo
+	iter, err := stub.RangeQueryState(prefixoRequisicao, prefixoRequisicao+"~")
+	if err != nil {
+		return resposta, fmt.Errorf("Falha ao listar as requisições: %s", err)
+	}
+	var chaves []string
+	for iter.HasNext() {
+		chave, valor, err := iter.Next()
+		if err != nil {
+			iter.Close()
+			return resposta, fmt.Errorf("Falha ao listar as requisições: %s", err)
+		}
+		var r Requisicao
+		if selecionar != nil && json.Unmarshal(valor, &r) == nil && !selecionar(r) {
+			resposta.Mantidas++
+			continue
+		}
+		chaves = append(chaves, chave)
+	}
+	iter.Close()
+
+	for _, chave := range chaves {
+		if err := stub.DelState(chave); err != nil {
+			return resposta, fmt.Errorf("Falha ao excluir a requisição %s: %s", chave, err)
+		}
+	}
+	resposta.Expurgadas = len(chaves)
+	return resposta, nil
+}
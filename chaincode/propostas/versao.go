@@ -0,0 +1,281 @@
+/*
+Descrição: versão do chaincode e migração do estado entre versões
+O Init de um novo deploy sobre o estado de uma versão anterior não recria a tabela
+'Proposta': a versão do esquema do estado é identificada e as migrações registradas
+são executadas em ordem até a versão atual. O invoke init continua reiniciando o estado.
+O commit do build (e, nos builds de release, a versão) é informado com:
+	go build -ldflags "-X github.com/CaueP/BlockchainDojo/chaincode/propostas.Commit=$(git rev-parse --short HEAD) -X github.com/CaueP/BlockchainDojo/chaincode/propostas.Versao=1.2.0"
+*/
+
+package propostas
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// Versao - versão semântica do chaincode. Cada nova versão do esquema do estado
+// incrementa a versão minor (esquema 3: 1.0.0, 4: 1.1.0, 5: 1.2.0).
+var Versao = "1.2.0"
+
+// Commit - commit do build, informado com -ldflags "-X ...propostas.Commit=<commit>"
+var Commit = "desconhecido"
+
+// VersaoEsquema - versão do esquema do estado gravado por esta versão do chaincode
+// 1: tabela 'Proposta' com as 5 colunas do desafio (variantes start, cert e apicall)
+// 2: colunas do boleto e do pagamento (nossoNumero, valor, dataPagamento)
+// 3: coluna cancelada e configuração gravada no estado
+// 4: colunas do vencimento e do beneficiário do boleto (dataVencimento, beneficiario)
+// 5: colunas do pagamento PIX (formaPagamento, endToEndId)
+const VersaoEsquema = 5
+
+// chave de estado com a versão que executou o último Init
+const chaveVersao = "versao"
+
+// InfoVersao - versão do chaincode e do esquema do estado
+type InfoVersao struct {
+	Versao        string `json:"versao"`
+	Commit        string `json:"commit"`
+	VersaoEsquema int    `json:"versao_esquema"`
+}
+
+// RespostaVersao - resposta da query versao: o build em execução, as funcionalidades
+// habilitadas pela configuração e a versão que gravou o estado no último Init
+type RespostaVersao struct {
+	InfoVersao
+	Funcionalidades []string    `json:"funcionalidades"`
+	Estado          *InfoVersao `json:"estado,omitempty"`
+}
+
+// Migracao - passo de migração do estado para a versão do esquema informada
+type Migracao struct {
+	Versao    int    `json:"versao_esquema"`
+	Descricao string `json:"descricao"`
+	executar  func(stub shim.ChaincodeStubInterface, cfg Configuracao, log *logging.Logger) error
+}
+
+// migracoes: passos registrados, em ordem de versão. Com a tabela simples, as colunas
+// adicionadas pelas migrações não são criadas.
+var migracoes = []Migracao{
+	{
+		Versao:    2,
+		Descricao: "Adiciona à tabela Proposta as colunas nossoNumero, valor e dataPagamento",
+		executar:  expandirTabela(8),
+	},
+	{
+		Versao:    3,
+		Descricao: "Adiciona à tabela Proposta a coluna cancelada",
+		executar:  expandirTabela(9),
+	},
+	{
+		Versao:    4,
+		Descricao: "Adiciona à tabela Proposta as colunas dataVencimento e beneficiario",
+		executar:  expandirTabela(11),
+	},
+	{
+		Versao:    5,
+		Descricao: "Adiciona à tabela Proposta as colunas formaPagamento e endToEndId",
+		executar:  expandirTabela(13),
+	},
+}
+
+// versaoAtual: versão do build em execução
+func versaoAtual() InfoVersao {
+	return InfoVersao{Versao: Versao, Commit: Commit, VersaoEsquema: VersaoEsquema}
+}
+
+// versaoEsquemaEstado: versão do esquema do estado existente (0: estado vazio). Os estados
+// gravados antes do registro da versão são identificados pela configuração e pelas
+// colunas da tabela 'Proposta'.
+func versaoEsquemaEstado(stub shim.ChaincodeStubInterface) (int, error) {
+	gravada, err := versaoGravada(stub)
+	if err != nil {
+		return 0, err
+	}
+	if gravada != nil {
+		return gravada.VersaoEsquema, nil
+	}
+
+	tabela, err := stub.GetTable(nomeTabelaProposta)
+	if err != nil || tabela == nil {
+		// sem a tabela 'Proposta' (GetTable retorna erro para tabelas inexistentes)
+		return 0, nil
+	}
+	cfg, err := stub.GetState(chaveConfiguracao)
+	if err != nil {
+		return 0, fmt.Errorf("Falha ao obter a configuração: %s", err)
+	}
+	switch colunas := len(tabela.ColumnDefinitions); {
+	case colunas >= 13:
+		return 5, nil
+	case colunas >= 11:
+		return 4, nil
+	case len(cfg) > 0 || colunas >= 9:
+		return 3, nil
+	case colunas >= 8:
+		return 2, nil
+	}
+	return 1, nil
+}
+
+// versaoGravada: versão que executou o último Init (nil se o estado for anterior ao registro da versão)
+func versaoGravada(stub shim.ChaincodeStubInterface) (*InfoVersao, error) {
+	b, err := stub.GetState(chaveVersao)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao obter a versão gravada: %s", err)
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var v InfoVersao
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("Versão gravada inválida: %s", err)
+	}
+	return &v, nil
+}
+
+// gravarVersao: registra no estado a versão do build que executou o Init
+func gravarVersao(stub shim.ChaincodeStubInterface) error {
+	b, err := json.Marshal(versaoAtual())
+	if err != nil {
+		return err
+	}
+	if err := stub.PutState(chaveVersao, b); err != nil {
+		return fmt.Errorf("Falha ao gravar a versão: %s", err)
+	}
+	return nil
+}
+
+// migrar: executa as migrações do estado da versão anterior até a versão atual e adequa
+// a tabela 'Proposta' ao layout configurado
+func migrar(stub shim.ChaincodeStubInterface, anterior int, cfg Configuracao, log *logging.Logger) error {
+	if anterior > VersaoEsquema {
+		return envelope.Novo(envelope.EsquemaIncompativel,
+			"encontrada", strconv.Itoa(anterior), "suportada", strconv.Itoa(VersaoEsquema))
+	}
+	for _, m := range migracoes {
+		if m.Versao <= anterior {
+			continue
+		}
+		log.Info("Executando migração", "versao_esquema", m.Versao, "descricao", m.Descricao)
+		if err := m.executar(stub, cfg, log); err != nil {
+			return fmt.Errorf("Falha na migração para o esquema versão %d: %s", m.Versao, err)
+		}
+	}
+
+	// Troca de layout entre deploys da mesma versão do esquema
+	tabela, err := stub.GetTable(nomeTabelaProposta)
+	if err != nil {
+		return fmt.Errorf("Falha ao obter a tabela %s: %s", nomeTabelaProposta, err)
+	}
+	colunas := colunasTabelaProposta(cfg.Tabela)
+	switch {
+	case len(tabela.ColumnDefinitions) < len(colunas):
+		return reconstruirTabela(stub, colunas, log)
+	case len(tabela.ColumnDefinitions) > len(colunas):
+		return envelope.Novo(envelope.ConfiguracaoInvalida, "detalhe",
+			"a tabela "+nomeTabelaProposta+" existente possui as colunas da tabela completa; utilize o invoke init para recriá-la")
+	}
+	return nil
+}
+
+// expandirTabela: migração que acrescenta à tabela 'Proposta' as colunas da tabela completa
+// até o total informado
+func expandirTabela(total int) func(shim.ChaincodeStubInterface, Configuracao, *logging.Logger) error {
+	return func(stub shim.ChaincodeStubInterface, cfg Configuracao, log *logging.Logger) error {
+		if cfg.Tabela == TabelaSimples {
+			return nil
+		}
+		tabela, err := stub.GetTable(nomeTabelaProposta)
+		if err != nil {
+			return fmt.Errorf("Falha ao obter a tabela %s: %s", nomeTabelaProposta, err)
+		}
+		if len(tabela.ColumnDefinitions) >= total {
+			return nil
+		}
+		return reconstruirTabela(stub, colunasTabelaProposta(TabelaCompleta)[:total], log)
+	}
+}
+
+// reconstruirTabela: recria a tabela 'Proposta' com as colunas informadas, copiando as
+// linhas existentes. As colunas novas recebem o valor zero do tipo (o fabric v0.6 não
+// permite alterar a definição de uma tabela).
+func reconstruirTabela(stub shim.ChaincodeStubInterface, colunas []*shim.ColumnDefinition, log *logging.Logger) error {
+	rows, err := stub.GetRows(nomeTabelaProposta, []shim.Column{})
+	if err != nil {
+		return fmt.Errorf("Falha ao ler a tabela %s: %s", nomeTabelaProposta, err)
+	}
+	var linhas []shim.Row
+	for row := range rows {
+		linhas = append(linhas, row)
+	}
+
+	if err := stub.DeleteTable(nomeTabelaProposta); err != nil {
+		return fmt.Errorf("Falha ao excluir a tabela %s: %s", nomeTabelaProposta, err)
+	}
+	if err := stub.CreateTable(nomeTabelaProposta, colunas); err != nil {
+		return fmt.Errorf("Falha ao criar a tabela %s: %s", nomeTabelaProposta, err)
+	}
+	for _, row := range linhas {
+		for i := len(row.Columns); i < len(colunas); i++ {
+			row.Columns = append(row.Columns, colunaVazia(colunas[i].Type))
+		}
+		if _, err := stub.InsertRow(nomeTabelaProposta, row); err != nil {
+			return fmt.Errorf("Falha ao copiar a linha %s: %s", row.Columns[0].GetString_(), err)
+		}
+	}
+	log.Info("Tabela reconstruída", "tabela", nomeTabelaProposta, "colunas", len(colunas), "linhas", len(linhas))
+	return nil
+}
+
+// colunaVazia: coluna com o valor zero do tipo
+func colunaVazia(tipo shim.ColumnDefinition_Type) *shim.Column {
+	switch tipo {
+	case shim.ColumnDefinition_INT64:
+		return &shim.Column{Value: &shim.Column_Int64{Int64: 0}}
+	case shim.ColumnDefinition_BOOL:
+		return &shim.Column{Value: &shim.Column_Bool{Bool: false}}
+	}
+	return &shim.Column{Value: &shim.Column_String_{String_: ""}}
+}
+
+// funcionalidades: funcionalidades habilitadas pela configuração
+func funcionalidades(cfg Configuracao) []string {
+	f := []string{"eventos", "documentos_json", "catalogo_funcoes"}
+	if cfg.Tabela == TabelaCompleta {
+		f = append(f, "boleto", "pagamento_oraculo", "cancelamento", "aging", "conciliacao")
+		if cfg.Pix != nil {
+			f = append(f, "pix")
+		}
+	}
+	if cfg.Autenticacao.Modo != AutenticacaoNenhuma {
+		f = append(f, "autenticacao_"+cfg.Autenticacao.Modo)
+	}
+	if cfg.Notificacao.Modo != NotificacaoNenhuma {
+		f = append(f, "notificacao_"+cfg.Notificacao.Modo)
+	}
+	return f
+}
+
+// versao: função Query que retorna a versão do chaincode em execução, sem argumentos
+func (t *BoletoPropostaChaincode) versao(stub shim.ChaincodeStubInterface, args []string, cfg Configuracao, log *logging.Logger) ([]byte, error) {
+	if err := validation.Versao(args); err != nil {
+		return nil, err
+	}
+	estado, err := versaoGravada(stub)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(RespostaVersao{
+		InfoVersao:      versaoAtual(),
+		Funcionalidades: funcionalidades(cfg),
+		Estado:          estado,
+	})
+}
@@ -0,0 +1,114 @@
+/*
+Descrição: executa cenários YAML de ponta a ponta contra o simulador (ver pacote scenario)
+Uso:
+	cenarios [-junit relatorio.xml] [-v] <cenario.yaml|diretório>...
+Diretórios são percorridos em busca de arquivos .yaml e .yml. O processo termina com
+status 1 se algum cenário falhar.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/chaincode/propostas"
+	"github.com/CaueP/BlockchainDojo/scenario"
+)
+
+func main() {
+	junit := flag.String("junit", "", "arquivo do relatório JUnit")
+	verboso := flag.Bool("v", false, "exibe o log do chaincode")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Uso: cenarios [-junit relatorio.xml] [-v] <cenario.yaml|diretório>...")
+		os.Exit(2)
+	}
+
+	arquivos, err := listarCenarios(flag.Args())
+	if err != nil {
+		sair(err)
+	}
+
+	// o chaincode executado no próprio processo escreve o seu log na saída padrão (pacote logging)
+	saida := os.Stdout
+	if *verboso {
+		os.Stdout = os.Stderr
+	} else if nulo, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stdout = nulo
+	}
+
+	fabrica := func() shim.Chaincode { return new(propostas.BoletoPropostaChaincode) }
+	var resultados []scenario.Resultado
+	for _, arquivo := range arquivos {
+		c, err := scenario.Carregar(arquivo)
+		if err != nil {
+			resultados = append(resultados, scenario.Resultado{Cenario: arquivo, Arquivo: arquivo, Erro: err})
+			continue
+		}
+		resultados = append(resultados, scenario.Executar(c, fabrica))
+	}
+
+	fmt.Fprint(saida, scenario.Resumo(resultados))
+	if *junit != "" {
+		f, err := os.Create(*junit)
+		if err != nil {
+			sair(err)
+		}
+		if err := scenario.RelatorioJUnit(f, resultados); err != nil {
+			sair(err)
+		}
+		if err := f.Close(); err != nil {
+			sair(err)
+		}
+	}
+
+	for _, r := range resultados {
+		if !r.Sucesso() {
+			os.Exit(1)
+		}
+	}
+}
+
+// listarCenarios: arquivos informados e arquivos YAML dos diretórios informados, em ordem
+func listarCenarios(caminhos []string) ([]string, error) {
+	var arquivos []string
+	for _, caminho := range caminhos {
+		info, err := os.Stat(caminho)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			arquivos = append(arquivos, caminho)
+			continue
+		}
+		var doDiretorio []string
+		err = filepath.Walk(caminho, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(p))
+			if !info.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				doDiretorio = append(doDiretorio, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(doDiretorio)
+		arquivos = append(arquivos, doDiretorio...)
+	}
+	return arquivos, nil
+}
+
+func sair(err error) {
+	fmt.Fprintln(os.Stderr, "cenarios: "+err.Error())
+	os.Exit(1)
+}
@@ -0,0 +1,282 @@
+/*
+Descrição: arquivos CNAB de cobrança: remessa CNAB 240 e retorno CNAB 240 ou 400 (ver pacote cnab)
+Uso:
+	cnab remessa -config cnab.json [-projecao propostas.db | -propostas lista.json | -peer <url> -chaincode <id>]
+	             [-sequencial <n>] [-gerado-em AAAA-MM-DDTHH:MM:SS] [-arquivo remessa.rem] [-comparar esperado.rem]
+	cnab retorno -arquivo retorno.ret [-projecao propostas.db | -propostas lista.json] -chave-oraculo oraculo.pem
+	             -peer <url> -chaincode <id> [-lote 50] [-simular] [-formato json|csv] [-pendencias]
+
+A remessa inclui as propostas com status boleto_emitido cujo beneficiário é a empresa da
+configuração (ou não foi informado na emissão do boleto). Com -comparar, o arquivo gerado
+é comparado byte a byte com o arquivo informado (por exemplo, as fixtures em cnab/fixtures).
+
+O retorno (CNAB 240 ou 400) é conciliado com as propostas pelo nosso número, e as
+liquidações conferidas são enviadas ao chaincode como confirmarPagamento, com os atestados
+assinados com a chave do oráculo do banco, em lotes de executarLote; o relatório
+lista todas as ocorrências, ou apenas as pendências com -pendencias.
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/cnab"
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/projection"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		uso()
+	}
+	var err error
+	switch os.Args[1] {
+	case "remessa":
+		err = remessa(os.Args[2:])
+	case "retorno":
+		err = retorno(os.Args[2:])
+	default:
+		uso()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "cnab: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func uso() {
+	fmt.Fprintln(os.Stderr, `Uso: cnab <comando> [opções]
+
+Comandos:
+  remessa    gera o arquivo de remessa CNAB 240 dos boletos emitidos (cnab remessa -h para as opções)
+  retorno    importa o arquivo de retorno CNAB 240 ou 400, liquidando as propostas pagas (cnab retorno -h)`)
+	os.Exit(2)
+}
+
+// remessa: gera a remessa das propostas com boleto emitido
+func remessa(args []string) error {
+	fs := flag.NewFlagSet("remessa", flag.ExitOnError)
+	config := fs.String("config", "cnab.json", "configuração do CNAB: banco, layout, empresa e pagadores")
+	o := registrarOrigem(fs)
+	sequencial := fs.Int("sequencial", 1, "número sequencial do arquivo (NSA)")
+	geradoEm := fs.String("gerado-em", "", "data e hora de geração, AAAA-MM-DDTHH:MM:SS (vazio = agora)")
+	saida := fs.String("arquivo", "", "grava a remessa no arquivo (vazio = saída padrão)")
+	comparar := fs.String("comparar", "", "compara a remessa gerada byte a byte com o arquivo informado")
+	fs.Parse(args)
+
+	cfg, err := cnab.CarregarConfiguracao(*config)
+	if err != nil {
+		return err
+	}
+	geracao := time.Now()
+	if *geradoEm != "" {
+		if geracao, err = time.Parse("2006-01-02T15:04:05", *geradoEm); err != nil {
+			return fmt.Errorf("Data de geração inválida: %s", *geradoEm)
+		}
+	}
+
+	lista, err := o.propostas()
+	if err != nil {
+		return err
+	}
+
+	rem := cnab.Remessa{Configuracao: cfg, Sequencial: *sequencial, Geracao: geracao}
+	for _, p := range lista {
+		if p.Status != events.StatusBoletoEmitido {
+			continue
+		}
+		if p.Beneficiario != "" && cnab.Digitos(p.Beneficiario) != cnab.Digitos(cfg.Empresa.Inscricao) {
+			continue
+		}
+		rem.Titulos = append(rem.Titulos, cnab.Titulo{
+			IDProposta:     p.ID,
+			NossoNumero:    p.NossoNumero,
+			Valor:          p.Valor,
+			DataVencimento: p.DataVencimento,
+			CpfPagador:     p.CpfPagador,
+		})
+	}
+	arquivo, err := rem.Gerar()
+	if err != nil {
+		return err
+	}
+
+	if *comparar != "" {
+		esperado, err := ioutil.ReadFile(*comparar)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(arquivo, esperado) {
+			return diferenca(arquivo, esperado)
+		}
+		fmt.Fprintf(os.Stderr, "Remessa idêntica a %s (%d títulos, %d bytes)\n", *comparar, len(rem.Titulos), len(arquivo))
+		return nil
+	}
+	if *saida != "" {
+		return ioutil.WriteFile(*saida, arquivo, 0644)
+	}
+	_, err = os.Stdout.Write(arquivo)
+	return err
+}
+
+// diferenca: erro com a linha e a posição (1 a 240) do primeiro byte divergente
+func diferenca(gerado, esperado []byte) error {
+	linha, coluna := 1, 1
+	for i := 0; i < len(gerado) && i < len(esperado); i++ {
+		if gerado[i] != esperado[i] {
+			return fmt.Errorf("Remessa diverge na linha %d, posição %d: gerado %q, esperado %q", linha, coluna, gerado[i], esperado[i])
+		}
+		if gerado[i] == '\n' {
+			linha, coluna = linha+1, 0
+		}
+		coluna++
+	}
+	return fmt.Errorf("Remessa com %d bytes, esperados %d", len(gerado), len(esperado))
+}
+
+// retorno: importa o retorno, liquidando as propostas conferidas, e imprime o relatório
+func retorno(args []string) error {
+	fs := flag.NewFlagSet("retorno", flag.ExitOnError)
+	arquivo := fs.String("arquivo", "", "arquivo de retorno CNAB 240 ou CNAB 400")
+	o := registrarOrigem(fs)
+	tamanho := fs.Int("lote", cnab.LoteBaixaPadrao, "propostas liquidadas por executarLote (no máximo o lote_maximo do chaincode)")
+	chaveOraculo := fs.String("chave-oraculo", "", "chave privada ECDSA (PEM) do oráculo do banco do retorno, que assina os atestados das liquidações")
+	simular := fs.Bool("simular", false, "apenas concilia o retorno, sem liquidar as propostas")
+	formato := fs.String("formato", "json", "formato do relatório: json ou csv")
+	pendencias := fs.Bool("pendencias", false, "lista no relatório apenas as ocorrências não conciliadas e as rejeições")
+	fs.Parse(args)
+
+	if *arquivo == "" {
+		return errors.New("Informe o arquivo de retorno com -arquivo")
+	}
+	if *formato != "json" && *formato != "csv" {
+		return fmt.Errorf("Formato desconhecido: %s", *formato)
+	}
+	if !*simular && o.chaincode == "" {
+		return errors.New("Informe o chaincode com -chaincode para liquidar as propostas, ou utilize -simular")
+	}
+	var chave *ecdsa.PrivateKey
+	if !*simular {
+		if *chaveOraculo == "" {
+			return errors.New("Informe a chave do oráculo do banco com -chave-oraculo para liquidar as propostas, ou utilize -simular")
+		}
+		pem, err := ioutil.ReadFile(*chaveOraculo)
+		if err != nil {
+			return err
+		}
+		if chave, err = oracle.DecodificarChavePrivada(pem); err != nil {
+			return err
+		}
+	}
+	conteudo, err := ioutil.ReadFile(*arquivo)
+	if err != nil {
+		return err
+	}
+	ret, err := cnab.LerRetorno(conteudo)
+	if err != nil {
+		return err
+	}
+	lista, err := o.propostas()
+	if err != nil {
+		return err
+	}
+
+	baixa := cnab.Conciliar(conteudo, ret, lista)
+	if !*simular {
+		// o relatório é impresso mesmo se o envio for interrompido, com os lotes já enviados
+		err = baixa.Enviar(o.ledger(), chave, *tamanho)
+	}
+	fmt.Fprintf(os.Stderr, "%d ocorrências: %d liquidadas, %d não conciliadas, %d rejeições\n",
+		len(baixa.Itens), baixa.Liquidadas, baixa.NaoConciliadas, baixa.Rejeicoes)
+
+	if *formato == "csv" {
+		b, errCSV := baixa.CSV(*pendencias)
+		if errCSV != nil {
+			return errCSV
+		}
+		os.Stdout.Write(b)
+		return err
+	}
+	if *pendencias {
+		var itens []cnab.ItemBaixa
+		for _, i := range baixa.Itens {
+			if i.Pendencia() {
+				itens = append(itens, i)
+			}
+		}
+		baixa.Itens = itens
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if errJSON := enc.Encode(baixa); errJSON != nil {
+		return errJSON
+	}
+	return err
+}
+
+// origem - origem das propostas: projeção, arquivo JSON ou chaincode
+type origem struct {
+	projecao, arquivo        string
+	peer, chaincode, usuario string
+}
+
+// registrarOrigem: opções da origem das propostas
+func registrarOrigem(fs *flag.FlagSet) *origem {
+	o := &origem{}
+	fs.StringVar(&o.projecao, "projecao", "", "lê as propostas da projeção (arquivo BoltDB)")
+	fs.StringVar(&o.arquivo, "propostas", "", "lê as propostas de um arquivo JSON no formato de listarPropostas")
+	fs.StringVar(&o.peer, "peer", "http://localhost:7050", "endereço da API REST do peer")
+	fs.StringVar(&o.chaincode, "chaincode", "", "ID do chaincode (sem -projecao e -propostas, lê as propostas com a query listarPropostas)")
+	fs.StringVar(&o.usuario, "usuario", "WebAppAdmin", "secureContext utilizado nas transações")
+	return o
+}
+
+// ledger: peer do chaincode informado
+func (o *origem) ledger() ledger.Ledger {
+	return &ledger.Peer{URL: o.peer, ChaincodeID: o.chaincode, SecureContext: o.usuario}
+}
+
+// propostas: propostas lidas da origem informada
+func (o *origem) propostas() ([]projection.Proposta, error) {
+	var lista []projection.Proposta
+	switch {
+	case o.projecao != "":
+		proj, err := projection.Abrir(o.projecao)
+		if err != nil {
+			return nil, err
+		}
+		defer proj.Fechar()
+		return proj.Propostas()
+	case o.arquivo != "":
+		b, err := ioutil.ReadFile(o.arquivo)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &lista); err != nil {
+			return nil, fmt.Errorf("Arquivo de propostas inválido: %s", err)
+		}
+	case o.chaincode != "":
+		b, err := o.ledger().Query("listarPropostas", nil)
+		if err != nil {
+			return nil, err
+		}
+		// listarPropostas e a projeção utilizam os mesmos nomes de campos
+		if err := json.Unmarshal(b, &lista); err != nil {
+			return nil, fmt.Errorf("Resposta inválida de listarPropostas: %s", err)
+		}
+	default:
+		return nil, errors.New("Informe a origem das propostas: -projecao, -propostas ou -chaincode")
+	}
+	return lista, nil
+}
@@ -0,0 +1,185 @@
+/*
+Descrição: conciliação dos boletos pagos no ledger com os extratos bancários (ver pacote reconciliation)
+Uso:
+	conciliacao -extrato extrato.ofx [-extrato conta2.csv] [-projecao propostas.db | -propostas lista.json | -peer <url> -chaincode <id>]
+	            [-inicio AAAA-MM-DD] [-fim AAAA-MM-DD] [-tolerancia-dias 2] [-formato json|csv] [-divergencias]
+	            [-registrar <id_conciliacao> -peer <url> -chaincode <id>]
+
+O relatório lista cada crédito dos extratos e cada boleto pago sem crédito, com a situação
+da conciliação. Com -registrar, a conciliação é gravada no ledger pelo invoke
+registrarConciliacao, e a situação de cada boleto fica disponível aos auditores na query
+listarStatusConciliacao.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/projection"
+	"github.com/CaueP/BlockchainDojo/reconciliation"
+)
+
+// listaExtratos - valores repetidos de -extrato
+type listaExtratos []string
+
+func (l *listaExtratos) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listaExtratos) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func main() {
+	var extratos listaExtratos
+	flag.Var(&extratos, "extrato", "extrato bancário em OFX ou CSV (repetir para mais de um)")
+	projecao := flag.String("projecao", "", "lê as propostas da projeção (arquivo BoltDB)")
+	arquivo := flag.String("propostas", "", "lê as propostas de um arquivo JSON no formato de listarPropostas")
+	peer := flag.String("peer", "http://localhost:7050", "endereço da API REST do peer")
+	chaincode := flag.String("chaincode", "", "ID do chaincode (sem -projecao e -propostas, lê as propostas com a query listarPropostas)")
+	usuario := flag.String("usuario", "WebAppAdmin", "secureContext utilizado nas transações")
+	inicio := flag.String("inicio", "", "início do período conciliado, AAAA-MM-DD (vazio = período dos extratos)")
+	fim := flag.String("fim", "", "fim do período conciliado, AAAA-MM-DD (vazio = período dos extratos)")
+	tolerancia := flag.Int("tolerancia-dias", reconciliation.ToleranciaDiasPadrao, "dias aceitos entre o pagamento no ledger e o crédito no extrato")
+	formato := flag.String("formato", "json", "formato do relatório: json ou csv")
+	divergencias := flag.Bool("divergencias", false, "lista no relatório apenas os itens que exigem análise")
+	registrar := flag.String("registrar", "", "grava a conciliação no ledger com o ID informado (registrarConciliacao)")
+	flag.Parse()
+
+	l := &ledger.Peer{URL: *peer, ChaincodeID: *chaincode, SecureContext: *usuario}
+	err := conciliar(extratos, *projecao, *arquivo, l, *inicio, *fim, *tolerancia, *formato, *divergencias, *registrar)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "conciliacao: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+// conciliar: lê os extratos e as propostas, imprime o relatório e, com idConciliacao,
+// registra a conciliação no ledger
+func conciliar(arquivos []string, projecao, arquivo string, l *ledger.Peer, inicio, fim string, tolerancia int, formato string, divergencias bool, idConciliacao string) error {
+	if len(arquivos) == 0 {
+		return errors.New("Informe ao menos um extrato com -extrato")
+	}
+	if formato != "json" && formato != "csv" {
+		return fmt.Errorf("Formato desconhecido: %s", formato)
+	}
+	if idConciliacao != "" && l.ChaincodeID == "" {
+		return errors.New("Informe o chaincode com -chaincode para registrar a conciliação")
+	}
+
+	var extratos []reconciliation.Extrato
+	for _, a := range arquivos {
+		conteudo, err := ioutil.ReadFile(a)
+		if err != nil {
+			return err
+		}
+		e, err := reconciliation.LerExtrato(a, conteudo)
+		if err != nil {
+			return err
+		}
+		extratos = append(extratos, e)
+	}
+	propostas, err := lerPropostas(projecao, arquivo, l)
+	if err != nil {
+		return err
+	}
+	var boletos []reconciliation.Boleto
+	for _, p := range propostas {
+		// boletos emitidos e propostas pagas por PIX
+		if p.NossoNumero == "" && p.EndToEndID == "" {
+			continue
+		}
+		boletos = append(boletos, reconciliation.Boleto{
+			IDProposta:    p.ID,
+			NossoNumero:   p.NossoNumero,
+			EndToEndID:    p.EndToEndID,
+			Valor:         p.Valor,
+			Status:        p.Status,
+			DataPagamento: p.DataPagamento,
+		})
+	}
+
+	r := reconciliation.Conciliar(boletos, extratos, reconciliation.Opcoes{Inicio: inicio, Fim: fim, ToleranciaDias: tolerancia})
+	fmt.Fprintf(os.Stderr, "Período %s a %s: %d itens, %d conciliados, %d ignorados\n",
+		r.Inicio, r.Fim, len(r.Itens), r.Resumo[reconciliation.SituacaoConciliado], r.Ignorados)
+
+	if idConciliacao != "" {
+		// o ID de requisição faz a repetição do comando retornar a resposta do registro original
+		doc := r.Documento(idConciliacao)
+		doc["id_requisicao"] = "conciliacao-" + idConciliacao
+		documento, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		res, err := l.Invoke("registrarConciliacao", []string{string(documento)})
+		if err != nil {
+			return fmt.Errorf("Falha ao registrar a conciliação %s: %s", idConciliacao, err)
+		}
+		fmt.Fprintf(os.Stderr, "Conciliação %s registrada (tx %s)\n", idConciliacao, res.TxID)
+	}
+
+	if formato == "csv" {
+		b, err := r.CSV(divergencias)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	if divergencias {
+		var itens []reconciliation.Item
+		for _, i := range r.Itens {
+			if i.Divergente() {
+				itens = append(itens, i)
+			}
+		}
+		r.Itens = itens
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// lerPropostas: propostas da projeção, do arquivo JSON ou do chaincode
+func lerPropostas(projecao, arquivo string, l *ledger.Peer) ([]projection.Proposta, error) {
+	var lista []projection.Proposta
+	switch {
+	case projecao != "":
+		proj, err := projection.Abrir(projecao)
+		if err != nil {
+			return nil, err
+		}
+		defer proj.Fechar()
+		return proj.Propostas()
+	case arquivo != "":
+		b, err := ioutil.ReadFile(arquivo)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &lista); err != nil {
+			return nil, fmt.Errorf("Arquivo de propostas inválido: %s", err)
+		}
+	default:
+		if l.ChaincodeID == "" {
+			return nil, errors.New("Informe a origem das propostas: -projecao, -propostas ou -chaincode")
+		}
+		b, err := l.Query("listarPropostas", nil)
+		if err != nil {
+			return nil, err
+		}
+		// listarPropostas e a projeção utilizam os mesmos nomes de campos
+		if err := json.Unmarshal(b, &lista); err != nil {
+			return nil, fmt.Errorf("Resposta inválida de listarPropostas: %s", err)
+		}
+	}
+	return lista, nil
+}
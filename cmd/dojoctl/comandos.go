@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/chaincode/propostas"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/projection"
+	"github.com/CaueP/BlockchainDojo/simulator"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// cli - ledger utilizado e formato da saída dos comandos
+type cli struct {
+	ledger ledger.Ledger
+	fonte  projection.Fonte
+	seguir bool // false no ledger em memória: não há novos blocos depois dos comandos já executados
+	saida  string
+	out    io.Writer
+
+	sim    *simulator.Simulador   // ledger em memória (-memoria)
+	lote   []simulator.Requisicao // invokes entre "bloco" e "fim" no shell
+	emLote bool
+}
+
+// executar: despacha o comando (grupo e subcomando) com os seus argumentos
+func (c *cli) executar(args []string) error {
+	if len(args) == 0 {
+		return errors.New("Comando não informado (utilize -h para a lista de comandos)")
+	}
+	if args[0] == "shell" {
+		return c.shell(os.Stdin)
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("Subcomando de %s não informado", args[0])
+	}
+
+	grupo, comando, resto := args[0], args[1], args[2:]
+	switch grupo + " " + comando {
+	case "proposta criar":
+		return c.criarProposta(resto)
+	case "proposta consultar":
+		return c.consultarProposta(resto)
+	case "proposta aceitar":
+		return c.aceitarProposta(resto)
+	case "admin listar":
+		return c.listarPropostas(resto)
+	case "admin funcoes":
+		return c.listarFuncoes(resto)
+	case "admin versao":
+		return c.versao(resto)
+	case "admin historico":
+		return c.historico(resto)
+	case "admin salvar":
+		return c.salvar(resto)
+	case "eventos seguir":
+		return c.seguirEventos(resto)
+	}
+	return fmt.Errorf("Comando desconhecido: %s %s", grupo, comando)
+}
+
+// criarProposta: proposta criar -> registrarProposta
+func (c *cli) criarProposta(args []string) error {
+	fs := flag.NewFlagSet("proposta criar", flag.ContinueOnError)
+	id := fs.String("id", "", "identificador (hash) da proposta")
+	cpf := fs.String("cpf", "", "CPF do pagador")
+	pagadorAceitou := fs.Bool("pagador-aceitou", false, "aceite do pagador")
+	beneficiarioAceitou := fs.Bool("beneficiario-aceitou", false, "aceite do beneficiário")
+	boletoPago := fs.Bool("boleto-pago", false, "status do pagamento do boleto")
+	nossoNumero := fs.String("nosso-numero", "", "nosso número do boleto (opcional, junto com -valor)")
+	valor := fs.Int64("valor", 0, "valor do boleto em centavos")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" || *cpf == "" {
+		return errors.New("Informe -id e -cpf")
+	}
+
+	argsCC := []string{
+		*id,
+		*cpf,
+		strconv.FormatBool(*pagadorAceitou),
+		strconv.FormatBool(*beneficiarioAceitou),
+		strconv.FormatBool(*boletoPago),
+	}
+	if *nossoNumero != "" {
+		argsCC = append(argsCC, *nossoNumero, strconv.FormatInt(*valor, 10))
+	}
+	return c.invoke("registrarProposta", argsCC)
+}
+
+// consultarProposta: proposta consultar <id> -> consultarProposta. No ledger em memória,
+// -na-transacao consulta o estado logo após a transação informada (ver admin historico).
+func (c *cli) consultarProposta(args []string) error {
+	fs := flag.NewFlagSet("proposta consultar", flag.ContinueOnError)
+	naTransacao := fs.Int("na-transacao", 0, "número da transação no histórico do ledger em memória")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("Uso: proposta consultar [-na-transacao <n>] <id>")
+	}
+
+	l := c.ledger
+	if *naTransacao != 0 {
+		if c.sim == nil {
+			return errors.New("-na-transacao disponível apenas com -memoria")
+		}
+		passado, err := c.sim.NaTransacao(*naTransacao)
+		if err != nil {
+			return err
+		}
+		l = passado
+	}
+	if err := validation.Validar("consultarProposta", fs.Args()); err != nil {
+		return err
+	}
+	payload, err := l.Query("consultarProposta", fs.Args())
+	if err != nil {
+		return err
+	}
+	var proposta map[string]interface{}
+	if err := json.Unmarshal(payload, &proposta); err != nil {
+		return fmt.Errorf("Resposta inválida de consultarProposta: %s", err)
+	}
+	return c.imprimir(json.RawMessage(payload), tabelaPropostas([]map[string]interface{}{proposta}))
+}
+
+// aceitarProposta: proposta aceitar <id> <parte> -> aceitarProposta
+func (c *cli) aceitarProposta(args []string) error {
+	if len(args) != 2 {
+		return errors.New("Uso: proposta aceitar <id> pagador|beneficiario")
+	}
+	return c.invoke("aceitarProposta", args)
+}
+
+// listarPropostas: admin listar -> listarPropostas
+func (c *cli) listarPropostas(args []string) error {
+	if len(args) != 0 {
+		return errors.New("Uso: admin listar")
+	}
+	payload, err := c.query("listarPropostas", nil)
+	if err != nil {
+		return err
+	}
+	var lista []map[string]interface{}
+	if err := json.Unmarshal(payload, &lista); err != nil {
+		return fmt.Errorf("Resposta inválida de listarPropostas: %s", err)
+	}
+	return c.imprimir(json.RawMessage(payload), tabelaPropostas(lista))
+}
+
+// listarFuncoes: admin funcoes -> listarFuncoes (catálogo das funções do chaincode)
+func (c *cli) listarFuncoes(args []string) error {
+	if len(args) != 0 {
+		return errors.New("Uso: admin funcoes")
+	}
+	payload, err := c.query("listarFuncoes", nil)
+	if err != nil {
+		return err
+	}
+	var catalogo []propostas.Funcao
+	if err := json.Unmarshal(payload, &catalogo); err != nil {
+		return fmt.Errorf("Resposta inválida de listarFuncoes: %s", err)
+	}
+
+	t := tabela{colunas: []string{"nome", "tipo", "argumentos", "papel", "disponivel", "descricao"}}
+	for _, f := range catalogo {
+		argumentos := make([]string, len(f.Argumentos))
+		for i, a := range f.Argumentos {
+			argumentos[i] = a.Nome
+			if a.Opcional {
+				argumentos[i] = "[" + a.Nome + "]"
+			}
+		}
+		t.linhas = append(t.linhas, []string{
+			f.Nome, f.Tipo, strings.Join(argumentos, " "), f.Papel, strconv.FormatBool(f.Disponivel), f.Descricao,
+		})
+	}
+	return c.imprimir(json.RawMessage(payload), t)
+}
+
+// versao: admin versao -> versao
+func (c *cli) versao(args []string) error {
+	if len(args) != 0 {
+		return errors.New("Uso: admin versao")
+	}
+	payload, err := c.query("versao", nil)
+	if err != nil {
+		return err
+	}
+	var v propostas.RespostaVersao
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return fmt.Errorf("Resposta inválida de versao: %s", err)
+	}
+
+	t := tabela{colunas: []string{"versao", "commit", "versao_esquema", "estado", "funcionalidades"}}
+	estado := ""
+	if v.Estado != nil {
+		estado = v.Estado.Versao + " (" + v.Estado.Commit + ", esquema " + strconv.Itoa(v.Estado.VersaoEsquema) + ")"
+	}
+	t.linhas = append(t.linhas, []string{
+		v.Versao, v.Commit, strconv.Itoa(v.VersaoEsquema), estado, strings.Join(v.Funcionalidades, " "),
+	})
+	return c.imprimir(json.RawMessage(payload), t)
+}
+
+// historico: admin historico -> transações do ledger em memória, numeradas na ordem
+// do histórico (o número é utilizado em proposta consultar -na-transacao)
+func (c *cli) historico(args []string) error {
+	if len(args) != 0 {
+		return errors.New("Uso: admin historico")
+	}
+	if c.sim == nil {
+		return errors.New("historico disponível apenas com -memoria")
+	}
+
+	var v []map[string]interface{}
+	t := tabela{colunas: []string{"numero", "bloco", "tx_id", "funcao", "args", "chamador", "validacao", "evento"}}
+	n := 0
+	for _, b := range c.sim.Blocos() {
+		for _, tx := range b.Transacoes {
+			n++
+			item := map[string]interface{}{
+				"numero":    n,
+				"bloco":     b.Numero,
+				"tx_id":     tx.TxID,
+				"tipo":      tx.Tipo,
+				"funcao":    tx.Funcao,
+				"args":      tx.Args,
+				"horario":   tx.Horario,
+				"validacao": tx.Validacao,
+			}
+			if tx.Chamador != "" {
+				item["chamador"] = tx.Chamador
+			}
+			evento := ""
+			if tx.Evento != nil {
+				item["evento"] = tx.Evento.Nome
+				evento = tx.Evento.Nome
+			}
+			v = append(v, item)
+			t.linhas = append(t.linhas, []string{
+				strconv.Itoa(n), strconv.FormatUint(b.Numero, 10), tx.TxID, tx.Funcao,
+				strings.Join(tx.Args, " "), tx.Chamador, tx.Validacao, evento,
+			})
+		}
+	}
+	return c.imprimir(v, t)
+}
+
+// salvar: admin salvar <arquivo> -> snapshot do ledger em memória, carregado com -carregar
+func (c *cli) salvar(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Uso: admin salvar <arquivo>")
+	}
+	if c.sim == nil {
+		return errors.New("salvar disponível apenas com -memoria")
+	}
+	if err := c.sim.SalvarArquivo(args[0]); err != nil {
+		return err
+	}
+	return c.imprimir(map[string]interface{}{"arquivo": args[0], "altura": c.sim.Altura(), "transacoes": c.sim.Transacoes()},
+		tabela{colunas: []string{"arquivo", "altura", "transacoes"}, linhas: [][]string{{args[0], strconv.FormatUint(c.sim.Altura(), 10), strconv.Itoa(c.sim.Transacoes())}}})
+}
+
+// seguirEventos: eventos seguir -> imprime os eventos do chaincode a partir do bloco
+// informado e, no peer, continua lendo os novos blocos até o processo ser interrompido
+func (c *cli) seguirEventos(args []string) error {
+	fs := flag.NewFlagSet("eventos seguir", flag.ContinueOnError)
+	desde := fs.Uint64("desde", 0, "bloco inicial")
+	idProposta := fs.String("proposta", "", "considera apenas os eventos desta proposta")
+	intervalo := fs.Duration("intervalo", 2*time.Second, "intervalo de leitura dos novos blocos")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	bloco, indice := *desde, -1
+	cabecalho := true
+	for {
+		var eventos []projection.EventoBloco
+		err := c.fonte.Ler(bloco, func(ev projection.EventoBloco) error {
+			if ev.Bloco < bloco || (ev.Bloco == bloco && ev.Indice <= indice) {
+				return nil
+			}
+			bloco, indice = ev.Bloco, ev.Indice
+			// os itens de um lote são listados como eventos da mesma posição
+			for _, e := range ev.Evento.Eventos() {
+				if *idProposta == "" || e.IDProposta == *idProposta {
+					eventos = append(eventos, projection.EventoBloco{Bloco: ev.Bloco, Indice: ev.Indice, Evento: e})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			if !c.seguir {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "Falha ao ler eventos: "+err.Error())
+		}
+		if err := c.imprimirEventos(eventos, cabecalho); err != nil {
+			return err
+		}
+		if len(eventos) > 0 {
+			cabecalho = false
+		}
+
+		if !c.seguir {
+			return nil
+		}
+		time.Sleep(*intervalo)
+	}
+}
+
+// shell: executa um comando por linha, sobre o mesmo ledger (útil com -memoria).
+// Linhas vazias e iniciadas por # são ignoradas. Um comando com erro não interrompe os demais.
+// No ledger em memória, os invokes entre as linhas "bloco" e "fim" são submetidos
+// concorrentemente em um único bloco, e o resultado da validação de cada um é impresso.
+func (c *cli) shell(entrada io.Reader) error {
+	falhas := 0
+	scanner := bufio.NewScanner(entrada)
+	for scanner.Scan() {
+		linha := strings.TrimSpace(scanner.Text())
+		if linha == "" || strings.HasPrefix(linha, "#") {
+			continue
+		}
+		args, err := separarArgumentos(linha)
+		if err == nil {
+			switch {
+			case len(args) > 0 && args[0] == "shell":
+				err = errors.New("shell não pode ser executado dentro do shell")
+			case linha == "bloco":
+				err = c.iniciarBloco()
+			case linha == "fim":
+				err = c.executarBloco()
+			default:
+				err = c.executar(args)
+			}
+		}
+		if err != nil {
+			falhas++
+			fmt.Fprintln(os.Stderr, "erro: "+linha+": "+mensagemErro(err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if c.emLote {
+		return errors.New("Bloco iniciado e não finalizado com \"fim\"")
+	}
+	if falhas > 0 {
+		return fmt.Errorf("%d comando(s) com erro", falhas)
+	}
+	return nil
+}
+
+// invoke: valida os argumentos com as mesmas regras do chaincode e executa a função
+func (c *cli) invoke(funcao string, args []string) error {
+	if err := validation.Validar(funcao, args); err != nil {
+		return err
+	}
+	if c.emLote {
+		c.lote = append(c.lote, simulator.Requisicao{Identidade: c.sim.Identidade, Funcao: funcao, Args: args})
+		return nil
+	}
+	res, err := c.ledger.Invoke(funcao, args)
+	if err != nil {
+		return err
+	}
+
+	// a resposta do chaincode é impressa como JSON quando possível
+	resultado := map[string]interface{}{"tx_id": res.TxID, "pendente": res.Pendente}
+	if len(res.Payload) > 0 {
+		var resposta interface{}
+		if err := json.Unmarshal(res.Payload, &resposta); err != nil {
+			resposta = string(res.Payload)
+		}
+		resultado["resposta"] = resposta
+	}
+	return c.imprimir(resultado, tabelaResultado(res))
+}
+
+// iniciarBloco: "bloco" no shell
+func (c *cli) iniciarBloco() error {
+	if c.sim == nil {
+		return errors.New("bloco disponível apenas com -memoria")
+	}
+	if c.emLote {
+		return errors.New("Bloco já iniciado")
+	}
+	c.emLote, c.lote = true, nil
+	return nil
+}
+
+// executarBloco: "fim" no shell
+func (c *cli) executarBloco() error {
+	if !c.emLote {
+		return errors.New("fim sem bloco iniciado")
+	}
+	lote := c.lote
+	c.emLote, c.lote = false, nil
+
+	resultados := c.sim.ExecutarBloco(lote)
+	var v []map[string]interface{}
+	t := tabela{colunas: []string{"tx_id", "funcao", "validacao", "resposta"}}
+	for i, r := range resultados {
+		item := map[string]interface{}{"tx_id": r.TxID, "funcao": lote[i].Funcao, "validacao": r.Validacao}
+		resposta := string(r.Resposta)
+		if r.Erro != nil {
+			item["erro"] = mensagemErro(r.Erro)
+			resposta = mensagemErro(r.Erro)
+		} else if len(r.Resposta) > 0 {
+			item["resposta"] = json.RawMessage(r.Resposta)
+		}
+		if len(r.Conflitos) > 0 {
+			item["conflitos"] = r.Conflitos
+		}
+		v = append(v, item)
+		t.linhas = append(t.linhas, []string{r.TxID, lote[i].Funcao, r.Validacao, resposta})
+	}
+	return c.imprimir(v, t)
+}
+
+// query: valida os argumentos e executa a função Query
+func (c *cli) query(funcao string, args []string) ([]byte, error) {
+	if err := validation.Validar(funcao, args); err != nil {
+		return nil, err
+	}
+	return c.ledger.Query(funcao, args)
+}
+
+// separarArgumentos: divide a linha em argumentos separados por espaços, respeitando aspas duplas
+func separarArgumentos(linha string) ([]string, error) {
+	var args []string
+	var atual strings.Builder
+	aspas, temArg := false, false
+	for _, r := range linha {
+		switch {
+		case r == '"':
+			aspas = !aspas
+			temArg = true
+		case (r == ' ' || r == '\t') && !aspas:
+			if temArg {
+				args = append(args, atual.String())
+				atual.Reset()
+				temArg = false
+			}
+		default:
+			atual.WriteRune(r)
+			temArg = true
+		}
+	}
+	if aspas {
+		return nil, errors.New("Aspas não fechadas")
+	}
+	if temArg {
+		args = append(args, atual.String())
+	}
+	return args, nil
+}
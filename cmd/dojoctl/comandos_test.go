@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/CaueP/BlockchainDojo/chaincode/propostas"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/simulator"
+)
+
+// ledgerFalso - registra as chamadas e responde com o payload programado
+type ledgerFalso struct {
+	chamadas []string
+	payload  []byte
+}
+
+func (l *ledgerFalso) Invoke(funcao string, args []string) (ledger.Resultado, error) {
+	l.chamadas = append(l.chamadas, fmt.Sprintf("invoke %s %q", funcao, args))
+	return ledger.Resultado{TxID: "tx1", Payload: l.payload}, nil
+}
+
+func (l *ledgerFalso) Query(funcao string, args []string) ([]byte, error) {
+	l.chamadas = append(l.chamadas, fmt.Sprintf("query %s %q", funcao, args))
+	return l.payload, nil
+}
+
+func TestComandos(t *testing.T) {
+	casos := []struct {
+		comando string
+		payload string
+		chamada string // "" se o comando for recusado antes do ledger
+		erro    string
+	}{
+		{"proposta criar -id p1 -cpf 111 -pagador-aceitou", `{}`,
+			`invoke registrarProposta ["p1" "111" "true" "false" "false"]`, ""},
+		{"proposta criar -id p1 -cpf 111 -nosso-numero 00000000001 -valor 15000", `{}`,
+			`invoke registrarProposta ["p1" "111" "false" "false" "false" "00000000001" "15000"]`, ""},
+		{"proposta criar -id p1", ``, "", "Informe -id e -cpf"},
+		{"proposta criar -id p1 -cpf 111 -aceito", ``, "", "flag provided but not defined"},
+		{"proposta consultar p1", `{"id_proposta": "p1"}`, `query consultarProposta ["p1"]`, ""},
+		{"proposta consultar -na-transacao 2 p1", ``, "", "-na-transacao disponível apenas com -memoria"},
+		{"proposta aceitar p1 beneficiario", `{}`, `invoke aceitarProposta ["p1" "beneficiario"]`, ""},
+		{"proposta aceitar p1", ``, "", "Uso: proposta aceitar"},
+		{"proposta aceitar p1 avalista", ``, "", "ARGUMENTO_INVALIDO"},
+		{"admin listar", `[]`, `query listarPropostas []`, ""},
+		{"admin funcoes", `[]`, `query listarFuncoes []`, ""},
+		{"admin versao", `{"versao": "1.0.0"}`, `query versao []`, ""},
+		{"admin historico", ``, "", "historico disponível apenas com -memoria"},
+		{"admin salvar", ``, "", "Uso: admin salvar <arquivo>"},
+		{"admin remover", ``, "", "Comando desconhecido: admin remover"},
+		{"proposta", ``, "", "Subcomando de proposta não informado"},
+	}
+	for _, c := range casos {
+		t.Run(c.comando, func(t *testing.T) {
+			l := &ledgerFalso{payload: []byte(c.payload)}
+			cl := &cli{ledger: l, saida: "json", out: new(bytes.Buffer)}
+			err := cl.executar(strings.Fields(c.comando))
+			if c.erro != "" {
+				if err == nil || !strings.Contains(err.Error(), c.erro) {
+					t.Fatalf("erro = %v, esperado %q", err, c.erro)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			var esperadas []string
+			if c.chamada != "" {
+				esperadas = []string{c.chamada}
+			}
+			if fmt.Sprint(l.chamadas) != fmt.Sprint(esperadas) {
+				t.Fatalf("chamadas %q, esperadas %q", l.chamadas, esperadas)
+			}
+		})
+	}
+}
+
+func TestSepararArgumentos(t *testing.T) {
+	casos := []struct {
+		linha string
+		args  []string
+		erro  bool
+	}{
+		{"proposta aceitar p1 pagador", []string{"proposta", "aceitar", "p1", "pagador"}, false},
+		{"  admin\tlistar  ", []string{"admin", "listar"}, false},
+		{`proposta criar -id p1 -cpf "111 222"`, []string{"proposta", "criar", "-id", "p1", "-cpf", "111 222"}, false},
+		{`proposta criar -id ""`, []string{"proposta", "criar", "-id", ""}, false},
+		{`proposta criar -id "p1`, nil, true},
+	}
+	for _, c := range casos {
+		args, err := separarArgumentos(c.linha)
+		if (err != nil) != c.erro {
+			t.Fatalf("%s: erro = %v", c.linha, err)
+		}
+		if fmt.Sprintf("%q", args) != fmt.Sprintf("%q", c.args) {
+			t.Fatalf("%s: argumentos %q, esperados %q", c.linha, args, c.args)
+		}
+	}
+}
+
+func TestTabelaPropostas(t *testing.T) {
+	tab := tabelaPropostas([]map[string]interface{}{
+		{"id_proposta": "p1", "valor": float64(15000), "pagador_aceitou": true, "tags": []interface{}{"a"}},
+		{"id_proposta": "p2", "beneficiario": "12.345.678/0001-90"},
+	})
+	colunas := append(append([]string(nil), colunasProposta...), "beneficiario", "tags")
+	if fmt.Sprint(tab.colunas) != fmt.Sprint(colunas) {
+		t.Fatalf("colunas %v, esperadas %v", tab.colunas, colunas)
+	}
+	linha := tab.linhas[0]
+	if linha[0] != "p1" || linha[2] != "true" || linha[6] != "15000" || linha[len(linha)-1] != `["a"]` || linha[len(linha)-2] != "" {
+		t.Fatalf("linha %q", linha)
+	}
+}
+
+func TestShellMemoria(t *testing.T) {
+	sim := simulator.Novo(new(propostas.BoletoPropostaChaincode))
+	if err := implantarMemoria(sim, nil); err != nil {
+		t.Fatal(err)
+	}
+	altura, transacoes := sim.Altura(), sim.Transacoes()
+	out := new(bytes.Buffer)
+	cl := &cli{ledger: sim, fonte: sim, sim: sim, saida: "csv", out: out}
+
+	entrada := strings.Join([]string{
+		"# duas propostas no mesmo bloco",
+		"bloco",
+		"proposta criar -id p1 -cpf 111",
+		"proposta criar -id p2 -cpf 222",
+		"fim",
+		"",
+		"proposta aceitar p1 pagador",
+	}, "\n")
+	if err := cl.shell(strings.NewReader(entrada)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), simulator.Valida); got != 2 {
+		t.Fatalf("%d invokes válidos no bloco, esperados 2:\n%s", got, out)
+	}
+	// o bloco com as duas propostas e o do aceite
+	if sim.Altura() != altura+2 || sim.Transacoes() != transacoes+3 {
+		t.Fatalf("altura %d com %d transações, esperada %d com %d", sim.Altura(), sim.Transacoes(), altura+2, transacoes+3)
+	}
+
+	casos := []struct {
+		entrada string
+		erro    string
+	}{
+		{"bloco\nproposta aceitar p2 pagador", "Bloco iniciado e não finalizado"},
+		{"fim", "1 comando(s) com erro"},
+		{"shell", "1 comando(s) com erro"},
+		{"proposta aceitar p9 pagador\nproposta aceitar p2 pagador", "1 comando(s) com erro"},
+	}
+	for _, c := range casos {
+		err := cl.shell(strings.NewReader(c.entrada))
+		if err == nil || !strings.Contains(err.Error(), c.erro) {
+			t.Fatalf("%q: erro = %v, esperado %q", c.entrada, err, c.erro)
+		}
+		cl.emLote, cl.lote = false, nil
+	}
+}
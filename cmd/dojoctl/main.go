@@ -0,0 +1,162 @@
+/*
+Descrição: cliente de linha de comando do chaincode de propostas
+Monta o nome da função e os argumentos posicionais de cada operação, no lugar das
+requisições digitadas no console do Bluemix.
+Uso:
+	dojoctl [-peer <url> -chaincode <id> | -memoria [-carregar <snapshot> [-atualizar]]] [-saida json|tabela|csv] <comando>
+
+	dojoctl proposta criar -id <id> -cpf <cpf> [-pagador-aceitou] [-beneficiario-aceitou] [-boleto-pago] [-nosso-numero <n> -valor <centavos>]
+	dojoctl proposta consultar [-na-transacao <n>] <id>
+	dojoctl proposta aceitar <id> pagador|beneficiario
+	dojoctl admin listar
+	dojoctl admin funcoes            (catálogo das funções do chaincode)
+	dojoctl admin versao             (versão do chaincode e do esquema do estado)
+	dojoctl admin historico          (ledger em memória: transações numeradas)
+	dojoctl admin salvar <arquivo>   (ledger em memória: snapshot do estado e do histórico)
+	dojoctl eventos seguir [-desde <bloco>] [-proposta <id>] [-intervalo 2s]
+	dojoctl shell   (executa um comando por linha da entrada padrão, sobre o mesmo ledger;
+	                 com -memoria, os invokes entre as linhas "bloco" e "fim" vão para um único bloco)
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/chaincode/propostas"
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/projection"
+	"github.com/CaueP/BlockchainDojo/simulator"
+)
+
+// listaOraculos - valores repetidos de -oraculo (codigoBanco=arquivo.pem)
+type listaOraculos []string
+
+func (l *listaOraculos) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listaOraculos) Set(v string) error {
+	if !strings.Contains(v, "=") {
+		return fmt.Errorf("Formato esperado: codigoBanco=arquivo.pem")
+	}
+	*l = append(*l, v)
+	return nil
+}
+
+func main() {
+	peer := flag.String("peer", "http://localhost:7050", "endereço da API REST do peer")
+	chaincode := flag.String("chaincode", "", "nome (hash) do chaincode")
+	usuario := flag.String("usuario", "WebAppAdmin", "secureContext utilizado nas transações")
+	memoria := flag.Bool("memoria", false, "executa o chaincode em um ledger em memória, sem peer")
+	carregar := flag.String("carregar", "", "snapshot carregado no ledger em memória no lugar do deploy (implica -memoria)")
+	atualizar := flag.Bool("atualizar", false, "com -carregar, executa o deploy desta versão do chaincode sobre o snapshot, migrando o estado")
+	saida := flag.String("saida", "json", "formato da saída: json, tabela ou csv")
+	flag.StringVar(&idioma, "idioma", envelope.IdiomaPadrao, "idioma das mensagens de erro do chaincode: pt-BR ou en")
+	endossantes := flag.Int("endossantes", 1, "endossantes que executam cada invoke no ledger em memória (detecta chaincode não determinístico)")
+	var oraculos listaOraculos
+	flag.Var(&oraculos, "oraculo", "oráculo registrado no deploy do ledger em memória, no formato codigoBanco=arquivo.pem (pode ser repetido)")
+	flag.Usage = uso
+	flag.Parse()
+
+	if *saida != "json" && *saida != "tabela" && *saida != "csv" {
+		sair(fmt.Errorf("Formato de saída inválido: %s", *saida))
+	}
+
+	c := &cli{saida: *saida, out: os.Stdout}
+	if *memoria || *carregar != "" {
+		// o chaincode executado no próprio processo escreve o seu log na saída padrão (pacote logging);
+		// o log vai para stderr para não se misturar à saída dos comandos
+		os.Stdout = os.Stderr
+
+		sim := simulator.Novo(new(propostas.BoletoPropostaChaincode))
+		if *carregar != "" {
+			var err error
+			if sim, err = simulator.CarregarArquivo(*carregar, new(propostas.BoletoPropostaChaincode)); err != nil {
+				sair(err)
+			}
+		}
+		sim.Endossantes = *endossantes
+		sim.Fabrica = func() shim.Chaincode { return new(propostas.BoletoPropostaChaincode) }
+		if *carregar == "" || *atualizar {
+			if err := implantarMemoria(sim, oraculos); err != nil {
+				sair(err)
+			}
+		}
+		c.ledger, c.fonte, c.sim = sim, sim, sim
+	} else {
+		if *chaincode == "" {
+			sair(fmt.Errorf("Informe o chaincode com -chaincode ou utilize -memoria"))
+		}
+		c.ledger = &ledger.Peer{URL: *peer, ChaincodeID: *chaincode, SecureContext: *usuario}
+		c.fonte = projection.FonteREST{URL: *peer, ChaincodeID: *chaincode}
+		c.seguir = true
+	}
+
+	if err := c.executar(flag.Args()); err != nil {
+		sair(err)
+	}
+}
+
+// implantarMemoria: executa o deploy do chaincode de propostas no ledger em memória
+func implantarMemoria(sim *simulator.Simulador, oraculos listaOraculos) error {
+	var args []string
+	for _, o := range oraculos {
+		partes := strings.SplitN(o, "=", 2)
+		chave, err := ioutil.ReadFile(partes[1])
+		if err != nil {
+			return fmt.Errorf("Falha ao ler a chave do oráculo do banco [%s]: %s", partes[0], err)
+		}
+		args = append(args, partes[0], string(chave))
+	}
+
+	_, err := sim.Implantar("init", args)
+	return err
+}
+
+func uso() {
+	fmt.Fprintln(os.Stderr, `Uso: dojoctl [opções] <comando>
+
+Comandos:
+  proposta criar -id <id> -cpf <cpf> [-pagador-aceitou] [-beneficiario-aceitou] [-boleto-pago] [-nosso-numero <n> -valor <centavos>]
+  proposta consultar [-na-transacao <n>] <id>
+  proposta aceitar <id> pagador|beneficiario
+  admin listar
+  admin funcoes             catálogo das funções do chaincode (listarFuncoes)
+  admin versao              versão do chaincode, commit do build e versão do esquema do estado
+  admin historico           transações do ledger em memória, numeradas
+  admin salvar <arquivo>    snapshot do ledger em memória (carregado com -carregar)
+  eventos seguir [-desde <bloco>] [-proposta <id>] [-intervalo 2s]
+  shell         executa um comando por linha da entrada padrão
+                (com -memoria, os invokes entre "bloco" e "fim" são concorrentes em um único bloco)
+
+Opções:`)
+	flag.PrintDefaults()
+}
+
+func sair(err error) {
+	fmt.Fprintln(os.Stderr, "dojoctl: "+mensagemErro(err))
+	os.Exit(1)
+}
+
+// idioma das mensagens de erro (-idioma)
+var idioma = envelope.IdiomaPadrao
+
+// mensagemErro: "CODIGO: mensagem" para os erros do chaincode (envelope), no idioma escolhido
+func mensagemErro(err error) string {
+	e, ok := envelope.Decodificar(err)
+	if !ok {
+		return err.Error()
+	}
+	if e.Parametros != nil {
+		e = e.Traduzir(idioma)
+	}
+	return string(e.Codigo) + ": " + e.Mensagem
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/projection"
+)
+
+// tabela - representação de um resultado nos formatos tabela e csv
+type tabela struct {
+	colunas []string
+	linhas  [][]string
+}
+
+// ordem das colunas das propostas, na ordem dos campos do chaincode
+var colunasProposta = []string{
+	"id_proposta", "cpf_pagador", "pagador_aceitou", "beneficiario_aceitou", "boleto_pago",
+	"nosso_numero", "valor", "data_pagamento", "cancelada", "status",
+}
+
+// imprimir: imprime v em JSON indentado ou t como tabela/csv, conforme -saida
+func (c *cli) imprimir(v interface{}, t tabela) error {
+	switch c.saida {
+	case "tabela":
+		return c.imprimirTabela(t, true)
+	case "csv":
+		return c.imprimirCSV(t, true)
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.out, string(b))
+	return err
+}
+
+func (c *cli) imprimirTabela(t tabela, cabecalho bool) error {
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	if cabecalho {
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(t.colunas, "\t")))
+	}
+	for _, l := range t.linhas {
+		fmt.Fprintln(w, strings.Join(l, "\t"))
+	}
+	return w.Flush()
+}
+
+func (c *cli) imprimirCSV(t tabela, cabecalho bool) error {
+	w := csv.NewWriter(c.out)
+	if cabecalho {
+		w.Write(t.colunas)
+	}
+	w.WriteAll(t.linhas)
+	return w.Error()
+}
+
+// imprimirEventos: em JSON, um evento por linha, para que a saída possa ser
+// processada enquanto os eventos chegam
+func (c *cli) imprimirEventos(eventos []projection.EventoBloco, cabecalho bool) error {
+	if c.saida == "json" {
+		enc := json.NewEncoder(c.out)
+		for _, ev := range eventos {
+			if err := enc.Encode(map[string]interface{}{"bloco": ev.Bloco, "indice": ev.Indice, "evento": ev.Evento}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t := tabela{colunas: []string{"bloco", "tipo", "id_proposta", "tx_id", "horario", "alterados"}}
+	for _, ev := range eventos {
+		alterados, _ := json.Marshal(ev.Evento.Alterados)
+		t.linhas = append(t.linhas, []string{
+			strconv.FormatUint(ev.Bloco, 10),
+			string(ev.Evento.Tipo),
+			ev.Evento.IDProposta,
+			ev.Evento.TxID,
+			ev.Evento.Horario,
+			string(alterados),
+		})
+	}
+	if len(t.linhas) == 0 && !cabecalho {
+		return nil
+	}
+	if c.saida == "csv" {
+		return c.imprimirCSV(t, cabecalho)
+	}
+	return c.imprimirTabela(t, cabecalho)
+}
+
+// tabelaPropostas: uma linha por proposta. Campos não previstos em colunasProposta
+// (versões futuras do chaincode) são incluídos ao final, em ordem alfabética.
+func tabelaPropostas(lista []map[string]interface{}) tabela {
+	t := tabela{colunas: append([]string(nil), colunasProposta...)}
+	conhecidas := make(map[string]bool)
+	for _, col := range colunasProposta {
+		conhecidas[col] = true
+	}
+	var extras []string
+	for _, p := range lista {
+		for k := range p {
+			if !conhecidas[k] {
+				conhecidas[k] = true
+				extras = append(extras, k)
+			}
+		}
+	}
+	sort.Strings(extras)
+	t.colunas = append(t.colunas, extras...)
+
+	for _, p := range lista {
+		linha := make([]string, len(t.colunas))
+		for i, col := range t.colunas {
+			linha[i] = textoValor(p[col])
+		}
+		t.linhas = append(t.linhas, linha)
+	}
+	return t
+}
+
+// tabelaResultado: resultado de um invoke
+func tabelaResultado(res ledger.Resultado) tabela {
+	return tabela{
+		colunas: []string{"tx_id", "pendente", "resposta"},
+		linhas:  [][]string{{res.TxID, strconv.FormatBool(res.Pendente), string(res.Payload)}},
+	}
+}
+
+// textoValor: valor de um campo JSON em uma célula da tabela
+func textoValor(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,33 @@
+/*
+Descrição: executa o gateway REST do chaincode de propostas (ver pacote gateway)
+Uso: gateway -addr :8080 -peer https://<id>-vp0.us.blockchain.ibm.com:443 -chaincode <id> -usuario WebAppAdmin
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/CaueP/BlockchainDojo/gateway"
+	"github.com/CaueP/BlockchainDojo/ledger"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "endereço do servidor HTTP")
+	peer := flag.String("peer", "http://localhost:7050", "endereço da API REST do peer")
+	chaincode := flag.String("chaincode", "", "nome (hash) do chaincode")
+	usuario := flag.String("usuario", "WebAppAdmin", "secureContext utilizado nas transações")
+	flag.Parse()
+
+	if *chaincode == "" {
+		log.Fatal("Informe o chaincode com -chaincode")
+	}
+
+	l := &ledger.Peer{URL: *peer, ChaincodeID: *chaincode, SecureContext: *usuario}
+
+	fmt.Println("Gateway de propostas escutando em " + *addr)
+	log.Fatal(http.ListenAndServe(*addr, gateway.Novo(l)))
+}
@@ -0,0 +1,48 @@
+/*
+Descrição: executa o serviço gRPC do chaincode de propostas (ver pacote grpcapi)
+Uso: grpc-propostas -addr :9090 -peer <url do peer> -chaincode <id> -usuario WebAppAdmin
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/CaueP/BlockchainDojo/grpcapi"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/projection"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "endereço do servidor gRPC")
+	peer := flag.String("peer", "http://localhost:7050", "endereço da API REST do peer")
+	chaincode := flag.String("chaincode", "", "nome (hash) do chaincode")
+	usuario := flag.String("usuario", "WebAppAdmin", "secureContext utilizado nas transações")
+	intervalo := flag.Duration("intervalo", 2*time.Second, "intervalo de leitura dos novos blocos para AcompanharProposta")
+	flag.Parse()
+
+	if *chaincode == "" {
+		log.Fatal("Informe o chaincode com -chaincode")
+	}
+
+	l := &ledger.Peer{URL: *peer, ChaincodeID: *chaincode, SecureContext: *usuario}
+
+	difusor := grpcapi.NovoDifusor()
+	go difusor.AcompanharFonte(projection.FonteREST{URL: *peer, ChaincodeID: *chaincode}, *intervalo, nil)
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	servidor := grpc.NewServer()
+	grpcapi.RegisterPropostasServer(servidor, grpcapi.NovoServidor(l, difusor))
+
+	fmt.Println("Serviço gRPC de propostas escutando em " + *addr)
+	log.Fatal(servidor.Serve(lis))
+}
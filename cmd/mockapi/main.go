@@ -0,0 +1,43 @@
+/*
+Descrição: executa o servidor de teste da API /atualizar (ver mockapi), em substituição ao app.js
+Uso: mockapi -addr :6001 -segredo <segredo HMAC>
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/mockapi"
+)
+
+func main() {
+	addr := flag.String("addr", ":6001", "endereço do servidor HTTP")
+	segredo := flag.String("segredo", "", "segredo HMAC para verificar o cabeçalho "+mockapi.CabecalhoAssinatura+" (vazio = não verificar)")
+	status := flag.Int("status", 0, "status HTTP respondido a todas as requisições válidas (0 = 200)")
+	atraso := flag.Duration("atraso", 0, "atraso antes de cada resposta")
+	flag.Parse()
+
+	servidor := mockapi.Novo(*segredo)
+	servidor.ProgramarPadrao(mockapi.Resposta{Status: *status, Atraso: *atraso})
+
+	servidor.Observar(logRequisicao)
+
+	fmt.Println("server starting on " + *addr)
+	log.Fatal(http.ListenAndServe(*addr, servidor))
+}
+
+// logRequisicao: registra cada requisição no console, como o morgan do app.js. É chamada
+// pelo servidor com a requisição que acabou de gravar, e não com a última da lista, que
+// pode ser a de outra requisição concorrente.
+func logRequisicao(r mockapi.Requisicao) {
+	linha := fmt.Sprintf("%s %s %d %s %s\n", r.Metodo, r.Caminho, r.Status, time.Since(r.Horario), string(r.Corpo))
+	if r.Erro != nil {
+		linha += "  erro: " + r.Erro.Error() + "\n"
+	}
+	fmt.Print(linha)
+}
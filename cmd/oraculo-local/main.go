@@ -0,0 +1,29 @@
+/*
+Descrição: executa o oráculo bancário local (ver oracle/local)
+Uso: oraculo-local -fixture oracle/local/fixtures/pagamentos.json -addr :6002
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/CaueP/BlockchainDojo/oracle/local"
+)
+
+func main() {
+	fixture := flag.String("fixture", "oracle/local/fixtures/pagamentos.json", "arquivo de fixture com a chave e os pagamentos do banco")
+	addr := flag.String("addr", ":6002", "endereço do servidor HTTP")
+	flag.Parse()
+
+	oraculo, err := local.Carregar(*fixture)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Oráculo local do banco " + oraculo.CodigoBanco() + " escutando em " + *addr)
+	log.Fatal(http.ListenAndServe(*addr, oraculo))
+}
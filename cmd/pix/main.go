@@ -0,0 +1,180 @@
+/*
+Descrição: geração do BR Code (PIX copia e cola) das propostas e do QR Code em PNG ou SVG, sem acesso à rede
+Uso:
+	pix -chave <chave> -nome <nome> -cidade <cidade> [-localizacao <url>] -txid <id_proposta> -valor <centavos>
+	pix -peer <url> -chaincode <id> -proposta <id_proposta> [-registrar [-valor <centavos>]]
+	    [-png qrcode.png] [-svg qrcode.svg] [-escala 8] [-nivel L|M|Q|H]
+	pix -decodificar <brcode>
+
+Sem -chaincode, o BR Code é gerado localmente com a conta informada nas flags; com
+-chaincode, é obtido da query gerarBRCode, com a conta PIX da configuração do chaincode e
+o valor da cobrança registrada. Com -registrar, a cobrança é antes registrada pelo invoke
+registrarCobrancaPix, com o valor da proposta (conferido com -valor, se informado); se a transação ainda não estiver confirmada (peer v0.6), apenas o
+seu ID é informado, e o BR Code é obtido depois, sem -registrar.
+O BR Code é impresso na saída padrão e, com -png ou -svg, o QR Code é gravado no arquivo.
+Com -decodificar, imprime os dados do BR Code em JSON, após conferir o CRC.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/pix"
+	"github.com/CaueP/BlockchainDojo/pix/qrcode"
+)
+
+// opcoes - flags da linha de comando
+type opcoes struct {
+	recebedor   pix.Recebedor
+	txid        string
+	valor       int64
+	peer        string
+	chaincode   string
+	registrar   bool
+	usuario     string
+	proposta    string
+	png         string
+	svg         string
+	escala      int
+	nivel       string
+	decodificar string
+}
+
+func main() {
+	var o opcoes
+	flag.StringVar(&o.recebedor.Chave, "chave", "", "chave PIX do recebedor")
+	flag.StringVar(&o.recebedor.Nome, "nome", "", "nome do recebedor (até 25 caracteres)")
+	flag.StringVar(&o.recebedor.Cidade, "cidade", "", "cidade do recebedor (até 15 caracteres)")
+	flag.StringVar(&o.recebedor.Localizacao, "localizacao", "", "URL da cobrança no PSP, sem https://, com {txid} (BR Code dinâmico)")
+	flag.StringVar(&o.txid, "txid", "", "txid da cobrança (ID da proposta)")
+	flag.Int64Var(&o.valor, "valor", 0, "valor em centavos (com -registrar, opcional: conferido com o valor da proposta)")
+	flag.StringVar(&o.peer, "peer", "http://localhost:7050", "endereço da API REST do peer")
+	flag.StringVar(&o.chaincode, "chaincode", "", "ID do chaincode (obtém o BR Code com a query gerarBRCode)")
+	flag.BoolVar(&o.registrar, "registrar", false, "com -chaincode, registra a cobrança com o invoke registrarCobrancaPix")
+	flag.StringVar(&o.usuario, "usuario", "WebAppAdmin", "secureContext utilizado nas transações")
+	flag.StringVar(&o.proposta, "proposta", "", "ID da proposta cobrada (com -chaincode)")
+	flag.StringVar(&o.png, "png", "", "grava o QR Code em PNG no arquivo informado")
+	flag.StringVar(&o.svg, "svg", "", "grava o QR Code em SVG no arquivo informado")
+	flag.IntVar(&o.escala, "escala", 8, "pixels (PNG) ou unidades (SVG) por módulo do QR Code")
+	flag.StringVar(&o.nivel, "nivel", qrcode.NivelPadrao.String(), "nível de correção de erros do QR Code: L, M, Q ou H")
+	flag.StringVar(&o.decodificar, "decodificar", "", "confere e imprime os dados do BR Code informado")
+	flag.Parse()
+
+	if err := executar(o); err != nil {
+		fmt.Fprintln(os.Stderr, "pix: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+// executar: gera (ou decodifica) o BR Code e grava as imagens pedidas
+func executar(o opcoes) error {
+	if o.decodificar != "" {
+		c, err := pix.Decodificar(o.decodificar)
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(struct {
+			pix.Recebedor
+			TxID  string `json:"txid"`
+			Valor int64  `json:"valor"`
+		}{c.Recebedor, c.TxID, c.Valor}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	nivel, err := qrcode.ConverterNivel(o.nivel)
+	if err != nil {
+		return err
+	}
+	brcode, err := gerar(o)
+	if err != nil || brcode == "" {
+		return err
+	}
+	fmt.Println(brcode)
+
+	if o.png == "" && o.svg == "" {
+		return nil
+	}
+	codigo, err := qrcode.Codificar([]byte(brcode), nivel)
+	if err != nil {
+		return err
+	}
+	if o.png != "" {
+		b, err := codigo.PNG(o.escala, qrcode.MargemPadrao)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(o.png, b, 0644); err != nil {
+			return err
+		}
+	}
+	if o.svg != "" {
+		b, err := codigo.SVG(o.escala, qrcode.MargemPadrao)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(o.svg, b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gerar: BR Code da query gerarBRCode (com -chaincode), de registrarCobrancaPix (com
+// -registrar) ou da conta informada nas flags. Vazio se a cobrança foi registrada em uma
+// transação ainda não confirmada.
+func gerar(o opcoes) (string, error) {
+	if o.chaincode == "" {
+		if o.txid == "" {
+			return "", errors.New("Informe o txid com -txid (ou o chaincode com -chaincode)")
+		}
+		return pix.Cobranca{Recebedor: o.recebedor, TxID: o.txid, Valor: o.valor}.BRCode()
+	}
+
+	if o.proposta == "" {
+		return "", errors.New("Informe a proposta com -proposta")
+	}
+	if o.valor != 0 && !o.registrar {
+		return "", errors.New("O valor é informado ao registrar a cobrança, com -registrar")
+	}
+	l := &ledger.Peer{URL: o.peer, ChaincodeID: o.chaincode, SecureContext: o.usuario}
+	var b []byte
+	if o.registrar {
+		args := []string{o.proposta}
+		if o.valor != 0 {
+			args = append(args, strconv.FormatInt(o.valor, 10))
+		}
+		res, err := l.Invoke("registrarCobrancaPix", args)
+		if err != nil {
+			return "", err
+		}
+		if res.Pendente || len(res.Payload) == 0 {
+			fmt.Fprintf(os.Stderr, "Cobrança registrada na transação %s; obtenha o BR Code após a confirmação, sem -registrar\n", res.TxID)
+			return "", nil
+		}
+		b = res.Payload
+	} else {
+		var err error
+		if b, err = l.Query("gerarBRCode", []string{o.proposta}); err != nil {
+			return "", err
+		}
+	}
+	var resposta struct {
+		BRCode string `json:"brcode"`
+	}
+	if err := json.Unmarshal(b, &resposta); err != nil {
+		return "", fmt.Errorf("Resposta inválida da cobrança PIX: %s", err)
+	}
+	return resposta.BRCode, nil
+}
@@ -0,0 +1,151 @@
+/*
+Descrição: mantém a projeção de leitura das propostas (ver pacote projection)
+Uso:
+	projecao -db propostas.db -peer http://localhost:7050 -chaincode <id> [-intervalo 10s]
+	projecao -db propostas.db -peer http://localhost:7050 -reconstruir
+	projecao -db propostas.db -exportar propostas|pagadores|pagamentos
+	projecao -db propostas.db -aging [-data AAAA-MM-DD] [-formato json|csv]
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/aging"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/projection"
+)
+
+func main() {
+	arquivo := flag.String("db", "propostas.db", "arquivo BoltDB da projeção")
+	peer := flag.String("peer", "http://localhost:7050", "endereço da API REST do peer")
+	chaincode := flag.String("chaincode", "", "ID do chaincode (vazio = eventos de todos os chaincodes)")
+	reconstruir := flag.Bool("reconstruir", false, "descarta a projeção e reaplica todos os eventos desde o bloco 0")
+	intervalo := flag.Duration("intervalo", 0, "intervalo entre sincronizações (0 = sincroniza uma vez e termina)")
+	exportar := flag.String("exportar", "", "imprime a projeção em JSON: propostas, pagadores ou pagamentos")
+	relatorioAging := flag.Bool("aging", false, "imprime o aging dos boletos em aberto (mesmo cálculo da query agingRecebiveis)")
+	dataReferencia := flag.String("data", "", "data de referência do aging, AAAA-MM-DD (vazio = hoje)")
+	formato := flag.String("formato", aging.FormatoJSON, "formato do aging: json ou csv")
+	flag.Parse()
+
+	proj, err := projection.Abrir(*arquivo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer proj.Fechar()
+
+	if *exportar != "" {
+		if err := exportarProjecao(proj, *exportar); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *relatorioAging {
+		if err := imprimirAging(proj, *dataReferencia, *formato); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	fonte := projection.FonteREST{URL: *peer, ChaincodeID: *chaincode}
+
+	if *reconstruir {
+		n, err := proj.Reconstruir(fonte)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Projeção reconstruída com %d eventos\n", n)
+	}
+
+	for {
+		n, err := proj.Sincronizar(fonte)
+		if err != nil {
+			log.Println(err)
+		} else if n > 0 {
+			pos, _ := proj.Posicao()
+			fmt.Printf("%d eventos aplicados (bloco %d)\n", n, pos.Bloco)
+		}
+		if *intervalo == 0 {
+			if err != nil {
+				os.Exit(1)
+			}
+			return
+		}
+		time.Sleep(*intervalo)
+	}
+}
+
+// exportarProjecao: imprime o conteúdo da projeção em JSON
+func exportarProjecao(proj *projection.Projecao, conteudo string) error {
+	var registros interface{}
+	var err error
+	switch conteudo {
+	case "propostas":
+		registros, err = proj.Propostas()
+	case "pagadores":
+		registros, err = proj.Pagadores()
+	case "pagamentos":
+		registros, err = proj.Pagamentos()
+	default:
+		return fmt.Errorf("Conteúdo desconhecido: %s", conteudo)
+	}
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(registros)
+}
+
+// imprimirAging: imprime o aging dos boletos em aberto da projeção na data de referência
+func imprimirAging(proj *projection.Projecao, data, formato string) error {
+	if formato != aging.FormatoJSON && formato != aging.FormatoCSV {
+		return fmt.Errorf("Formato desconhecido: %s", formato)
+	}
+	referencia := time.Now()
+	if data != "" {
+		var err error
+		if referencia, err = time.Parse(oracle.LayoutData, data); err != nil {
+			return fmt.Errorf("Data de referência inválida: %s", data)
+		}
+	}
+
+	propostas, err := proj.Propostas()
+	if err != nil {
+		return err
+	}
+	var titulos []aging.Titulo
+	for _, p := range propostas {
+		if !aging.EmAberto(p.NossoNumero, p.BoletoPago, p.Cancelada) {
+			continue
+		}
+		titulos = append(titulos, aging.Titulo{
+			IDProposta:     p.ID,
+			Beneficiario:   p.Beneficiario,
+			CpfPagador:     p.CpfPagador,
+			Valor:          p.Valor,
+			DataVencimento: p.DataVencimento,
+		})
+	}
+
+	relatorio := aging.Calcular(titulos, referencia)
+	if formato == aging.FormatoCSV {
+		b, err := relatorio.CSV()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(relatorio)
+}
@@ -0,0 +1,303 @@
+/*
+Descrição: baixa das liquidações do retorno nas propostas
+As ocorrências de liquidação são associadas às propostas pelo nosso número. As propostas
+com boleto emitido e valor conferido são liquidadas com confirmarPagamento, em lotes do
+invoke executarLote, com o atestado de cada liquidação (valor e data de pagamento do
+retorno) assinado com a chave do oráculo do banco; as demais ocorrências ficam no
+relatório. Cada lote é enviado com um ID de requisição derivado do conteúdo do arquivo,
+portanto a importação repetida do mesmo retorno não liquida as propostas de novo.
+*/
+
+package cnab
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/projection"
+)
+
+// LoteBaixaPadrao - propostas liquidadas por executarLote quando o tamanho não é informado
+const LoteBaixaPadrao = 50
+
+// Situações das ocorrências no relatório da baixa
+const (
+	SituacaoLiquidada       = "liquidada"        // enviada ao chaincode
+	SituacaoALiquidar       = "a_liquidar"       // conferida, ainda não enviada ao chaincode (ou -simular)
+	SituacaoNaoEncontrada   = "nao_encontrada"   // nenhuma proposta com o nosso número
+	SituacaoValorDivergente = "valor_divergente" // valor pago, sem encargos, diferente do boleto
+	SituacaoJaPaga          = "ja_paga"          // proposta já liquidada (ou liquidada antes no mesmo arquivo)
+	SituacaoStatusInvalido  = "status_invalido"  // proposta cancelada ou sem boleto emitido
+	SituacaoRecusada        = "recusada"         // operação recusada pelo chaincode no lote (ou atestado não assinado)
+	SituacaoRejeicao        = "rejeicao"         // entrada do título rejeitada pelo banco
+	SituacaoTarifa          = "tarifa"           // débito de tarifa
+	SituacaoInformativa     = "informativa"      // demais ocorrências, sem ação
+)
+
+// ItemBaixa - ocorrência do retorno com a proposta associada e a situação da baixa
+type ItemBaixa struct {
+	Ocorrencia
+	IDProposta    string `json:"id_proposta,omitempty"`
+	ValorProposta int64  `json:"valor_proposta,omitempty"`
+	Situacao      string `json:"situacao"`
+	Detalhe       string `json:"detalhe,omitempty"`
+	Lote          int    `json:"lote,omitempty"` // lote em que a proposta foi enviada (a partir de 1)
+
+	proposta projection.Proposta
+}
+
+// LoteBaixa - lote de liquidações enviado ao chaincode
+type LoteBaixa struct {
+	Numero       int    `json:"numero"`
+	IDRequisicao string `json:"id_requisicao"`
+	Propostas    int    `json:"propostas"`
+	TxID         string `json:"tx_id,omitempty"`
+	Pendente     bool   `json:"pendente,omitempty"` // peer real: transação ainda não confirmada
+}
+
+// Baixa - relatório da importação de um retorno
+type Baixa struct {
+	Formato        string      `json:"formato"`
+	Banco          string      `json:"banco"`
+	Itens          []ItemBaixa `json:"itens"`
+	Liquidadas     int         `json:"liquidadas"`
+	NaoConciliadas int         `json:"nao_conciliadas"` // não encontradas, divergentes, já pagas, com status inválido ou recusadas
+	Rejeicoes      int         `json:"rejeicoes"`
+	Tarifas        int64       `json:"tarifas"` // total das tarifas do arquivo, em centavos
+	Lotes          []LoteBaixa `json:"lotes,omitempty"`
+
+	resumo string // SHA-256 do arquivo, para os IDs das requisições
+}
+
+// Conciliar: associa as ocorrências às propostas pelo nosso número e define a situação de
+// cada uma. As liquidações conferidas ficam como a_liquidar até o envio (ver Enviar).
+func Conciliar(conteudo []byte, ret Retorno, propostas []projection.Proposta) *Baixa {
+	soma := sha256.Sum256(conteudo)
+	b := &Baixa{Formato: ret.Formato, Banco: ret.Banco, resumo: hex.EncodeToString(soma[:8])}
+
+	porNossoNumero := make(map[string]projection.Proposta)
+	for _, p := range propostas {
+		if p.NossoNumero != "" {
+			porNossoNumero[normalizarNossoNumero(p.NossoNumero)] = p
+		}
+	}
+
+	liquidadas := make(map[string]bool)
+	for _, o := range ret.Ocorrencias {
+		item := ItemBaixa{Ocorrencia: o}
+		b.Tarifas += o.Tarifa
+		p, encontrada := porNossoNumero[normalizarNossoNumero(o.NossoNumero)]
+		if encontrada {
+			item.IDProposta, item.ValorProposta, item.proposta = p.ID, p.Valor, p
+		}
+
+		switch o.Tipo {
+		case OcorrenciaRejeicao:
+			item.Situacao = SituacaoRejeicao
+			b.Rejeicoes++
+		case OcorrenciaTarifa:
+			item.Situacao = SituacaoTarifa
+		case OcorrenciaOutra:
+			item.Situacao = SituacaoInformativa
+		case OcorrenciaLiquidacao:
+			switch {
+			case !encontrada:
+				item.Situacao = SituacaoNaoEncontrada
+			case p.Status == events.StatusPaga || liquidadas[p.ID]:
+				item.Situacao = SituacaoJaPaga
+			case p.Status != events.StatusBoletoEmitido:
+				item.Situacao, item.Detalhe = SituacaoStatusInvalido, "status "+p.Status
+			case o.Principal() != p.Valor:
+				item.Situacao = SituacaoValorDivergente
+				item.Detalhe = fmt.Sprintf("pago %d (principal %d), boleto %d", o.ValorPago, o.Principal(), p.Valor)
+			default:
+				item.Situacao = SituacaoALiquidar
+				liquidadas[p.ID] = true
+			}
+			if item.Situacao != SituacaoALiquidar {
+				b.NaoConciliadas++
+			}
+		}
+		b.Itens = append(b.Itens, item)
+	}
+	return b
+}
+
+// normalizarNossoNumero: nosso número sem os zeros à esquerda (o retorno pode alinhá-lo
+// de outra forma que a remessa)
+func normalizarNossoNumero(s string) string {
+	d := Digitos(s)
+	if d == "" {
+		return s
+	}
+	for len(d) > 1 && d[0] == '0' {
+		d = d[1:]
+	}
+	return d
+}
+
+// operacaoLote - operação do documento de executarLote
+type operacaoLote struct {
+	Funcao    string      `json:"funcao"`
+	Documento interface{} `json:"documento"`
+}
+
+// documentoLiquidacao: documento de confirmarPagamento com o atestado da liquidação. O
+// valor é o principal, já conferido com o valor do boleto, e a data de pagamento é a data
+// da ocorrência no retorno.
+func (b *Baixa) documentoLiquidacao(chave *ecdsa.PrivateKey, item ItemBaixa) (map[string]interface{}, error) {
+	atestado := oracle.Atestado{
+		CodigoBanco:   b.Banco,
+		NossoNumero:   item.proposta.NossoNumero,
+		Valor:         item.Principal(),
+		DataPagamento: item.Data,
+	}
+	if err := oracle.Assinar(chave, &atestado); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id_proposta": item.IDProposta,
+		"atestado":    atestado,
+	}, nil
+}
+
+// Enviar: liquida as propostas conferidas em lotes de executarLote, com os atestados
+// assinados com a chave do oráculo do banco do retorno (registrado no chaincode). Se o
+// atestado de uma liquidação não puder ser assinado (por exemplo, sem data de pagamento)
+// ou se o chaincode recusar uma operação (LOTE_REJEITADO), a proposta fica como recusada
+// e o restante do lote é enviado. Erros de comunicação com o ledger interrompem o envio.
+func (b *Baixa) Enviar(l ledger.Ledger, chave *ecdsa.PrivateKey, tamanho int) error {
+	if chave == nil {
+		return fmt.Errorf("Chave do oráculo do banco %s não informada", b.Banco)
+	}
+	if tamanho < 1 {
+		tamanho = LoteBaixaPadrao
+	}
+	documentos := make(map[int]map[string]interface{})
+	var pendentes []int
+	for i, item := range b.Itens {
+		if item.Situacao != SituacaoALiquidar {
+			continue
+		}
+		documento, err := b.documentoLiquidacao(chave, item)
+		if err != nil {
+			b.Itens[i].Situacao, b.Itens[i].Detalhe = SituacaoRecusada, err.Error()
+			b.NaoConciliadas++
+			continue
+		}
+		documentos[i] = documento
+		pendentes = append(pendentes, i)
+	}
+
+	for inicio := 0; inicio < len(pendentes); inicio += tamanho {
+		fim := inicio + tamanho
+		if fim > len(pendentes) {
+			fim = len(pendentes)
+		}
+		lote := pendentes[inicio:fim]
+		numero := len(b.Lotes) + 1
+		for tentativa := 1; len(lote) > 0; tentativa++ {
+			id := fmt.Sprintf("retorno-%s-%d-%d", b.resumo, inicio/tamanho+1, tentativa)
+			recusado, err := b.enviarLote(l, lote, documentos, numero, id)
+			if err != nil {
+				return err
+			}
+			if recusado < 0 {
+				break
+			}
+			// reenvia o lote sem a operação recusada
+			lote = append(append([]int{}, lote[:recusado]...), lote[recusado+1:]...)
+		}
+	}
+	return nil
+}
+
+// enviarLote: envia as liquidações dos itens informados em um executarLote. Retorna a posição
+// da operação recusada pelo chaincode ou -1 se o lote foi aceito.
+func (b *Baixa) enviarLote(l ledger.Ledger, itens []int, documentos map[int]map[string]interface{}, numero int, id string) (int, error) {
+	documento := map[string]interface{}{"id_requisicao": id}
+	var operacoes []operacaoLote
+	for _, i := range itens {
+		operacoes = append(operacoes, operacaoLote{Funcao: "confirmarPagamento", Documento: documentos[i]})
+	}
+	documento["operacoes"] = operacoes
+	arg, err := json.Marshal(documento)
+	if err != nil {
+		return -1, err
+	}
+
+	res, err := l.Invoke("executarLote", []string{string(arg)})
+	if err != nil {
+		e, ok := envelope.Decodificar(err)
+		if !ok || e.Codigo != envelope.LoteRejeitado {
+			return -1, fmt.Errorf("Falha ao enviar o lote %d: %s", numero, err)
+		}
+		indice, err := strconv.Atoi(e.Parametros["indice"])
+		if err != nil || indice < 0 || indice >= len(itens) {
+			return -1, fmt.Errorf("Lote %d recusado sem a posição da operação: %s", numero, e.Mensagem)
+		}
+		item := &b.Itens[itens[indice]]
+		item.Situacao, item.Detalhe = SituacaoRecusada, e.Parametros["detalhe"]
+		b.NaoConciliadas++
+		return indice, nil
+	}
+
+	for _, i := range itens {
+		b.Itens[i].Situacao, b.Itens[i].Lote = SituacaoLiquidada, numero
+	}
+	b.Liquidadas += len(itens)
+	b.Lotes = append(b.Lotes, LoteBaixa{Numero: numero, IDRequisicao: id, Propostas: len(itens), TxID: res.TxID, Pendente: res.Pendente})
+	return -1, nil
+}
+
+// colunasBaixaCSV: cabeçalho do relatório da baixa em CSV
+var colunasBaixaCSV = []string{
+	"linha", "codigo", "tipo", "nosso_numero", "id_proposta", "valor_titulo", "valor_pago", "encargos",
+	"descontos", "valor_proposta", "tarifa", "data_ocorrencia", "motivos", "situacao", "detalhe", "lote",
+}
+
+// CSV: relatório em CSV, com uma linha por ocorrência. Com apenasPendencias, apenas as
+// ocorrências não conciliadas e as rejeições.
+func (b *Baixa) CSV(apenasPendencias bool) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	linhas := [][]string{colunasBaixaCSV}
+	for _, i := range b.Itens {
+		if apenasPendencias && !i.Pendencia() {
+			continue
+		}
+		lote := ""
+		if i.Lote > 0 {
+			lote = strconv.Itoa(i.Lote)
+		}
+		linhas = append(linhas, []string{
+			strconv.Itoa(i.Linha), i.Codigo, i.Tipo, i.NossoNumero, i.IDProposta,
+			strconv.FormatInt(i.ValorTitulo, 10), strconv.FormatInt(i.ValorPago, 10), strconv.FormatInt(i.Encargos, 10),
+			strconv.FormatInt(i.Descontos, 10), strconv.FormatInt(i.ValorProposta, 10), strconv.FormatInt(i.Tarifa, 10),
+			i.Data, i.Motivos, i.Situacao, i.Detalhe, lote,
+		})
+	}
+	if err := w.WriteAll(linhas); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Pendencia: indica se a ocorrência exige tratamento manual (não conciliada ou rejeitada)
+func (i ItemBaixa) Pendencia() bool {
+	switch i.Situacao {
+	case SituacaoNaoEncontrada, SituacaoValorDivergente, SituacaoJaPaga, SituacaoStatusInvalido, SituacaoRecusada, SituacaoRejeicao:
+		return true
+	}
+	return false
+}
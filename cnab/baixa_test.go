@@ -0,0 +1,147 @@
+package cnab
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/projection"
+)
+
+// ledgerLote - ledger que grava os documentos de executarLote e recusa a primeira
+// operação da proposta informada
+type ledgerLote struct {
+	recusar    string
+	documentos []string
+}
+
+func (l *ledgerLote) Invoke(funcao string, args []string) (ledger.Resultado, error) {
+	l.documentos = append(l.documentos, args[0])
+	var lote struct {
+		Operacoes []struct {
+			Documento struct {
+				IDProposta string `json:"id_proposta"`
+			} `json:"documento"`
+		} `json:"operacoes"`
+	}
+	json.Unmarshal([]byte(args[0]), &lote)
+	for i, op := range lote.Operacoes {
+		if op.Documento.IDProposta == l.recusar {
+			l.recusar = ""
+			err := envelope.Lote(i, "confirmarPagamento", envelope.Novo(envelope.PropostaJaPaga, "id", op.Documento.IDProposta))
+			return ledger.Resultado{}, &ledger.ErroChaincode{Mensagem: err.Error()}
+		}
+	}
+	return ledger.Resultado{TxID: "tx-1"}, nil
+}
+
+func (l *ledgerLote) Query(funcao string, args []string) ([]byte, error) {
+	return nil, nil
+}
+
+// baixaFixture: baixa do retorno 237 com as propostas de fixtures/propostas.json
+func baixaFixture(t *testing.T) *Baixa {
+	conteudo, err := ioutil.ReadFile("fixtures/retorno_237.ret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := LerRetorno(conteudo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile("fixtures/propostas.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lista []projection.Proposta
+	if err := json.Unmarshal(b, &lista); err != nil {
+		t.Fatal(err)
+	}
+	return Conciliar(conteudo, ret, lista)
+}
+
+func TestEnviarAtestados(t *testing.T) {
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publica, err := oracle.CodificarChavePublica(&chave.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baixa := baixaFixture(t)
+	l := &ledgerLote{}
+	if err := baixa.Enviar(l, chave, 0); err != nil {
+		t.Fatal(err)
+	}
+	if baixa.Liquidadas != 2 || len(l.documentos) != 1 {
+		t.Fatalf("%d liquidadas em %d lotes, esperadas 2 em 1", baixa.Liquidadas, len(l.documentos))
+	}
+
+	var lote struct {
+		Operacoes []struct {
+			Funcao    string `json:"funcao"`
+			Documento struct {
+				IDProposta string          `json:"id_proposta"`
+				Atestado   oracle.Atestado `json:"atestado"`
+			} `json:"documento"`
+		} `json:"operacoes"`
+	}
+	if err := json.Unmarshal([]byte(l.documentos[0]), &lote); err != nil {
+		t.Fatal(err)
+	}
+	esperados := map[string]string{"f2c4a8b0": "20100000011", "0ab3d6e1": "20100000029"}
+	for _, op := range lote.Operacoes {
+		a := op.Documento.Atestado
+		if op.Funcao != "confirmarPagamento" {
+			t.Errorf("função %s, esperada confirmarPagamento", op.Funcao)
+		}
+		if a.CodigoBanco != "237" || a.NossoNumero != esperados[op.Documento.IDProposta] || a.DataPagamento != "2026-11-10" {
+			t.Errorf("atestado de %s: %+v", op.Documento.IDProposta, a)
+		}
+		if err := oracle.Verificar(publica, a); err != nil {
+			t.Errorf("atestado de %s: %s", op.Documento.IDProposta, err)
+		}
+	}
+
+	// a mesma baixa gera o mesmo documento, reconhecido como repetição pelo chaincode
+	repetida := baixaFixture(t)
+	l2 := &ledgerLote{}
+	if err := repetida.Enviar(l2, chave, 0); err != nil {
+		t.Fatal(err)
+	}
+	if l2.documentos[0] != l.documentos[0] {
+		t.Fatalf("documento diferente no reenvio:\n%s\n%s", l2.documentos[0], l.documentos[0])
+	}
+}
+
+func TestEnviarRecusada(t *testing.T) {
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	baixa := baixaFixture(t)
+	l := &ledgerLote{recusar: "f2c4a8b0"}
+	if err := baixa.Enviar(l, chave, 0); err != nil {
+		t.Fatal(err)
+	}
+	if baixa.Liquidadas != 1 || len(l.documentos) != 2 {
+		t.Fatalf("%d liquidadas em %d envios, esperada 1 em 2", baixa.Liquidadas, len(l.documentos))
+	}
+	for _, i := range baixa.Itens {
+		if i.IDProposta == "f2c4a8b0" && i.Situacao != SituacaoRecusada && i.Situacao != SituacaoJaPaga {
+			t.Errorf("ocorrência da linha %d: %s", i.Linha, i.Situacao)
+		}
+	}
+
+	if err := baixaFixture(t).Enviar(l, nil, 0); err == nil {
+		t.Fatal("envio sem a chave do oráculo aceito")
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Descrição: arquivos CNAB 240 (FEBRABAN) de cobrança
+Os bancos registram os boletos a partir de um arquivo de remessa, com registros de 240
+posições: header de arquivo, header de lote, segmentos P, Q e R de cada título, trailer
+de lote e trailer de arquivo. O layout segue o padrão FEBRABAN; as variações de cada
+banco (versões, códigos da carteira, alinhamento do nosso número, segmento R etc.) são
+declaradas em Layout, com valores conhecidos em Layouts e sobrescritos pela configuração.
+*/
+
+// Package cnab gera os arquivos de remessa CNAB 240 dos boletos emitidos, a partir das
+// propostas do chaincode ou da projeção, e importa os arquivos de retorno (CNAB 240 e
+// CNAB 400), liquidando as propostas pagas.
+package cnab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TamanhoRegistro - posições de cada registro do CNAB 240
+const TamanhoRegistro = 240
+
+// Layout - variações do CNAB 240 de um banco. Os campos com posições são os do registro
+// indicado no comentário.
+type Layout struct {
+	Nome          string `json:"nome"`
+	VersaoArquivo string `json:"versao_arquivo"` // header de arquivo, 164-166
+	VersaoLote    string `json:"versao_lote"`    // header de lote, 14-16
+	Densidade     string `json:"densidade"`      // header de arquivo, 167-171
+
+	Carteira           string `json:"carteira"`            // segmento P, 58
+	FormaCadastramento string `json:"forma_cadastramento"` // segmento P, 59: 1 com registro
+	TipoDocumento      string `json:"tipo_documento"`      // segmento P, 60: 1 tradicional, 2 escritural
+	EmissaoBoleto      string `json:"emissao_boleto"`      // segmento P, 61: 1 banco, 2 beneficiário
+	Distribuicao       string `json:"distribuicao"`        // segmento P, 62: 1 banco, 2 beneficiário
+	Especie            string `json:"especie"`             // segmento P, 107-108: 02 duplicata mercantil
+	CodigoBaixa        string `json:"codigo_baixa"`        // segmento P, 224: 1 baixar, 2 não baixar
+	PrazoBaixa         int    `json:"prazo_baixa"`         // segmento P, 225-227, em dias
+
+	// NossoNumeroADireita: nosso número alinhado à direita com zeros (padrão: à esquerda
+	// com brancos), no segmento P, 38-57
+	NossoNumeroADireita bool `json:"nosso_numero_a_direita"`
+	// SegmentoR: inclui o segmento R (multa) de cada título
+	SegmentoR bool `json:"segmento_r"`
+	// TotaisTrailerLote: preenche a quantidade e o valor dos títulos em cobrança simples
+	// no trailer de lote (alguns bancos exigem zeros na remessa)
+	TotaisTrailerLote bool `json:"totais_trailer_lote"`
+	// QuebraLinha: separador dos registros ("crlf", padrão, ou "lf")
+	QuebraLinha string `json:"quebra_linha"`
+}
+
+// LayoutPadrao - layout FEBRABAN sem as variações de um banco
+var LayoutPadrao = Layout{
+	Nome:               "FEBRABAN",
+	VersaoArquivo:      "087",
+	VersaoLote:         "045",
+	Densidade:          "01600",
+	Carteira:           "1",
+	FormaCadastramento: "1",
+	TipoDocumento:      "1",
+	EmissaoBoleto:      "2",
+	Distribuicao:       "2",
+	Especie:            "02",
+	CodigoBaixa:        "1",
+	PrazoBaixa:         60,
+	SegmentoR:          true,
+	QuebraLinha:        "crlf",
+}
+
+// Layouts - layouts conhecidos, pelo código do banco
+var Layouts = map[string]Layout{
+	"001": {
+		Nome:               "Banco do Brasil",
+		VersaoArquivo:      "083",
+		VersaoLote:         "042",
+		Densidade:          "00000",
+		Carteira:           "7",
+		FormaCadastramento: "1",
+		TipoDocumento:      "1",
+		EmissaoBoleto:      "2",
+		Distribuicao:       "2",
+		Especie:            "02",
+		CodigoBaixa:        "0",
+		SegmentoR:          true,
+		QuebraLinha:        "crlf",
+	},
+	"033": {
+		Nome:                "Santander",
+		VersaoArquivo:       "040",
+		VersaoLote:          "030",
+		Densidade:           "00000",
+		Carteira:            "5",
+		FormaCadastramento:  "1",
+		TipoDocumento:       "1",
+		EmissaoBoleto:       "2",
+		Distribuicao:        "2",
+		Especie:             "02",
+		CodigoBaixa:         "3",
+		NossoNumeroADireita: true,
+		TotaisTrailerLote:   true,
+		QuebraLinha:         "crlf",
+	},
+}
+
+// LayoutBanco: layout conhecido do banco (LayoutPadrao para os demais)
+func LayoutBanco(banco string) Layout {
+	if l, ok := Layouts[banco]; ok {
+		return l
+	}
+	return LayoutPadrao
+}
+
+// Empresa - beneficiário titular do convênio de cobrança
+type Empresa struct {
+	Inscricao          string `json:"inscricao"` // CPF ou CNPJ, com ou sem pontuação
+	Nome               string `json:"nome"`
+	Convenio           string `json:"convenio"` // código do convênio, no formato do banco (20 posições)
+	Agencia            string `json:"agencia"`
+	DigitoAgencia      string `json:"digito_agencia"`
+	Conta              string `json:"conta"`
+	DigitoConta        string `json:"digito_conta"`
+	DigitoAgenciaConta string `json:"digito_agencia_conta,omitempty"`
+}
+
+// Pagador - nome e endereço do pagador, exigidos no segmento Q
+type Pagador struct {
+	Nome     string `json:"nome"`
+	Endereco string `json:"endereco"`
+	Bairro   string `json:"bairro"`
+	CEP      string `json:"cep"`
+	Cidade   string `json:"cidade"`
+	UF       string `json:"uf"`
+}
+
+// Configuracao - arquivo de configuração da remessa: banco, layout (sobrescreve os campos
+// informados do layout do banco), beneficiário, encargos e cadastro dos pagadores
+type Configuracao struct {
+	Banco     string          `json:"banco"` // código do banco, 3 dígitos
+	NomeBanco string          `json:"nome_banco"`
+	Layout    json.RawMessage `json:"layout,omitempty"`
+	Empresa   Empresa         `json:"empresa"`
+	// Encargos em centésimos de percentual (200 = 2,00%); zero: isento
+	JurosMes int64 `json:"juros_mes,omitempty"`
+	Multa    int64 `json:"multa,omitempty"`
+	// DiasProtesto: dias após o vencimento para o protesto (zero: não protestar)
+	DiasProtesto int `json:"dias_protesto,omitempty"`
+	// Pagadores: nome e endereço pelo CPF ou CNPJ do pagador
+	Pagadores map[string]Pagador `json:"pagadores"`
+}
+
+// CarregarConfiguracao: lê a configuração em JSON e valida os campos obrigatórios
+func CarregarConfiguracao(arquivo string) (Configuracao, error) {
+	var cfg Configuracao
+	b, err := ioutil.ReadFile(arquivo)
+	if err != nil {
+		return cfg, fmt.Errorf("Falha ao ler a configuração do CNAB: %s", err)
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("Configuração do CNAB inválida: %s", err)
+	}
+	if len(Digitos(cfg.Banco)) != 3 {
+		return cfg, fmt.Errorf("Configuração do CNAB inválida: banco deve ter 3 dígitos")
+	}
+	if n := len(Digitos(cfg.Empresa.Inscricao)); n != 11 && n != 14 {
+		return cfg, fmt.Errorf("Configuração do CNAB inválida: inscrição da empresa deve ser um CPF ou CNPJ")
+	}
+	if cfg.Empresa.Nome == "" || cfg.Empresa.Agencia == "" || cfg.Empresa.Conta == "" {
+		return cfg, fmt.Errorf("Configuração do CNAB inválida: informe nome, agência e conta da empresa")
+	}
+	if _, err := cfg.LayoutEfetivo(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// LayoutEfetivo: layout do banco com os campos sobrescritos pela configuração
+func (cfg Configuracao) LayoutEfetivo() (Layout, error) {
+	l := LayoutBanco(cfg.Banco)
+	if len(cfg.Layout) > 0 {
+		if err := json.Unmarshal(cfg.Layout, &l); err != nil {
+			return l, fmt.Errorf("Layout do CNAB inválido: %s", err)
+		}
+	}
+	if l.QuebraLinha != "crlf" && l.QuebraLinha != "lf" {
+		return l, fmt.Errorf("Layout do CNAB inválido: quebra_linha deve ser crlf ou lf")
+	}
+	return l, nil
+}
+
+// Pagador: cadastro do pagador pelo CPF ou CNPJ, comparado sem pontuação
+func (cfg Configuracao) Pagador(inscricao string) (Pagador, bool) {
+	for chave, p := range cfg.Pagadores {
+		if Digitos(chave) == Digitos(inscricao) {
+			return p, true
+		}
+	}
+	return Pagador{}, false
+}
+
+// Digitos: apenas os dígitos do texto (CPF, CNPJ, CEP etc.)
+func Digitos(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// tipoInscricao: 1 para CPF, 2 para CNPJ e 0 sem inscrição
+func tipoInscricao(inscricao string) string {
+	switch len(Digitos(inscricao)) {
+	case 11:
+		return "1"
+	case 14:
+		return "2"
+	}
+	return "0"
+}
+
+// acentos: letras acentuadas e as correspondentes sem acento, para os campos alfanuméricos
+var acentos = strings.NewReplacer(
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A",
+	"É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"Í", "I", "Ì", "I", "Î", "I", "Ï", "I",
+	"Ó", "O", "Ò", "O", "Ô", "O", "Õ", "O", "Ö", "O",
+	"Ú", "U", "Ù", "U", "Û", "U", "Ü", "U",
+	"Ç", "C", "Ñ", "N",
+)
+
+// alfa: campo alfanumérico em maiúsculas, sem acentos, alinhado à esquerda com brancos
+func alfa(s string, tamanho int) string {
+	s = acentos.Replace(strings.ToUpper(s))
+	s = strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	if len(s) > tamanho {
+		return s[:tamanho]
+	}
+	return s + strings.Repeat(" ", tamanho-len(s))
+}
+
+// num: campo numérico alinhado à direita com zeros (os dígitos excedentes à esquerda
+// são descartados)
+func num(s string, tamanho int) string {
+	s = Digitos(s)
+	if len(s) > tamanho {
+		return s[len(s)-tamanho:]
+	}
+	return strings.Repeat("0", tamanho-len(s)) + s
+}
+
+// valor: campo numérico de um inteiro (centavos, quantidades)
+func valor(v int64, tamanho int) string {
+	return num(strconv.FormatInt(v, 10), tamanho)
+}
+
+// brancos: campo reservado preenchido com brancos
+func brancos(tamanho int) string {
+	return strings.Repeat(" ", tamanho)
+}
@@ -0,0 +1,238 @@
+/*
+Descrição: arquivo de remessa CNAB 240 com a entrada dos títulos (código de movimento 01)
+Cada título gera os segmentos P (dados do boleto), Q (pagador) e, conforme o layout, R
+(multa), em um único lote de cobrança. O seu número (segmento P, 63-77) e a
+identificação do título na empresa (196-220) recebem o ID da proposta, devolvido pelo
+banco no retorno.
+*/
+
+package cnab
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/oracle"
+)
+
+// Titulo - boleto emitido incluído na remessa
+type Titulo struct {
+	IDProposta     string
+	NossoNumero    string
+	Valor          int64  // em centavos
+	DataVencimento string // AAAA-MM-DD
+	CpfPagador     string
+}
+
+// Remessa - dados de um arquivo de remessa
+type Remessa struct {
+	Configuracao
+	Sequencial int       // número sequencial do arquivo (NSA), crescente por convênio
+	Geracao    time.Time // data e hora de geração do arquivo
+	Titulos    []Titulo
+}
+
+// registros: registros de 240 posições do arquivo, na ordem, e o primeiro erro de montagem
+type registros struct {
+	linhas []string
+	err    error
+}
+
+// incluir: acrescenta o registro, conferindo o tamanho. Um registro com tamanho
+// diferente de 240 posições é descartado e o erro é retornado por Gerar.
+func (r *registros) incluir(partes ...string) {
+	registro := strings.Join(partes, "")
+	if len(registro) != TamanhoRegistro {
+		if r.err == nil {
+			r.err = fmt.Errorf("Registro CNAB %d com %d posições: %q", len(r.linhas)+1, len(registro), registro)
+		}
+		return
+	}
+	r.linhas = append(r.linhas, registro)
+}
+
+// Gerar: arquivo de remessa com um lote de cobrança. Retorna erro se um título não
+// tiver vencimento, se o pagador não estiver cadastrado na configuração ou se um
+// registro montado não tiver 240 posições.
+func (rem Remessa) Gerar() ([]byte, error) {
+	layout, err := rem.LayoutEfetivo()
+	if err != nil {
+		return nil, err
+	}
+	if len(rem.Titulos) == 0 {
+		return nil, fmt.Errorf("Nenhum título para a remessa")
+	}
+
+	var regs registros
+	regs.incluir(rem.headerArquivo(layout)...)
+	regs.incluir(rem.headerLote(layout)...)
+	sequencial := 0
+	var total int64
+	for _, t := range rem.Titulos {
+		vencimento, err := time.Parse(oracle.LayoutData, t.DataVencimento)
+		if err != nil {
+			return nil, fmt.Errorf("Título da proposta %s sem data de vencimento válida", t.IDProposta)
+		}
+		pagador, ok := rem.Pagador(t.CpfPagador)
+		if !ok {
+			return nil, fmt.Errorf("Pagador %s da proposta %s sem cadastro na configuração", t.CpfPagador, t.IDProposta)
+		}
+
+		sequencial++
+		regs.incluir(rem.segmentoP(layout, sequencial, t, vencimento)...)
+		sequencial++
+		regs.incluir(rem.segmentoQ(sequencial, t, pagador)...)
+		if layout.SegmentoR {
+			sequencial++
+			regs.incluir(rem.segmentoR(sequencial, vencimento)...)
+		}
+		total += t.Valor
+	}
+	// registros do lote: header, detalhes e trailer
+	regs.incluir(rem.trailerLote(layout, sequencial+2, len(rem.Titulos), total)...)
+	// registros do arquivo: headers e trailers do arquivo e do lote, e os detalhes
+	regs.incluir(rem.trailerArquivo(sequencial + 4)...)
+	if regs.err != nil {
+		return nil, regs.err
+	}
+
+	quebra := "\r\n"
+	if layout.QuebraLinha == "lf" {
+		quebra = "\n"
+	}
+	var buf bytes.Buffer
+	for _, r := range regs.linhas {
+		buf.WriteString(r)
+		buf.WriteString(quebra)
+	}
+	return buf.Bytes(), nil
+}
+
+// contaEmpresa: agência, conta e dígitos da empresa (20 posições, a partir da agência)
+func (rem Remessa) contaEmpresa() string {
+	e := rem.Empresa
+	return num(e.Agencia, 5) + alfa(e.DigitoAgencia, 1) + num(e.Conta, 12) + alfa(e.DigitoConta, 1) + alfa(e.DigitoAgenciaConta, 1)
+}
+
+// headerArquivo: registro 0
+func (rem Remessa) headerArquivo(l Layout) []string {
+	e := rem.Empresa
+	return []string{
+		num(rem.Banco, 3), "0000", "0", brancos(9),
+		tipoInscricao(e.Inscricao), num(e.Inscricao, 14), alfa(e.Convenio, 20),
+		rem.contaEmpresa(),
+		alfa(e.Nome, 30), alfa(rem.NomeBanco, 30), brancos(10),
+		"1", rem.Geracao.Format("02012006"), rem.Geracao.Format("150405"),
+		valor(int64(rem.Sequencial), 6), num(l.VersaoArquivo, 3), num(l.Densidade, 5),
+		brancos(20), brancos(20), brancos(29),
+	}
+}
+
+// headerLote: registro 1, lote 0001 de cobrança (operação R, serviço 01)
+func (rem Remessa) headerLote(l Layout) []string {
+	e := rem.Empresa
+	return []string{
+		num(rem.Banco, 3), "0001", "1", "R", "01", brancos(2), num(l.VersaoLote, 3), brancos(1),
+		tipoInscricao(e.Inscricao), num(e.Inscricao, 15), alfa(e.Convenio, 20),
+		rem.contaEmpresa(),
+		alfa(e.Nome, 30), brancos(40), brancos(40),
+		valor(int64(rem.Sequencial), 8), rem.Geracao.Format("02012006"), num("", 8), brancos(33),
+	}
+}
+
+// inicioDetalhe: posições 1-17 dos segmentos (banco, lote, tipo 3, sequencial, segmento e movimento 01)
+func (rem Remessa) inicioDetalhe(sequencial int, segmento string) string {
+	return num(rem.Banco, 3) + "0001" + "3" + valor(int64(sequencial), 5) + segmento + brancos(1) + "01"
+}
+
+// segmentoP: dados do boleto
+func (rem Remessa) segmentoP(l Layout, sequencial int, t Titulo, vencimento time.Time) []string {
+	nossoNumero := alfa(t.NossoNumero, 20)
+	if l.NossoNumeroADireita {
+		nossoNumero = num(t.NossoNumero, 20)
+	}
+
+	// juros de mora: 2 (taxa mensal) a partir do dia seguinte ao vencimento, ou 3 (isento)
+	codigoJuros, dataJuros := "3", num("", 8)
+	if rem.JurosMes > 0 {
+		codigoJuros, dataJuros = "2", vencimento.AddDate(0, 0, 1).Format("02012006")
+	}
+	// protesto: 1 (dias corridos) ou 3 (não protestar)
+	codigoProtesto := "3"
+	if rem.DiasProtesto > 0 {
+		codigoProtesto = "1"
+	}
+
+	return []string{
+		rem.inicioDetalhe(sequencial, "P"),
+		rem.contaEmpresa(),
+		nossoNumero,
+		alfa(l.Carteira, 1), alfa(l.FormaCadastramento, 1), alfa(l.TipoDocumento, 1),
+		alfa(l.EmissaoBoleto, 1), alfa(l.Distribuicao, 1),
+		alfa(t.IDProposta, 15), vencimento.Format("02012006"), valor(t.Valor, 15),
+		num("", 5), brancos(1), num(l.Especie, 2), "N", rem.Geracao.Format("02012006"),
+		codigoJuros, dataJuros, valor(rem.JurosMes, 15),
+		"0", num("", 8), num("", 15), // desconto 1
+		num("", 15), num("", 15), // IOF e abatimento
+		alfa(t.IDProposta, 25),
+		codigoProtesto, valor(int64(rem.DiasProtesto), 2),
+		alfa(l.CodigoBaixa, 1), valor(int64(l.PrazoBaixa), 3),
+		"09", num("", 10), brancos(1),
+	}
+}
+
+// segmentoQ: pagador (sem sacador/avalista)
+func (rem Remessa) segmentoQ(sequencial int, t Titulo, p Pagador) []string {
+	cep := num(p.CEP, 8)
+	return []string{
+		rem.inicioDetalhe(sequencial, "Q"),
+		tipoInscricao(t.CpfPagador), num(t.CpfPagador, 15),
+		alfa(p.Nome, 40), alfa(p.Endereco, 40), alfa(p.Bairro, 15),
+		cep[:5], cep[5:], alfa(p.Cidade, 15), alfa(p.UF, 2),
+		"0", num("", 15), brancos(40),
+		num("", 3), brancos(20), brancos(8),
+	}
+}
+
+// segmentoR: multa (percentual, a partir do dia seguinte ao vencimento); sem descontos 2 e 3
+func (rem Remessa) segmentoR(sequencial int, vencimento time.Time) []string {
+	codigoMulta, dataMulta := "0", num("", 8)
+	if rem.Multa > 0 {
+		codigoMulta, dataMulta = "2", vencimento.AddDate(0, 0, 1).Format("02012006")
+	}
+	return []string{
+		rem.inicioDetalhe(sequencial, "R"),
+		"0", num("", 8), num("", 15), // desconto 2
+		"0", num("", 8), num("", 15), // desconto 3
+		codigoMulta, dataMulta, valor(rem.Multa, 15),
+		brancos(10), brancos(40), brancos(40), brancos(20),
+		num("", 8), num("", 3), num("", 5), brancos(1), num("", 12), brancos(1), brancos(1),
+		"0", brancos(9),
+	}
+}
+
+// trailerLote: registro 5, com a quantidade de registros do lote
+func (rem Remessa) trailerLote(l Layout, quantidade, titulos int, total int64) []string {
+	simples, valorSimples := num("", 6), num("", 17)
+	if l.TotaisTrailerLote {
+		simples, valorSimples = valor(int64(titulos), 6), valor(total, 17)
+	}
+	return []string{
+		num(rem.Banco, 3), "0001", "5", brancos(9), valor(int64(quantidade), 6),
+		simples, valorSimples,
+		num("", 6), num("", 17), // vinculada
+		num("", 6), num("", 17), // caucionada
+		num("", 6), num("", 17), // descontada
+		brancos(8), brancos(117),
+	}
+}
+
+// trailerArquivo: registro 9, com a quantidade de lotes e de registros do arquivo
+func (rem Remessa) trailerArquivo(quantidade int) []string {
+	return []string{
+		num(rem.Banco, 3), "9999", "9", brancos(9), valor(1, 6), valor(int64(quantidade), 6),
+		num("", 6), brancos(205),
+	}
+}
@@ -0,0 +1,119 @@
+package cnab
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/projection"
+)
+
+// remessaFixture: remessa das propostas de fixtures/propostas.json, selecionadas como
+// no comando cnab remessa, com o NSA e a data de geração das remessas gravadas
+func remessaFixture(t *testing.T, configuracao string) Remessa {
+	cfg, err := CarregarConfiguracao(configuracao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile("fixtures/propostas.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lista []projection.Proposta
+	if err := json.Unmarshal(b, &lista); err != nil {
+		t.Fatal(err)
+	}
+
+	rem := Remessa{
+		Configuracao: cfg,
+		Sequencial:   7,
+		Geracao:      time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC),
+	}
+	for _, p := range lista {
+		if p.Status != events.StatusBoletoEmitido {
+			continue
+		}
+		if p.Beneficiario != "" && Digitos(p.Beneficiario) != Digitos(cfg.Empresa.Inscricao) {
+			continue
+		}
+		rem.Titulos = append(rem.Titulos, Titulo{
+			IDProposta:     p.ID,
+			NossoNumero:    p.NossoNumero,
+			Valor:          p.Valor,
+			DataVencimento: p.DataVencimento,
+			CpfPagador:     p.CpfPagador,
+		})
+	}
+	return rem
+}
+
+func TestGerarFixtures(t *testing.T) {
+	casos := []struct {
+		configuracao string
+		remessa      string
+	}{
+		{"fixtures/configuracao_001.json", "fixtures/remessa_001.rem"},
+		{"fixtures/configuracao_033.json", "fixtures/remessa_033.rem"},
+	}
+	for _, c := range casos {
+		t.Run(c.remessa, func(t *testing.T) {
+			arquivo, err := remessaFixture(t, c.configuracao).Gerar()
+			if err != nil {
+				t.Fatal(err)
+			}
+			esperado, err := ioutil.ReadFile(c.remessa)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Equal(arquivo, esperado) {
+				return
+			}
+			gerados := strings.SplitAfter(string(arquivo), "\n")
+			gravados := strings.SplitAfter(string(esperado), "\n")
+			for i := 0; i < len(gerados) && i < len(gravados); i++ {
+				if gerados[i] != gravados[i] {
+					t.Fatalf("registro %d diferente:\ngerado:   %q\nesperado: %q", i+1, gerados[i], gravados[i])
+				}
+			}
+			t.Fatalf("remessa com %d registros, esperados %d", len(gerados), len(gravados))
+		})
+	}
+}
+
+func TestGerarErros(t *testing.T) {
+	casos := []struct {
+		nome     string
+		alterar  func(*Remessa)
+		mensagem string
+	}{
+		{"sem títulos", func(r *Remessa) { r.Titulos = nil }, "Nenhum título"},
+		{"sem vencimento", func(r *Remessa) { r.Titulos[0].DataVencimento = "" }, "sem data de vencimento"},
+		{"pagador sem cadastro", func(r *Remessa) { r.Titulos[0].CpfPagador = "000.000.001-91" }, "sem cadastro"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			rem := remessaFixture(t, "fixtures/configuracao_001.json")
+			c.alterar(&rem)
+			if _, err := rem.Gerar(); err == nil || !strings.Contains(err.Error(), c.mensagem) {
+				t.Fatalf("erro = %v, esperado %q", err, c.mensagem)
+			}
+		})
+	}
+}
+
+func TestIncluirTamanho(t *testing.T) {
+	var regs registros
+	regs.incluir(brancos(TamanhoRegistro))
+	regs.incluir(brancos(TamanhoRegistro - 1))
+	regs.incluir(brancos(TamanhoRegistro + 1))
+	if len(regs.linhas) != 1 {
+		t.Fatalf("%d registros incluídos, esperado 1", len(regs.linhas))
+	}
+	if regs.err == nil || !strings.Contains(regs.err.Error(), "Registro CNAB 2 com 239 posições") {
+		t.Fatalf("erro = %v", regs.err)
+	}
+}
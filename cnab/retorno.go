@@ -0,0 +1,236 @@
+/*
+Descrição: leitura dos arquivos de retorno de cobrança (CNAB 240 e CNAB 400)
+O formato é identificado pelo tamanho dos registros. No CNAB 240, cada título tem um
+segmento T (nosso número, valor do título, tarifa e motivos da ocorrência) seguido de
+um segmento U (valores pagos e datas). No CNAB 400, cada título tem um registro de
+detalhe (tipo 1), no layout de retorno do Bradesco, adotado por outros bancos.
+*/
+
+package cnab
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/oracle"
+)
+
+// Formatos de arquivo
+const (
+	Formato240 = "240"
+	Formato400 = "400"
+)
+
+// Tipos de ocorrência do retorno
+const (
+	OcorrenciaLiquidacao = "liquidacao" // título pago (06 e 17; no CNAB 400 também 15)
+	OcorrenciaRejeicao   = "rejeicao"   // entrada rejeitada (03)
+	OcorrenciaTarifa     = "tarifa"     // débito de tarifas ou custas (28)
+	OcorrenciaOutra      = "outra"      // entrada confirmada, baixa etc.
+)
+
+// Ocorrencia - ocorrência de um título no retorno. Os valores são em centavos e as datas
+// no formato AAAA-MM-DD.
+type Ocorrencia struct {
+	Linha       int    `json:"linha"` // linha do registro (no CNAB 240, do segmento T)
+	Codigo      string `json:"codigo"`
+	Tipo        string `json:"tipo"`
+	NossoNumero string `json:"nosso_numero"`
+	SeuNumero   string `json:"seu_numero,omitempty"` // ID da proposta enviado na remessa (truncado)
+	ValorTitulo int64  `json:"valor_titulo"`
+	ValorPago   int64  `json:"valor_pago"`
+	Encargos    int64  `json:"encargos"` // juros e multa incluídos no valor pago
+	Descontos   int64  `json:"descontos"`
+	Tarifa      int64  `json:"tarifa"`
+	Data        string `json:"data_ocorrencia,omitempty"`
+	DataCredito string `json:"data_credito,omitempty"`
+	Motivos     string `json:"motivos,omitempty"` // códigos dos motivos da rejeição
+}
+
+// Principal: valor pago sem os encargos e com os descontos e abatimentos, comparado
+// ao valor do boleto
+func (o Ocorrencia) Principal() int64 {
+	return o.ValorPago - o.Encargos + o.Descontos
+}
+
+// Retorno - arquivo de retorno lido
+type Retorno struct {
+	Formato     string       `json:"formato"`
+	Banco       string       `json:"banco"`
+	Ocorrencias []Ocorrencia `json:"ocorrencias"`
+}
+
+// LerRetorno: ocorrências dos títulos do arquivo de retorno CNAB 240 ou CNAB 400
+func LerRetorno(conteudo []byte) (Retorno, error) {
+	var linhas []string
+	for _, l := range bytes.Split(conteudo, []byte("\n")) {
+		linhas = append(linhas, strings.TrimRight(string(l), "\r"))
+	}
+	for len(linhas) > 0 && strings.TrimSpace(linhas[len(linhas)-1]) == "" {
+		linhas = linhas[:len(linhas)-1]
+	}
+	if len(linhas) == 0 {
+		return Retorno{}, fmt.Errorf("Arquivo de retorno vazio")
+	}
+
+	switch len(linhas[0]) {
+	case 240:
+		return lerRetorno240(linhas)
+	case 400:
+		return lerRetorno400(linhas)
+	}
+	return Retorno{}, fmt.Errorf("Formato de retorno desconhecido: registros com %d posições", len(linhas[0]))
+}
+
+// campo: posições de inicio a fim (a partir de 1, inclusive), sem os brancos das pontas
+func campo(registro string, inicio, fim int) string {
+	return strings.TrimSpace(registro[inicio-1 : fim])
+}
+
+// centavos: campo numérico com duas casas decimais
+func centavos(registro string, inicio, fim, linha int) (int64, error) {
+	s := campo(registro, inicio, fim)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Linha %d: valor inválido nas posições %d-%d: %q", linha, inicio, fim, s)
+	}
+	return v, nil
+}
+
+// data: campo DDMMAAAA ou DDMMAA convertido para AAAA-MM-DD (vazio para zeros ou brancos)
+func data(registro string, inicio, fim, linha int) (string, error) {
+	s := campo(registro, inicio, fim)
+	if strings.Trim(s, "0") == "" {
+		return "", nil
+	}
+	layout := "02012006"
+	if len(s) == 6 {
+		layout = "020106"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return "", fmt.Errorf("Linha %d: data inválida nas posições %d-%d: %q", linha, inicio, fim, s)
+	}
+	return t.Format(oracle.LayoutData), nil
+}
+
+// tipoOcorrencia: tipo do código de ocorrência (movimento) do retorno
+func tipoOcorrencia(formato, codigo string) string {
+	switch codigo {
+	case "06", "17":
+		return OcorrenciaLiquidacao
+	case "15":
+		if formato == Formato400 {
+			return OcorrenciaLiquidacao // liquidação em cartório
+		}
+	case "03":
+		return OcorrenciaRejeicao
+	case "28":
+		return OcorrenciaTarifa
+	}
+	return OcorrenciaOutra
+}
+
+// lerRetorno240: segmentos T e U do lote de cobrança
+func lerRetorno240(linhas []string) (Retorno, error) {
+	ret := Retorno{Formato: Formato240, Banco: linhas[0][:3]}
+	var atual *Ocorrencia
+	for i, r := range linhas {
+		n := i + 1
+		if len(r) != 240 {
+			return ret, fmt.Errorf("Linha %d com %d posições (esperadas 240)", n, len(r))
+		}
+		if r[7] != '3' {
+			continue
+		}
+		var err error
+		switch r[13] {
+		case 'T':
+			o := Ocorrencia{Linha: n, Codigo: campo(r, 16, 17), NossoNumero: campo(r, 38, 57), SeuNumero: campo(r, 59, 73), Motivos: campo(r, 214, 223)}
+			o.Tipo = tipoOcorrencia(Formato240, o.Codigo)
+			if o.ValorTitulo, err = centavos(r, 82, 96, n); err != nil {
+				return ret, err
+			}
+			if o.Tarifa, err = centavos(r, 199, 213, n); err != nil {
+				return ret, err
+			}
+			ret.Ocorrencias = append(ret.Ocorrencias, o)
+			atual = &ret.Ocorrencias[len(ret.Ocorrencias)-1]
+		case 'U':
+			if atual == nil {
+				return ret, fmt.Errorf("Linha %d: segmento U sem o segmento T correspondente", n)
+			}
+			var desconto, abatimento int64
+			if atual.Encargos, err = centavos(r, 18, 32, n); err != nil {
+				return ret, err
+			}
+			if desconto, err = centavos(r, 33, 47, n); err != nil {
+				return ret, err
+			}
+			if abatimento, err = centavos(r, 48, 62, n); err != nil {
+				return ret, err
+			}
+			atual.Descontos = desconto + abatimento
+			if atual.ValorPago, err = centavos(r, 78, 92, n); err != nil {
+				return ret, err
+			}
+			if atual.Data, err = data(r, 138, 145, n); err != nil {
+				return ret, err
+			}
+			if atual.DataCredito, err = data(r, 146, 153, n); err != nil {
+				return ret, err
+			}
+			atual = nil
+		}
+	}
+	return ret, nil
+}
+
+// lerRetorno400: registros de detalhe (tipo 1)
+func lerRetorno400(linhas []string) (Retorno, error) {
+	if !strings.HasPrefix(linhas[0], "02RETORNO") {
+		return Retorno{}, fmt.Errorf("Header do retorno CNAB 400 inválido")
+	}
+	ret := Retorno{Formato: Formato400, Banco: linhas[0][76:79]}
+	for i, r := range linhas {
+		n := i + 1
+		if len(r) != 400 {
+			return ret, fmt.Errorf("Linha %d com %d posições (esperadas 400)", n, len(r))
+		}
+		if r[0] != '1' {
+			continue
+		}
+		o := Ocorrencia{Linha: n, Codigo: campo(r, 109, 110), NossoNumero: campo(r, 71, 82), SeuNumero: campo(r, 117, 126), Motivos: campo(r, 319, 328)}
+		o.Tipo = tipoOcorrencia(Formato400, o.Codigo)
+		var err error
+		var juros, outrosCreditos, desconto, abatimento int64
+		valores := []struct {
+			destino     *int64
+			inicio, fim int
+		}{
+			{&o.ValorTitulo, 153, 165}, {&o.Tarifa, 176, 188}, {&abatimento, 228, 240}, {&desconto, 241, 253},
+			{&o.ValorPago, 254, 266}, {&juros, 267, 279}, {&outrosCreditos, 280, 292},
+		}
+		for _, v := range valores {
+			if *v.destino, err = centavos(r, v.inicio, v.fim, n); err != nil {
+				return ret, err
+			}
+		}
+		o.Encargos = juros + outrosCreditos
+		o.Descontos = desconto + abatimento
+		if o.Data, err = data(r, 111, 116, n); err != nil {
+			return ret, err
+		}
+		if o.DataCredito, err = data(r, 296, 301, n); err != nil {
+			return ret, err
+		}
+		ret.Ocorrencias = append(ret.Ocorrencias, o)
+	}
+	return ret, nil
+}
@@ -0,0 +1,204 @@
+/*
+Descrição: modelo de respostas e erros das funções do chaincode de propostas
+O chaincode só pode retornar uma mensagem de texto como erro; a mensagem é, portanto,
+o JSON do envelope de erro, com um código estável, os parâmetros e a mensagem no idioma
+configurado. Os clientes (gateway, gRPC, dojoctl) decodificam o envelope e podem
+traduzir a mensagem para o idioma do chamador a partir do código e dos parâmetros.
+*/
+
+// Package envelope define os códigos de erro, as mensagens em pt-BR e en e as
+// respostas JSON das funções do chaincode de propostas.
+package envelope
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Codigo - código estável do erro, utilizado pelos clientes no lugar da mensagem
+type Codigo string
+
+// Códigos de erro
+const (
+	// Argumentos e documentos
+	ArgumentosInvalidos Codigo = "ARGUMENTOS_INVALIDOS" // quantidade de argumentos incorreta
+	ArgumentoInvalido   Codigo = "ARGUMENTO_INVALIDO"   // valor de um argumento inválido
+	CampoObrigatorio    Codigo = "CAMPO_OBRIGATORIO"    // argumento obrigatório vazio
+	DocumentoInvalido   Codigo = "DOCUMENTO_INVALIDO"   // documento JSON fora do esquema da função
+	AtestadoInvalido    Codigo = "ATESTADO_INVALIDO"    // atestado do oráculo mal formado
+
+	// Permissões e funções
+	NaoAutorizado        Codigo = "NAO_AUTORIZADO"
+	FuncaoDesconhecida   Codigo = "FUNCAO_DESCONHECIDA"
+	FuncaoIndisponivel   Codigo = "FUNCAO_INDISPONIVEL"
+	ConfiguracaoInvalida Codigo = "CONFIGURACAO_INVALIDA"
+	EsquemaIncompativel  Codigo = "ESQUEMA_INCOMPATIVEL" // estado gravado por uma versão mais recente do chaincode
+
+	// Requisições idempotentes
+	IDRequisicaoReutilizado Codigo = "ID_REQUISICAO_REUTILIZADO" // ID já utilizado com outra função ou outros argumentos
+
+	// Lotes de operações
+	LoteExcedido  Codigo = "LOTE_EXCEDIDO"  // quantidade de operações acima do máximo configurado
+	LoteRejeitado Codigo = "LOTE_REJEITADO" // uma das operações falhou (ver Causa)
+
+	// Estado da proposta
+	PropostaNaoEncontrada Codigo = "PROPOSTA_NAO_ENCONTRADA"
+	PropostaJaPaga        Codigo = "PROPOSTA_JA_PAGA"
+	PropostaCancelada     Codigo = "PROPOSTA_CANCELADA"
+	PropostaNaoAceita     Codigo = "PROPOSTA_NAO_ACEITA"
+	AceiteJaRegistrado    Codigo = "ACEITE_JA_REGISTRADO"
+	BoletoJaEmitido       Codigo = "BOLETO_JA_EMITIDO"
+
+	// Conciliação com os extratos bancários
+	ConciliacaoNaoEncontrada Codigo = "CONCILIACAO_NAO_ENCONTRADA"
+	ConciliacaoJaRegistrada  Codigo = "CONCILIACAO_JA_REGISTRADA"
+
+	// Pagamento
+	PixNaoConfigurado        Codigo = "PIX_NAO_CONFIGURADO"         // configuração sem a conta PIX do recebedor
+	CobrancaPixNaoRegistrada Codigo = "COBRANCA_PIX_NAO_REGISTRADA" // proposta sem registrarCobrancaPix
+	CobrancaPixJaRegistrada  Codigo = "COBRANCA_PIX_JA_REGISTRADA"  // registrarCobrancaPix repetido
+	OraculoNaoRegistrado     Codigo = "ORACULO_NAO_REGISTRADO"
+	AssinaturaInvalida       Codigo = "ASSINATURA_INVALIDA"
+	AtestadoDivergente       Codigo = "ATESTADO_DIVERGENTE"
+
+	// Falhas de execução
+	NotificacaoFalhou Codigo = "NOTIFICACAO_FALHOU"
+	ErroInterno       Codigo = "ERRO_INTERNO"
+
+	// Pontos de entrada fora da blockchain (gateway REST, gRPC)
+	RequisicaoInvalida Codigo = "REQUISICAO_INVALIDA"
+	RotaNaoEncontrada  Codigo = "ROTA_NAO_ENCONTRADA"
+	MetodoNaoSuportado Codigo = "METODO_NAO_SUPORTADO"
+	LedgerIndisponivel Codigo = "LEDGER_INDISPONIVEL" // falha de comunicação com o peer
+)
+
+// Erro - envelope de erro das funções do chaincode
+type Erro struct {
+	Codigo     Codigo            `json:"codigo"`
+	Mensagem   string            `json:"mensagem"`
+	Parametros map[string]string `json:"parametros,omitempty"`
+	Campos     []Campo           `json:"campos,omitempty"` // violações do esquema (DOCUMENTO_INVALIDO)
+	Causa      *Erro             `json:"causa,omitempty"`  // erro da operação que rejeitou o lote (LOTE_REJEITADO)
+}
+
+// Campo - violação do esquema em um campo do documento
+type Campo struct {
+	Caminho    string            `json:"caminho"` // JSON Pointer do campo ("/valor"); "/" para o documento
+	Regra      string            `json:"regra"`   // palavra-chave do JSON Schema violada (type, required...)
+	Parametros map[string]string `json:"parametros,omitempty"`
+	Mensagem   string            `json:"mensagem"`
+}
+
+// Novo: cria o erro com a mensagem no idioma padrão. Os parâmetros são informados em
+// pares (nome, valor) e substituem {nome} na mensagem.
+func Novo(codigo Codigo, parametros ...string) *Erro {
+	e := &Erro{Codigo: codigo}
+	if len(parametros) > 0 {
+		e.Parametros = make(map[string]string)
+		for i := 0; i+1 < len(parametros); i += 2 {
+			e.Parametros[parametros[i]] = parametros[i+1]
+		}
+	}
+	e.Mensagem = Mensagem(IdiomaPadrao, codigo, e.Parametros)
+	return e
+}
+
+// Interno: erro inesperado (falha do stub, de serialização...), com o detalhe original
+func Interno(err error) *Erro {
+	if e, ok := err.(*Erro); ok {
+		return e
+	}
+	return Novo(ErroInterno, "detalhe", err.Error())
+}
+
+// Error: JSON do envelope, retornado pelo chaincode como mensagem de erro
+func (e *Erro) Error() string {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return string(e.Codigo) + ": " + e.Mensagem
+	}
+	return string(b)
+}
+
+// Traduzir: cópia do erro com a mensagem (e as mensagens dos campos) no idioma informado
+func (e *Erro) Traduzir(idioma string) *Erro {
+	t := *e
+	t.Mensagem = Mensagem(idioma, e.Codigo, e.Parametros)
+	if len(e.Campos) > 0 {
+		t.Campos = make([]Campo, len(e.Campos))
+		for i, c := range e.Campos {
+			c.Mensagem = MensagemCampo(idioma, c.Regra, c.Parametros)
+			t.Campos[i] = c
+		}
+		if e.Codigo == DocumentoInvalido {
+			t.Parametros = copiarParametros(e.Parametros)
+			t.Parametros["detalhes"] = detalhesCampos(t.Campos)
+			t.Mensagem = Mensagem(idioma, e.Codigo, t.Parametros)
+		}
+	}
+	if e.Causa != nil {
+		t.Causa = e.Causa.Traduzir(idioma)
+		t.Parametros = copiarParametros(e.Parametros)
+		t.Parametros["detalhe"] = t.Causa.Mensagem
+		t.Mensagem = Mensagem(idioma, e.Codigo, t.Parametros)
+	}
+	return &t
+}
+
+// Lote: erro LOTE_REJEITADO da operação do lote na posição indice (a partir de 0), com
+// o erro da operação como causa
+func Lote(indice int, funcao string, causa error) *Erro {
+	c := Interno(causa)
+	e := Novo(LoteRejeitado, "indice", strconv.Itoa(indice), "funcao", funcao, "detalhe", c.Mensagem)
+	e.Causa = c
+	return e
+}
+
+// Documento: erro DOCUMENTO_INVALIDO com as violações de cada campo
+func Documento(funcao string, campos []Campo) *Erro {
+	for i := range campos {
+		campos[i].Mensagem = MensagemCampo(IdiomaPadrao, campos[i].Regra, campos[i].Parametros)
+	}
+	e := Novo(DocumentoInvalido, "funcao", funcao, "detalhes", detalhesCampos(campos))
+	e.Campos = campos
+	return e
+}
+
+func detalhesCampos(campos []Campo) string {
+	textos := make([]string, len(campos))
+	for i, c := range campos {
+		textos[i] = c.Caminho + ": " + c.Mensagem
+	}
+	return strings.Join(textos, "; ")
+}
+
+func copiarParametros(p map[string]string) map[string]string {
+	c := make(map[string]string, len(p))
+	for k, v := range p {
+		c[k] = v
+	}
+	return c
+}
+
+// Decodificar: obtém o envelope de um erro retornado pelo chaincode. O peer pode
+// acrescentar um prefixo à mensagem, ignorado aqui. Retorna false para erros que
+// não são envelopes (chaincode anterior ao envelope, falhas de comunicação).
+func Decodificar(err error) (*Erro, bool) {
+	if err == nil {
+		return nil, false
+	}
+	if e, ok := err.(*Erro); ok {
+		return e, true
+	}
+	mensagem := err.Error()
+	inicio := strings.Index(mensagem, "{")
+	if inicio < 0 {
+		return nil, false
+	}
+	var e Erro
+	if json.Unmarshal([]byte(mensagem[inicio:]), &e) != nil || e.Codigo == "" {
+		return nil, false
+	}
+	return &e, true
+}
@@ -0,0 +1,243 @@
+package envelope
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// codigosDeclarados: valores das constantes do tipo Codigo declaradas em envelope.go
+func codigosDeclarados(t *testing.T) []Codigo {
+	t.Helper()
+	arquivo, err := parser.ParseFile(token.NewFileSet(), "envelope.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codigos []Codigo
+	ast.Inspect(arquivo, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || spec.Type == nil || len(spec.Values) == 0 {
+			return true
+		}
+		if tipo, ok := spec.Type.(*ast.Ident); !ok || tipo.Name != "Codigo" {
+			return true
+		}
+		valor, err := strconv.Unquote(spec.Values[0].(*ast.BasicLit).Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		codigos = append(codigos, Codigo(valor))
+		return true
+	})
+	return codigos
+}
+
+var parametroModelo = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// parametrosModelo: {nome} de cada parâmetro do modelo, em ordem alfabética
+func parametrosModelo(modelo string) []string {
+	p := parametroModelo.FindAllString(modelo, -1)
+	sort.Strings(p)
+	return p
+}
+
+func TestMensagensDosCodigos(t *testing.T) {
+	codigos := codigosDeclarados(t)
+	if len(codigos) < 30 {
+		t.Fatalf("%d códigos declarados", len(codigos))
+	}
+	for _, c := range codigos {
+		modelos, ok := mensagens[c]
+		if !ok {
+			t.Fatalf("%s sem mensagem", c)
+		}
+		pt, en := modelos[IdiomaPortugues], modelos[IdiomaIngles]
+		if pt == "" || en == "" || len(modelos) != 2 {
+			t.Fatalf("%s: mensagens %q", c, modelos)
+		}
+		if !reflect.DeepEqual(parametrosModelo(pt), parametrosModelo(en)) {
+			t.Fatalf("%s: parâmetros %v em pt-BR e %v em en", c, parametrosModelo(pt), parametrosModelo(en))
+		}
+	}
+	if len(mensagens) != len(codigos) {
+		t.Fatalf("%d mensagens para %d códigos", len(mensagens), len(codigos))
+	}
+	for regra, modelos := range mensagensCampo {
+		if !reflect.DeepEqual(parametrosModelo(modelos[IdiomaPortugues]), parametrosModelo(modelos[IdiomaIngles])) {
+			t.Fatalf("regra %s: parâmetros diferentes entre os idiomas", regra)
+		}
+	}
+}
+
+func TestNovo(t *testing.T) {
+	casos := []struct {
+		nome       string
+		codigo     Codigo
+		parametros []string
+		mensagem   string
+	}{
+		{"parâmetro", PropostaNaoEncontrada, []string{"id", "p9"}, "Proposta [p9] não existente."},
+		{"sem parâmetros", PropostaNaoEncontrada, nil, "Proposta [{id}] não existente."},
+		{"parâmetro sem valor", PropostaNaoEncontrada, []string{"id", "p9", "extra"}, "Proposta [p9] não existente."},
+		{"código sem mensagem", Codigo("DESCONHECIDO"), nil, "DESCONHECIDO"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			e := Novo(c.codigo, c.parametros...)
+			if e.Codigo != c.codigo || e.Mensagem != c.mensagem {
+				t.Fatalf("erro %s %q, esperado %s %q", e.Codigo, e.Mensagem, c.codigo, c.mensagem)
+			}
+			if _, existe := e.Parametros["extra"]; existe {
+				t.Fatal("parâmetro sem valor registrado")
+			}
+		})
+	}
+
+	if e := Novo(PropostaNaoEncontrada); e.Parametros != nil || e.Error() != `{"codigo":"PROPOSTA_NAO_ENCONTRADA","mensagem":"Proposta [{id}] não existente."}` {
+		t.Fatalf("JSON %s", e.Error())
+	}
+}
+
+func TestInterno(t *testing.T) {
+	original := Novo(PropostaJaPaga, "id", "p1")
+	if e := Interno(original); e != original {
+		t.Fatalf("envelope substituído: %v", e)
+	}
+	e := Interno(errors.New("falha do stub"))
+	if e.Codigo != ErroInterno || e.Parametros["detalhe"] != "falha do stub" {
+		t.Fatalf("erro %v", e)
+	}
+}
+
+func TestTraduzir(t *testing.T) {
+	campos := []Campo{
+		{Caminho: "/valor", Regra: "type", Parametros: map[string]string{"esperado": "integer", "recebido": "string"}},
+		{Caminho: "/id_proposta", Regra: "required"},
+	}
+	casos := []struct {
+		nome     string
+		erro     *Erro
+		idioma   string
+		mensagem string
+		causa    string // mensagem da causa traduzida
+		campos   []string
+	}{
+		{"código", Novo(PropostaNaoEncontrada, "id", "p9"), IdiomaIngles, "Proposal [p9] not found.", "", nil},
+		{"idioma sem tradução", Novo(PropostaNaoEncontrada, "id", "p9"), "es", "Proposta [p9] não existente.", "", nil},
+		{"documento", Documento("registrarProposta", campos), IdiomaIngles,
+			"Invalid document for registrarProposta: /valor: expected integer, got string; /id_proposta: required", "",
+			[]string{"expected integer, got string", "required"}},
+		{"lote", Lote(2, "aceitarProposta", Novo(PropostaNaoEncontrada, "id", "p9")), IdiomaIngles,
+			"Batch rejected at operation 2 (aceitarProposta); no operation was applied: Proposal [p9] not found.",
+			"Proposal [p9] not found.", nil},
+		{"lote com erro interno", Lote(0, "emitirBoleto", errors.New("falha do stub")), IdiomaIngles,
+			"Batch rejected at operation 0 (emitirBoleto); no operation was applied: Internal error: falha do stub",
+			"Internal error: falha do stub", nil},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			antes := c.erro.Error()
+			tr := c.erro.Traduzir(c.idioma)
+			if tr.Mensagem != c.mensagem {
+				t.Fatalf("mensagem %q, esperada %q", tr.Mensagem, c.mensagem)
+			}
+			if c.causa != "" && (tr.Causa == nil || tr.Causa.Mensagem != c.causa) {
+				t.Fatalf("causa %+v, esperada %q", tr.Causa, c.causa)
+			}
+			for i, m := range c.campos {
+				if tr.Campos[i].Mensagem != m {
+					t.Fatalf("campo %s: %q, esperado %q", tr.Campos[i].Caminho, tr.Campos[i].Mensagem, m)
+				}
+			}
+			// a tradução é uma cópia: o erro original é mantido no idioma padrão
+			if c.erro.Error() != antes {
+				t.Fatalf("erro original alterado: %s", c.erro.Error())
+			}
+			// e volta ao original ao ser traduzido para o idioma padrão
+			if volta := tr.Traduzir(IdiomaPadrao); volta.Error() != antes {
+				t.Fatalf("tradução de volta %s, esperado %s", volta.Error(), antes)
+			}
+		})
+	}
+}
+
+func TestDecodificar(t *testing.T) {
+	lote := Lote(1, "aceitarProposta", Novo(AceiteJaRegistrado, "id", "p1", "parte", "pagador"))
+	casos := []struct {
+		nome   string
+		erro   error
+		codigo Codigo // "" se o erro não for um envelope
+	}{
+		{"envelope", Novo(PropostaJaPaga, "id", "p1"), PropostaJaPaga},
+		{"mensagem do chaincode", errors.New(Novo(PropostaJaPaga, "id", "p1").Error()), PropostaJaPaga},
+		{"prefixo do peer", errors.New("Error executing chaincode: " + lote.Error()), LoteRejeitado},
+		{"texto", errors.New("Failed getting metadata"), ""},
+		{"JSON sem código", errors.New(`falha: {"mensagem": "x"}`), ""},
+		{"JSON inválido", errors.New(`falha: {"codigo": `), ""},
+		{"nil", nil, ""},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			e, ok := Decodificar(c.erro)
+			if ok != (c.codigo != "") {
+				t.Fatalf("envelope = %v, %v", e, ok)
+			}
+			if ok && e.Codigo != c.codigo {
+				t.Fatalf("código %s, esperado %s", e.Codigo, c.codigo)
+			}
+		})
+	}
+
+	e, _ := Decodificar(errors.New("Error: " + lote.Error()))
+	if !reflect.DeepEqual(e, lote) {
+		t.Fatalf("envelope decodificado %+v, esperado %+v", e, lote)
+	}
+}
+
+func TestIdioma(t *testing.T) {
+	casos := []struct {
+		aceitos string
+		idioma  string
+	}{
+		{"", IdiomaPadrao},
+		{"en", IdiomaIngles},
+		{"en-US,en;q=0.9", IdiomaIngles},
+		{"EN-GB", IdiomaIngles},
+		{"pt-PT", IdiomaPortugues},
+		{"fr-FR, en;q=0.8, pt;q=0.5", IdiomaIngles},
+		{"fr, de", IdiomaPadrao},
+	}
+	for _, c := range casos {
+		if got := Idioma(c.aceitos); got != c.idioma {
+			t.Fatalf("Idioma(%q) = %s, esperado %s", c.aceitos, got, c.idioma)
+		}
+	}
+	for idioma, suportado := range map[string]bool{IdiomaPortugues: true, IdiomaIngles: true, "pt": false, "es": false} {
+		if IdiomaSuportado(idioma) != suportado {
+			t.Fatalf("IdiomaSuportado(%q) = %v", idioma, !suportado)
+		}
+	}
+}
+
+func TestResposta(t *testing.T) {
+	r := Resposta{Operacao: OperacaoAceita, IDProposta: "p1", Status: "aceita"}
+	b, err := r.Codificar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"operacao":"aceita","id_proposta":"p1","status":"aceita"}` {
+		t.Fatalf("JSON %s", b)
+	}
+	if d, err := DecodificarResposta(b); err != nil || d != r {
+		t.Fatalf("resposta %+v, %v", d, err)
+	}
+	if _, err := DecodificarResposta([]byte("ok")); err == nil || err.(*Erro).Codigo != ErroInterno {
+		t.Fatalf("erro = %v, esperado %s", err, ErroInterno)
+	}
+}
@@ -0,0 +1,252 @@
+package envelope
+
+import (
+	"strings"
+)
+
+// Idiomas das mensagens
+const (
+	IdiomaPortugues = "pt-BR"
+	IdiomaIngles    = "en"
+	IdiomaPadrao    = IdiomaPortugues
+)
+
+// mensagens por código e idioma; {nome} é substituído pelo parâmetro de mesmo nome
+var mensagens = map[Codigo]map[string]string{
+	ArgumentosInvalidos: {
+		IdiomaPortugues: "Quantidade de argumentos incorreta. Esperado {esperado}",
+		IdiomaIngles:    "Incorrect number of arguments. Expecting {esperado}",
+	},
+	ArgumentoInvalido: {
+		IdiomaPortugues: "Valor inválido para {campo} [{valor}]",
+		IdiomaIngles:    "Invalid value for {campo} [{valor}]",
+	},
+	CampoObrigatorio: {
+		IdiomaPortugues: "{campo} não informado",
+		IdiomaIngles:    "{campo} not provided",
+	},
+	DocumentoInvalido: {
+		IdiomaPortugues: "Documento inválido para {funcao}: {detalhes}",
+		IdiomaIngles:    "Invalid document for {funcao}: {detalhes}",
+	},
+	AtestadoInvalido: {
+		IdiomaPortugues: "Atestado inválido: {detalhe}",
+		IdiomaIngles:    "Invalid attestation: {detalhe}",
+	},
+	NaoAutorizado: {
+		IdiomaPortugues: "Chamador não autorizado a executar {funcao}",
+		IdiomaIngles:    "The caller is not authorized to call {funcao}",
+	},
+	FuncaoDesconhecida: {
+		IdiomaPortugues: "Função desconhecida: {funcao}",
+		IdiomaIngles:    "Unknown function: {funcao}",
+	},
+	FuncaoIndisponivel: {
+		IdiomaPortugues: "Função {funcao} indisponível com a tabela simples",
+		IdiomaIngles:    "Function {funcao} is not available with the simple table",
+	},
+	ConfiguracaoInvalida: {
+		IdiomaPortugues: "Configuração inválida: {detalhe}",
+		IdiomaIngles:    "Invalid configuration: {detalhe}",
+	},
+	EsquemaIncompativel: {
+		IdiomaPortugues: "Estado gravado com o esquema versão {encontrada}; esta versão do chaincode suporta até a versão {suportada}",
+		IdiomaIngles:    "State written with schema version {encontrada}; this chaincode version supports up to version {suportada}",
+	},
+	IDRequisicaoReutilizado: {
+		IdiomaPortugues: "ID de requisição [{id_requisicao}] já utilizado em {funcao} com outros argumentos",
+		IdiomaIngles:    "Request ID [{id_requisicao}] already used in {funcao} with different arguments",
+	},
+	LoteExcedido: {
+		IdiomaPortugues: "Lote com {quantidade} operações excede o máximo de {maximo}",
+		IdiomaIngles:    "Batch with {quantidade} operations exceeds the maximum of {maximo}",
+	},
+	LoteRejeitado: {
+		IdiomaPortugues: "Lote rejeitado na operação {indice} ({funcao}); nenhuma operação foi aplicada: {detalhe}",
+		IdiomaIngles:    "Batch rejected at operation {indice} ({funcao}); no operation was applied: {detalhe}",
+	},
+	PropostaNaoEncontrada: {
+		IdiomaPortugues: "Proposta [{id}] não existente.",
+		IdiomaIngles:    "Proposal [{id}] not found.",
+	},
+	PropostaJaPaga: {
+		IdiomaPortugues: "Boleto da Proposta [{id}] já está pago.",
+		IdiomaIngles:    "The boleto of proposal [{id}] is already paid.",
+	},
+	PropostaCancelada: {
+		IdiomaPortugues: "Proposta [{id}] cancelada.",
+		IdiomaIngles:    "Proposal [{id}] is cancelled.",
+	},
+	PropostaNaoAceita: {
+		IdiomaPortugues: "Proposta [{id}] ainda não foi aceita pelas duas partes.",
+		IdiomaIngles:    "Proposal [{id}] has not been accepted by both parties yet.",
+	},
+	AceiteJaRegistrado: {
+		IdiomaPortugues: "Parte {parte} já aceitou a Proposta [{id}].",
+		IdiomaIngles:    "Party {parte} has already accepted proposal [{id}].",
+	},
+	BoletoJaEmitido: {
+		IdiomaPortugues: "Proposta [{id}] já possui o boleto {nosso_numero}.",
+		IdiomaIngles:    "Proposal [{id}] already has bill {nosso_numero}.",
+	},
+	ConciliacaoNaoEncontrada: {
+		IdiomaPortugues: "Conciliação [{id_conciliacao}] não existente.",
+		IdiomaIngles:    "Reconciliation [{id_conciliacao}] not found.",
+	},
+	ConciliacaoJaRegistrada: {
+		IdiomaPortugues: "Conciliação [{id_conciliacao}] já registrada.",
+		IdiomaIngles:    "Reconciliation [{id_conciliacao}] already recorded.",
+	},
+	PixNaoConfigurado: {
+		IdiomaPortugues: "Cobrança PIX não configurada: informe a conta do recebedor (pix) na configuração do Init.",
+		IdiomaIngles:    "PIX charges are not configured: set the receiving account (pix) in the Init configuration.",
+	},
+	CobrancaPixNaoRegistrada: {
+		IdiomaPortugues: "Proposta [{id}] não possui cobrança PIX registrada.",
+		IdiomaIngles:    "Proposal [{id}] has no registered PIX charge.",
+	},
+	CobrancaPixJaRegistrada: {
+		IdiomaPortugues: "Proposta [{id}] já possui cobrança PIX registrada.",
+		IdiomaIngles:    "Proposal [{id}] already has a registered PIX charge.",
+	},
+	OraculoNaoRegistrado: {
+		IdiomaPortugues: "Banco [{banco}] não possui oráculo registrado.",
+		IdiomaIngles:    "Bank [{banco}] has no registered oracle.",
+	},
+	AssinaturaInvalida: {
+		IdiomaPortugues: "Assinatura inválida para o atestado do banco [{banco}]",
+		IdiomaIngles:    "Invalid signature for the attestation of bank [{banco}]",
+	},
+	AtestadoDivergente: {
+		IdiomaPortugues: "Atestado não corresponde à Proposta [{id}]: {campo} [{recebido}], esperado [{esperado}].",
+		IdiomaIngles:    "Attestation does not match proposal [{id}]: {campo} [{recebido}], expected [{esperado}].",
+	},
+	NotificacaoFalhou: {
+		IdiomaPortugues: "Falha ao notificar a API externa: {detalhe}",
+		IdiomaIngles:    "Failed to notify the external API: {detalhe}",
+	},
+	ErroInterno: {
+		IdiomaPortugues: "Erro interno: {detalhe}",
+		IdiomaIngles:    "Internal error: {detalhe}",
+	},
+	RequisicaoInvalida: {
+		IdiomaPortugues: "Requisição inválida: {detalhe}",
+		IdiomaIngles:    "Invalid request: {detalhe}",
+	},
+	RotaNaoEncontrada: {
+		IdiomaPortugues: "Rota não encontrada: {rota}",
+		IdiomaIngles:    "Route not found: {rota}",
+	},
+	MetodoNaoSuportado: {
+		IdiomaPortugues: "Método não suportado: {metodo}",
+		IdiomaIngles:    "Method not allowed: {metodo}",
+	},
+	LedgerIndisponivel: {
+		IdiomaPortugues: "Falha ao acessar o ledger: {detalhe}",
+		IdiomaIngles:    "Failed to reach the ledger: {detalhe}",
+	},
+}
+
+// mensagens das violações do esquema, por palavra-chave do JSON Schema
+var mensagensCampo = map[string]map[string]string{
+	"json": {
+		IdiomaPortugues: "JSON inválido: {detalhe}",
+		IdiomaIngles:    "invalid JSON: {detalhe}",
+	},
+	"type": {
+		IdiomaPortugues: "esperado {esperado}, recebido {recebido}",
+		IdiomaIngles:    "expected {esperado}, got {recebido}",
+	},
+	"required": {
+		IdiomaPortugues: "obrigatório",
+		IdiomaIngles:    "required",
+	},
+	"dependentRequired": {
+		IdiomaPortugues: "obrigatório quando {campo} é informado",
+		IdiomaIngles:    "required when {campo} is present",
+	},
+	"additionalProperties": {
+		IdiomaPortugues: "campo desconhecido",
+		IdiomaIngles:    "unknown field",
+	},
+	"enum": {
+		IdiomaPortugues: "valor {valor} não permitido, esperado um de {permitidos}",
+		IdiomaIngles:    "value {valor} not allowed, expected one of {permitidos}",
+	},
+	"minLength": {
+		IdiomaPortugues: "deve ter no mínimo {limite} caracteres",
+		IdiomaIngles:    "must have at least {limite} characters",
+	},
+	"maxLength": {
+		IdiomaPortugues: "deve ter no máximo {limite} caracteres",
+		IdiomaIngles:    "must have at most {limite} characters",
+	},
+	"pattern": {
+		IdiomaPortugues: "deve corresponder ao padrão {padrao}",
+		IdiomaIngles:    "must match the pattern {padrao}",
+	},
+	"minimum": {
+		IdiomaPortugues: "deve ser maior ou igual a {limite}",
+		IdiomaIngles:    "must be greater than or equal to {limite}",
+	},
+	"exclusiveMinimum": {
+		IdiomaPortugues: "deve ser maior que {limite}",
+		IdiomaIngles:    "must be greater than {limite}",
+	},
+	"minItems": {
+		IdiomaPortugues: "deve ter no mínimo {limite} itens",
+		IdiomaIngles:    "must have at least {limite} items",
+	},
+}
+
+// Mensagem: mensagem do código no idioma informado (ou no idioma padrão, se não houver tradução)
+func Mensagem(idioma string, codigo Codigo, parametros map[string]string) string {
+	modelos, ok := mensagens[codigo]
+	if !ok {
+		return string(codigo)
+	}
+	return preencher(modelo(modelos, idioma), parametros)
+}
+
+// MensagemCampo: mensagem da violação do esquema no idioma informado
+func MensagemCampo(idioma, regra string, parametros map[string]string) string {
+	modelos, ok := mensagensCampo[regra]
+	if !ok {
+		return regra
+	}
+	return preencher(modelo(modelos, idioma), parametros)
+}
+
+// Idioma: idioma suportado correspondente ao informado pelo chamador (ex.: cabeçalho
+// Accept-Language "en-US,en;q=0.9"), ou o idioma padrão
+func Idioma(aceitos string) string {
+	for _, parte := range strings.Split(aceitos, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(parte, ";", 2)[0]))
+		switch {
+		case strings.HasPrefix(tag, "pt"):
+			return IdiomaPortugues
+		case strings.HasPrefix(tag, "en"):
+			return IdiomaIngles
+		}
+	}
+	return IdiomaPadrao
+}
+
+// IdiomaSuportado: indica se há mensagens no idioma
+func IdiomaSuportado(idioma string) bool {
+	return idioma == IdiomaPortugues || idioma == IdiomaIngles
+}
+
+func modelo(modelos map[string]string, idioma string) string {
+	if m, ok := modelos[idioma]; ok {
+		return m
+	}
+	return modelos[IdiomaPadrao]
+}
+
+func preencher(modelo string, parametros map[string]string) string {
+	for nome, valor := range parametros {
+		modelo = strings.Replace(modelo, "{"+nome+"}", valor, -1)
+	}
+	return modelo
+}
@@ -0,0 +1,57 @@
+package envelope
+
+import (
+	"encoding/json"
+)
+
+// Operações concluídas pelas funções Invoke
+const (
+	OperacaoRegistrada    = "registrada"     // registrarProposta de uma nova proposta
+	OperacaoAtualizada    = "atualizada"     // registrarProposta de uma proposta existente
+	OperacaoAceita        = "aceita"         // aceitarProposta
+	OperacaoBoletoEmitido = "boleto_emitido" // emitirBoleto
+	OperacaoPaga          = "paga"           // confirmarPagamento
+	OperacaoCancelada     = "cancelada"      // cancelarProposta
+	OperacaoLoteExecutado = "lote_executado" // executarLote (ver RespostaLote)
+
+	OperacaoConciliacaoRegistrada = "conciliacao_registrada" // registrarConciliacao
+)
+
+// Resposta - resposta das funções Invoke: a operação concluída, a proposta afetada
+// e o status da proposta após a transação
+type Resposta struct {
+	Operacao   string `json:"operacao"`
+	IDProposta string `json:"id_proposta"`
+	Status     string `json:"status"`
+}
+
+// RespostaLote - resposta de executarLote: a resposta de cada operação, na ordem do lote
+type RespostaLote struct {
+	Operacao   string          `json:"operacao"`
+	Resultados []ResultadoLote `json:"resultados"`
+}
+
+// ResultadoLote - resposta de uma operação do lote
+type ResultadoLote struct {
+	Indice   int      `json:"indice"`
+	Funcao   string   `json:"funcao"`
+	Resposta Resposta `json:"resposta"`
+}
+
+// Codificar: JSON da resposta
+func (r Resposta) Codificar() ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, Interno(err)
+	}
+	return b, nil
+}
+
+// DecodificarResposta: converte a resposta de uma função Invoke
+func DecodificarResposta(payload []byte) (Resposta, error) {
+	var r Resposta
+	if err := json.Unmarshal(payload, &r); err != nil {
+		return r, Interno(err)
+	}
+	return r, nil
+}
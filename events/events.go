@@ -0,0 +1,154 @@
+/*
+Descrição: eventos emitidos pelo chaincode a cada mudança de estado de uma Proposta
+O nome do evento no chaincode (stub.SetEvent) é o Tipo e o payload é o Evento em JSON.
+Como o fabric v0.6 entrega apenas um evento por transação, cada evento traz todos os
+campos alterados pela transação em Alterados, e o lote de operações (executarLote) emite
+um único evento LoteExecutado com os eventos de cada operação em Itens.
+*/
+
+// Package events define os eventos publicados pelo chaincode de propostas, para
+// uso pelos sistemas que acompanham as propostas sem consultar o chaincode.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Versao é a versão atual do formato dos eventos
+// 1: eventos de uma proposta
+// 2: evento LoteExecutado, com os eventos das operações em Itens
+// Os eventos de uma proposta continuam gravados com a versão 1, para os consumidores
+// anteriores ao lote.
+const Versao = 2
+
+// Tipo - tipo do evento, também utilizado como nome do evento no chaincode
+type Tipo string
+
+// Tipos de evento
+const (
+	PropostaCriada      Tipo = "PropostaCriada"
+	PropostaAceita      Tipo = "PropostaAceita"
+	BoletoEmitido       Tipo = "BoletoEmitido"
+	PagamentoRegistrado Tipo = "PagamentoRegistrado"
+	PropostaCancelada   Tipo = "PropostaCancelada"
+	// PropostaAtualizada é emitido quando registrarProposta altera campos que
+	// não correspondem a nenhum dos eventos acima (ex.: CPF do pagador)
+	PropostaAtualizada Tipo = "PropostaAtualizada"
+	// LoteExecutado agrupa os eventos das operações de um lote, em ordem
+	LoteExecutado Tipo = "LoteExecutado"
+)
+
+// Tipos: todos os tipos de evento, na ordem do ciclo de vida da proposta
+var Tipos = []Tipo{PropostaCriada, PropostaAceita, BoletoEmitido, PagamentoRegistrado, PropostaCancelada, PropostaAtualizada, LoteExecutado}
+
+// Evento - payload JSON dos eventos do chaincode
+type Evento struct {
+	Versao     int    `json:"versao"`
+	Tipo       Tipo   `json:"tipo"`
+	TxID       string `json:"tx_id"`
+	Horario    string `json:"horario,omitempty"` // timestamp da transação (RFC 3339)
+	IDProposta string `json:"id_proposta"`
+	Alterados  Campos `json:"alterados"`
+	// Itens: eventos das operações de um LoteExecutado (sem IDProposta nem Alterados)
+	Itens []Evento `json:"itens,omitempty"`
+}
+
+// Eventos: eventos de uma proposta contidos no evento, na ordem em que foram emitidos:
+// os Itens de um LoteExecutado ou o próprio evento
+func (e Evento) Eventos() []Evento {
+	if e.Tipo == LoteExecutado {
+		return e.Itens
+	}
+	return []Evento{e}
+}
+
+// Formas de pagamento de uma proposta
+const (
+	FormaPagamentoBoleto = "boleto"
+	FormaPagamentoPix    = "pix"
+)
+
+// Campos - campos da proposta alterados pela transação.
+// Apenas os campos alterados são preenchidos.
+type Campos struct {
+	CpfPagador          *string `json:"cpf_pagador,omitempty"`
+	PagadorAceitou      *bool   `json:"pagador_aceitou,omitempty"`
+	BeneficiarioAceitou *bool   `json:"beneficiario_aceitou,omitempty"`
+	BoletoPago          *bool   `json:"boleto_pago,omitempty"`
+	NossoNumero         *string `json:"nosso_numero,omitempty"`
+	Valor               *int64  `json:"valor,omitempty"`
+	DataPagamento       *string `json:"data_pagamento,omitempty"`
+	Cancelada           *bool   `json:"cancelada,omitempty"`
+	DataVencimento      *string `json:"data_vencimento,omitempty"`
+	Beneficiario        *string `json:"beneficiario,omitempty"`
+	Status              *string `json:"status,omitempty"`
+	FormaPagamento      *string `json:"forma_pagamento,omitempty"` // FormaPagamentoBoleto ou FormaPagamentoPix
+	EndToEndID          *string `json:"end_to_end_id,omitempty"`   // ID fim a fim do pagamento PIX
+
+	// informações da transação que não são campos da proposta
+	CodigoBanco *string `json:"codigo_banco,omitempty"` // banco que confirmou o pagamento
+	Motivo      *string `json:"motivo,omitempty"`       // motivo do cancelamento
+}
+
+// Vazio: indica se nenhum campo foi alterado
+func (c Campos) Vazio() bool {
+	return c == Campos{}
+}
+
+// Codificar: converte o evento em JSON, preenchendo a versão (2 apenas no LoteExecutado)
+func Codificar(e Evento) ([]byte, error) {
+	e.Versao = 1
+	if e.Tipo == LoteExecutado {
+		e.Versao = Versao
+		itens := make([]Evento, len(e.Itens))
+		for i, item := range e.Itens {
+			item.Versao = 1
+			itens[i] = item
+		}
+		e.Itens = itens
+	}
+	return json.Marshal(e)
+}
+
+// Decodificar: converte o payload de um evento do chaincode, rejeitando versões
+// mais novas que a suportada por este pacote
+func Decodificar(payload []byte) (Evento, error) {
+	var e Evento
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return e, fmt.Errorf("Evento inválido: %s", err)
+	}
+	if e.Versao < 1 || e.Versao > Versao {
+		return e, fmt.Errorf("Versão de evento não suportada: %d", e.Versao)
+	}
+	if !TipoValido(e.Tipo) {
+		return e, fmt.Errorf("Tipo de evento desconhecido: %s", e.Tipo)
+	}
+	for _, item := range e.Itens {
+		if !TipoValido(item.Tipo) || item.Tipo == LoteExecutado {
+			return e, fmt.Errorf("Tipo de evento do lote inválido: %s", item.Tipo)
+		}
+	}
+	return e, nil
+}
+
+// TipoValido: indica se o tipo corresponde a um dos eventos do chaincode
+func TipoValido(t Tipo) bool {
+	for _, tipo := range Tipos {
+		if t == tipo {
+			return true
+		}
+	}
+	return false
+}
+
+// Funções auxiliares para preencher os campos alterados
+
+// String: ponteiro para s
+func String(s string) *string { return &s }
+
+// Bool: ponteiro para b
+func Bool(b bool) *bool { return &b }
+
+// Int64: ponteiro para i
+func Int64(i int64) *int64 { return &i }
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodificar(t *testing.T) {
+	casos := []struct {
+		nome    string
+		evento  Evento
+		payload string
+	}{
+		{"proposta", Evento{Tipo: PropostaAceita, TxID: "tx-1", IDProposta: "p1", Alterados: Campos{PagadorAceitou: Bool(true)}},
+			`{"versao":1,"tipo":"PropostaAceita","tx_id":"tx-1","id_proposta":"p1","alterados":{"pagador_aceitou":true}}`},
+		{"lote", Evento{Tipo: LoteExecutado, TxID: "tx-2", Itens: []Evento{{Tipo: PropostaCancelada, IDProposta: "p2", Alterados: Campos{Cancelada: Bool(true)}}}},
+			`{"versao":2,"tipo":"LoteExecutado","tx_id":"tx-2","id_proposta":"","alterados":{},"itens":[{"versao":1,"tipo":"PropostaCancelada","tx_id":"","id_proposta":"p2","alterados":{"cancelada":true}}]}`},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			b, err := Codificar(c.evento)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != c.payload {
+				t.Fatalf("payload %s, esperado %s", b, c.payload)
+			}
+			e, err := Decodificar(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.Tipo != c.evento.Tipo || len(e.Eventos()) != 1 {
+				t.Fatalf("evento decodificado %+v", e)
+			}
+		})
+	}
+}
+
+func TestDecodificarInvalido(t *testing.T) {
+	casos := []struct {
+		nome    string
+		payload string
+		erro    string
+	}{
+		{"JSON", `{"versao":`, "Evento inválido"},
+		{"versão futura", `{"versao":3,"tipo":"PropostaCriada"}`, "Versão de evento não suportada"},
+		{"sem versão", `{"tipo":"PropostaCriada"}`, "Versão de evento não suportada"},
+		{"tipo", `{"versao":1,"tipo":"PropostaExcluida"}`, "Tipo de evento desconhecido"},
+		{"lote dentro do lote", `{"versao":2,"tipo":"LoteExecutado","itens":[{"versao":2,"tipo":"LoteExecutado"}]}`, "Tipo de evento do lote inválido"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if _, err := Decodificar([]byte(c.payload)); err == nil || !strings.Contains(err.Error(), c.erro) {
+				t.Fatalf("erro = %v, esperado %q", err, c.erro)
+			}
+		})
+	}
+}
+
+func TestDerivarStatus(t *testing.T) {
+	casos := []struct {
+		pagador, beneficiario bool
+		nossoNumero           string
+		pago, cancelada       bool
+		status                string
+	}{
+		{false, false, "", false, false, StatusCriada},
+		{true, false, "", false, false, StatusCriada},
+		{true, true, "", false, false, StatusAceita},
+		{true, true, "0001", false, false, StatusBoletoEmitido},
+		{true, true, "0001", true, false, StatusPaga},
+		{true, true, "0001", false, true, StatusCancelada},
+		{true, true, "0001", true, true, StatusCancelada},
+	}
+	for _, c := range casos {
+		if s := DerivarStatus(c.pagador, c.beneficiario, c.nossoNumero, c.pago, c.cancelada); s != c.status {
+			t.Errorf("DerivarStatus%v = %s, esperado %s", c, s, c.status)
+		}
+	}
+}
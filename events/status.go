@@ -0,0 +1,27 @@
+package events
+
+// Status derivado dos campos da proposta
+const (
+	StatusCriada        = "criada"
+	StatusAceita        = "aceita"
+	StatusBoletoEmitido = "boleto_emitido"
+	StatusPaga          = "paga"
+	StatusCancelada     = "cancelada"
+)
+
+// DerivarStatus: calcula o status da proposta a partir dos seus campos
+// (cancelada > paga > boleto_emitido > aceita > criada)
+func DerivarStatus(pagadorAceitou, beneficiarioAceitou bool, nossoNumero string, boletoPago, cancelada bool) string {
+	switch {
+	case cancelada:
+		return StatusCancelada
+	case boletoPago:
+		return StatusPaga
+	case nossoNumero != "":
+		return StatusBoletoEmitido
+	case pagadorAceitou && beneficiarioAceitou:
+		return StatusAceita
+	default:
+		return StatusCriada
+	}
+}
@@ -0,0 +1,542 @@
+/*
+Descrição: gateway REST do chaincode de propostas
+Traduz as rotas HTTP para as funções do chaincode com argumentos posicionais e
+converte os erros do chaincode (ver pacote envelope) em status HTTP, com a mensagem
+no idioma do cabeçalho Accept-Language. A especificação OpenAPI
+das rotas é publicada em GET /openapi.json, e os esquemas dos documentos JSON aceitos
+pelas funções Invoke em GET /esquemas/{funcao}.json.
+*/
+
+// Package gateway implementa a API REST de propostas sobre um ledger.Ledger.
+package gateway
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/pix/qrcode"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// Gateway - handler HTTP da API de propostas
+type Gateway struct {
+	ledger ledger.Ledger
+}
+
+// Novo: cria o gateway sobre o ledger informado (peer real ou simulador)
+func Novo(l ledger.Ledger) *Gateway {
+	return &Gateway{ledger: l}
+}
+
+// NovaProposta - corpo de POST /propostas
+type NovaProposta struct {
+	ID                  string `json:"id_proposta"`
+	CpfPagador          string `json:"cpf_pagador"`
+	PagadorAceitou      bool   `json:"pagador_aceitou"`
+	BeneficiarioAceitou bool   `json:"beneficiario_aceitou"`
+	BoletoPago          bool   `json:"boleto_pago"`
+	NossoNumero         string `json:"nosso_numero,omitempty"`
+	Valor               int64  `json:"valor,omitempty"`
+}
+
+// Aceite - corpo de POST /propostas/{id}/aceite
+type Aceite struct {
+	Parte string `json:"parte"` // pagador ou beneficiario
+}
+
+// Boleto - corpo de POST /propostas/{id}/boleto
+type Boleto struct {
+	NossoNumero    string `json:"nosso_numero"`
+	Valor          int64  `json:"valor"`
+	DataVencimento string `json:"data_vencimento,omitempty"` // AAAA-MM-DD
+	Beneficiario   string `json:"beneficiario,omitempty"`    // CPF ou CNPJ
+}
+
+// Cancelamento - corpo de POST /propostas/{id}/cancelamento
+type Cancelamento struct {
+	Motivo string `json:"motivo"`
+}
+
+// NovaCobrancaPix - corpo de POST /propostas/{id}/pix
+type NovaCobrancaPix struct {
+	Valor int64 `json:"valor,omitempty"` // em centavos, conferido com o valor da proposta (opcional)
+}
+
+// CobrancaPix - resposta de registrarCobrancaPix e de gerarBRCode, retornada em
+// POST e GET /propostas/{id}/pix
+type CobrancaPix struct {
+	IDProposta string `json:"id_proposta"`
+	TxID       string `json:"txid"`
+	Valor      int64  `json:"valor"`
+	BRCode     string `json:"brcode"`
+}
+
+// Formatos da cobrança PIX em GET /propostas/{id}/pix
+const (
+	FormatoPixJSON = "json"
+	FormatoPixPNG  = "png"
+	FormatoPixSVG  = "svg"
+)
+
+// EscalaPixPadrao - pixels por módulo do QR Code da cobrança PIX
+const EscalaPixPadrao = 8
+
+// ServeHTTP - rotas da API:
+// POST /propostas                        -> registrarProposta
+// GET  /propostas/{id}                   -> consultarProposta
+// POST /propostas/{id}/aceite            -> aceitarProposta
+// POST /propostas/{id}/boleto            -> emitirBoleto
+// POST /propostas/{id}/pagamento         -> confirmarPagamento (corpo: atestado do oráculo)
+// POST /propostas/{id}/cancelamento      -> cancelarProposta
+// POST /propostas/{id}/pix               -> registrarCobrancaPix
+// GET  /propostas/{id}/pix               -> gerarBRCode (?formato=json|png|svg&escala=)
+// POST /propostas/lote                   -> executarLote (corpo: documento do lote)
+// GET  /relatorios/aging                 -> agingRecebiveis (?formato=json|csv&data=AAAA-MM-DD)
+// POST /conciliacoes                     -> registrarConciliacao (corpo: documento da conciliação)
+// GET  /conciliacoes/{id}                -> consultarConciliacao
+// GET  /relatorios/conciliacao           -> listarStatusConciliacao (?situacao=)
+// GET  /openapi.json                     -> especificação OpenAPI
+// GET  /esquemas/{funcao}.json           -> esquema JSON do documento aceito pela função
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	caminho := strings.Trim(r.URL.Path, "/")
+	partes := strings.Split(caminho, "/")
+
+	switch {
+	case caminho == "openapi.json" && r.Method == "GET":
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(EspecificacaoOpenAPI))
+	case len(partes) == 2 && partes[0] == "esquemas" && r.Method == "GET":
+		esquema, ok := validation.EsquemaJSON(strings.TrimSuffix(partes[1], ".json"))
+		if !ok {
+			responderErro(w, r, envelope.Novo(envelope.RotaNaoEncontrada, "rota", r.URL.Path))
+			return
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.Write(esquema)
+	case caminho == "propostas" && r.Method == "POST":
+		g.registrarProposta(w, r)
+	case caminho == "propostas/lote" && r.Method == "POST":
+		g.executarLote(w, r)
+	case caminho == "propostas/lote":
+		// não é a consulta de uma proposta com o id "lote"
+		w.Header().Set("Allow", "POST")
+		responderErro(w, r, envelope.Novo(envelope.MetodoNaoSuportado, "metodo", r.Method))
+	case caminho == "relatorios/aging" && r.Method == "GET":
+		g.agingRecebiveis(w, r)
+	case caminho == "conciliacoes" && r.Method == "POST":
+		g.registrarConciliacao(w, r)
+	case len(partes) == 2 && partes[0] == "conciliacoes" && r.Method == "GET":
+		g.query(w, r, "consultarConciliacao", []string{partes[1]})
+	case caminho == "relatorios/conciliacao" && r.Method == "GET":
+		g.query(w, r, "listarStatusConciliacao", []string{r.URL.Query().Get("situacao")})
+	case len(partes) == 2 && partes[0] == "propostas" && r.Method == "GET":
+		g.consultarProposta(w, r, partes[1])
+	case len(partes) == 3 && partes[0] == "propostas" && partes[2] == "pix" && r.Method == "GET":
+		g.cobrancaPix(w, r, partes[1])
+	case len(partes) == 3 && partes[0] == "propostas" && r.Method == "POST":
+		g.acao(w, r, partes[1], partes[2])
+	case caminho == "propostas" || (len(partes) >= 2 && len(partes) <= 3 && partes[0] == "propostas"):
+		responderErro(w, r, envelope.Novo(envelope.MetodoNaoSuportado, "metodo", r.Method))
+	default:
+		responderErro(w, r, envelope.Novo(envelope.RotaNaoEncontrada, "rota", r.URL.Path))
+	}
+}
+
+// registrarProposta: POST /propostas
+func (g *Gateway) registrarProposta(w http.ResponseWriter, r *http.Request) {
+	var p NovaProposta
+	if !decodificar(w, r, &p) {
+		return
+	}
+
+	args := []string{
+		p.ID,
+		p.CpfPagador,
+		strconv.FormatBool(p.PagadorAceitou),
+		strconv.FormatBool(p.BeneficiarioAceitou),
+		strconv.FormatBool(p.BoletoPago),
+	}
+	if p.NossoNumero != "" {
+		args = append(args, p.NossoNumero, strconv.FormatInt(p.Valor, 10))
+	}
+
+	res, ok := g.invoke(w, r, "registrarProposta", args)
+	if !ok {
+		return
+	}
+
+	// 201 para uma nova proposta, 200 para uma existente
+	status := http.StatusOK
+	if resposta, err := envelope.DecodificarResposta(res.Payload); err == nil && resposta.Operacao == envelope.OperacaoRegistrada {
+		status = http.StatusCreated
+	}
+	responderResultado(w, status, res)
+}
+
+// executarLote: POST /propostas/lote. O documento é repassado ao chaincode sem alterações
+// e validado pelo esquema de executarLote.
+func (g *Gateway) executarLote(w http.ResponseWriter, r *http.Request) {
+	lote, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responderErro(w, r, envelope.Novo(envelope.RequisicaoInvalida, "detalhe", err.Error()))
+		return
+	}
+	res, ok := g.invoke(w, r, "executarLote", []string{string(lote)})
+	if !ok {
+		return
+	}
+	responderResultado(w, http.StatusOK, res)
+}
+
+// registrarConciliacao: POST /conciliacoes. O documento é repassado ao chaincode sem
+// alterações e validado pelo esquema de registrarConciliacao.
+func (g *Gateway) registrarConciliacao(w http.ResponseWriter, r *http.Request) {
+	conciliacao, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responderErro(w, r, envelope.Novo(envelope.RequisicaoInvalida, "detalhe", err.Error()))
+		return
+	}
+	res, ok := g.invoke(w, r, "registrarConciliacao", []string{string(conciliacao)})
+	if !ok {
+		return
+	}
+	responderResultado(w, http.StatusCreated, res)
+}
+
+// query: executa a query com os argumentos validados e responde com o JSON retornado
+func (g *Gateway) query(w http.ResponseWriter, r *http.Request, funcao string, args []string) {
+	if err := validation.Validar(funcao, args); err != nil {
+		responderErro(w, r, ErroLedger(err))
+		return
+	}
+	payload, err := g.ledger.Query(funcao, args)
+	if err != nil {
+		responderErro(w, r, ErroLedger(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(payload)
+}
+
+// consultarProposta: GET /propostas/{id}
+func (g *Gateway) consultarProposta(w http.ResponseWriter, r *http.Request, id string) {
+	payload, err := g.ledger.Query("consultarProposta", []string{id})
+	if err != nil {
+		responderErro(w, r, ErroLedger(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(payload)
+}
+
+// agingRecebiveis: GET /relatorios/aging, em JSON ou, com formato=csv, em CSV
+func (g *Gateway) agingRecebiveis(w http.ResponseWriter, r *http.Request) {
+	formato := r.URL.Query().Get("formato")
+	args := []string{formato, r.URL.Query().Get("data")}
+	if err := validation.Validar("agingRecebiveis", args); err != nil {
+		responderErro(w, r, ErroLedger(err))
+		return
+	}
+	payload, err := g.ledger.Query("agingRecebiveis", args)
+	if err != nil {
+		responderErro(w, r, ErroLedger(err))
+		return
+	}
+	if formato == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Write(payload)
+}
+
+// cobrancaPix: GET /propostas/{id}/pix. Em JSON, a resposta de gerarBRCode; em PNG ou SVG,
+// o QR Code do BR Code, gerado pelo gateway (o chaincode não gera imagens)
+func (g *Gateway) cobrancaPix(w http.ResponseWriter, r *http.Request, id string) {
+	parametros := r.URL.Query()
+	formato := parametros.Get("formato")
+	if formato == "" {
+		formato = FormatoPixJSON
+	}
+	if formato != FormatoPixJSON && formato != FormatoPixPNG && formato != FormatoPixSVG {
+		responderErro(w, r, envelope.Novo(envelope.ArgumentoInvalido, "campo", "formato", "valor", formato))
+		return
+	}
+	escala := EscalaPixPadrao
+	if e := parametros.Get("escala"); e != "" {
+		n, err := strconv.Atoi(e)
+		if err != nil || n < 1 || n > 64 {
+			responderErro(w, r, envelope.Novo(envelope.ArgumentoInvalido, "campo", "escala", "valor", e))
+			return
+		}
+		escala = n
+	}
+
+	args := []string{id}
+	if err := validation.Validar("gerarBRCode", args); err != nil {
+		responderErro(w, r, ErroLedger(err))
+		return
+	}
+	payload, err := g.ledger.Query("gerarBRCode", args)
+	if err != nil {
+		responderErro(w, r, ErroLedger(err))
+		return
+	}
+	if formato == FormatoPixJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(payload)
+		return
+	}
+
+	var cobranca CobrancaPix
+	if err := json.Unmarshal(payload, &cobranca); err != nil {
+		responderErro(w, r, envelope.Interno(err))
+		return
+	}
+	codigo, err := qrcode.Codificar([]byte(cobranca.BRCode), qrcode.NivelPadrao)
+	if err != nil {
+		responderErro(w, r, envelope.Interno(err))
+		return
+	}
+	imagem, tipo := []byte(nil), "image/svg+xml"
+	if formato == FormatoPixPNG {
+		imagem, err = codigo.PNG(escala, qrcode.MargemPadrao)
+		tipo = "image/png"
+	} else {
+		imagem, err = codigo.SVG(escala, qrcode.MargemPadrao)
+	}
+	if err != nil {
+		responderErro(w, r, envelope.Interno(err))
+		return
+	}
+	w.Header().Set("Content-Type", tipo)
+	w.Header().Set("X-BR-Code", cobranca.BRCode)
+	w.Write(imagem)
+}
+
+// acao: POST /propostas/{id}/{aceite|boleto|pagamento|cancelamento|pix}
+func (g *Gateway) acao(w http.ResponseWriter, r *http.Request, id, acao string) {
+	var funcao string
+	var args []string
+
+	switch acao {
+	case "aceite":
+		var a Aceite
+		if !decodificar(w, r, &a) {
+			return
+		}
+		funcao, args = "aceitarProposta", []string{id, a.Parte}
+	case "boleto":
+		var b Boleto
+		if !decodificar(w, r, &b) {
+			return
+		}
+		funcao, args = "emitirBoleto", []string{id, b.NossoNumero, strconv.FormatInt(b.Valor, 10)}
+		if b.DataVencimento != "" || b.Beneficiario != "" {
+			args = append(args, b.DataVencimento)
+		}
+		if b.Beneficiario != "" {
+			args = append(args, b.Beneficiario)
+		}
+	case "pagamento":
+		// o atestado é repassado ao chaincode sem alterações, pois a assinatura é verificada sobre os seus campos
+		atestado, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			responderErro(w, r, envelope.Novo(envelope.RequisicaoInvalida, "detalhe", err.Error()))
+			return
+		}
+		funcao, args = "confirmarPagamento", []string{id, string(atestado)}
+	case "cancelamento":
+		var c Cancelamento
+		if !decodificar(w, r, &c) {
+			return
+		}
+		funcao, args = "cancelarProposta", []string{id, c.Motivo}
+	case "pix":
+		var c NovaCobrancaPix
+		if !decodificar(w, r, &c) {
+			return
+		}
+		funcao, args = "registrarCobrancaPix", []string{id}
+		if c.Valor != 0 {
+			args = append(args, strconv.FormatInt(c.Valor, 10))
+		}
+	default:
+		responderErro(w, r, envelope.Novo(envelope.RotaNaoEncontrada, "rota", r.URL.Path))
+		return
+	}
+
+	res, ok := g.invoke(w, r, funcao, args)
+	if !ok {
+		return
+	}
+	responderResultado(w, http.StatusOK, res)
+}
+
+// invoke: valida os argumentos com as mesmas regras do chaincode e executa a função,
+// respondendo com o erro correspondente em caso de falha. O cabeçalho Idempotency-Key
+// é repassado ao chaincode como o ID da requisição: as repetições retornam a resposta original.
+func (g *Gateway) invoke(w http.ResponseWriter, r *http.Request, funcao string, args []string) (ledger.Resultado, bool) {
+	if id := r.Header.Get("Idempotency-Key"); id != "" {
+		args = validation.ComIDRequisicao(id, args)
+	}
+	if err := validation.Validar(funcao, args); err != nil {
+		responderErro(w, r, ErroLedger(err))
+		return ledger.Resultado{}, false
+	}
+	res, err := g.ledger.Invoke(funcao, args)
+	if err != nil {
+		responderErro(w, r, ErroLedger(err))
+		return ledger.Resultado{}, false
+	}
+	return res, true
+}
+
+// decodificar: decodifica o corpo JSON da requisição, respondendo 400 em caso de erro
+func decodificar(w http.ResponseWriter, r *http.Request, destino interface{}) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(destino); err != nil {
+		responderErro(w, r, envelope.Novo(envelope.RequisicaoInvalida, "detalhe", "JSON inválido: "+err.Error()))
+		return false
+	}
+	return true
+}
+
+// responderResultado: responde com a resposta do chaincode ou, se a transação ainda
+// não foi confirmada (peer real), com 202 e o ID da transação
+func responderResultado(w http.ResponseWriter, status int, res ledger.Resultado) {
+	w.Header().Set("Content-Type", "application/json")
+	if res.TxID != "" {
+		w.Header().Set("X-Tx-Id", res.TxID)
+	}
+	if res.Pendente {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"tx_id": res.TxID})
+		return
+	}
+	w.WriteHeader(status)
+	if len(res.Payload) == 0 {
+		w.Write([]byte("{}"))
+		return
+	}
+	w.Write(res.Payload)
+}
+
+// responderErro: corpo JSON das respostas de erro ({"erro": envelope}), com o status
+// correspondente ao código e a mensagem no idioma do chamador
+func responderErro(w http.ResponseWriter, r *http.Request, e *envelope.Erro) {
+	if aceitos := r.Header.Get("Accept-Language"); aceitos != "" && e.Parametros != nil {
+		e = e.Traduzir(envelope.Idioma(aceitos))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusEnvelope(e))
+	json.NewEncoder(w).Encode(map[string]*envelope.Erro{"erro": e})
+}
+
+// status HTTP de cada código de erro
+var statusCodigos = map[envelope.Codigo]int{
+	envelope.ArgumentosInvalidos:      http.StatusBadRequest,
+	envelope.ArgumentoInvalido:        http.StatusBadRequest,
+	envelope.CampoObrigatorio:         http.StatusBadRequest,
+	envelope.DocumentoInvalido:        http.StatusBadRequest,
+	envelope.AtestadoInvalido:         http.StatusBadRequest,
+	envelope.ConfiguracaoInvalida:     http.StatusBadRequest,
+	envelope.EsquemaIncompativel:      http.StatusConflict,
+	envelope.IDRequisicaoReutilizado:  http.StatusConflict,
+	envelope.LoteExcedido:             http.StatusBadRequest,
+	envelope.LoteRejeitado:            http.StatusConflict,
+	envelope.RequisicaoInvalida:       http.StatusBadRequest,
+	envelope.NaoAutorizado:            http.StatusForbidden,
+	envelope.PropostaNaoEncontrada:    http.StatusNotFound,
+	envelope.CobrancaPixNaoRegistrada: http.StatusNotFound,
+	envelope.ConciliacaoNaoEncontrada: http.StatusNotFound,
+	envelope.ConciliacaoJaRegistrada:  http.StatusConflict,
+	envelope.RotaNaoEncontrada:        http.StatusNotFound,
+	envelope.MetodoNaoSuportado:       http.StatusMethodNotAllowed,
+	envelope.PropostaJaPaga:           http.StatusConflict,
+	envelope.PropostaCancelada:        http.StatusConflict,
+	envelope.PropostaNaoAceita:        http.StatusConflict,
+	envelope.AceiteJaRegistrado:       http.StatusConflict,
+	envelope.BoletoJaEmitido:          http.StatusConflict,
+	envelope.CobrancaPixJaRegistrada:  http.StatusConflict,
+	envelope.OraculoNaoRegistrado:     http.StatusUnprocessableEntity,
+	envelope.AssinaturaInvalida:       http.StatusUnprocessableEntity,
+	envelope.AtestadoDivergente:       http.StatusUnprocessableEntity,
+	envelope.FuncaoDesconhecida:       http.StatusNotImplemented,
+	envelope.FuncaoIndisponivel:       http.StatusNotImplemented,
+	envelope.PixNaoConfigurado:        http.StatusNotImplemented,
+	envelope.NotificacaoFalhou:        http.StatusBadGateway,
+	envelope.LedgerIndisponivel:       http.StatusBadGateway,
+}
+
+// statusEnvelope: status HTTP do erro; o lote rejeitado responde com o status do erro
+// da operação que o rejeitou
+func statusEnvelope(e *envelope.Erro) int {
+	if e.Codigo == envelope.LoteRejeitado && e.Causa != nil {
+		return statusEnvelope(e.Causa)
+	}
+	return StatusCodigo(e.Codigo)
+}
+
+// StatusCodigo: status HTTP correspondente ao código de erro (500 para ERRO_INTERNO)
+func StatusCodigo(codigo envelope.Codigo) int {
+	if status, ok := statusCodigos[codigo]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// códigos das mensagens de erro do chaincode anterior ao envelope,
+// verificados na ordem (mensagens mais específicas primeiro)
+var codigosMensagens = []struct {
+	trecho string
+	codigo envelope.Codigo
+}{
+	{"não existente", envelope.PropostaNaoEncontrada},
+	{"not an administrator", envelope.NaoAutorizado},
+	{"Certificado inválido", envelope.NaoAutorizado},
+	{"does not have the rights", envelope.NaoAutorizado},
+	{"Assinatura inválida", envelope.AssinaturaInvalida},
+	{"não possui oráculo", envelope.OraculoNaoRegistrado},
+	{"não corresponde", envelope.AtestadoDivergente},
+	{"diferente do valor", envelope.AtestadoDivergente},
+	{"já está pago", envelope.PropostaJaPaga},
+	{"já aceitou", envelope.AceiteJaRegistrado},
+	{"cancelada", envelope.PropostaCancelada},
+	{"ainda não foi aceita", envelope.PropostaNaoAceita},
+	{"função desconhecida", envelope.FuncaoDesconhecida},
+	{"Incorrect number of arguments", envelope.ArgumentosInvalidos},
+	{"Failed decod", envelope.ArgumentoInvalido},
+	{"inválid", envelope.ArgumentoInvalido},
+	{"não informado", envelope.CampoObrigatorio},
+}
+
+// ErroLedger: envelope do erro retornado pelo ledger ou pela validação. Erros de um
+// chaincode anterior ao envelope mantêm a mensagem original, com o código deduzido da
+// mensagem; erros que não vêm do chaincode (falha de comunicação com o peer) resultam
+// em LEDGER_INDISPONIVEL.
+func ErroLedger(err error) *envelope.Erro {
+	if e, ok := envelope.Decodificar(err); ok {
+		return e
+	}
+	if _, ok := err.(*ledger.ErroChaincode); !ok {
+		return envelope.Novo(envelope.LedgerIndisponivel, "detalhe", err.Error())
+	}
+	for _, m := range codigosMensagens {
+		if strings.Contains(err.Error(), m.trecho) {
+			return &envelope.Erro{Codigo: m.codigo, Mensagem: err.Error()}
+		}
+	}
+	return &envelope.Erro{Codigo: envelope.ErroInterno, Mensagem: err.Error()}
+}
+
+// StatusErro: status HTTP correspondente ao erro retornado pelo ledger
+func StatusErro(err error) int {
+	return statusEnvelope(ErroLedger(err))
+}
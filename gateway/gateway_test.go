@@ -0,0 +1,215 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/ledger"
+)
+
+// ledgerFalso - registra as chamadas e responde com o payload ou o erro programado
+type ledgerFalso struct {
+	chamadas []string
+	payload  []byte
+	pendente bool
+	err      error
+}
+
+func (l *ledgerFalso) Invoke(funcao string, args []string) (ledger.Resultado, error) {
+	l.chamadas = append(l.chamadas, fmt.Sprintf("invoke %s %q", funcao, args))
+	if l.err != nil {
+		return ledger.Resultado{}, l.err
+	}
+	return ledger.Resultado{TxID: "tx1", Payload: l.payload, Pendente: l.pendente}, nil
+}
+
+func (l *ledgerFalso) Query(funcao string, args []string) ([]byte, error) {
+	l.chamadas = append(l.chamadas, fmt.Sprintf("query %s %q", funcao, args))
+	return l.payload, l.err
+}
+
+// requisitar: executa a requisição no gateway sobre o ledger falso
+func requisitar(l *ledgerFalso, metodo, caminho, corpo string, cabecalhos ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(metodo, caminho, strings.NewReader(corpo))
+	for i := 0; i+1 < len(cabecalhos); i += 2 {
+		r.Header.Set(cabecalhos[i], cabecalhos[i+1])
+	}
+	w := httptest.NewRecorder()
+	Novo(l).ServeHTTP(w, r)
+	return w
+}
+
+const atestadoBoleto = `{"codigo_banco":"001","nosso_numero":"00000000001","valor":15000,"data_pagamento":"2026-11-20","assinatura":"MEUCIQ=="}`
+
+func TestArgumentos(t *testing.T) {
+	casos := []struct {
+		nome       string
+		metodo     string
+		caminho    string
+		corpo      string
+		cabecalhos []string
+		chamada    string
+	}{
+		{"registrarProposta", "POST", "/propostas",
+			`{"id_proposta": "p1", "cpf_pagador": "111", "pagador_aceitou": true}`, nil,
+			`invoke registrarProposta ["p1" "111" "true" "false" "false"]`},
+		{"registrarProposta com boleto", "POST", "/propostas",
+			`{"id_proposta": "p1", "cpf_pagador": "111", "nosso_numero": "00000000001", "valor": 15000}`, nil,
+			`invoke registrarProposta ["p1" "111" "false" "false" "false" "00000000001" "15000"]`},
+		{"Idempotency-Key", "POST", "/propostas",
+			`{"id_proposta": "p1", "cpf_pagador": "111"}`, []string{"Idempotency-Key", "r1"},
+			`invoke registrarProposta ["id_requisicao=r1" "p1" "111" "false" "false" "false"]`},
+		{"aceitarProposta", "POST", "/propostas/p1/aceite", `{"parte": "pagador"}`, nil,
+			`invoke aceitarProposta ["p1" "pagador"]`},
+		{"emitirBoleto", "POST", "/propostas/p1/boleto", `{"nosso_numero": "00000000001", "valor": 15000}`, nil,
+			`invoke emitirBoleto ["p1" "00000000001" "15000"]`},
+		{"emitirBoleto com vencimento", "POST", "/propostas/p1/boleto",
+			`{"nosso_numero": "00000000001", "valor": 15000, "data_vencimento": "2026-11-30"}`, nil,
+			`invoke emitirBoleto ["p1" "00000000001" "15000" "2026-11-30"]`},
+		{"emitirBoleto só com beneficiário", "POST", "/propostas/p1/boleto",
+			`{"nosso_numero": "00000000001", "valor": 15000, "beneficiario": "12.345.678/0001-90"}`, nil,
+			`invoke emitirBoleto ["p1" "00000000001" "15000" "" "12.345.678/0001-90"]`},
+		{"confirmarPagamento repassa o atestado", "POST", "/propostas/p1/pagamento", atestadoBoleto, nil,
+			fmt.Sprintf(`invoke confirmarPagamento %q`, []string{"p1", atestadoBoleto})},
+		{"cancelarProposta", "POST", "/propostas/p1/cancelamento", `{"motivo": "desistência"}`, nil,
+			`invoke cancelarProposta ["p1" "desistência"]`},
+		{"registrarCobrancaPix", "POST", "/propostas/p1/pix", `{}`, nil,
+			`invoke registrarCobrancaPix ["p1"]`},
+		{"registrarCobrancaPix com valor", "POST", "/propostas/p1/pix", `{"valor": 15000}`, nil,
+			`invoke registrarCobrancaPix ["p1" "15000"]`},
+		{"consultarProposta", "GET", "/propostas/p1", "", nil,
+			`query consultarProposta ["p1"]`},
+		{"gerarBRCode", "GET", "/propostas/p1/pix", "", nil,
+			`query gerarBRCode ["p1"]`},
+		{"agingRecebiveis", "GET", "/relatorios/aging?formato=csv&data=2026-11-02", "", nil,
+			`query agingRecebiveis ["csv" "2026-11-02"]`},
+		{"agingRecebiveis padrão", "GET", "/relatorios/aging", "", nil,
+			`query agingRecebiveis ["" ""]`},
+		{"consultarConciliacao", "GET", "/conciliacoes/c1", "", nil,
+			`query consultarConciliacao ["c1"]`},
+		{"listarStatusConciliacao", "GET", "/relatorios/conciliacao", "", nil,
+			`query listarStatusConciliacao [""]`},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			l := &ledgerFalso{payload: []byte(`{}`)}
+			w := requisitar(l, c.metodo, c.caminho, c.corpo, c.cabecalhos...)
+			if w.Code >= 300 {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			if len(l.chamadas) != 1 || l.chamadas[0] != c.chamada {
+				t.Fatalf("chamadas %q, esperada %s", l.chamadas, c.chamada)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	naoEncontrada := envelope.Novo(envelope.PropostaNaoEncontrada, "id", "p1")
+	casos := []struct {
+		nome    string
+		metodo  string
+		caminho string
+		corpo   string
+		ledger  ledgerFalso
+		status  int
+		codigo  envelope.Codigo // "" se a requisição for aceita
+		chamou  bool
+	}{
+		{"proposta nova", "POST", "/propostas", `{"id_proposta": "p1", "cpf_pagador": "111"}`,
+			ledgerFalso{payload: []byte(`{"operacao":"registrada"}`)}, http.StatusCreated, "", true},
+		{"proposta existente", "POST", "/propostas", `{"id_proposta": "p1", "cpf_pagador": "111"}`,
+			ledgerFalso{payload: []byte(`{"operacao":"atualizada"}`)}, http.StatusOK, "", true},
+		{"transação pendente", "POST", "/propostas/p1/aceite", `{"parte": "pagador"}`,
+			ledgerFalso{pendente: true}, http.StatusAccepted, "", true},
+		{"JSON inválido", "POST", "/propostas/p1/aceite", `{"parte": `,
+			ledgerFalso{}, http.StatusBadRequest, envelope.RequisicaoInvalida, false},
+		{"campo desconhecido", "POST", "/propostas/p1/cancelamento", `{"motivo": "x", "data": "2026-11-02"}`,
+			ledgerFalso{}, http.StatusBadRequest, envelope.RequisicaoInvalida, false},
+		{"argumento inválido", "POST", "/propostas/p1/boleto", `{"nosso_numero": "00000000001", "valor": 0}`,
+			ledgerFalso{}, http.StatusBadRequest, envelope.ArgumentoInvalido, false},
+		{"formato da cobrança PIX", "GET", "/propostas/p1/pix?formato=gif", "",
+			ledgerFalso{}, http.StatusBadRequest, envelope.ArgumentoInvalido, false},
+		{"ação desconhecida", "POST", "/propostas/p1/estorno", `{}`,
+			ledgerFalso{}, http.StatusNotFound, envelope.RotaNaoEncontrada, false},
+		{"método", "DELETE", "/propostas/p1", "",
+			ledgerFalso{}, http.StatusMethodNotAllowed, envelope.MetodoNaoSuportado, false},
+		{"rota", "GET", "/boletos", "",
+			ledgerFalso{}, http.StatusNotFound, envelope.RotaNaoEncontrada, false},
+		{"envelope do chaincode", "GET", "/propostas/p1", "",
+			ledgerFalso{err: &ledger.ErroChaincode{Mensagem: "Error: " + naoEncontrada.Error()}}, http.StatusNotFound, envelope.PropostaNaoEncontrada, true},
+		{"mensagem do chaincode anterior ao envelope", "POST", "/propostas/p1/pagamento", atestadoBoleto,
+			ledgerFalso{err: &ledger.ErroChaincode{Mensagem: "Boleto da Proposta [p1] já está pago."}}, http.StatusConflict, envelope.PropostaJaPaga, true},
+		{"lote rejeitado com o status da causa", "POST", "/propostas/p1/aceite", `{"parte": "pagador"}`,
+			ledgerFalso{err: envelope.Lote(1, "aceitarProposta", naoEncontrada)}, http.StatusNotFound, envelope.LoteRejeitado, true},
+		{"peer indisponível", "GET", "/propostas/p1", "",
+			ledgerFalso{err: errors.New("connection refused")}, http.StatusBadGateway, envelope.LedgerIndisponivel, true},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			l := c.ledger
+			w := requisitar(&l, c.metodo, c.caminho, c.corpo)
+			if w.Code != c.status {
+				t.Fatalf("status %d, esperado %d: %s", w.Code, c.status, w.Body)
+			}
+			if chamou := len(l.chamadas) > 0; chamou != c.chamou {
+				t.Fatalf("chamadas ao ledger %q", l.chamadas)
+			}
+			if c.codigo == "" {
+				return
+			}
+			var corpo struct {
+				Erro envelope.Erro `json:"erro"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &corpo); err != nil {
+				t.Fatal(err)
+			}
+			if corpo.Erro.Codigo != c.codigo {
+				t.Fatalf("código %s, esperado %s", corpo.Erro.Codigo, c.codigo)
+			}
+		})
+	}
+}
+
+func TestMetodoDoLote(t *testing.T) {
+	for _, metodo := range []string{"GET", "PUT", "DELETE"} {
+		l := &ledgerFalso{}
+		w := requisitar(l, metodo, "/propostas/lote", "")
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "POST" {
+			t.Fatalf("%s /propostas/lote: status %d, Allow %q; esperado 405 com Allow: POST", metodo, w.Code, w.Header().Get("Allow"))
+		}
+		if len(l.chamadas) != 0 {
+			t.Fatalf("%s /propostas/lote: chamadas ao ledger %q", metodo, l.chamadas)
+		}
+	}
+}
+
+func TestIdiomaDoErro(t *testing.T) {
+	l := &ledgerFalso{err: envelope.Novo(envelope.PropostaNaoEncontrada, "id", "p1")}
+	casos := []struct {
+		aceitos  string
+		mensagem string
+	}{
+		{"", "Proposta [p1] não existente."},
+		{"en-US,en;q=0.9", "Proposal [p1] not found."},
+		{"fr", "Proposta [p1] não existente."},
+	}
+	for _, c := range casos {
+		w := requisitar(l, "GET", "/propostas/p1", "", "Accept-Language", c.aceitos)
+		var corpo struct {
+			Erro envelope.Erro `json:"erro"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &corpo); err != nil {
+			t.Fatal(err)
+		}
+		if corpo.Erro.Mensagem != c.mensagem {
+			t.Fatalf("Accept-Language %q: mensagem %q, esperada %q", c.aceitos, corpo.Erro.Mensagem, c.mensagem)
+		}
+	}
+}
@@ -0,0 +1,520 @@
+package gateway
+
+// EspecificacaoOpenAPI - documento OpenAPI 3.0 da API de propostas, publicado em GET /openapi.json
+const EspecificacaoOpenAPI = `{
+  "openapi": "3.0.0",
+  "info": {
+    "title": "Blockchain Dojo - Propostas",
+    "version": "1.0.0",
+    "description": "Gateway REST do chaincode BoletoPropostaChaincode. Cada rota corresponde a uma função do chaincode; os erros do chaincode são repassados no campo erro, como um envelope com código estável e mensagem no idioma de Accept-Language."
+  },
+  "paths": {
+    "/propostas": {
+      "post": {
+        "summary": "Registra uma nova proposta ou atualiza uma existente (registrarProposta)",
+        "operationId": "registrarProposta",
+        "parameters": [ { "$ref": "#/components/parameters/IdempotencyKey" } ],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/NovaProposta" } } }
+        },
+        "responses": {
+          "201": { "description": "Proposta registrada", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Resposta" } } } },
+          "200": { "description": "Proposta atualizada", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Resposta" } } } },
+          "202": { "$ref": "#/components/responses/Pendente" },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "403": { "$ref": "#/components/responses/Erro" },
+          "409": { "$ref": "#/components/responses/Erro" },
+          "502": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/propostas/lote": {
+      "post": {
+        "summary": "Executa uma lista de operações em uma transação: todas são aplicadas ou nenhuma (executarLote)",
+        "operationId": "executarLote",
+        "parameters": [ { "$ref": "#/components/parameters/IdempotencyKey" } ],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Lote" } } }
+        },
+        "responses": {
+          "200": { "description": "Lote executado", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/RespostaLote" } } } },
+          "202": { "$ref": "#/components/responses/Pendente" },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "403": { "$ref": "#/components/responses/Erro" },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "409": { "$ref": "#/components/responses/Erro" },
+          "502": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/propostas/{id}": {
+      "get": {
+        "summary": "Consulta uma proposta (consultarProposta)",
+        "operationId": "consultarProposta",
+        "parameters": [ { "$ref": "#/components/parameters/Id" } ],
+        "responses": {
+          "200": { "description": "Proposta", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Proposta" } } } },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "502": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/propostas/{id}/aceite": {
+      "post": {
+        "summary": "Registra o aceite do pagador ou do beneficiário (aceitarProposta)",
+        "operationId": "aceitarProposta",
+        "parameters": [ { "$ref": "#/components/parameters/Id" }, { "$ref": "#/components/parameters/IdempotencyKey" } ],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Aceite" } } }
+        },
+        "responses": {
+          "200": { "description": "Aceite registrado", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Resposta" } } } },
+          "202": { "$ref": "#/components/responses/Pendente" },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "409": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/propostas/{id}/boleto": {
+      "post": {
+        "summary": "Registra o boleto emitido para a proposta (emitirBoleto)",
+        "operationId": "emitirBoleto",
+        "parameters": [ { "$ref": "#/components/parameters/Id" }, { "$ref": "#/components/parameters/IdempotencyKey" } ],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Boleto" } } }
+        },
+        "responses": {
+          "200": { "description": "Boleto registrado", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Resposta" } } } },
+          "202": { "$ref": "#/components/responses/Pendente" },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "409": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/propostas/{id}/pagamento": {
+      "post": {
+        "summary": "Liquida a proposta com o atestado de pagamento (boleto ou PIX) assinado pelo oráculo do banco (confirmarPagamento)",
+        "operationId": "confirmarPagamento",
+        "parameters": [ { "$ref": "#/components/parameters/Id" }, { "$ref": "#/components/parameters/IdempotencyKey" } ],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Atestado" } } }
+        },
+        "responses": {
+          "200": { "description": "Pagamento registrado", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Resposta" } } } },
+          "202": { "$ref": "#/components/responses/Pendente" },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "409": { "$ref": "#/components/responses/Erro" },
+          "422": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/propostas/{id}/pix": {
+      "post": {
+        "summary": "Registra uma única vez a cobrança PIX da proposta aceita, com o valor da proposta, e retorna o BR Code (registrarCobrancaPix)",
+        "operationId": "registrarCobrancaPix",
+        "parameters": [ { "$ref": "#/components/parameters/Id" }, { "$ref": "#/components/parameters/IdempotencyKey" } ],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/NovaCobrancaPix" } } }
+        },
+        "responses": {
+          "200": { "description": "Cobrança PIX registrada", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/CobrancaPix" } } } },
+          "202": { "$ref": "#/components/responses/Pendente" },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "409": { "$ref": "#/components/responses/Erro" },
+          "501": { "$ref": "#/components/responses/Erro" }
+        }
+      },
+      "get": {
+        "summary": "BR Code da cobrança PIX registrada, com o ID da proposta como txid (gerarBRCode), em JSON ou como QR Code",
+        "operationId": "gerarBRCode",
+        "parameters": [
+          { "$ref": "#/components/parameters/Id" },
+          { "name": "formato", "in": "query", "schema": { "type": "string", "enum": [ "json", "png", "svg" ], "default": "json" } },
+          { "name": "escala", "in": "query", "description": "Pixels por módulo do QR Code (png e svg)", "schema": { "type": "integer", "minimum": 1, "maximum": 64, "default": 8 } }
+        ],
+        "responses": {
+          "200": {
+            "description": "Cobrança PIX; nas imagens, o BR Code também é retornado no cabeçalho X-BR-Code",
+            "content": {
+              "application/json": { "schema": { "$ref": "#/components/schemas/CobrancaPix" } },
+              "image/png": { "schema": { "type": "string", "format": "binary" } },
+              "image/svg+xml": { "schema": { "type": "string" } }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "409": { "$ref": "#/components/responses/Erro" },
+          "501": { "$ref": "#/components/responses/Erro" },
+          "502": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/propostas/{id}/cancelamento": {
+      "post": {
+        "summary": "Cancela uma proposta ainda não paga (cancelarProposta)",
+        "operationId": "cancelarProposta",
+        "parameters": [ { "$ref": "#/components/parameters/Id" }, { "$ref": "#/components/parameters/IdempotencyKey" } ],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Cancelamento" } } }
+        },
+        "responses": {
+          "200": { "description": "Proposta cancelada", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Resposta" } } } },
+          "202": { "$ref": "#/components/responses/Pendente" },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "409": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/relatorios/aging": {
+      "get": {
+        "summary": "Aging dos boletos em aberto por beneficiário e por pagador (agingRecebiveis)",
+        "operationId": "agingRecebiveis",
+        "parameters": [
+          { "name": "formato", "in": "query", "schema": { "type": "string", "enum": [ "json", "csv" ], "default": "json" } },
+          { "name": "data", "in": "query", "description": "Data de referência; padrão: data da transação", "schema": { "type": "string", "format": "date" } }
+        ],
+        "responses": {
+          "200": {
+            "description": "Relatório de aging; em CSV, uma linha por beneficiário, por pagador e o total, com os valores das faixas em centavos",
+            "content": {
+              "application/json": { "schema": { "$ref": "#/components/schemas/RelatorioAging" } },
+              "text/csv": { "schema": { "type": "string" } }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "502": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/conciliacoes": {
+      "post": {
+        "summary": "Registra a conciliação dos boletos pagos com os extratos bancários (registrarConciliacao)",
+        "operationId": "registrarConciliacao",
+        "parameters": [ { "$ref": "#/components/parameters/IdempotencyKey" } ],
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/NovaConciliacao" } } }
+        },
+        "responses": {
+          "201": { "description": "Conciliação registrada", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/RespostaConciliacao" } } } },
+          "202": { "$ref": "#/components/responses/Pendente" },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "403": { "$ref": "#/components/responses/Erro" },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "409": { "$ref": "#/components/responses/Erro" },
+          "502": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/conciliacoes/{id_conciliacao}": {
+      "get": {
+        "summary": "Consulta uma conciliação registrada (consultarConciliacao)",
+        "operationId": "consultarConciliacao",
+        "parameters": [ { "name": "id_conciliacao", "in": "path", "required": true, "schema": { "type": "string" } } ],
+        "responses": {
+          "200": { "description": "Conciliação, com os itens completados com os dados do ledger", "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Conciliacao" } } } },
+          "404": { "$ref": "#/components/responses/Erro" },
+          "502": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    },
+    "/relatorios/conciliacao": {
+      "get": {
+        "summary": "Situação da conciliação de cada boleto emitido (listarStatusConciliacao)",
+        "operationId": "listarStatusConciliacao",
+        "parameters": [
+          { "name": "situacao", "in": "query", "description": "Lista apenas os boletos na situação informada", "schema": { "type": "string", "enum": [ "conciliado", "somente_ledger", "somente_extrato", "valor_divergente", "data_divergente", "duplicado", "nao_conciliado" ] } }
+        ],
+        "responses": {
+          "200": { "description": "Boletos com a situação da última conciliação que os incluiu", "content": { "application/json": { "schema": { "type": "array", "items": { "$ref": "#/components/schemas/StatusConciliacao" } } } } },
+          "400": { "$ref": "#/components/responses/Erro" },
+          "502": { "$ref": "#/components/responses/Erro" }
+        }
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "Id": { "name": "id", "in": "path", "required": true, "description": "Hash que identifica a proposta", "schema": { "type": "string" } },
+      "IdempotencyKey": { "name": "Idempotency-Key", "in": "header", "required": false, "description": "ID da requisição do cliente; as repetições com o mesmo ID retornam a resposta original sem executar a função de novo, e o mesmo ID com outros argumentos resulta em ID_REQUISICAO_REUTILIZADO (409)", "schema": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$" } }
+    },
+    "responses": {
+      "Pendente": {
+        "description": "Transação submetida ao peer; o resultado será conhecido quando o bloco for confirmado",
+        "content": { "application/json": { "schema": { "type": "object", "properties": { "tx_id": { "type": "string" } } } } }
+      },
+      "Erro": {
+        "description": "Erro retornado pelo chaincode ou pelo gateway, com a mensagem no idioma do cabeçalho Accept-Language (pt-BR ou en)",
+        "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Erro" } } }
+      }
+    },
+    "schemas": {
+      "NovaProposta": {
+        "type": "object",
+        "required": [ "id_proposta", "cpf_pagador" ],
+        "additionalProperties": false,
+        "properties": {
+          "id_proposta": { "type": "string" },
+          "cpf_pagador": { "type": "string" },
+          "pagador_aceitou": { "type": "boolean" },
+          "beneficiario_aceitou": { "type": "boolean" },
+          "boleto_pago": { "type": "boolean", "description": "false com a tabela completa, em que o pagamento é registrado por POST /propostas/{id}/pagamento" },
+          "nosso_numero": { "type": "string" },
+          "valor": { "type": "integer", "format": "int64", "description": "Valor do boleto em centavos, obrigatório junto com nosso_numero" }
+        }
+      },
+      "Proposta": {
+        "type": "object",
+        "properties": {
+          "id_proposta": { "type": "string" },
+          "cpf_pagador": { "type": "string" },
+          "pagador_aceitou": { "type": "boolean" },
+          "beneficiario_aceitou": { "type": "boolean" },
+          "boleto_pago": { "type": "boolean" },
+          "nosso_numero": { "type": "string" },
+          "valor": { "type": "integer", "format": "int64" },
+          "data_pagamento": { "type": "string" },
+          "cancelada": { "type": "boolean" },
+          "data_vencimento": { "type": "string" },
+          "beneficiario": { "type": "string" },
+          "forma_pagamento": { "type": "string", "enum": [ "", "boleto", "pix" ], "description": "Forma do pagamento confirmado (vazio: não paga)" },
+          "end_to_end_id": { "type": "string", "description": "ID fim a fim do pagamento PIX" },
+          "status": { "type": "string", "enum": [ "criada", "aceita", "boleto_emitido", "paga", "cancelada" ] }
+        }
+      },
+      "CobrancaPix": {
+        "type": "object",
+        "properties": {
+          "id_proposta": { "type": "string" },
+          "txid": { "type": "string" },
+          "valor": { "type": "integer", "format": "int64", "description": "Valor em centavos" },
+          "brcode": { "type": "string", "description": "BR Code (PIX copia e cola), com o CRC16" }
+        }
+      },
+      "Aceite": {
+        "type": "object",
+        "required": [ "parte" ],
+        "additionalProperties": false,
+        "properties": { "parte": { "type": "string", "enum": [ "pagador", "beneficiario" ] } }
+      },
+      "Boleto": {
+        "type": "object",
+        "required": [ "nosso_numero", "valor" ],
+        "additionalProperties": false,
+        "properties": {
+          "nosso_numero": { "type": "string" },
+          "valor": { "type": "integer", "format": "int64", "minimum": 1 },
+          "data_vencimento": { "type": "string", "format": "date", "description": "Vencimento do boleto, utilizado no aging" },
+          "beneficiario": { "type": "string", "description": "CPF ou CNPJ do beneficiário, utilizado no aging" }
+        }
+      },
+      "Atestado": {
+        "type": "object",
+        "required": [ "codigo_banco", "valor", "data_pagamento", "assinatura" ],
+        "description": "Atestado do boleto, com o nosso_numero, ou do PIX, com o end_to_end_id e o txid",
+        "properties": {
+          "codigo_banco": { "type": "string", "pattern": "^[0-9]{3}$" },
+          "nosso_numero": { "type": "string" },
+          "end_to_end_id": { "type": "string", "pattern": "^E[0-9]{20}[A-Za-z0-9]{11}$", "description": "ID fim a fim do pagamento PIX" },
+          "txid": { "type": "string", "description": "txid da cobrança PIX (ID da proposta)" },
+          "valor": { "type": "integer", "format": "int64" },
+          "data_pagamento": { "type": "string", "format": "date" },
+          "assinatura": { "type": "string", "format": "byte", "description": "Assinatura ECDSA (DER, base64) do oráculo do banco" }
+        }
+      },
+      "Cancelamento": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": { "motivo": { "type": "string" } }
+      },
+      "NovaCobrancaPix": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": { "valor": { "type": "integer", "format": "int64", "description": "Valor em centavos, conferido com o valor da proposta" } }
+      },
+      "Resposta": {
+        "type": "object",
+        "properties": {
+          "operacao": { "type": "string", "enum": [ "registrada", "atualizada", "aceita", "boleto_emitido", "paga", "cancelada" ] },
+          "id_proposta": { "type": "string" },
+          "status": { "type": "string", "enum": [ "criada", "aceita", "boleto_emitido", "paga", "cancelada" ] }
+        }
+      },
+      "Lote": {
+        "type": "object",
+        "description": "Operações executadas em ordem; o máximo é definido pela configuração do chaincode (lote_maximo)",
+        "required": [ "operacoes" ],
+        "properties": {
+          "operacoes": {
+            "type": "array",
+            "minItems": 1,
+            "items": {
+              "type": "object",
+              "required": [ "funcao", "documento" ],
+              "properties": {
+                "funcao": { "type": "string", "enum": [ "registrarProposta", "aceitarProposta", "confirmarPagamento", "cancelarProposta" ] },
+                "documento": { "type": "object", "description": "Documento da função (ver GET /esquemas/{funcao}.json)" }
+              }
+            }
+          }
+        }
+      },
+      "RespostaLote": {
+        "type": "object",
+        "properties": {
+          "operacao": { "type": "string", "enum": [ "lote_executado" ] },
+          "resultados": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "indice": { "type": "integer" },
+                "funcao": { "type": "string" },
+                "resposta": { "$ref": "#/components/schemas/Resposta" }
+              }
+            }
+          }
+        }
+      },
+      "Faixa": {
+        "type": "object",
+        "properties": {
+          "quantidade": { "type": "integer" },
+          "valor": { "type": "integer", "format": "int64", "description": "Em centavos" }
+        }
+      },
+      "FaixasAging": {
+        "type": "object",
+        "properties": {
+          "a_vencer": { "$ref": "#/components/schemas/Faixa" },
+          "dias_1_30": { "$ref": "#/components/schemas/Faixa" },
+          "dias_31_60": { "$ref": "#/components/schemas/Faixa" },
+          "dias_61_90": { "$ref": "#/components/schemas/Faixa" },
+          "dias_90_mais": { "$ref": "#/components/schemas/Faixa" },
+          "sem_vencimento": { "$ref": "#/components/schemas/Faixa" },
+          "total": { "$ref": "#/components/schemas/Faixa" }
+        }
+      },
+      "GrupoAging": {
+        "allOf": [
+          { "type": "object", "properties": { "chave": { "type": "string", "description": "CPF ou CNPJ do beneficiário ou CPF do pagador" } } },
+          { "$ref": "#/components/schemas/FaixasAging" }
+        ]
+      },
+      "RelatorioAging": {
+        "type": "object",
+        "properties": {
+          "data_referencia": { "type": "string", "format": "date" },
+          "por_beneficiario": { "type": "array", "items": { "$ref": "#/components/schemas/GrupoAging" } },
+          "por_pagador": { "type": "array", "items": { "$ref": "#/components/schemas/GrupoAging" } },
+          "total": { "$ref": "#/components/schemas/FaixasAging" }
+        }
+      },
+      "ItemConciliacao": {
+        "type": "object",
+        "required": [ "situacao" ],
+        "properties": {
+          "situacao": { "type": "string", "enum": [ "conciliado", "somente_ledger", "somente_extrato", "valor_divergente", "data_divergente", "duplicado" ] },
+          "id_proposta": { "type": "string", "description": "Obrigatório exceto em somente_extrato e duplicado" },
+          "nosso_numero": { "type": "string", "readOnly": true },
+          "end_to_end_id": { "type": "string", "readOnly": true, "description": "ID fim a fim do pagamento PIX, preenchido pelo chaincode" },
+          "valor_ledger": { "type": "integer", "readOnly": true, "description": "Valor do boleto em centavos, preenchido pelo chaincode" },
+          "data_ledger": { "type": "string", "format": "date", "readOnly": true, "description": "Data de pagamento no ledger, preenchida pelo chaincode" },
+          "id_lancamento": { "type": "string" },
+          "valor_extrato": { "type": "integer", "description": "Valor do lançamento em centavos" },
+          "data_extrato": { "type": "string", "format": "date" },
+          "descricao": { "type": "string" },
+          "arquivo": { "type": "string" },
+          "criterio": { "type": "string", "enum": [ "nosso_numero", "id_proposta", "end_to_end_id", "valor" ] },
+          "detalhe": { "type": "string" }
+        }
+      },
+      "NovaConciliacao": {
+        "type": "object",
+        "required": [ "id_conciliacao", "itens" ],
+        "properties": {
+          "id_conciliacao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$" },
+          "inicio": { "type": "string", "format": "date" },
+          "fim": { "type": "string", "format": "date" },
+          "extratos": { "type": "array", "items": { "type": "string" } },
+          "itens": { "type": "array", "items": { "$ref": "#/components/schemas/ItemConciliacao" } }
+        }
+      },
+      "Conciliacao": {
+        "allOf": [
+          { "$ref": "#/components/schemas/NovaConciliacao" },
+          {
+            "type": "object",
+            "properties": {
+              "resumo": { "type": "object", "description": "Quantidade de itens por situação", "additionalProperties": { "type": "integer" } },
+              "tx_id": { "type": "string" },
+              "horario": { "type": "integer", "description": "Horário da transação, em segundos" }
+            }
+          }
+        ]
+      },
+      "RespostaConciliacao": {
+        "type": "object",
+        "properties": {
+          "operacao": { "type": "string", "enum": [ "conciliacao_registrada" ] },
+          "id_conciliacao": { "type": "string" },
+          "boletos": { "type": "integer", "description": "Propostas com a situação da conciliação atualizada" },
+          "resumo": { "type": "object", "additionalProperties": { "type": "integer" } }
+        }
+      },
+      "StatusConciliacao": {
+        "type": "object",
+        "properties": {
+          "id_proposta": { "type": "string" },
+          "nosso_numero": { "type": "string" },
+          "end_to_end_id": { "type": "string", "description": "ID fim a fim do pagamento PIX" },
+          "valor": { "type": "integer" },
+          "status": { "type": "string", "description": "Status atual da proposta" },
+          "situacao": { "type": "string", "description": "Situação do item mais grave da última conciliação do boleto, ou nao_conciliado" },
+          "detalhe": { "type": "string" },
+          "id_conciliacao": { "type": "string" },
+          "tx_id": { "type": "string" },
+          "horario": { "type": "integer" }
+        }
+      },
+      "Erro": {
+        "type": "object",
+        "properties": {
+          "erro": {
+            "type": "object",
+            "properties": {
+              "codigo": { "type": "string", "description": "Código estável do erro (ver pacote envelope), ex.: PROPOSTA_NAO_ENCONTRADA" },
+              "mensagem": { "type": "string" },
+              "parametros": { "type": "object", "additionalProperties": { "type": "string" } },
+              "campos": {
+                "type": "array",
+                "items": {
+                  "type": "object",
+                  "properties": {
+                    "caminho": { "type": "string" },
+                    "regra": { "type": "string" },
+                    "parametros": { "type": "object", "additionalProperties": { "type": "string" } },
+                    "mensagem": { "type": "string" }
+                  }
+                }
+              },
+              "causa": { "type": "object", "description": "Erro da operação que rejeitou o lote (LOTE_REJEITADO), no mesmo formato" }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
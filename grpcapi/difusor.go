@@ -0,0 +1,102 @@
+package grpcapi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/logging"
+	"github.com/CaueP/BlockchainDojo/projection"
+)
+
+// Difusor - distribui os eventos do chaincode aos assinantes de cada proposta
+type Difusor struct {
+	// Log: eventos descartados e falhas de leitura da fonte (nil: sem log)
+	Log *logging.Logger
+
+	mu         sync.Mutex
+	assinantes map[string]map[chan events.Evento]struct{}
+}
+
+// NovoDifusor: cria um difusor sem assinantes, com o log na saída padrão
+func NovoDifusor() *Difusor {
+	return &Difusor{
+		Log:        logging.Novo("", "difusor"),
+		assinantes: make(map[string]map[chan events.Evento]struct{}),
+	}
+}
+
+// Assinar: recebe os eventos da proposta informada até que a função retornada seja chamada
+func (d *Difusor) Assinar(idProposta string) (<-chan events.Evento, func()) {
+	ch := make(chan events.Evento, 64)
+
+	d.mu.Lock()
+	if d.assinantes[idProposta] == nil {
+		d.assinantes[idProposta] = make(map[chan events.Evento]struct{})
+	}
+	d.assinantes[idProposta][ch] = struct{}{}
+	d.mu.Unlock()
+
+	cancelar := func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if _, ok := d.assinantes[idProposta][ch]; !ok {
+			return
+		}
+		delete(d.assinantes[idProposta], ch)
+		if len(d.assinantes[idProposta]) == 0 {
+			delete(d.assinantes, idProposta)
+		}
+		close(ch)
+	}
+	return ch, cancelar
+}
+
+// Publicar: entrega o evento aos assinantes da proposta. Assinantes que não
+// consomem os eventos a tempo perdem o evento, em vez de bloquear os demais.
+func (d *Difusor) Publicar(e events.Evento) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ch := range d.assinantes[e.IDProposta] {
+		select {
+		case ch <- e:
+		default:
+			d.Log.Aviso("Assinante lento, evento descartado", "id_proposta", e.IDProposta, "tipo", string(e.Tipo))
+		}
+	}
+}
+
+// AcompanharFonte: lê periodicamente os novos eventos da fonte e os publica, até que
+// parar seja fechado. Os eventos existentes na primeira leitura não são publicados,
+// pois já estão refletidos no estado consultado pelos assinantes.
+func (d *Difusor) AcompanharFonte(fonte projection.Fonte, intervalo time.Duration, parar <-chan struct{}) {
+	var bloco uint64
+	indice := -1
+	primeira := true
+
+	for {
+		err := fonte.Ler(bloco, func(ev projection.EventoBloco) error {
+			if ev.Bloco < bloco || (ev.Bloco == bloco && ev.Indice <= indice) {
+				return nil
+			}
+			bloco, indice = ev.Bloco, ev.Indice
+			if !primeira {
+				for _, e := range ev.Evento.Eventos() {
+					d.Publicar(e)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			d.Log.Erro("Falha ao ler eventos", "bloco", bloco, "erro", err)
+		} else {
+			primeira = false
+		}
+
+		select {
+		case <-parar:
+			return
+		case <-time.After(intervalo):
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package grpcapi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/logging"
+)
+
+func TestPublicarAssinanteLento(t *testing.T) {
+	var saida bytes.Buffer
+	d := NovoDifusor()
+	d.Log = &logging.Logger{Nivel: logging.Info, Funcao: "difusor", Saida: &saida}
+	ch, cancelar := d.Assinar("p1")
+	defer cancelar()
+
+	// o canal do assinante comporta 64 eventos; o seguinte é descartado e registrado no log
+	for i := 0; i <= cap(ch); i++ {
+		d.Publicar(events.Evento{Tipo: events.PropostaAceita, IDProposta: "p1"})
+	}
+	if len(ch) != cap(ch) {
+		t.Fatalf("%d eventos entregues, esperados %d", len(ch), cap(ch))
+	}
+	linhas := strings.Split(strings.TrimSpace(saida.String()), "\n")
+	if len(linhas) != 1 || !strings.Contains(linhas[0], `"nivel":"aviso"`) || !strings.Contains(linhas[0], `"id_proposta":"p1","tipo":"PropostaAceita"`) {
+		t.Fatalf("log %q, esperado um aviso do evento descartado", saida.String())
+	}
+}
@@ -0,0 +1,966 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v3.21.12
+// source: grpcapi/propostas.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Proposta struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	IdProposta          string                 `protobuf:"bytes,1,opt,name=id_proposta,json=idProposta,proto3" json:"id_proposta,omitempty"`
+	CpfPagador          string                 `protobuf:"bytes,2,opt,name=cpf_pagador,json=cpfPagador,proto3" json:"cpf_pagador,omitempty"`
+	PagadorAceitou      bool                   `protobuf:"varint,3,opt,name=pagador_aceitou,json=pagadorAceitou,proto3" json:"pagador_aceitou,omitempty"`
+	BeneficiarioAceitou bool                   `protobuf:"varint,4,opt,name=beneficiario_aceitou,json=beneficiarioAceitou,proto3" json:"beneficiario_aceitou,omitempty"`
+	BoletoPago          bool                   `protobuf:"varint,5,opt,name=boleto_pago,json=boletoPago,proto3" json:"boleto_pago,omitempty"`
+	NossoNumero         string                 `protobuf:"bytes,6,opt,name=nosso_numero,json=nossoNumero,proto3" json:"nosso_numero,omitempty"`
+	Valor               int64                  `protobuf:"varint,7,opt,name=valor,proto3" json:"valor,omitempty"`
+	DataPagamento       string                 `protobuf:"bytes,8,opt,name=data_pagamento,json=dataPagamento,proto3" json:"data_pagamento,omitempty"`
+	Cancelada           bool                   `protobuf:"varint,9,opt,name=cancelada,proto3" json:"cancelada,omitempty"`
+	Status              string                 `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`
+	FormaPagamento      string                 `protobuf:"bytes,11,opt,name=forma_pagamento,json=formaPagamento,proto3" json:"forma_pagamento,omitempty"`
+	EndToEndId          string                 `protobuf:"bytes,12,opt,name=end_to_end_id,json=endToEndId,proto3" json:"end_to_end_id,omitempty"`
+	DataVencimento      string                 `protobuf:"bytes,13,opt,name=data_vencimento,json=dataVencimento,proto3" json:"data_vencimento,omitempty"`
+	Beneficiario        string                 `protobuf:"bytes,14,opt,name=beneficiario,proto3" json:"beneficiario,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Proposta) Reset() {
+	*x = Proposta{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Proposta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Proposta) ProtoMessage() {}
+
+func (x *Proposta) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Proposta.ProtoReflect.Descriptor instead.
+func (*Proposta) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Proposta) GetIdProposta() string {
+	if x != nil {
+		return x.IdProposta
+	}
+	return ""
+}
+
+func (x *Proposta) GetCpfPagador() string {
+	if x != nil {
+		return x.CpfPagador
+	}
+	return ""
+}
+
+func (x *Proposta) GetPagadorAceitou() bool {
+	if x != nil {
+		return x.PagadorAceitou
+	}
+	return false
+}
+
+func (x *Proposta) GetBeneficiarioAceitou() bool {
+	if x != nil {
+		return x.BeneficiarioAceitou
+	}
+	return false
+}
+
+func (x *Proposta) GetBoletoPago() bool {
+	if x != nil {
+		return x.BoletoPago
+	}
+	return false
+}
+
+func (x *Proposta) GetNossoNumero() string {
+	if x != nil {
+		return x.NossoNumero
+	}
+	return ""
+}
+
+func (x *Proposta) GetValor() int64 {
+	if x != nil {
+		return x.Valor
+	}
+	return 0
+}
+
+func (x *Proposta) GetDataPagamento() string {
+	if x != nil {
+		return x.DataPagamento
+	}
+	return ""
+}
+
+func (x *Proposta) GetCancelada() bool {
+	if x != nil {
+		return x.Cancelada
+	}
+	return false
+}
+
+func (x *Proposta) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Proposta) GetFormaPagamento() string {
+	if x != nil {
+		return x.FormaPagamento
+	}
+	return ""
+}
+
+func (x *Proposta) GetEndToEndId() string {
+	if x != nil {
+		return x.EndToEndId
+	}
+	return ""
+}
+
+func (x *Proposta) GetDataVencimento() string {
+	if x != nil {
+		return x.DataVencimento
+	}
+	return ""
+}
+
+func (x *Proposta) GetBeneficiario() string {
+	if x != nil {
+		return x.Beneficiario
+	}
+	return ""
+}
+
+type RegistrarPropostaRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	IdProposta          string                 `protobuf:"bytes,1,opt,name=id_proposta,json=idProposta,proto3" json:"id_proposta,omitempty"`
+	CpfPagador          string                 `protobuf:"bytes,2,opt,name=cpf_pagador,json=cpfPagador,proto3" json:"cpf_pagador,omitempty"`
+	PagadorAceitou      bool                   `protobuf:"varint,3,opt,name=pagador_aceitou,json=pagadorAceitou,proto3" json:"pagador_aceitou,omitempty"`
+	BeneficiarioAceitou bool                   `protobuf:"varint,4,opt,name=beneficiario_aceitou,json=beneficiarioAceitou,proto3" json:"beneficiario_aceitou,omitempty"`
+	BoletoPago          bool                   `protobuf:"varint,5,opt,name=boleto_pago,json=boletoPago,proto3" json:"boleto_pago,omitempty"`
+	NossoNumero         string                 `protobuf:"bytes,6,opt,name=nosso_numero,json=nossoNumero,proto3" json:"nosso_numero,omitempty"`
+	Valor               int64                  `protobuf:"varint,7,opt,name=valor,proto3" json:"valor,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *RegistrarPropostaRequest) Reset() {
+	*x = RegistrarPropostaRequest{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegistrarPropostaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegistrarPropostaRequest) ProtoMessage() {}
+
+func (x *RegistrarPropostaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegistrarPropostaRequest.ProtoReflect.Descriptor instead.
+func (*RegistrarPropostaRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RegistrarPropostaRequest) GetIdProposta() string {
+	if x != nil {
+		return x.IdProposta
+	}
+	return ""
+}
+
+func (x *RegistrarPropostaRequest) GetCpfPagador() string {
+	if x != nil {
+		return x.CpfPagador
+	}
+	return ""
+}
+
+func (x *RegistrarPropostaRequest) GetPagadorAceitou() bool {
+	if x != nil {
+		return x.PagadorAceitou
+	}
+	return false
+}
+
+func (x *RegistrarPropostaRequest) GetBeneficiarioAceitou() bool {
+	if x != nil {
+		return x.BeneficiarioAceitou
+	}
+	return false
+}
+
+func (x *RegistrarPropostaRequest) GetBoletoPago() bool {
+	if x != nil {
+		return x.BoletoPago
+	}
+	return false
+}
+
+func (x *RegistrarPropostaRequest) GetNossoNumero() string {
+	if x != nil {
+		return x.NossoNumero
+	}
+	return ""
+}
+
+func (x *RegistrarPropostaRequest) GetValor() int64 {
+	if x != nil {
+		return x.Valor
+	}
+	return 0
+}
+
+type ConsultarPropostaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IdProposta    string                 `protobuf:"bytes,1,opt,name=id_proposta,json=idProposta,proto3" json:"id_proposta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsultarPropostaRequest) Reset() {
+	*x = ConsultarPropostaRequest{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsultarPropostaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsultarPropostaRequest) ProtoMessage() {}
+
+func (x *ConsultarPropostaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsultarPropostaRequest.ProtoReflect.Descriptor instead.
+func (*ConsultarPropostaRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ConsultarPropostaRequest) GetIdProposta() string {
+	if x != nil {
+		return x.IdProposta
+	}
+	return ""
+}
+
+type AceitarPropostaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IdProposta    string                 `protobuf:"bytes,1,opt,name=id_proposta,json=idProposta,proto3" json:"id_proposta,omitempty"`
+	Parte         string                 `protobuf:"bytes,2,opt,name=parte,proto3" json:"parte,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AceitarPropostaRequest) Reset() {
+	*x = AceitarPropostaRequest{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AceitarPropostaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AceitarPropostaRequest) ProtoMessage() {}
+
+func (x *AceitarPropostaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AceitarPropostaRequest.ProtoReflect.Descriptor instead.
+func (*AceitarPropostaRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AceitarPropostaRequest) GetIdProposta() string {
+	if x != nil {
+		return x.IdProposta
+	}
+	return ""
+}
+
+func (x *AceitarPropostaRequest) GetParte() string {
+	if x != nil {
+		return x.Parte
+	}
+	return ""
+}
+
+type EmitirBoletoRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	IdProposta     string                 `protobuf:"bytes,1,opt,name=id_proposta,json=idProposta,proto3" json:"id_proposta,omitempty"`
+	NossoNumero    string                 `protobuf:"bytes,2,opt,name=nosso_numero,json=nossoNumero,proto3" json:"nosso_numero,omitempty"`
+	Valor          int64                  `protobuf:"varint,3,opt,name=valor,proto3" json:"valor,omitempty"`
+	DataVencimento string                 `protobuf:"bytes,4,opt,name=data_vencimento,json=dataVencimento,proto3" json:"data_vencimento,omitempty"`
+	Beneficiario   string                 `protobuf:"bytes,5,opt,name=beneficiario,proto3" json:"beneficiario,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *EmitirBoletoRequest) Reset() {
+	*x = EmitirBoletoRequest{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmitirBoletoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmitirBoletoRequest) ProtoMessage() {}
+
+func (x *EmitirBoletoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmitirBoletoRequest.ProtoReflect.Descriptor instead.
+func (*EmitirBoletoRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EmitirBoletoRequest) GetIdProposta() string {
+	if x != nil {
+		return x.IdProposta
+	}
+	return ""
+}
+
+func (x *EmitirBoletoRequest) GetNossoNumero() string {
+	if x != nil {
+		return x.NossoNumero
+	}
+	return ""
+}
+
+func (x *EmitirBoletoRequest) GetValor() int64 {
+	if x != nil {
+		return x.Valor
+	}
+	return 0
+}
+
+func (x *EmitirBoletoRequest) GetDataVencimento() string {
+	if x != nil {
+		return x.DataVencimento
+	}
+	return ""
+}
+
+func (x *EmitirBoletoRequest) GetBeneficiario() string {
+	if x != nil {
+		return x.Beneficiario
+	}
+	return ""
+}
+
+type Atestado struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CodigoBanco   string                 `protobuf:"bytes,1,opt,name=codigo_banco,json=codigoBanco,proto3" json:"codigo_banco,omitempty"`
+	NossoNumero   string                 `protobuf:"bytes,2,opt,name=nosso_numero,json=nossoNumero,proto3" json:"nosso_numero,omitempty"`
+	Valor         int64                  `protobuf:"varint,3,opt,name=valor,proto3" json:"valor,omitempty"`
+	DataPagamento string                 `protobuf:"bytes,4,opt,name=data_pagamento,json=dataPagamento,proto3" json:"data_pagamento,omitempty"`
+	Assinatura    string                 `protobuf:"bytes,5,opt,name=assinatura,proto3" json:"assinatura,omitempty"`
+	EndToEndId    string                 `protobuf:"bytes,6,opt,name=end_to_end_id,json=endToEndId,proto3" json:"end_to_end_id,omitempty"`
+	Txid          string                 `protobuf:"bytes,7,opt,name=txid,proto3" json:"txid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Atestado) Reset() {
+	*x = Atestado{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Atestado) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Atestado) ProtoMessage() {}
+
+func (x *Atestado) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Atestado.ProtoReflect.Descriptor instead.
+func (*Atestado) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Atestado) GetCodigoBanco() string {
+	if x != nil {
+		return x.CodigoBanco
+	}
+	return ""
+}
+
+func (x *Atestado) GetNossoNumero() string {
+	if x != nil {
+		return x.NossoNumero
+	}
+	return ""
+}
+
+func (x *Atestado) GetValor() int64 {
+	if x != nil {
+		return x.Valor
+	}
+	return 0
+}
+
+func (x *Atestado) GetDataPagamento() string {
+	if x != nil {
+		return x.DataPagamento
+	}
+	return ""
+}
+
+func (x *Atestado) GetAssinatura() string {
+	if x != nil {
+		return x.Assinatura
+	}
+	return ""
+}
+
+func (x *Atestado) GetEndToEndId() string {
+	if x != nil {
+		return x.EndToEndId
+	}
+	return ""
+}
+
+func (x *Atestado) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+type ConfirmarPagamentoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IdProposta    string                 `protobuf:"bytes,1,opt,name=id_proposta,json=idProposta,proto3" json:"id_proposta,omitempty"`
+	Atestado      *Atestado              `protobuf:"bytes,2,opt,name=atestado,proto3" json:"atestado,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmarPagamentoRequest) Reset() {
+	*x = ConfirmarPagamentoRequest{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmarPagamentoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmarPagamentoRequest) ProtoMessage() {}
+
+func (x *ConfirmarPagamentoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmarPagamentoRequest.ProtoReflect.Descriptor instead.
+func (*ConfirmarPagamentoRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ConfirmarPagamentoRequest) GetIdProposta() string {
+	if x != nil {
+		return x.IdProposta
+	}
+	return ""
+}
+
+func (x *ConfirmarPagamentoRequest) GetAtestado() *Atestado {
+	if x != nil {
+		return x.Atestado
+	}
+	return nil
+}
+
+type CancelarPropostaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IdProposta    string                 `protobuf:"bytes,1,opt,name=id_proposta,json=idProposta,proto3" json:"id_proposta,omitempty"`
+	Motivo        string                 `protobuf:"bytes,2,opt,name=motivo,proto3" json:"motivo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelarPropostaRequest) Reset() {
+	*x = CancelarPropostaRequest{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelarPropostaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelarPropostaRequest) ProtoMessage() {}
+
+func (x *CancelarPropostaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelarPropostaRequest.ProtoReflect.Descriptor instead.
+func (*CancelarPropostaRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CancelarPropostaRequest) GetIdProposta() string {
+	if x != nil {
+		return x.IdProposta
+	}
+	return ""
+}
+
+func (x *CancelarPropostaRequest) GetMotivo() string {
+	if x != nil {
+		return x.Motivo
+	}
+	return ""
+}
+
+type Transacao struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TxId          string                 `protobuf:"bytes,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Pendente      bool                   `protobuf:"varint,2,opt,name=pendente,proto3" json:"pendente,omitempty"`
+	Resposta      string                 `protobuf:"bytes,3,opt,name=resposta,proto3" json:"resposta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transacao) Reset() {
+	*x = Transacao{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transacao) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transacao) ProtoMessage() {}
+
+func (x *Transacao) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transacao.ProtoReflect.Descriptor instead.
+func (*Transacao) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Transacao) GetTxId() string {
+	if x != nil {
+		return x.TxId
+	}
+	return ""
+}
+
+func (x *Transacao) GetPendente() bool {
+	if x != nil {
+		return x.Pendente
+	}
+	return false
+}
+
+func (x *Transacao) GetResposta() string {
+	if x != nil {
+		return x.Resposta
+	}
+	return ""
+}
+
+type AcompanharPropostaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IdProposta    string                 `protobuf:"bytes,1,opt,name=id_proposta,json=idProposta,proto3" json:"id_proposta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcompanharPropostaRequest) Reset() {
+	*x = AcompanharPropostaRequest{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcompanharPropostaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcompanharPropostaRequest) ProtoMessage() {}
+
+func (x *AcompanharPropostaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcompanharPropostaRequest.ProtoReflect.Descriptor instead.
+func (*AcompanharPropostaRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AcompanharPropostaRequest) GetIdProposta() string {
+	if x != nil {
+		return x.IdProposta
+	}
+	return ""
+}
+
+type Atualizacao struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tipo          string                 `protobuf:"bytes,1,opt,name=tipo,proto3" json:"tipo,omitempty"`
+	TxId          string                 `protobuf:"bytes,2,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Horario       string                 `protobuf:"bytes,3,opt,name=horario,proto3" json:"horario,omitempty"`
+	Proposta      *Proposta              `protobuf:"bytes,4,opt,name=proposta,proto3" json:"proposta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Atualizacao) Reset() {
+	*x = Atualizacao{}
+	mi := &file_grpcapi_propostas_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Atualizacao) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Atualizacao) ProtoMessage() {}
+
+func (x *Atualizacao) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_propostas_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Atualizacao.ProtoReflect.Descriptor instead.
+func (*Atualizacao) Descriptor() ([]byte, []int) {
+	return file_grpcapi_propostas_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Atualizacao) GetTipo() string {
+	if x != nil {
+		return x.Tipo
+	}
+	return ""
+}
+
+func (x *Atualizacao) GetTxId() string {
+	if x != nil {
+		return x.TxId
+	}
+	return ""
+}
+
+func (x *Atualizacao) GetHorario() string {
+	if x != nil {
+		return x.Horario
+	}
+	return ""
+}
+
+func (x *Atualizacao) GetProposta() *Proposta {
+	if x != nil {
+		return x.Proposta
+	}
+	return nil
+}
+
+var File_grpcapi_propostas_proto protoreflect.FileDescriptor
+
+const file_grpcapi_propostas_proto_rawDesc = "" +
+	"\n" +
+	"\x17grpcapi/propostas.proto\x12\x0edojo.propostas\"\xf8\x03\n" +
+	"\bProposta\x12\x1f\n" +
+	"\vid_proposta\x18\x01 \x01(\tR\n" +
+	"idProposta\x12\x1f\n" +
+	"\vcpf_pagador\x18\x02 \x01(\tR\n" +
+	"cpfPagador\x12'\n" +
+	"\x0fpagador_aceitou\x18\x03 \x01(\bR\x0epagadorAceitou\x121\n" +
+	"\x14beneficiario_aceitou\x18\x04 \x01(\bR\x13beneficiarioAceitou\x12\x1f\n" +
+	"\vboleto_pago\x18\x05 \x01(\bR\n" +
+	"boletoPago\x12!\n" +
+	"\fnosso_numero\x18\x06 \x01(\tR\vnossoNumero\x12\x14\n" +
+	"\x05valor\x18\a \x01(\x03R\x05valor\x12%\n" +
+	"\x0edata_pagamento\x18\b \x01(\tR\rdataPagamento\x12\x1c\n" +
+	"\tcancelada\x18\t \x01(\bR\tcancelada\x12\x16\n" +
+	"\x06status\x18\n" +
+	" \x01(\tR\x06status\x12'\n" +
+	"\x0fforma_pagamento\x18\v \x01(\tR\x0eformaPagamento\x12!\n" +
+	"\rend_to_end_id\x18\f \x01(\tR\n" +
+	"endToEndId\x12'\n" +
+	"\x0fdata_vencimento\x18\r \x01(\tR\x0edataVencimento\x12\"\n" +
+	"\fbeneficiario\x18\x0e \x01(\tR\fbeneficiario\"\x92\x02\n" +
+	"\x18RegistrarPropostaRequest\x12\x1f\n" +
+	"\vid_proposta\x18\x01 \x01(\tR\n" +
+	"idProposta\x12\x1f\n" +
+	"\vcpf_pagador\x18\x02 \x01(\tR\n" +
+	"cpfPagador\x12'\n" +
+	"\x0fpagador_aceitou\x18\x03 \x01(\bR\x0epagadorAceitou\x121\n" +
+	"\x14beneficiario_aceitou\x18\x04 \x01(\bR\x13beneficiarioAceitou\x12\x1f\n" +
+	"\vboleto_pago\x18\x05 \x01(\bR\n" +
+	"boletoPago\x12!\n" +
+	"\fnosso_numero\x18\x06 \x01(\tR\vnossoNumero\x12\x14\n" +
+	"\x05valor\x18\a \x01(\x03R\x05valor\";\n" +
+	"\x18ConsultarPropostaRequest\x12\x1f\n" +
+	"\vid_proposta\x18\x01 \x01(\tR\n" +
+	"idProposta\"O\n" +
+	"\x16AceitarPropostaRequest\x12\x1f\n" +
+	"\vid_proposta\x18\x01 \x01(\tR\n" +
+	"idProposta\x12\x14\n" +
+	"\x05parte\x18\x02 \x01(\tR\x05parte\"\xbc\x01\n" +
+	"\x13EmitirBoletoRequest\x12\x1f\n" +
+	"\vid_proposta\x18\x01 \x01(\tR\n" +
+	"idProposta\x12!\n" +
+	"\fnosso_numero\x18\x02 \x01(\tR\vnossoNumero\x12\x14\n" +
+	"\x05valor\x18\x03 \x01(\x03R\x05valor\x12'\n" +
+	"\x0fdata_vencimento\x18\x04 \x01(\tR\x0edataVencimento\x12\"\n" +
+	"\fbeneficiario\x18\x05 \x01(\tR\fbeneficiario\"\xe4\x01\n" +
+	"\bAtestado\x12!\n" +
+	"\fcodigo_banco\x18\x01 \x01(\tR\vcodigoBanco\x12!\n" +
+	"\fnosso_numero\x18\x02 \x01(\tR\vnossoNumero\x12\x14\n" +
+	"\x05valor\x18\x03 \x01(\x03R\x05valor\x12%\n" +
+	"\x0edata_pagamento\x18\x04 \x01(\tR\rdataPagamento\x12\x1e\n" +
+	"\n" +
+	"assinatura\x18\x05 \x01(\tR\n" +
+	"assinatura\x12!\n" +
+	"\rend_to_end_id\x18\x06 \x01(\tR\n" +
+	"endToEndId\x12\x12\n" +
+	"\x04txid\x18\a \x01(\tR\x04txid\"r\n" +
+	"\x19ConfirmarPagamentoRequest\x12\x1f\n" +
+	"\vid_proposta\x18\x01 \x01(\tR\n" +
+	"idProposta\x124\n" +
+	"\batestado\x18\x02 \x01(\v2\x18.dojo.propostas.AtestadoR\batestado\"R\n" +
+	"\x17CancelarPropostaRequest\x12\x1f\n" +
+	"\vid_proposta\x18\x01 \x01(\tR\n" +
+	"idProposta\x12\x16\n" +
+	"\x06motivo\x18\x02 \x01(\tR\x06motivo\"X\n" +
+	"\tTransacao\x12\x13\n" +
+	"\x05tx_id\x18\x01 \x01(\tR\x04txId\x12\x1a\n" +
+	"\bpendente\x18\x02 \x01(\bR\bpendente\x12\x1a\n" +
+	"\bresposta\x18\x03 \x01(\tR\bresposta\"<\n" +
+	"\x19AcompanharPropostaRequest\x12\x1f\n" +
+	"\vid_proposta\x18\x01 \x01(\tR\n" +
+	"idProposta\"\x86\x01\n" +
+	"\vAtualizacao\x12\x12\n" +
+	"\x04tipo\x18\x01 \x01(\tR\x04tipo\x12\x13\n" +
+	"\x05tx_id\x18\x02 \x01(\tR\x04txId\x12\x18\n" +
+	"\ahorario\x18\x03 \x01(\tR\ahorario\x124\n" +
+	"\bproposta\x18\x04 \x01(\v2\x18.dojo.propostas.PropostaR\bproposta2\xf8\x04\n" +
+	"\tPropostas\x12X\n" +
+	"\x11RegistrarProposta\x12(.dojo.propostas.RegistrarPropostaRequest\x1a\x19.dojo.propostas.Transacao\x12W\n" +
+	"\x11ConsultarProposta\x12(.dojo.propostas.ConsultarPropostaRequest\x1a\x18.dojo.propostas.Proposta\x12T\n" +
+	"\x0fAceitarProposta\x12&.dojo.propostas.AceitarPropostaRequest\x1a\x19.dojo.propostas.Transacao\x12N\n" +
+	"\fEmitirBoleto\x12#.dojo.propostas.EmitirBoletoRequest\x1a\x19.dojo.propostas.Transacao\x12Z\n" +
+	"\x12ConfirmarPagamento\x12).dojo.propostas.ConfirmarPagamentoRequest\x1a\x19.dojo.propostas.Transacao\x12V\n" +
+	"\x10CancelarProposta\x12'.dojo.propostas.CancelarPropostaRequest\x1a\x19.dojo.propostas.Transacao\x12^\n" +
+	"\x12AcompanharProposta\x12).dojo.propostas.AcompanharPropostaRequest\x1a\x1b.dojo.propostas.Atualizacao0\x01B)Z'github.com/CaueP/BlockchainDojo/grpcapib\x06proto3"
+
+var (
+	file_grpcapi_propostas_proto_rawDescOnce sync.Once
+	file_grpcapi_propostas_proto_rawDescData []byte
+)
+
+func file_grpcapi_propostas_proto_rawDescGZIP() []byte {
+	file_grpcapi_propostas_proto_rawDescOnce.Do(func() {
+		file_grpcapi_propostas_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_grpcapi_propostas_proto_rawDesc), len(file_grpcapi_propostas_proto_rawDesc)))
+	})
+	return file_grpcapi_propostas_proto_rawDescData
+}
+
+var file_grpcapi_propostas_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_grpcapi_propostas_proto_goTypes = []any{
+	(*Proposta)(nil),                  // 0: dojo.propostas.Proposta
+	(*RegistrarPropostaRequest)(nil),  // 1: dojo.propostas.RegistrarPropostaRequest
+	(*ConsultarPropostaRequest)(nil),  // 2: dojo.propostas.ConsultarPropostaRequest
+	(*AceitarPropostaRequest)(nil),    // 3: dojo.propostas.AceitarPropostaRequest
+	(*EmitirBoletoRequest)(nil),       // 4: dojo.propostas.EmitirBoletoRequest
+	(*Atestado)(nil),                  // 5: dojo.propostas.Atestado
+	(*ConfirmarPagamentoRequest)(nil), // 6: dojo.propostas.ConfirmarPagamentoRequest
+	(*CancelarPropostaRequest)(nil),   // 7: dojo.propostas.CancelarPropostaRequest
+	(*Transacao)(nil),                 // 8: dojo.propostas.Transacao
+	(*AcompanharPropostaRequest)(nil), // 9: dojo.propostas.AcompanharPropostaRequest
+	(*Atualizacao)(nil),               // 10: dojo.propostas.Atualizacao
+}
+var file_grpcapi_propostas_proto_depIdxs = []int32{
+	5,  // 0: dojo.propostas.ConfirmarPagamentoRequest.atestado:type_name -> dojo.propostas.Atestado
+	0,  // 1: dojo.propostas.Atualizacao.proposta:type_name -> dojo.propostas.Proposta
+	1,  // 2: dojo.propostas.Propostas.RegistrarProposta:input_type -> dojo.propostas.RegistrarPropostaRequest
+	2,  // 3: dojo.propostas.Propostas.ConsultarProposta:input_type -> dojo.propostas.ConsultarPropostaRequest
+	3,  // 4: dojo.propostas.Propostas.AceitarProposta:input_type -> dojo.propostas.AceitarPropostaRequest
+	4,  // 5: dojo.propostas.Propostas.EmitirBoleto:input_type -> dojo.propostas.EmitirBoletoRequest
+	6,  // 6: dojo.propostas.Propostas.ConfirmarPagamento:input_type -> dojo.propostas.ConfirmarPagamentoRequest
+	7,  // 7: dojo.propostas.Propostas.CancelarProposta:input_type -> dojo.propostas.CancelarPropostaRequest
+	9,  // 8: dojo.propostas.Propostas.AcompanharProposta:input_type -> dojo.propostas.AcompanharPropostaRequest
+	8,  // 9: dojo.propostas.Propostas.RegistrarProposta:output_type -> dojo.propostas.Transacao
+	0,  // 10: dojo.propostas.Propostas.ConsultarProposta:output_type -> dojo.propostas.Proposta
+	8,  // 11: dojo.propostas.Propostas.AceitarProposta:output_type -> dojo.propostas.Transacao
+	8,  // 12: dojo.propostas.Propostas.EmitirBoleto:output_type -> dojo.propostas.Transacao
+	8,  // 13: dojo.propostas.Propostas.ConfirmarPagamento:output_type -> dojo.propostas.Transacao
+	8,  // 14: dojo.propostas.Propostas.CancelarProposta:output_type -> dojo.propostas.Transacao
+	10, // 15: dojo.propostas.Propostas.AcompanharProposta:output_type -> dojo.propostas.Atualizacao
+	9,  // [9:16] is the sub-list for method output_type
+	2,  // [2:9] is the sub-list for method input_type
+	2,  // [2:2] is the sub-list for extension type_name
+	2,  // [2:2] is the sub-list for extension extendee
+	0,  // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_grpcapi_propostas_proto_init() }
+func file_grpcapi_propostas_proto_init() {
+	if File_grpcapi_propostas_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_grpcapi_propostas_proto_rawDesc), len(file_grpcapi_propostas_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_grpcapi_propostas_proto_goTypes,
+		DependencyIndexes: file_grpcapi_propostas_proto_depIdxs,
+		MessageInfos:      file_grpcapi_propostas_proto_msgTypes,
+	}.Build()
+	File_grpcapi_propostas_proto = out.File
+	file_grpcapi_propostas_proto_goTypes = nil
+	file_grpcapi_propostas_proto_depIdxs = nil
+}
@@ -0,0 +1,349 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.12
+// source: grpcapi/propostas.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// PropostasClient is the client API for Propostas service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PropostasClient interface {
+	RegistrarProposta(ctx context.Context, in *RegistrarPropostaRequest, opts ...grpc.CallOption) (*Transacao, error)
+	ConsultarProposta(ctx context.Context, in *ConsultarPropostaRequest, opts ...grpc.CallOption) (*Proposta, error)
+	AceitarProposta(ctx context.Context, in *AceitarPropostaRequest, opts ...grpc.CallOption) (*Transacao, error)
+	EmitirBoleto(ctx context.Context, in *EmitirBoletoRequest, opts ...grpc.CallOption) (*Transacao, error)
+	ConfirmarPagamento(ctx context.Context, in *ConfirmarPagamentoRequest, opts ...grpc.CallOption) (*Transacao, error)
+	CancelarProposta(ctx context.Context, in *CancelarPropostaRequest, opts ...grpc.CallOption) (*Transacao, error)
+	AcompanharProposta(ctx context.Context, in *AcompanharPropostaRequest, opts ...grpc.CallOption) (Propostas_AcompanharPropostaClient, error)
+}
+
+type propostasClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPropostasClient(cc grpc.ClientConnInterface) PropostasClient {
+	return &propostasClient{cc}
+}
+
+func (c *propostasClient) RegistrarProposta(ctx context.Context, in *RegistrarPropostaRequest, opts ...grpc.CallOption) (*Transacao, error) {
+	out := new(Transacao)
+	err := c.cc.Invoke(ctx, "/dojo.propostas.Propostas/RegistrarProposta", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *propostasClient) ConsultarProposta(ctx context.Context, in *ConsultarPropostaRequest, opts ...grpc.CallOption) (*Proposta, error) {
+	out := new(Proposta)
+	err := c.cc.Invoke(ctx, "/dojo.propostas.Propostas/ConsultarProposta", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *propostasClient) AceitarProposta(ctx context.Context, in *AceitarPropostaRequest, opts ...grpc.CallOption) (*Transacao, error) {
+	out := new(Transacao)
+	err := c.cc.Invoke(ctx, "/dojo.propostas.Propostas/AceitarProposta", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *propostasClient) EmitirBoleto(ctx context.Context, in *EmitirBoletoRequest, opts ...grpc.CallOption) (*Transacao, error) {
+	out := new(Transacao)
+	err := c.cc.Invoke(ctx, "/dojo.propostas.Propostas/EmitirBoleto", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *propostasClient) ConfirmarPagamento(ctx context.Context, in *ConfirmarPagamentoRequest, opts ...grpc.CallOption) (*Transacao, error) {
+	out := new(Transacao)
+	err := c.cc.Invoke(ctx, "/dojo.propostas.Propostas/ConfirmarPagamento", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *propostasClient) CancelarProposta(ctx context.Context, in *CancelarPropostaRequest, opts ...grpc.CallOption) (*Transacao, error) {
+	out := new(Transacao)
+	err := c.cc.Invoke(ctx, "/dojo.propostas.Propostas/CancelarProposta", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *propostasClient) AcompanharProposta(ctx context.Context, in *AcompanharPropostaRequest, opts ...grpc.CallOption) (Propostas_AcompanharPropostaClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Propostas_ServiceDesc.Streams[0], "/dojo.propostas.Propostas/AcompanharProposta", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &propostasAcompanharPropostaClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Propostas_AcompanharPropostaClient interface {
+	Recv() (*Atualizacao, error)
+	grpc.ClientStream
+}
+
+type propostasAcompanharPropostaClient struct {
+	grpc.ClientStream
+}
+
+func (x *propostasAcompanharPropostaClient) Recv() (*Atualizacao, error) {
+	m := new(Atualizacao)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PropostasServer is the server API for Propostas service.
+// All implementations must embed UnimplementedPropostasServer
+// for forward compatibility
+type PropostasServer interface {
+	RegistrarProposta(context.Context, *RegistrarPropostaRequest) (*Transacao, error)
+	ConsultarProposta(context.Context, *ConsultarPropostaRequest) (*Proposta, error)
+	AceitarProposta(context.Context, *AceitarPropostaRequest) (*Transacao, error)
+	EmitirBoleto(context.Context, *EmitirBoletoRequest) (*Transacao, error)
+	ConfirmarPagamento(context.Context, *ConfirmarPagamentoRequest) (*Transacao, error)
+	CancelarProposta(context.Context, *CancelarPropostaRequest) (*Transacao, error)
+	AcompanharProposta(*AcompanharPropostaRequest, Propostas_AcompanharPropostaServer) error
+	mustEmbedUnimplementedPropostasServer()
+}
+
+// UnimplementedPropostasServer must be embedded to have forward compatible implementations.
+type UnimplementedPropostasServer struct {
+}
+
+func (UnimplementedPropostasServer) RegistrarProposta(context.Context, *RegistrarPropostaRequest) (*Transacao, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegistrarProposta not implemented")
+}
+func (UnimplementedPropostasServer) ConsultarProposta(context.Context, *ConsultarPropostaRequest) (*Proposta, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConsultarProposta not implemented")
+}
+func (UnimplementedPropostasServer) AceitarProposta(context.Context, *AceitarPropostaRequest) (*Transacao, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AceitarProposta not implemented")
+}
+func (UnimplementedPropostasServer) EmitirBoleto(context.Context, *EmitirBoletoRequest) (*Transacao, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EmitirBoleto not implemented")
+}
+func (UnimplementedPropostasServer) ConfirmarPagamento(context.Context, *ConfirmarPagamentoRequest) (*Transacao, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmarPagamento not implemented")
+}
+func (UnimplementedPropostasServer) CancelarProposta(context.Context, *CancelarPropostaRequest) (*Transacao, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelarProposta not implemented")
+}
+func (UnimplementedPropostasServer) AcompanharProposta(*AcompanharPropostaRequest, Propostas_AcompanharPropostaServer) error {
+	return status.Errorf(codes.Unimplemented, "method AcompanharProposta not implemented")
+}
+func (UnimplementedPropostasServer) mustEmbedUnimplementedPropostasServer() {}
+
+// UnsafePropostasServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PropostasServer will
+// result in compilation errors.
+type UnsafePropostasServer interface {
+	mustEmbedUnimplementedPropostasServer()
+}
+
+func RegisterPropostasServer(s grpc.ServiceRegistrar, srv PropostasServer) {
+	s.RegisterService(&Propostas_ServiceDesc, srv)
+}
+
+func _Propostas_RegistrarProposta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegistrarPropostaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PropostasServer).RegistrarProposta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dojo.propostas.Propostas/RegistrarProposta",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PropostasServer).RegistrarProposta(ctx, req.(*RegistrarPropostaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Propostas_ConsultarProposta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConsultarPropostaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PropostasServer).ConsultarProposta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dojo.propostas.Propostas/ConsultarProposta",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PropostasServer).ConsultarProposta(ctx, req.(*ConsultarPropostaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Propostas_AceitarProposta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AceitarPropostaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PropostasServer).AceitarProposta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dojo.propostas.Propostas/AceitarProposta",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PropostasServer).AceitarProposta(ctx, req.(*AceitarPropostaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Propostas_EmitirBoleto_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmitirBoletoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PropostasServer).EmitirBoleto(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dojo.propostas.Propostas/EmitirBoleto",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PropostasServer).EmitirBoleto(ctx, req.(*EmitirBoletoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Propostas_ConfirmarPagamento_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmarPagamentoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PropostasServer).ConfirmarPagamento(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dojo.propostas.Propostas/ConfirmarPagamento",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PropostasServer).ConfirmarPagamento(ctx, req.(*ConfirmarPagamentoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Propostas_CancelarProposta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelarPropostaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PropostasServer).CancelarProposta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dojo.propostas.Propostas/CancelarProposta",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PropostasServer).CancelarProposta(ctx, req.(*CancelarPropostaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Propostas_AcompanharProposta_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AcompanharPropostaRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PropostasServer).AcompanharProposta(m, &propostasAcompanharPropostaServer{stream})
+}
+
+type Propostas_AcompanharPropostaServer interface {
+	Send(*Atualizacao) error
+	grpc.ServerStream
+}
+
+type propostasAcompanharPropostaServer struct {
+	grpc.ServerStream
+}
+
+func (x *propostasAcompanharPropostaServer) Send(m *Atualizacao) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Propostas_ServiceDesc is the grpc.ServiceDesc for Propostas service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Propostas_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dojo.propostas.Propostas",
+	HandlerType: (*PropostasServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegistrarProposta",
+			Handler:    _Propostas_RegistrarProposta_Handler,
+		},
+		{
+			MethodName: "ConsultarProposta",
+			Handler:    _Propostas_ConsultarProposta_Handler,
+		},
+		{
+			MethodName: "AceitarProposta",
+			Handler:    _Propostas_AceitarProposta_Handler,
+		},
+		{
+			MethodName: "EmitirBoleto",
+			Handler:    _Propostas_EmitirBoleto_Handler,
+		},
+		{
+			MethodName: "ConfirmarPagamento",
+			Handler:    _Propostas_ConfirmarPagamento_Handler,
+		},
+		{
+			MethodName: "CancelarProposta",
+			Handler:    _Propostas_CancelarProposta_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AcompanharProposta",
+			Handler:       _Propostas_AcompanharProposta_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "grpcapi/propostas.proto",
+}
@@ -0,0 +1,212 @@
+/*
+Descrição: serviço gRPC do chaincode de propostas
+As requisições são convertidas nos argumentos posicionais do chaincode e validadas
+com o pacote validation antes de chegar ao ledger, de modo que os erros sejam os
+mesmos do chaincode e do gateway REST.
+*/
+
+// Package grpcapi implementa o serviço gRPC Propostas (ver propostas.proto).
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/CaueP/BlockchainDojo/gateway"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/validation"
+)
+
+// MetadataIDRequisicao: chave dos metadados gRPC com o ID da requisição, o equivalente
+// ao cabeçalho Idempotency-Key do gateway
+const MetadataIDRequisicao = "idempotency-key"
+
+// Servidor - implementação de PropostasServer sobre um ledger.Ledger
+type Servidor struct {
+	UnimplementedPropostasServer
+
+	ledger  ledger.Ledger
+	difusor *Difusor
+}
+
+// NovoServidor: cria o serviço sobre o ledger informado. O difusor fornece os
+// eventos utilizados por AcompanharProposta.
+func NovoServidor(l ledger.Ledger, d *Difusor) *Servidor {
+	return &Servidor{ledger: l, difusor: d}
+}
+
+// RegistrarProposta - registrarProposta
+func (s *Servidor) RegistrarProposta(ctx context.Context, req *RegistrarPropostaRequest) (*Transacao, error) {
+	args := []string{
+		req.IdProposta,
+		req.CpfPagador,
+		strconv.FormatBool(req.PagadorAceitou),
+		strconv.FormatBool(req.BeneficiarioAceitou),
+		strconv.FormatBool(req.BoletoPago),
+	}
+	if req.NossoNumero != "" {
+		args = append(args, req.NossoNumero, strconv.FormatInt(req.Valor, 10))
+	}
+	return s.invoke(ctx, "registrarProposta", args)
+}
+
+// ConsultarProposta - consultarProposta
+func (s *Servidor) ConsultarProposta(ctx context.Context, req *ConsultarPropostaRequest) (*Proposta, error) {
+	return s.consultar(req.IdProposta)
+}
+
+// AceitarProposta - aceitarProposta
+func (s *Servidor) AceitarProposta(ctx context.Context, req *AceitarPropostaRequest) (*Transacao, error) {
+	return s.invoke(ctx, "aceitarProposta", []string{req.IdProposta, req.Parte})
+}
+
+// EmitirBoleto - emitirBoleto
+func (s *Servidor) EmitirBoleto(ctx context.Context, req *EmitirBoletoRequest) (*Transacao, error) {
+	args := []string{req.IdProposta, req.NossoNumero, strconv.FormatInt(req.Valor, 10)}
+	if req.DataVencimento != "" || req.Beneficiario != "" {
+		args = append(args, req.DataVencimento)
+	}
+	if req.Beneficiario != "" {
+		args = append(args, req.Beneficiario)
+	}
+	return s.invoke(ctx, "emitirBoleto", args)
+}
+
+// ConfirmarPagamento - confirmarPagamento
+func (s *Servidor) ConfirmarPagamento(ctx context.Context, req *ConfirmarPagamentoRequest) (*Transacao, error) {
+	a := req.Atestado
+	if a == nil {
+		a = &Atestado{}
+	}
+	campos := map[string]interface{}{
+		"codigo_banco":   a.CodigoBanco,
+		"nosso_numero":   a.NossoNumero,
+		"valor":          a.Valor,
+		"data_pagamento": a.DataPagamento,
+		"assinatura":     a.Assinatura,
+	}
+	// atestado do pagamento PIX
+	if a.EndToEndId != "" || a.Txid != "" {
+		campos["end_to_end_id"] = a.EndToEndId
+		campos["txid"] = a.Txid
+	}
+	atestado, err := json.Marshal(campos)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return s.invoke(ctx, "confirmarPagamento", []string{req.IdProposta, string(atestado)})
+}
+
+// CancelarProposta - cancelarProposta
+func (s *Servidor) CancelarProposta(ctx context.Context, req *CancelarPropostaRequest) (*Transacao, error) {
+	return s.invoke(ctx, "cancelarProposta", []string{req.IdProposta, req.Motivo})
+}
+
+// AcompanharProposta - envia o estado atual da proposta e uma atualização a cada evento
+// recebido do difusor, até o cliente cancelar a chamada
+func (s *Servidor) AcompanharProposta(req *AcompanharPropostaRequest, stream Propostas_AcompanharPropostaServer) error {
+	if s.difusor == nil {
+		return status.Error(codes.Unimplemented, "Acompanhamento de propostas não configurado")
+	}
+
+	// assina antes de consultar o estado atual, para não perder eventos entre as duas operações
+	eventos, cancelar := s.difusor.Assinar(req.IdProposta)
+	defer cancelar()
+
+	atual, err := s.consultar(req.IdProposta)
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&Atualizacao{Proposta: atual}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-eventos:
+			if !ok {
+				return nil
+			}
+			proposta, err := s.consultar(req.IdProposta)
+			if err != nil {
+				return err
+			}
+			err = stream.Send(&Atualizacao{
+				Tipo:     string(ev.Tipo),
+				TxId:     ev.TxID,
+				Horario:  ev.Horario,
+				Proposta: proposta,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// consultar: executa consultarProposta e converte o JSON retornado
+func (s *Servidor) consultar(idProposta string) (*Proposta, error) {
+	args := []string{idProposta}
+	if err := validation.Validar("consultarProposta", args); err != nil {
+		return nil, erroGRPC(err)
+	}
+	payload, err := s.ledger.Query("consultarProposta", args)
+	if err != nil {
+		return nil, erroGRPC(err)
+	}
+	var p Proposta
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, status.Errorf(codes.Internal, "Resposta inválida de consultarProposta: %s", err)
+	}
+	return &p, nil
+}
+
+// invoke: valida os argumentos com as mesmas regras do chaincode e executa a função.
+// O ID da requisição nos metadados (MetadataIDRequisicao) é repassado ao chaincode:
+// as repetições retornam a resposta original.
+func (s *Servidor) invoke(ctx context.Context, funcao string, args []string) (*Transacao, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(MetadataIDRequisicao); len(ids) > 0 && ids[0] != "" {
+			args = validation.ComIDRequisicao(ids[0], args)
+		}
+	}
+	if err := validation.Validar(funcao, args); err != nil {
+		return nil, erroGRPC(err)
+	}
+	res, err := s.ledger.Invoke(funcao, args)
+	if err != nil {
+		return nil, erroGRPC(err)
+	}
+	return &Transacao{TxId: res.TxID, Pendente: res.Pendente, Resposta: string(res.Payload)}, nil
+}
+
+// códigos gRPC correspondentes aos status HTTP do gateway
+var codigosHTTP = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.FailedPrecondition,
+	http.StatusUnprocessableEntity: codes.InvalidArgument,
+	http.StatusMethodNotAllowed:    codes.Unimplemented,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusBadGateway:          codes.Unavailable,
+}
+
+// erroGRPC: converte o erro do ledger ou da validação em um status gRPC, com o envelope
+// de erro (JSON) como mensagem
+func erroGRPC(err error) error {
+	e := gateway.ErroLedger(err)
+	codigo, ok := codigosHTTP[gateway.StatusCodigo(e.Codigo)]
+	if !ok {
+		codigo = codes.Internal
+	}
+	return status.Error(codigo, e.Error())
+}
@@ -0,0 +1,226 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/CaueP/BlockchainDojo/chaincode/propostas"
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/simulator"
+)
+
+// ledgerFalso - registra as chamadas e responde com o payload ou o erro programado
+type ledgerFalso struct {
+	chamadas []string
+	payload  []byte
+	err      error
+}
+
+func (l *ledgerFalso) Invoke(funcao string, args []string) (ledger.Resultado, error) {
+	l.chamadas = append(l.chamadas, fmt.Sprintf("invoke %s %q", funcao, args))
+	if l.err != nil {
+		return ledger.Resultado{}, l.err
+	}
+	return ledger.Resultado{TxID: "tx1", Payload: l.payload}, nil
+}
+
+func (l *ledgerFalso) Query(funcao string, args []string) ([]byte, error) {
+	l.chamadas = append(l.chamadas, fmt.Sprintf("query %s %q", funcao, args))
+	return l.payload, l.err
+}
+
+func TestArgumentos(t *testing.T) {
+	ctx := context.Background()
+	casos := []struct {
+		nome    string
+		chamar  func(*Servidor) error
+		chamada string
+	}{
+		{"RegistrarProposta", func(s *Servidor) error {
+			_, err := s.RegistrarProposta(ctx, &RegistrarPropostaRequest{IdProposta: "p1", CpfPagador: "111", BeneficiarioAceitou: true})
+			return err
+		}, `invoke registrarProposta ["p1" "111" "false" "true" "false"]`},
+		{"RegistrarProposta com boleto", func(s *Servidor) error {
+			_, err := s.RegistrarProposta(ctx, &RegistrarPropostaRequest{IdProposta: "p1", CpfPagador: "111", NossoNumero: "00000000001", Valor: 15000})
+			return err
+		}, `invoke registrarProposta ["p1" "111" "false" "false" "false" "00000000001" "15000"]`},
+		{"ConsultarProposta", func(s *Servidor) error {
+			_, err := s.ConsultarProposta(ctx, &ConsultarPropostaRequest{IdProposta: "p1"})
+			return err
+		}, `query consultarProposta ["p1"]`},
+		{"AceitarProposta", func(s *Servidor) error {
+			_, err := s.AceitarProposta(ctx, &AceitarPropostaRequest{IdProposta: "p1", Parte: "beneficiario"})
+			return err
+		}, `invoke aceitarProposta ["p1" "beneficiario"]`},
+		{"EmitirBoleto", func(s *Servidor) error {
+			_, err := s.EmitirBoleto(ctx, &EmitirBoletoRequest{IdProposta: "p1", NossoNumero: "00000000001", Valor: 15000})
+			return err
+		}, `invoke emitirBoleto ["p1" "00000000001" "15000"]`},
+		{"EmitirBoleto com vencimento e beneficiário", func(s *Servidor) error {
+			_, err := s.EmitirBoleto(ctx, &EmitirBoletoRequest{IdProposta: "p1", NossoNumero: "00000000001", Valor: 15000,
+				DataVencimento: "2026-11-30", Beneficiario: "12.345.678/0001-90"})
+			return err
+		}, `invoke emitirBoleto ["p1" "00000000001" "15000" "2026-11-30" "12.345.678/0001-90"]`},
+		{"EmitirBoleto só com beneficiário", func(s *Servidor) error {
+			_, err := s.EmitirBoleto(ctx, &EmitirBoletoRequest{IdProposta: "p1", NossoNumero: "00000000001", Valor: 15000, Beneficiario: "12.345.678/0001-90"})
+			return err
+		}, `invoke emitirBoleto ["p1" "00000000001" "15000" "" "12.345.678/0001-90"]`},
+		{"ConfirmarPagamento", func(s *Servidor) error {
+			_, err := s.ConfirmarPagamento(ctx, &ConfirmarPagamentoRequest{IdProposta: "p1", Atestado: &Atestado{
+				CodigoBanco: "001", NossoNumero: "00000000001", Valor: 15000, DataPagamento: "2026-11-20", Assinatura: "MEUCIQ==",
+			}})
+			return err
+		}, `invoke confirmarPagamento ["p1" "{\"assinatura\":\"MEUCIQ==\",\"codigo_banco\":\"001\",\"data_pagamento\":\"2026-11-20\",\"nosso_numero\":\"00000000001\",\"valor\":15000}"]`},
+		{"CancelarProposta", func(s *Servidor) error {
+			_, err := s.CancelarProposta(ctx, &CancelarPropostaRequest{IdProposta: "p1", Motivo: "desistência"})
+			return err
+		}, `invoke cancelarProposta ["p1" "desistência"]`},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			l := &ledgerFalso{payload: []byte(`{"id_proposta": "p1"}`)}
+			if err := c.chamar(NovoServidor(l, nil)); err != nil {
+				t.Fatal(err)
+			}
+			if len(l.chamadas) != 1 || l.chamadas[0] != c.chamada {
+				t.Fatalf("chamadas %q, esperada %s", l.chamadas, c.chamada)
+			}
+		})
+	}
+}
+
+func TestErros(t *testing.T) {
+	ctx := context.Background()
+	casos := []struct {
+		nome   string
+		req    *AceitarPropostaRequest
+		err    error // erro do ledger
+		codigo codes.Code
+		chamou bool
+	}{
+		{"argumento inválido", &AceitarPropostaRequest{IdProposta: "p1", Parte: "avalista"}, nil, codes.InvalidArgument, false},
+		{"proposta não encontrada", &AceitarPropostaRequest{IdProposta: "p1", Parte: "pagador"},
+			envelope.Novo(envelope.PropostaNaoEncontrada, "id", "p1"), codes.NotFound, true},
+		{"aceite já registrado", &AceitarPropostaRequest{IdProposta: "p1", Parte: "pagador"},
+			envelope.Novo(envelope.AceiteJaRegistrado, "id", "p1", "parte", "pagador"), codes.FailedPrecondition, true},
+		{"não autorizado", &AceitarPropostaRequest{IdProposta: "p1", Parte: "pagador"},
+			envelope.Novo(envelope.NaoAutorizado, "funcao", "aceitarProposta"), codes.PermissionDenied, true},
+		{"erro interno", &AceitarPropostaRequest{IdProposta: "p1", Parte: "pagador"},
+			envelope.Interno(errors.New("falha do stub")), codes.Internal, true},
+		{"peer indisponível", &AceitarPropostaRequest{IdProposta: "p1", Parte: "pagador"},
+			errors.New("connection refused"), codes.Unavailable, true},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			l := &ledgerFalso{err: c.err}
+			_, err := NovoServidor(l, nil).AceitarProposta(ctx, c.req)
+			if status.Code(err) != c.codigo {
+				t.Fatalf("erro %v, esperado o código %s", err, c.codigo)
+			}
+			if chamou := len(l.chamadas) > 0; chamou != c.chamou {
+				t.Fatalf("chamadas ao ledger %q", l.chamadas)
+			}
+			if _, ok := envelope.Decodificar(errors.New(status.Convert(err).Message())); !ok {
+				t.Fatalf("mensagem sem o envelope de erro: %s", err)
+			}
+		})
+	}
+}
+
+func TestConfirmarPagamentoPix(t *testing.T) {
+	ctx := context.Background()
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publica, err := oracle.CodificarChavePublica(&chave.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := simulator.Novo(&propostas.BoletoPropostaChaincode{})
+	if _, err := sim.Implantar("init", []string{`{"pix": {"chave": "12345678909", "nome": "BLOCKCHAIN DOJO", "cidade": "SAO PAULO"}}`, "001", string(publica)}); err != nil {
+		t.Fatal(err)
+	}
+	s := NovoServidor(sim, nil)
+	if _, err := s.RegistrarProposta(ctx, &RegistrarPropostaRequest{IdProposta: "p1", CpfPagador: "111.111.111-11", PagadorAceitou: true, BeneficiarioAceitou: true}); err != nil {
+		t.Fatal(err)
+	}
+	boleto := &EmitirBoletoRequest{IdProposta: "p1", NossoNumero: "00000000001", Valor: 15000, DataVencimento: "2026-11-30", Beneficiario: "12.345.678/0001-90"}
+	if _, err := s.EmitirBoleto(ctx, boleto); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.Invoke("registrarCobrancaPix", []string{"p1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := oracle.Atestado{CodigoBanco: "001", EndToEndID: "E00000000202611201430PIXDOJO0001", TxID: "p1", Valor: 15000, DataPagamento: "2026-11-20"}
+	if err := oracle.Assinar(chave, &a); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.ConfirmarPagamento(ctx, &ConfirmarPagamentoRequest{IdProposta: "p1", Atestado: &Atestado{
+		CodigoBanco: a.CodigoBanco, EndToEndId: a.EndToEndID, Txid: a.TxID, Valor: a.Valor, DataPagamento: a.DataPagamento, Assinatura: a.Assinatura,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := s.ConsultarProposta(ctx, &ConsultarPropostaRequest{IdProposta: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.BoletoPago || p.FormaPagamento != "pix" || p.EndToEndId != a.EndToEndID {
+		t.Fatalf("proposta %v, esperada paga por PIX com o ID fim a fim %s", p, a.EndToEndID)
+	}
+	if p.DataVencimento != boleto.DataVencimento || p.Beneficiario != boleto.Beneficiario {
+		t.Fatalf("proposta %v, esperados o vencimento e o beneficiário do boleto", p)
+	}
+}
+
+func TestRequisicaoRepetida(t *testing.T) {
+	sim := simulator.Novo(&propostas.BoletoPropostaChaincode{})
+	if _, err := sim.Implantar("init", []string{`{}`}); err != nil {
+		t.Fatal(err)
+	}
+	s := NovoServidor(sim, nil)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataIDRequisicao, "req-1"))
+	if _, err := s.RegistrarProposta(context.Background(), &RegistrarPropostaRequest{IdProposta: "p1", CpfPagador: "111.111.111-11", PagadorAceitou: true, BeneficiarioAceitou: true}); err != nil {
+		t.Fatal(err)
+	}
+	boleto := &EmitirBoletoRequest{IdProposta: "p1", NossoNumero: "00000000001", Valor: 15000}
+	original, err := s.EmitirBoleto(ctx, boleto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a repetição com o mesmo ID retorna a resposta original, sem emitir o boleto de novo
+	repetida, err := s.EmitirBoleto(ctx, boleto)
+	if err != nil {
+		t.Fatalf("repetição: %s", err)
+	}
+	if repetida.Resposta != original.Resposta {
+		t.Fatalf("resposta da repetição %q, esperada a original %q", repetida.Resposta, original.Resposta)
+	}
+	// sem o ID, a mesma chamada é uma nova emissão
+	_, err = s.EmitirBoleto(context.Background(), boleto)
+	if e, ok := envelope.Decodificar(errors.New(status.Convert(err).Message())); !ok || e.Codigo != envelope.BoletoJaEmitido {
+		t.Fatalf("erro %v, esperado %s", err, envelope.BoletoJaEmitido)
+	}
+	// o mesmo ID com outros argumentos é recusado
+	_, err = s.EmitirBoleto(ctx, &EmitirBoletoRequest{IdProposta: "p1", NossoNumero: "00000000002", Valor: 15000})
+	if e, ok := envelope.Decodificar(errors.New(status.Convert(err).Message())); !ok || e.Codigo != envelope.IDRequisicaoReutilizado {
+		t.Fatalf("erro %v, esperado %s", err, envelope.IDRequisicaoReutilizado)
+	}
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("código %s, esperado %s", status.Code(err), codes.FailedPrecondition)
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Descrição: cliente do chaincode de propostas utilizado pelas ferramentas fora da blockchain
+(gateway REST, gRPC, linha de comando). A interface Ledger permite trocar o peer real
+por um simulador em memória.
+*/
+
+// Package ledger define a interface de acesso ao chaincode de propostas e o
+// cliente da API REST (JSON-RPC) do peer.
+package ledger
+
+// Ledger - executa funções Invoke e Query do chaincode com argumentos posicionais.
+// Erros retornados pela própria função do chaincode devem ser do tipo *ErroChaincode.
+type Ledger interface {
+	// Invoke executa uma função Invoke do chaincode
+	Invoke(funcao string, args []string) (Resultado, error)
+	// Query executa uma função Query do chaincode e retorna a resposta (JSON)
+	Query(funcao string, args []string) ([]byte, error)
+}
+
+// Resultado - resultado de um Invoke
+type Resultado struct {
+	TxID     string
+	Payload  []byte // resposta da função, quando disponível
+	Pendente bool   // true se a transação foi apenas submetida (peer real, v0.6) e a resposta não é conhecida
+}
+
+// ErroChaincode - erro retornado pela função do chaincode (mensagem original preservada)
+type ErroChaincode struct {
+	Mensagem string
+}
+
+func (e *ErroChaincode) Error() string {
+	return e.Mensagem
+}
@@ -0,0 +1,133 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Peer - cliente da API REST do peer (POST /chaincode, JSON-RPC 2.0), no mesmo formato
+// das requisições de deploy, invoke e query utilizadas no Bluemix
+type Peer struct {
+	URL           string // endereço da API REST do peer, ex.: https://<id>-vp0.us.blockchain.ibm.com:443
+	ChaincodeID   string // nome (hash) do chaincode
+	SecureContext string // usuário registrado no peer, ex.: WebAppAdmin
+	Metadata      []byte // metadata enviado nas transações (certificado do chamador)
+	Cliente       *http.Client
+
+	id int64
+}
+
+type requisicaoRPC struct {
+	JSONRPC string    `json:"jsonrpc"`
+	Method  string    `json:"method"`
+	Params  paramsRPC `json:"params"`
+	ID      int64     `json:"id"`
+}
+
+type paramsRPC struct {
+	Type        int `json:"type"`
+	ChaincodeID struct {
+		Name string `json:"name"`
+	} `json:"chaincodeID"`
+	CtorMsg struct {
+		Function string   `json:"function"`
+		Args     []string `json:"args"`
+	} `json:"ctorMsg"`
+	SecureContext string `json:"secureContext,omitempty"`
+	Metadata      []int  `json:"metadata,omitempty"`
+}
+
+type respostaRPC struct {
+	Result *struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+// Invoke - implementação de Ledger. O peer v0.6 apenas submete a transação e retorna
+// o seu ID, portanto o resultado é sempre Pendente.
+func (p *Peer) Invoke(funcao string, args []string) (Resultado, error) {
+	msg, err := p.chamar("invoke", funcao, args)
+	if err != nil {
+		return Resultado{}, err
+	}
+	return Resultado{TxID: msg, Pendente: true}, nil
+}
+
+// Query - implementação de Ledger
+func (p *Peer) Query(funcao string, args []string) ([]byte, error) {
+	msg, err := p.chamar("query", funcao, args)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(msg), nil
+}
+
+// chamar: envia a requisição JSON-RPC e retorna a mensagem do resultado
+func (p *Peer) chamar(metodo, funcao string, args []string) (string, error) {
+	req := requisicaoRPC{
+		JSONRPC: "2.0",
+		Method:  metodo,
+		ID:      atomic.AddInt64(&p.id, 1),
+	}
+	req.Params.Type = 1
+	req.Params.ChaincodeID.Name = p.ChaincodeID
+	req.Params.CtorMsg.Function = funcao
+	req.Params.CtorMsg.Args = args
+	if req.Params.CtorMsg.Args == nil {
+		req.Params.CtorMsg.Args = []string{}
+	}
+	req.Params.SecureContext = p.SecureContext
+	for _, b := range p.Metadata {
+		req.Params.Metadata = append(req.Params.Metadata, int(b))
+	}
+
+	corpo, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	cliente := p.Cliente
+	if cliente == nil {
+		cliente = http.DefaultClient
+	}
+	resp, err := cliente.Post(strings.TrimSuffix(p.URL, "/")+"/chaincode", "application/json", bytes.NewReader(corpo))
+	if err != nil {
+		return "", fmt.Errorf("Falha ao chamar o peer: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var r respostaRPC
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", fmt.Errorf("Resposta inválida do peer (%s): %s", resp.Status, err)
+	}
+	if r.Error != nil {
+		// a mensagem de erro da função do chaincode vem no campo data
+		return "", &ErroChaincode{Mensagem: mensagemChaincode(r.Error.Data, r.Error.Message)}
+	}
+	if r.Result == nil {
+		return "", fmt.Errorf("Resposta vazia do peer (%s)", resp.Status)
+	}
+	return r.Result.Message, nil
+}
+
+// mensagemChaincode: remove o prefixo adicionado pelo peer à mensagem de erro do chaincode
+func mensagemChaincode(data, padrao string) string {
+	if data == "" {
+		return padrao
+	}
+	for _, prefixo := range []string{"Error when querying chaincode: ", "Error when invoking chaincode: "} {
+		data = strings.TrimPrefix(data, prefixo)
+	}
+	data = strings.TrimPrefix(data, "Error:")
+	return strings.TrimSpace(data)
+}
@@ -0,0 +1,173 @@
+/*
+Descrição: log estruturado do chaincode de propostas
+Cada linha é um objeto JSON com o nível, o ID da transação e a função executada,
+para correlacionar as linhas de uma mesma transação no log do peer. Os dados pessoais
+(CPF, CNPJ) e os certificados são mascarados antes da escrita (ver mascara.go).
+*/
+
+// Package logging implementa o log em JSON, com níveis e mascaramento de dados
+// pessoais, utilizado pelo chaincode de propostas.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Nivel - nível de severidade das linhas do log
+type Nivel int
+
+// Níveis do log, do mais detalhado ao mais grave
+const (
+	Debug Nivel = iota
+	Info
+	Aviso
+	Erro
+)
+
+// NivelPadrao - nível utilizado quando a configuração não informa nenhum
+const NivelPadrao = Info
+
+var nomesNiveis = map[Nivel]string{
+	Debug: "debug",
+	Info:  "info",
+	Aviso: "aviso",
+	Erro:  "erro",
+}
+
+// String: nome do nível, como gravado no log e na configuração
+func (n Nivel) String() string {
+	if nome, ok := nomesNiveis[n]; ok {
+		return nome
+	}
+	return fmt.Sprintf("nivel(%d)", int(n))
+}
+
+// ConverterNivel: nível correspondente ao nome (debug, info, aviso ou erro; também
+// aceita os nomes em inglês warning e error)
+func ConverterNivel(nome string) (Nivel, error) {
+	switch strings.ToLower(strings.TrimSpace(nome)) {
+	case "debug":
+		return Debug, nil
+	case "info":
+		return Info, nil
+	case "aviso", "warn", "warning":
+		return Aviso, nil
+	case "erro", "error":
+		return Erro, nil
+	}
+	return NivelPadrao, fmt.Errorf("nível de log desconhecido [%s]", nome)
+}
+
+// Logger - log de uma transação. As linhas abaixo do nível configurado são descartadas.
+type Logger struct {
+	Nivel  Nivel
+	TxID   string
+	Funcao string
+	// Saida: destino das linhas (nil: a saída padrão do processo no momento da escrita)
+	Saida  io.Writer
+	campos []campo
+}
+
+type campo struct {
+	nome  string
+	valor interface{}
+}
+
+// as linhas de transações executadas em paralelo (simulador, endossantes) não se misturam
+var escrita sync.Mutex
+
+// Novo: log da transação txID, executando a função informada, no nível padrão
+func Novo(txID, funcao string) *Logger {
+	return &Logger{Nivel: NivelPadrao, TxID: txID, Funcao: funcao}
+}
+
+// Com: cópia do log que acrescenta os campos informados, em pares (nome, valor),
+// a todas as linhas
+func (l *Logger) Com(pares ...interface{}) *Logger {
+	c := *l
+	c.campos = append(append([]campo(nil), l.campos...), converterPares(pares)...)
+	return &c
+}
+
+// Debug: detalhes da execução (argumentos, valores lidos do estado)
+func (l *Logger) Debug(mensagem string, pares ...interface{}) { l.escrever(Debug, mensagem, pares) }
+
+// Info: operações concluídas
+func (l *Logger) Info(mensagem string, pares ...interface{}) { l.escrever(Info, mensagem, pares) }
+
+// Aviso: transações rejeitadas por regras de negócio ou permissão
+func (l *Logger) Aviso(mensagem string, pares ...interface{}) { l.escrever(Aviso, mensagem, pares) }
+
+// Erro: falhas inesperadas (stub, serialização, API externa)
+func (l *Logger) Erro(mensagem string, pares ...interface{}) { l.escrever(Erro, mensagem, pares) }
+
+// Habilitado: indica se as linhas do nível serão escritas, para evitar montar campos
+// custosos que seriam descartados
+func (l *Logger) Habilitado(n Nivel) bool {
+	return l != nil && n >= l.Nivel
+}
+
+func (l *Logger) escrever(n Nivel, mensagem string, pares []interface{}) {
+	if !l.Habilitado(n) {
+		return
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	escreverCampo(&b, "hora", time.Now().UTC().Format(time.RFC3339Nano), true)
+	escreverCampo(&b, "nivel", n.String(), false)
+	if l.TxID != "" {
+		escreverCampo(&b, "tx_id", l.TxID, false)
+	}
+	if l.Funcao != "" {
+		escreverCampo(&b, "funcao", l.Funcao, false)
+	}
+	escreverCampo(&b, "mensagem", Mascarar(mensagem), false)
+	for _, c := range append(append([]campo(nil), l.campos...), converterPares(pares)...) {
+		escreverCampo(&b, c.nome, valorMascarado(c.valor), false)
+	}
+	b.WriteString("}\n")
+
+	escrita.Lock()
+	defer escrita.Unlock()
+	saida := l.Saida
+	if saida == nil {
+		saida = os.Stdout
+	}
+	saida.Write(b.Bytes())
+}
+
+func escreverCampo(b *bytes.Buffer, nome string, valor interface{}, primeiro bool) {
+	if !primeiro {
+		b.WriteByte(',')
+	}
+	n, _ := json.Marshal(nome)
+	v, err := json.Marshal(valor)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(valor))
+	}
+	b.Write(n)
+	b.WriteByte(':')
+	b.Write(v)
+}
+
+// converterPares: pares (nome, valor); um nome sem valor é registrado com valor nulo
+func converterPares(pares []interface{}) []campo {
+	campos := make([]campo, 0, (len(pares)+1)/2)
+	for i := 0; i < len(pares); i += 2 {
+		nome := fmt.Sprint(pares[i])
+		var valor interface{}
+		if i+1 < len(pares) {
+			valor = pares[i+1]
+		}
+		campos = append(campos, campo{nome: nome, valor: valor})
+	}
+	return campos
+}
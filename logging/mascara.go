@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// blocos PEM (certificados e chaves dos oráculos)
+	rePEM = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]+-----[\s\S]*?-----END [A-Z0-9 ]+-----`)
+	// certificados e metadata em hexadecimal ou base64; IDs de proposta (hashes) são mais curtos
+	reHex    = regexp.MustCompile(`\b[0-9a-fA-F]{128,}\b`)
+	reBase64 = regexp.MustCompile(`[A-Za-z0-9+/]{128,}={0,2}`)
+	// sequências de dígitos e da pontuação de CPF e CNPJ, delimitadas por qualquer caractere
+	// que não seja dígito (inclusive letras e _: "cpf12345678909", "id_12345678909")
+	reDigitos = regexp.MustCompile(`[0-9][0-9./-]*[0-9]`)
+	// CNPJ (00.000.000/0000-00 ou 14 dígitos) e CPF (000.000.000-00 ou 11 dígitos); apenas
+	// os com dígitos verificadores válidos são mascarados, e não outros números do mesmo tamanho
+	reCNPJ = regexp.MustCompile(`^\d{2}\.?\d{3}\.?\d{3}/?\d{4}-?\d{2}$`)
+	reCPF  = regexp.MustCompile(`^\d{3}\.?\d{3}\.?\d{3}-?\d{2}$`)
+
+	// pesos do módulo 11 do segundo dígito verificador; os do primeiro são os mesmos sem o primeiro peso
+	pesosCPF  = []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}
+	pesosCNPJ = []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+)
+
+// Mascarar: substitui no texto os certificados e chaves (PEM, hexadecimal ou base64
+// longos) por um resumo e mantém apenas os primeiros e os últimos dígitos de CPFs e CNPJs
+func Mascarar(texto string) string {
+	texto = rePEM.ReplaceAllStringFunc(texto, func(pem string) string { return resumo([]byte(pem)) })
+	texto = reHex.ReplaceAllStringFunc(texto, func(h string) string {
+		if b, err := hex.DecodeString(h); err == nil {
+			return resumo(b)
+		}
+		return resumo([]byte(h))
+	})
+	texto = reBase64.ReplaceAllStringFunc(texto, func(b string) string { return resumo([]byte(b)) })
+	texto = reDigitos.ReplaceAllStringFunc(texto, func(numero string) string {
+		switch {
+		case reCNPJ.MatchString(numero) && verificadoresValidos(numero, pesosCNPJ):
+			return mascararDigitos(numero, 2)
+		case reCPF.MatchString(numero) && verificadoresValidos(numero, pesosCPF):
+			return mascararDigitos(numero, 3)
+		}
+		return numero
+	})
+	return texto
+}
+
+// Bytes: resumo de bytes (certificados, metadata, assinaturas) para o log, com o tamanho e
+// o início do SHA-256, suficiente para comparar identidades sem expor o conteúdo
+func Bytes(b []byte) string {
+	return resumo(b)
+}
+
+func resumo(b []byte) string {
+	h := sha256.Sum256(b)
+	return fmt.Sprintf("[%d bytes sha256:%s]", len(b), hex.EncodeToString(h[:4]))
+}
+
+// verificadoresValidos: confere os dois últimos dígitos do documento (módulo 11)
+func verificadoresValidos(documento string, pesos []int) bool {
+	var d []int
+	for _, r := range documento {
+		if r >= '0' && r <= '9' {
+			d = append(d, int(r-'0'))
+		}
+	}
+	n := len(d)
+	return d[n-2] == digitoVerificador(d[:n-2], pesos[1:]) && d[n-1] == digitoVerificador(d[:n-1], pesos)
+}
+
+func digitoVerificador(digitos, pesos []int) int {
+	soma := 0
+	for i, p := range pesos {
+		soma += digitos[i] * p
+	}
+	if r := soma % 11; r >= 2 {
+		return 11 - r
+	}
+	return 0
+}
+
+// mascararDigitos: mantém os primeiros dígitos informados, os dois últimos e a pontuação
+func mascararDigitos(documento string, visiveis int) string {
+	total := 0
+	for _, r := range documento {
+		if r >= '0' && r <= '9' {
+			total++
+		}
+	}
+	var b strings.Builder
+	i := 0
+	for _, r := range documento {
+		if r < '0' || r > '9' {
+			b.WriteRune(r)
+			continue
+		}
+		if i < visiveis || i >= total-2 {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('*')
+		}
+		i++
+	}
+	return b.String()
+}
+
+// valorMascarado: valor do campo pronto para o JSON do log
+func valorMascarado(valor interface{}) interface{} {
+	switch v := valor.(type) {
+	case nil, bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return v
+	case string:
+		return Mascarar(v)
+	case []byte:
+		return resumo(v)
+	case error:
+		return Mascarar(v.Error())
+	case fmt.Stringer:
+		return Mascarar(v.String())
+	case []string:
+		m := make([]string, len(v))
+		for i, s := range v {
+			m[i] = Mascarar(s)
+		}
+		return m
+	}
+	return Mascarar(fmt.Sprintf("%+v", valor))
+}
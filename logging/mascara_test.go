@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// resumoEsperado: resumo dos bytes, calculado no teste
+func resumoEsperado(b []byte, tamanho int) string {
+	h := sha256.Sum256(b)
+	return "[" + strconv.Itoa(tamanho) + " bytes sha256:" + hex.EncodeToString(h[:4]) + "]"
+}
+
+func TestMascarar(t *testing.T) {
+	pem := "-----BEGIN CERTIFICATE-----\nMIIBkTCB+wIJAK\n-----END CERTIFICATE-----"
+	certificado := bytes.Repeat([]byte{0xca, 0xfe}, 64)
+	hexa := hex.EncodeToString(certificado)
+	base64 := strings.Repeat("QUJD", 40)
+	idProposta := strings.Repeat("ab12", 16) // hash da proposta: 64 caracteres
+
+	casos := []struct {
+		nome     string
+		texto    string
+		esperado string
+	}{
+		// certificados e chaves
+		{"PEM", "chave " + pem + " registrada", "chave " + resumoEsperado([]byte(pem), len(pem)) + " registrada"},
+		{"hexadecimal", "admin=" + hexa, "admin=" + resumoEsperado(certificado, len(certificado))},
+		{"base64", "metadata " + base64 + "==", "metadata " + resumoEsperado([]byte(base64+"=="), len(base64)+2)},
+		{"hash da proposta", "id_proposta " + idProposta, "id_proposta " + idProposta},
+		{"hexadecimal curto", "tx " + hexa[:100], "tx " + hexa[:100]},
+		{"PEM incompleto", "-----BEGIN CERTIFICATE-----\nMIIB", "-----BEGIN CERTIFICATE-----\nMIIB"},
+
+		// CPF
+		{"CPF formatado", "cpf 123.456.789-09", "cpf 123.***.***-09"},
+		{"CPF sem pontuação", "cpf 12345678909.", "cpf 123******09."},
+		{"CPF junto a letras", "cpf12345678909", "cpf123******09"},
+		{"CPF junto a _", "id_12345678909", "id_123******09"},
+		{"CPF em JSON", `{"cpf_pagador":"111.111.111-11"}`, `{"cpf_pagador":"111.***.***-11"}`},
+		{"dois CPFs", "12345678909,11111111111", "123******09,111******11"},
+		{"11 dígitos sem verificadores", "nosso_numero 12345678900", "nosso_numero 12345678900"},
+		{"CPF formatado inválido", "123.456.789-00", "123.456.789-00"},
+		{"parte de um número maior", "linha 1234567890912", "linha 1234567890912"},
+		{"data", "vencimento 2026-11-30", "vencimento 2026-11-30"},
+
+		// CNPJ
+		{"CNPJ formatado", "beneficiario 11.222.333/0001-81", "beneficiario 11.***.***/****-81"},
+		{"CNPJ sem pontuação", "cnpj11222333000181", "cnpj11**********81"},
+		{"14 dígitos sem verificadores", "codigo 11222333000180", "codigo 11222333000180"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if got := Mascarar(c.texto); got != c.esperado {
+				t.Fatalf("Mascarar(%q) = %q, esperado %q", c.texto, got, c.esperado)
+			}
+		})
+	}
+}
+
+func TestLinhaMascarada(t *testing.T) {
+	var saida bytes.Buffer
+	l := &Logger{Nivel: Info, TxID: "tx-1", Funcao: "registrarProposta", Saida: &saida}
+	admin := []byte("certificado do administrador")
+	l.Com("cpf", "cpf12345678909").Info("Proposta do pagador 123.456.789-09", "admin", admin, "valor", 15000)
+	l.Debug("descartada", "cpf", "123.456.789-09")
+
+	var linha map[string]interface{}
+	if err := json.Unmarshal(saida.Bytes(), &linha); err != nil {
+		t.Fatalf("%s: %s", err, saida.String())
+	}
+	esperados := map[string]interface{}{
+		"nivel":    "info",
+		"tx_id":    "tx-1",
+		"mensagem": "Proposta do pagador 123.***.***-09",
+		"cpf":      "cpf123******09",
+		"admin":    resumoEsperado(admin, len(admin)),
+		"valor":    float64(15000),
+	}
+	for k, v := range esperados {
+		if linha[k] != v {
+			t.Fatalf("%s = %v, esperado %v", k, linha[k], v)
+		}
+	}
+	if Bytes(admin) != esperados["admin"] {
+		t.Fatalf("Bytes = %s", Bytes(admin))
+	}
+}
@@ -0,0 +1,350 @@
+/*
+Descrição: servidor de teste da API externa /atualizar (substitui o mock app.js)
+Valida o JSON recebido contra o formato da Proposta, verifica a assinatura HMAC
+(quando configurada), grava todas as requisições e pode ser programado para
+responder com erros ou atrasos, para exercitar o chaincode apicall sem rede.
+*/
+
+// Package mockapi implementa um servidor de teste para a API /atualizar.
+package mockapi
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CabecalhoAssinatura é o cabeçalho com a assinatura HMAC-SHA256 do corpo ("sha256=<hex>")
+const CabecalhoAssinatura = "X-Assinatura"
+
+// Proposta - JSON esperado pela rota /atualizar
+type Proposta struct {
+	ID                  string `json:"id_proposta"`
+	CpfPagador          string `json:"cpf_pagador"`
+	BoletoPago          bool   `json:"boletoPago"`
+	PagadorAceitou      *bool  `json:"pagador_aceitou,omitempty"`
+	BeneficiarioAceitou *bool  `json:"beneficiario_aceitou,omitempty"`
+}
+
+// Requisicao - requisição recebida e gravada pelo servidor
+type Requisicao struct {
+	Metodo    string
+	Caminho   string
+	Cabecalho http.Header
+	Corpo     []byte
+	Proposta  Proposta
+	Erro      error // erro de validação ou de assinatura, nil se a requisição foi aceita
+	Status    int   // status HTTP respondido
+	Horario   time.Time
+}
+
+// Resposta - resposta programada para a próxima requisição a /atualizar
+type Resposta struct {
+	Status int           // status HTTP (0 = 200)
+	Corpo  string        // corpo da resposta (vazio = eco do JSON recebido)
+	Atraso time.Duration // tempo de espera antes de responder
+}
+
+// Servidor - servidor de teste da API /atualizar
+type Servidor struct {
+	segredo []byte
+
+	mu          sync.Mutex
+	requisicoes []Requisicao
+	programadas []Resposta
+	padrao      Resposta
+	recebida    chan struct{}
+	observador  func(Requisicao)
+
+	http *http.Server
+	url  string
+}
+
+// Novo: cria o servidor. Se o segredo não for vazio, toda requisição deve trazer
+// o cabeçalho X-Assinatura com o HMAC-SHA256 do corpo.
+func Novo(segredo string) *Servidor {
+	return &Servidor{
+		segredo:  []byte(segredo),
+		recebida: make(chan struct{}, 1024),
+	}
+}
+
+// Iniciar: inicia o servidor em uma porta local livre e retorna a sua URL base.
+// Não utiliza o net/http/httptest, que traria o pacote testing para os binários.
+func (s *Servidor) Iniciar() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("Falha ao abrir uma porta local: %s", err)
+	}
+	s.http = &http.Server{Handler: s}
+	s.url = "http://" + l.Addr().String()
+	go s.http.Serve(l)
+	return s.url, nil
+}
+
+// URL: URL base do servidor iniciado com Iniciar
+func (s *Servidor) URL() string {
+	return s.url
+}
+
+// Fechar: encerra o servidor iniciado com Iniciar
+func (s *Servidor) Fechar() {
+	if s.http != nil {
+		s.http.Close()
+	}
+}
+
+// Programar: enfileira respostas para as próximas requisições, na ordem informada
+func (s *Servidor) Programar(respostas ...Resposta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.programadas = append(s.programadas, respostas...)
+}
+
+// ProgramarPadrao: define a resposta utilizada quando não há respostas enfileiradas
+func (s *Servidor) ProgramarPadrao(r Resposta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.padrao = r
+}
+
+// Limpar: descarta as requisições gravadas e as respostas programadas
+func (s *Servidor) Limpar() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requisicoes = nil
+	s.programadas = nil
+	s.padrao = Resposta{}
+	for len(s.recebida) > 0 {
+		<-s.recebida
+	}
+}
+
+// Observar: fn é chamada com cada requisição gravada, na goroutine que a atendeu,
+// antes do envio da resposta
+func (s *Servidor) Observar(fn func(Requisicao)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observador = fn
+}
+
+// Requisicoes: cópia das requisições gravadas, na ordem de chegada
+func (s *Servidor) Requisicoes() []Requisicao {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Requisicao(nil), s.requisicoes...)
+}
+
+// Aceitas: propostas das requisições que passaram na validação
+func (s *Servidor) Aceitas() []Proposta {
+	var propostas []Proposta
+	for _, r := range s.Requisicoes() {
+		if r.Erro == nil {
+			propostas = append(propostas, r.Proposta)
+		}
+	}
+	return propostas
+}
+
+// Aguardar: espera até que n requisições tenham sido gravadas ou o timeout expire
+func (s *Servidor) Aguardar(n int, timeout time.Duration) error {
+	limite := time.After(timeout)
+	for {
+		s.mu.Lock()
+		total := len(s.requisicoes)
+		s.mu.Unlock()
+		if total >= n {
+			return nil
+		}
+		select {
+		case <-s.recebida:
+		case <-limite:
+			return fmt.Errorf("Esperadas %d requisições, recebidas %d", n, total)
+		}
+	}
+}
+
+// Assinar: calcula o valor do cabeçalho X-Assinatura para o corpo informado
+func Assinar(segredo string, corpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write(corpo)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP - rota POST /atualizar
+func (s *Servidor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	corpo, _ := ioutil.ReadAll(r.Body)
+	req := Requisicao{
+		Metodo:    r.Method,
+		Caminho:   r.URL.Path,
+		Cabecalho: r.Header,
+		Corpo:     corpo,
+		Horario:   time.Now(),
+	}
+
+	status, resposta := s.responder(&req)
+
+	s.mu.Lock()
+	req.Status = status
+	s.requisicoes = append(s.requisicoes, req)
+	observador := s.observador
+	s.mu.Unlock()
+	select {
+	case s.recebida <- struct{}{}:
+	default:
+	}
+	if observador != nil {
+		observador(req)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resposta)
+}
+
+// responder: valida a requisição e escolhe a resposta a ser enviada
+func (s *Servidor) responder(req *Requisicao) (int, []byte) {
+	if req.Caminho != "/atualizar" {
+		req.Erro = fmt.Errorf("Rota não encontrada: %s", req.Caminho)
+		return http.StatusNotFound, corpoErro(req.Erro)
+	}
+	if req.Metodo != "POST" {
+		req.Erro = fmt.Errorf("Método não suportado: %s", req.Metodo)
+		return http.StatusMethodNotAllowed, corpoErro(req.Erro)
+	}
+	if len(s.segredo) > 0 {
+		if err := s.verificarAssinatura(req.Cabecalho.Get(CabecalhoAssinatura), req.Corpo); err != nil {
+			req.Erro = err
+			return http.StatusUnauthorized, corpoErro(err)
+		}
+	}
+	proposta, err := Validar(req.Corpo)
+	if err != nil {
+		req.Erro = err
+		return http.StatusBadRequest, corpoErro(err)
+	}
+	req.Proposta = proposta
+
+	// Resposta programada
+	s.mu.Lock()
+	r := s.padrao
+	if len(s.programadas) > 0 {
+		r = s.programadas[0]
+		s.programadas = s.programadas[1:]
+	}
+	s.mu.Unlock()
+
+	if r.Atraso > 0 {
+		time.Sleep(r.Atraso)
+	}
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if r.Corpo != "" {
+		return status, []byte(r.Corpo)
+	}
+	// Assim como o app.js, devolve o JSON recebido
+	return status, req.Corpo
+}
+
+// verificarAssinatura: compara o cabeçalho X-Assinatura com o HMAC do corpo
+func (s *Servidor) verificarAssinatura(assinatura string, corpo []byte) error {
+	if assinatura == "" {
+		return fmt.Errorf("Cabeçalho %s ausente", CabecalhoAssinatura)
+	}
+	if !hmac.Equal([]byte(assinatura), []byte(Assinar(string(s.segredo), corpo))) {
+		return fmt.Errorf("Assinatura HMAC inválida")
+	}
+	return nil
+}
+
+// Validar: valida o JSON contra o formato da Proposta. São obrigatórios id_proposta,
+// cpf_pagador e boletoPago (também aceito como boleto_pago, formato enviado pelo chaincode).
+func Validar(corpo []byte) (Proposta, error) {
+	var p Proposta
+	var campos map[string]json.RawMessage
+	dec := json.NewDecoder(bytes.NewReader(corpo))
+	if err := dec.Decode(&campos); err != nil {
+		return p, fmt.Errorf("JSON inválido: %s", err)
+	}
+
+	var erros []string
+	lerString := func(nome string, destino *string) {
+		valor, ok := campos[nome]
+		if !ok {
+			erros = append(erros, nome+": obrigatório")
+			return
+		}
+		if err := json.Unmarshal(valor, destino); err != nil {
+			erros = append(erros, nome+": deve ser string")
+			return
+		}
+		if strings.TrimSpace(*destino) == "" {
+			erros = append(erros, nome+": não pode ser vazio")
+		}
+	}
+	lerBool := func(nome string) *bool {
+		valor, ok := campos[nome]
+		if !ok {
+			return nil
+		}
+		var b bool
+		if err := json.Unmarshal(valor, &b); err != nil {
+			erros = append(erros, nome+": deve ser booleano")
+			return nil
+		}
+		return &b
+	}
+
+	lerString("id_proposta", &p.ID)
+	lerString("cpf_pagador", &p.CpfPagador)
+
+	boletoPago := lerBool("boletoPago")
+	if alias := lerBool("boleto_pago"); boletoPago == nil {
+		boletoPago = alias
+	}
+	if boletoPago == nil {
+		if _, ok := campos["boletoPago"]; !ok {
+			if _, ok := campos["boleto_pago"]; !ok {
+				erros = append(erros, "boletoPago: obrigatório")
+			}
+		}
+	} else {
+		p.BoletoPago = *boletoPago
+	}
+	p.PagadorAceitou = lerBool("pagador_aceitou")
+	p.BeneficiarioAceitou = lerBool("beneficiario_aceitou")
+
+	var desconhecidos []string
+	for nome := range campos {
+		switch nome {
+		case "id_proposta", "cpf_pagador", "boletoPago", "boleto_pago", "pagador_aceitou", "beneficiario_aceitou":
+		default:
+			desconhecidos = append(desconhecidos, nome+": campo desconhecido")
+		}
+	}
+	sort.Strings(desconhecidos)
+	erros = append(erros, desconhecidos...)
+
+	if len(erros) > 0 {
+		return p, fmt.Errorf("Proposta inválida: %s", strings.Join(erros, "; "))
+	}
+	return p, nil
+}
+
+// corpoErro: corpo JSON das respostas de erro
+func corpoErro(err error) []byte {
+	corpo, _ := json.Marshal(map[string]string{"erro": err.Error()})
+	return corpo
+}
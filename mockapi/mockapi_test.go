@@ -0,0 +1,144 @@
+package mockapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidar(t *testing.T) {
+	casos := []struct {
+		nome  string
+		corpo string
+		erro  string // "" se a proposta for aceita
+		pago  bool
+	}{
+		{"formato do app.js", `{"id_proposta": "p1", "cpf_pagador": "111", "boletoPago": true}`, "", true},
+		{"formato do chaincode", `{"id_proposta": "p1", "cpf_pagador": "111", "boleto_pago": true, "pagador_aceitou": true, "beneficiario_aceitou": false}`, "", true},
+		{"boletoPago prevalece", `{"id_proposta": "p1", "cpf_pagador": "111", "boletoPago": false, "boleto_pago": true}`, "", false},
+		{"JSON inválido", `{"id_proposta": `, "JSON inválido", false},
+		{"sem id", `{"cpf_pagador": "111", "boletoPago": false}`, "id_proposta: obrigatório", false},
+		{"id vazio", `{"id_proposta": " ", "cpf_pagador": "111", "boletoPago": false}`, "id_proposta: não pode ser vazio", false},
+		{"cpf numérico", `{"id_proposta": "p1", "cpf_pagador": 111, "boletoPago": false}`, "cpf_pagador: deve ser string", false},
+		{"sem boletoPago", `{"id_proposta": "p1", "cpf_pagador": "111"}`, "boletoPago: obrigatório", false},
+		{"boletoPago texto", `{"id_proposta": "p1", "cpf_pagador": "111", "boletoPago": "true"}`, "boletoPago: deve ser booleano", false},
+		{"campo desconhecido", `{"id_proposta": "p1", "cpf_pagador": "111", "boletoPago": false, "valor": 1}`, "valor: campo desconhecido", false},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			p, err := Validar([]byte(c.corpo))
+			if c.erro != "" {
+				if err == nil || !strings.Contains(err.Error(), c.erro) {
+					t.Fatalf("erro = %v, esperado %q", err, c.erro)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.ID != "p1" || p.BoletoPago != c.pago {
+				t.Fatalf("proposta %+v", p)
+			}
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	const corpo = `{"id_proposta": "p1", "cpf_pagador": "111", "boletoPago": false}`
+	casos := []struct {
+		nome       string
+		segredo    string
+		metodo     string
+		caminho    string
+		assinatura string
+		status     int
+	}{
+		{"aceita", "", "POST", "/atualizar", "", http.StatusOK},
+		{"rota", "", "POST", "/outra", "", http.StatusNotFound},
+		{"método", "", "GET", "/atualizar", "", http.StatusMethodNotAllowed},
+		{"assinatura válida", "s3", "POST", "/atualizar", Assinar("s3", []byte(corpo)), http.StatusOK},
+		{"sem assinatura", "s3", "POST", "/atualizar", "", http.StatusUnauthorized},
+		{"assinatura de outro segredo", "s3", "POST", "/atualizar", Assinar("s4", []byte(corpo)), http.StatusUnauthorized},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			s := Novo(c.segredo)
+			r := httptest.NewRequest(c.metodo, c.caminho, strings.NewReader(corpo))
+			if c.assinatura != "" {
+				r.Header.Set(CabecalhoAssinatura, c.assinatura)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			if w.Code != c.status {
+				t.Fatalf("status %d, esperado %d: %s", w.Code, c.status, w.Body)
+			}
+			reqs := s.Requisicoes()
+			if len(reqs) != 1 || reqs[0].Status != c.status {
+				t.Fatalf("requisições gravadas %+v", reqs)
+			}
+			if aceita := reqs[0].Erro == nil; aceita != (c.status == http.StatusOK) {
+				t.Fatalf("erro gravado %v com status %d", reqs[0].Erro, c.status)
+			}
+			if c.status == http.StatusOK && w.Body.String() != corpo {
+				t.Fatalf("corpo %s, esperado o eco da requisição", w.Body)
+			}
+		})
+	}
+}
+
+func TestRespostasProgramadas(t *testing.T) {
+	s := Novo("")
+	s.Programar(Resposta{Status: http.StatusInternalServerError, Corpo: `{"erro": "indisponível"}`})
+	s.ProgramarPadrao(Resposta{Status: http.StatusAccepted})
+
+	var observadas []int
+	s.Observar(func(r Requisicao) { observadas = append(observadas, r.Status) })
+
+	for _, esperado := range []int{http.StatusInternalServerError, http.StatusAccepted, http.StatusAccepted} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("POST", "/atualizar", strings.NewReader(`{"id_proposta": "p1", "cpf_pagador": "111", "boletoPago": true}`)))
+		if w.Code != esperado {
+			t.Fatalf("status %d, esperado %d", w.Code, esperado)
+		}
+	}
+	if len(observadas) != 3 || len(s.Aceitas()) != 3 {
+		t.Fatalf("observadas %v, aceitas %d", observadas, len(s.Aceitas()))
+	}
+
+	s.Limpar()
+	if len(s.Requisicoes()) != 0 {
+		t.Fatal("requisições mantidas depois de Limpar")
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("POST", "/atualizar", strings.NewReader(`{"id_proposta": "p1", "cpf_pagador": "111", "boletoPago": true}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d depois de Limpar, esperado 200", w.Code)
+	}
+}
+
+func TestIniciar(t *testing.T) {
+	s := Novo("")
+	url, err := s.Iniciar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Fechar()
+
+	resp, err := http.Post(url+"/atualizar", "application/json", strings.NewReader(`{"id_proposta": "p1", "cpf_pagador": "111", "boletoPago": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := s.Aguardar(1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Aguardar(2, 10*time.Millisecond); err == nil {
+		t.Fatal("Aguardar retornou sem a segunda requisição")
+	}
+	if aceitas := s.Aceitas(); len(aceitas) != 1 || !aceitas[0].BoletoPago {
+		t.Fatalf("aceitas %+v", aceitas)
+	}
+}
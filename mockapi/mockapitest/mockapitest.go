@@ -0,0 +1,49 @@
+/*
+Descrição: verificações dos testes sobre as requisições recebidas pelo servidor de teste
+da API /atualizar, separadas do pacote mockapi para que os binários que o utilizam
+(cmd/mockapi, cenários) não dependam do pacote testing.
+*/
+
+// Package mockapitest reúne as verificações de teste do servidor mockapi.
+package mockapitest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/mockapi"
+)
+
+// VerificarTotal: falha o teste se o servidor não tiver recebido exatamente n
+// requisições em até um segundo
+func VerificarTotal(t testing.TB, s *mockapi.Servidor, n int) {
+	t.Helper()
+	if err := s.Aguardar(n, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if total := len(s.Requisicoes()); total != n {
+		t.Fatalf("Esperadas %d requisições, recebidas %d", n, total)
+	}
+}
+
+// VerificarSemErros: falha o teste se alguma requisição foi rejeitada na validação
+func VerificarSemErros(t testing.TB, s *mockapi.Servidor) {
+	t.Helper()
+	for i, r := range s.Requisicoes() {
+		if r.Erro != nil {
+			t.Errorf("Requisição %d rejeitada: %s", i, r.Erro)
+		}
+	}
+}
+
+// VerificarProposta: falha o teste se nenhuma requisição aceita trouxe a proposta
+// com o id e o status de pagamento informados
+func VerificarProposta(t testing.TB, s *mockapi.Servidor, idProposta string, boletoPago bool) {
+	t.Helper()
+	for _, p := range s.Aceitas() {
+		if p.ID == idProposta && p.BoletoPago == boletoPago {
+			return
+		}
+	}
+	t.Errorf("Proposta [%s] com boletoPago=%t não recebida em /atualizar", idProposta, boletoPago)
+}
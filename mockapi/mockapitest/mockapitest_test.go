@@ -0,0 +1,69 @@
+package mockapitest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CaueP/BlockchainDojo/mockapi"
+)
+
+// tbFalso: registra as falhas das verificações sem interromper o teste
+type tbFalso struct {
+	testing.TB
+	falhas []string
+}
+
+func (t *tbFalso) Helper() {}
+
+func (t *tbFalso) Fatal(args ...interface{}) { t.falhas = append(t.falhas, fmt.Sprint(args...)) }
+
+func (t *tbFalso) Fatalf(formato string, args ...interface{}) {
+	t.falhas = append(t.falhas, fmt.Sprintf(formato, args...))
+}
+
+func (t *tbFalso) Errorf(formato string, args ...interface{}) {
+	t.falhas = append(t.falhas, fmt.Sprintf(formato, args...))
+}
+
+func TestVerificacoes(t *testing.T) {
+	s := mockapi.Novo("")
+	url, err := s.Iniciar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Fechar()
+	for _, corpo := range []string{
+		`{"id_proposta": "p1", "cpf_pagador": "111", "boletoPago": true}`,
+		`{"id_proposta": "p2"}`,
+	} {
+		resp, err := http.Post(url+"/atualizar", "application/json", strings.NewReader(corpo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	VerificarTotal(t, s, 2)
+	VerificarProposta(t, s, "p1", true)
+
+	casos := []struct {
+		nome      string
+		verificar func(testing.TB)
+		falha     string
+	}{
+		{"total", func(tb testing.TB) { VerificarTotal(tb, s, 3) }, "Esperadas 3 requisições"},
+		{"sem erros", func(tb testing.TB) { VerificarSemErros(tb, s) }, "Requisição 1 rejeitada"},
+		{"proposta", func(tb testing.TB) { VerificarProposta(tb, s, "p1", false) }, "Proposta [p1] com boletoPago=false não recebida"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			tb := &tbFalso{}
+			c.verificar(tb)
+			if len(tb.falhas) == 0 || !strings.HasPrefix(tb.falhas[0], c.falha) {
+				t.Fatalf("falhas %q, esperada %q", tb.falhas, c.falha)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Descrição: oráculo bancário local, utilizado em testes no lugar do oráculo real do banco
+Os pagamentos e a chave privada do banco são lidos de um arquivo de fixture
+(ver fixtures/pagamentos.json) e cada atestado é assinado no momento da consulta.
+*/
+
+// Package local implementa um oráculo de pagamentos que assina atestados a
+// partir de um arquivo de fixture, para testes sem acesso ao banco.
+package local
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/CaueP/BlockchainDojo/oracle"
+)
+
+// Pagamento - pagamento registrado no arquivo de fixture: boleto, pelo nosso número, ou
+// PIX, pelo ID fim a fim e o txid da cobrança
+type Pagamento struct {
+	NossoNumero   string `json:"nosso_numero,omitempty"`
+	EndToEndID    string `json:"end_to_end_id,omitempty"`
+	TxID          string `json:"txid,omitempty"`
+	Valor         int64  `json:"valor"`
+	DataPagamento string `json:"data_pagamento"`
+}
+
+// Fixture - conteúdo do arquivo de fixture do oráculo
+type Fixture struct {
+	CodigoBanco  string      `json:"codigo_banco"`
+	ChavePrivada string      `json:"chave_privada"`
+	Pagamentos   []Pagamento `json:"pagamentos"`
+}
+
+// Oraculo - oráculo local de um banco
+type Oraculo struct {
+	codigoBanco string
+	chave       *ecdsa.PrivateKey
+	pagamentos  map[string]Pagamento
+	pix         map[string]Pagamento // pelo ID fim a fim
+}
+
+// Carregar: cria o oráculo a partir de um arquivo de fixture
+func Carregar(arquivo string) (*Oraculo, error) {
+	conteudo, err := ioutil.ReadFile(arquivo)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao ler fixture do oráculo [%s]: %s", arquivo, err)
+	}
+	var f Fixture
+	if err := json.Unmarshal(conteudo, &f); err != nil {
+		return nil, fmt.Errorf("Fixture do oráculo inválida [%s]: %s", arquivo, err)
+	}
+	return Novo(f)
+}
+
+// Novo: cria o oráculo a partir de uma fixture já decodificada
+func Novo(f Fixture) (*Oraculo, error) {
+	chave, err := oracle.DecodificarChavePrivada([]byte(f.ChavePrivada))
+	if err != nil {
+		return nil, err
+	}
+	o := &Oraculo{
+		codigoBanco: f.CodigoBanco,
+		chave:       chave,
+		pagamentos:  make(map[string]Pagamento),
+		pix:         make(map[string]Pagamento),
+	}
+	for _, p := range f.Pagamentos {
+		if p.EndToEndID != "" {
+			o.pix[p.EndToEndID] = p
+		} else {
+			o.pagamentos[p.NossoNumero] = p
+		}
+	}
+	return o, nil
+}
+
+// CodigoBanco: código do banco representado pelo oráculo
+func (o *Oraculo) CodigoBanco() string {
+	return o.codigoBanco
+}
+
+// ChavePublica: chave pública do oráculo em PEM, a ser registrada no chaincode
+func (o *Oraculo) ChavePublica() ([]byte, error) {
+	return oracle.CodificarChavePublica(&o.chave.PublicKey)
+}
+
+// Atestar: retorna o atestado assinado do pagamento do boleto com o nosso número informado
+func (o *Oraculo) Atestar(nossoNumero string) (oracle.Atestado, error) {
+	p, ok := o.pagamentos[nossoNumero]
+	if !ok {
+		return oracle.Atestado{}, fmt.Errorf("Pagamento do nosso número [%s] não encontrado", nossoNumero)
+	}
+	a := oracle.Atestado{
+		CodigoBanco:   o.codigoBanco,
+		NossoNumero:   p.NossoNumero,
+		Valor:         p.Valor,
+		DataPagamento: p.DataPagamento,
+	}
+	if err := oracle.Assinar(o.chave, &a); err != nil {
+		return oracle.Atestado{}, err
+	}
+	return a, nil
+}
+
+// AtestarPix: retorna o atestado assinado do pagamento PIX com o ID fim a fim informado
+func (o *Oraculo) AtestarPix(endToEndID string) (oracle.Atestado, error) {
+	p, ok := o.pix[endToEndID]
+	if !ok {
+		return oracle.Atestado{}, fmt.Errorf("Pagamento PIX [%s] não encontrado", endToEndID)
+	}
+	a := oracle.Atestado{
+		CodigoBanco:   o.codigoBanco,
+		EndToEndID:    p.EndToEndID,
+		TxID:          p.TxID,
+		Valor:         p.Valor,
+		DataPagamento: p.DataPagamento,
+	}
+	if err := oracle.Assinar(o.chave, &a); err != nil {
+		return oracle.Atestado{}, err
+	}
+	return a, nil
+}
+
+// ServeHTTP - rotas do oráculo:
+// GET /chave: chave pública do oráculo (PEM)
+// GET /atestados/{nossoNumero}: atestado assinado do pagamento do boleto (JSON)
+// GET /atestados/pix/{endToEndId}: atestado assinado do pagamento PIX (JSON)
+func (o *Oraculo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Método não suportado", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/chave":
+		chave, err := o.ChavePublica()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-pem-file")
+		w.Write(chave)
+	case strings.HasPrefix(r.URL.Path, "/atestados/"):
+		var a oracle.Atestado
+		var err error
+		if e2e := strings.TrimPrefix(r.URL.Path, "/atestados/pix/"); e2e != r.URL.Path {
+			a, err = o.AtestarPix(e2e)
+		} else {
+			a, err = o.Atestar(strings.TrimPrefix(r.URL.Path, "/atestados/"))
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(a)
+	default:
+		http.NotFound(w, r)
+	}
+}
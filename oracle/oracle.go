@@ -0,0 +1,199 @@
+/*
+Descrição: atestados de pagamento assinados pelos oráculos dos bancos
+Um atestado confirma que o boleto identificado por (codigoBanco, nossoNumero)
+foi pago com um determinado valor e data, ou, no pagamento PIX, que a cobrança
+identificada pelo txid foi paga pela transação identificada pelo ID fim a fim
+(endToEndId). O oráculo do banco assina o atestado
+com sua chave privada ECDSA (P-256) e o chaincode verifica a assinatura com a
+chave pública registrada para aquele banco antes de liquidar a proposta.
+*/
+
+// Package oracle define o formato dos atestados de pagamento e as funções de
+// assinatura e verificação utilizadas pelo chaincode e pelos oráculos.
+package oracle
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/pix"
+)
+
+// LayoutData é o formato das datas de pagamento (AAAA-MM-DD)
+const LayoutData = "2006-01-02"
+
+// Atestado - confirmação de pagamento emitida pelo oráculo de um banco
+type Atestado struct {
+	CodigoBanco   string `json:"codigo_banco"`
+	NossoNumero   string `json:"nosso_numero"`            // vazio no pagamento PIX
+	EndToEndID    string `json:"end_to_end_id,omitempty"` // ID fim a fim do pagamento PIX
+	TxID          string `json:"txid,omitempty"`          // txid da cobrança PIX (ID da proposta)
+	Valor         int64  `json:"valor"`                   // em centavos
+	DataPagamento string `json:"data_pagamento"`
+	Assinatura    string `json:"assinatura,omitempty"` // DER em base64
+}
+
+var reCodigoBanco = regexp.MustCompile(`^[0-9]{3}$`)
+
+// ValidarCodigoBanco: verifica o código do banco (3 dígitos)
+func ValidarCodigoBanco(codigoBanco string) error {
+	if !reCodigoBanco.MatchString(codigoBanco) {
+		return fmt.Errorf("Código de banco inválido [%s]. Esperado 3 dígitos", codigoBanco)
+	}
+	return nil
+}
+
+// Validar: verifica se os campos do atestado estão preenchidos corretamente
+func (a Atestado) Validar() error {
+	if err := ValidarCodigoBanco(a.CodigoBanco); err != nil {
+		return err
+	}
+	switch {
+	case a.NossoNumero == "" && a.EndToEndID == "":
+		return errors.New("Nosso número ou ID fim a fim do PIX não informado")
+	case a.NossoNumero != "" && a.EndToEndID != "":
+		return errors.New("Informe o nosso número (boleto) ou o ID fim a fim (PIX), não ambos")
+	case a.EndToEndID != "":
+		if err := pix.ValidarEndToEndID(a.EndToEndID); err != nil {
+			return err
+		}
+		if err := pix.ValidarTxID(a.TxID, true); err != nil {
+			return err
+		}
+	case a.TxID != "":
+		return errors.New("txid informado no atestado de boleto")
+	}
+	if a.Valor <= 0 {
+		return fmt.Errorf("Valor inválido [%d]", a.Valor)
+	}
+	if _, err := time.Parse(LayoutData, a.DataPagamento); err != nil {
+		return fmt.Errorf("Data de pagamento inválida [%s]. Esperado AAAA-MM-DD", a.DataPagamento)
+	}
+	return nil
+}
+
+// Mensagem: representação canônica do atestado que é assinada pelo oráculo
+// (todos os campos, exceto a assinatura, separados por '|'). O ID fim a fim e o txid
+// são acrescentados apenas no PIX, mantendo a mensagem dos atestados de boleto.
+func (a Atestado) Mensagem() []byte {
+	campos := []string{
+		a.CodigoBanco,
+		a.NossoNumero,
+		strconv.FormatInt(a.Valor, 10),
+		a.DataPagamento,
+	}
+	if a.EndToEndID != "" {
+		campos = append(campos, a.EndToEndID, a.TxID)
+	}
+	return []byte(strings.Join(campos, "|"))
+}
+
+// Pix: indica se o atestado é de um pagamento PIX
+func (a Atestado) Pix() bool {
+	return a.EndToEndID != ""
+}
+
+// Decodificar: converte o JSON recebido como argumento do chaincode em um Atestado
+func Decodificar(atestadoJSON []byte) (Atestado, error) {
+	var a Atestado
+	if err := json.Unmarshal(atestadoJSON, &a); err != nil {
+		return a, fmt.Errorf("Atestado inválido: %s", err)
+	}
+	return a, nil
+}
+
+// Assinar: preenche a assinatura do atestado com a chave privada do oráculo. Sem fonte
+// aleatória, ecdsa.PrivateKey.Sign gera a assinatura determinística da RFC 6979 (Go 1.24
+// ou posterior); a repetição de uma requisição idempotente, porém, não depende disso, pois
+// o chaincode desconsidera as assinaturas ao comparar os argumentos.
+func Assinar(chave *ecdsa.PrivateKey, a *Atestado) error {
+	if err := a.Validar(); err != nil {
+		return err
+	}
+	hash := sha256.Sum256(a.Mensagem())
+	sig, err := chave.Sign(nil, hash[:], crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("Falha ao assinar atestado: %s", err)
+	}
+	a.Assinatura = base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
+
+// Verificar: valida os campos do atestado e a sua assinatura com a chave
+// pública do oráculo (PEM, formato PKIX)
+func Verificar(chavePublicaPEM []byte, a Atestado) error {
+	if err := a.Validar(); err != nil {
+		return err
+	}
+	chave, err := DecodificarChavePublica(chavePublicaPEM)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(a.Assinatura)
+	if err != nil || len(sig) == 0 {
+		return errors.New("Assinatura do atestado ausente ou mal formada")
+	}
+	hash := sha256.Sum256(a.Mensagem())
+	if !ecdsa.VerifyASN1(chave, hash[:], sig) {
+		return fmt.Errorf("Assinatura inválida para o atestado do banco [%s]", a.CodigoBanco)
+	}
+	return nil
+}
+
+// DecodificarChavePublica: converte uma chave pública ECDSA em PEM
+func DecodificarChavePublica(chavePEM []byte) (*ecdsa.PublicKey, error) {
+	bloco, _ := pem.Decode(chavePEM)
+	if bloco == nil {
+		return nil, errors.New("Chave pública do oráculo não está em formato PEM")
+	}
+	chave, err := x509.ParsePKIXPublicKey(bloco.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao decodificar chave pública do oráculo: %s", err)
+	}
+	ecChave, ok := chave.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("Chave pública do oráculo não é ECDSA")
+	}
+	return ecChave, nil
+}
+
+// DecodificarChavePrivada: converte uma chave privada ECDSA em PEM (SEC 1 ou PKCS #8)
+func DecodificarChavePrivada(chavePEM []byte) (*ecdsa.PrivateKey, error) {
+	bloco, _ := pem.Decode(chavePEM)
+	if bloco == nil {
+		return nil, errors.New("Chave privada do oráculo não está em formato PEM")
+	}
+	if chave, err := x509.ParseECPrivateKey(bloco.Bytes); err == nil {
+		return chave, nil
+	}
+	chave, err := x509.ParsePKCS8PrivateKey(bloco.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao decodificar chave privada do oráculo: %s", err)
+	}
+	ecChave, ok := chave.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("Chave privada do oráculo não é ECDSA")
+	}
+	return ecChave, nil
+}
+
+// CodificarChavePublica: converte a chave pública em PEM, no formato aceito
+// pelo chaincode ao registrar um oráculo
+func CodificarChavePublica(chave *ecdsa.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(chave)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
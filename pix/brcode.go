@@ -0,0 +1,289 @@
+/*
+Descrição: BR Code do PIX (padrão EMV QRCPS-MPM do Banco Central)
+O BR Code é uma sequência de campos TLV (ID de 2 dígitos, tamanho de 2 dígitos e valor)
+terminada pelo CRC16 (CCITT-FALSE) do próprio texto. A cobrança de uma proposta é de uso
+único (ponto de iniciação 12) e tem o ID da proposta como txid: com a chave do recebedor,
+o txid vai no campo adicional 62-05; com a localização da cobrança no PSP do recebedor
+(BR Code dinâmico), o txid faz parte da URL e o campo 62-05 é "***".
+*/
+
+// Package pix gera e decodifica o BR Code das cobranças PIX das propostas e valida as
+// chaves, os txids e os IDs fim a fim (end-to-end) dos pagamentos.
+package pix
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GUI - identificador do arranjo PIX no campo 26 do BR Code
+const GUI = "br.gov.bcb.pix"
+
+// Limites dos campos do recebedor no BR Code
+const (
+	TamanhoMaximoNome   = 25
+	TamanhoMaximoCidade = 15
+)
+
+// IDs dos campos do BR Code
+const (
+	idFormato       = "00"
+	idIniciacao     = "01"
+	idConta         = "26"
+	idCategoria     = "52"
+	idMoeda         = "53"
+	idValor         = "54"
+	idPais          = "58"
+	idNome          = "59"
+	idCidade        = "60"
+	idAdicionais    = "62"
+	idCRC           = "63"
+	idContaGUI      = "00"
+	idContaChave    = "01"
+	idContaURL      = "25"
+	idAdicionalTxID = "05"
+)
+
+// Recebedor - conta PIX que recebe os pagamentos das propostas
+type Recebedor struct {
+	Chave  string `json:"chave"`  // CPF, CNPJ, e-mail, telefone (+55...) ou chave aleatória
+	Nome   string `json:"nome"`   // até 25 caracteres, sem acentos no BR Code
+	Cidade string `json:"cidade"` // até 15 caracteres, sem acentos no BR Code
+	// Localizacao: URL da cobrança no PSP do recebedor, sem https://, com {txid} no lugar
+	// do txid. Se informada, o BR Code é dinâmico: o aplicativo do pagador obtém a cobrança
+	// (chave e valor) no PSP.
+	Localizacao string `json:"localizacao,omitempty"`
+}
+
+// Cobranca - dados do BR Code de uma cobrança
+type Cobranca struct {
+	Recebedor
+	TxID  string // ID da proposta
+	Valor int64  // em centavos
+}
+
+var (
+	reTxID       = regexp.MustCompile(`^[A-Za-z0-9]{1,25}$`)
+	reTxIDCob    = regexp.MustCompile(`^[A-Za-z0-9]{1,35}$`)
+	reEndToEndID = regexp.MustCompile(`^E[0-9]{8}([0-9]{12})[A-Za-z0-9]{11}$`)
+	reCPFCNPJ    = regexp.MustCompile(`^([0-9]{11}|[0-9]{14})$`)
+	reTelefone   = regexp.MustCompile(`^\+55[0-9]{10,11}$`)
+	reEmail      = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	reAleatoria  = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+// semAcentos: letras acentuadas do nome e da cidade do recebedor
+var semAcentos = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i", "ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "É", "E", "Ê", "E", "Í", "I", "Ó", "O", "Ô", "O", "Õ", "O", "Ú", "U", "Ü", "U", "Ç", "C",
+)
+
+// ValidarChave: verifica o formato da chave PIX (CPF ou CNPJ apenas com dígitos, e-mail,
+// telefone no formato +55DDDNUMERO ou chave aleatória)
+func ValidarChave(chave string) error {
+	switch {
+	case reCPFCNPJ.MatchString(chave), reTelefone.MatchString(chave), reAleatoria.MatchString(chave):
+		return nil
+	case len(chave) <= 77 && reEmail.MatchString(chave):
+		return nil
+	}
+	return fmt.Errorf("Chave PIX inválida [%s]", chave)
+}
+
+// ValidarTxID: verifica se o txid pode ser utilizado no BR Code (até 25 letras e dígitos
+// com a chave; até 35 na cobrança do PSP, com a localização)
+func ValidarTxID(txid string, dinamico bool) error {
+	maximo := 25
+	if dinamico {
+		maximo = 35
+	}
+	if reTxID.MatchString(txid) || dinamico && reTxIDCob.MatchString(txid) {
+		return nil
+	}
+	return fmt.Errorf("txid inválido [%s]. Esperado até %d letras e dígitos", txid, maximo)
+}
+
+// ValidarEndToEndID: verifica o ID fim a fim do pagamento PIX: E, ISPB do participante
+// (8 dígitos), data e hora AAAAMMDDHHMM e 11 letras ou dígitos
+func ValidarEndToEndID(id string) error {
+	m := reEndToEndID.FindStringSubmatch(id)
+	if m == nil {
+		return fmt.Errorf("ID fim a fim inválido [%s]. Esperado E, ISPB, AAAAMMDDHHMM e 11 caracteres", id)
+	}
+	if _, err := time.Parse("200601021504", m[1]); err != nil {
+		return fmt.Errorf("ID fim a fim inválido [%s]: data e hora %s", id, m[1])
+	}
+	return nil
+}
+
+// Validar: verifica a chave, o nome, a cidade e a localização do recebedor
+func (r Recebedor) Validar() error {
+	if err := ValidarChave(r.Chave); err != nil {
+		return err
+	}
+	if err := campoTexto("nome do recebedor", r.Nome, TamanhoMaximoNome); err != nil {
+		return err
+	}
+	if err := campoTexto("cidade do recebedor", r.Cidade, TamanhoMaximoCidade); err != nil {
+		return err
+	}
+	if r.Localizacao != "" {
+		if strings.Contains(r.Localizacao, "://") || !strings.Contains(r.Localizacao, "{txid}") {
+			return fmt.Errorf("Localização inválida [%s]. Esperada a URL sem https:// e com {txid}", r.Localizacao)
+		}
+		if len(r.Localizacao) > 77 {
+			return fmt.Errorf("Localização inválida [%s]. Máximo de 77 caracteres", r.Localizacao)
+		}
+	}
+	return nil
+}
+
+// campoTexto: verifica se o texto, sem acentos, tem apenas caracteres ASCII imprimíveis
+// e no máximo o tamanho informado
+func campoTexto(nome, valor string, maximo int) error {
+	texto := semAcentos.Replace(strings.TrimSpace(valor))
+	if texto == "" {
+		return fmt.Errorf("O %s não foi informado", nome)
+	}
+	if len(texto) > maximo {
+		return fmt.Errorf("O %s [%s] excede %d caracteres", nome, valor, maximo)
+	}
+	for _, c := range texto {
+		if c < 0x20 || c > 0x7e {
+			return fmt.Errorf("O %s [%s] contém o caractere %q", nome, valor, c)
+		}
+	}
+	return nil
+}
+
+// BRCode: texto do BR Code da cobrança, com o CRC16
+func (c Cobranca) BRCode() (string, error) {
+	if err := c.Recebedor.Validar(); err != nil {
+		return "", err
+	}
+	dinamico := c.Localizacao != ""
+	if err := ValidarTxID(c.TxID, dinamico); err != nil {
+		return "", err
+	}
+	if c.Valor <= 0 {
+		return "", fmt.Errorf("Valor inválido [%d]", c.Valor)
+	}
+
+	conta := campo(idContaGUI, GUI)
+	referencia := c.TxID
+	if dinamico {
+		conta += campo(idContaURL, strings.Replace(c.Localizacao, "{txid}", c.TxID, -1))
+		referencia = "***"
+	} else {
+		conta += campo(idContaChave, c.Chave)
+	}
+
+	var b strings.Builder
+	b.WriteString(campo(idFormato, "01"))
+	b.WriteString(campo(idIniciacao, "12"))
+	b.WriteString(campo(idConta, conta))
+	b.WriteString(campo(idCategoria, "0000"))
+	b.WriteString(campo(idMoeda, "986"))
+	b.WriteString(campo(idValor, fmt.Sprintf("%d.%02d", c.Valor/100, c.Valor%100)))
+	b.WriteString(campo(idPais, "BR"))
+	b.WriteString(campo(idNome, semAcentos.Replace(strings.TrimSpace(c.Nome))))
+	b.WriteString(campo(idCidade, semAcentos.Replace(strings.TrimSpace(c.Cidade))))
+	b.WriteString(campo(idAdicionais, campo(idAdicionalTxID, referencia)))
+	b.WriteString(idCRC + "04")
+	return b.String() + fmt.Sprintf("%04X", CRC16(b.String())), nil
+}
+
+// campo: campo TLV com o ID e o tamanho do valor (até 99 caracteres)
+func campo(id, valor string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(valor), valor)
+}
+
+// CRC16: CRC16 CCITT-FALSE (polinômio 0x1021, valor inicial 0xFFFF) do texto, calculado
+// pelo BR Code sobre todos os campos e o início do campo 63 ("6304")
+func CRC16(texto string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(texto); i++ {
+		crc ^= uint16(texto[i]) << 8
+		for b := 0; b < 8; b++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Decodificar: lê o BR Code, verificando o CRC16, e retorna a cobrança. No BR Code
+// dinâmico, o txid é o informado no campo 62-05 ("***") e a localização é a URL recebida.
+func Decodificar(brcode string) (Cobranca, error) {
+	var c Cobranca
+	brcode = strings.TrimSpace(brcode)
+	if len(brcode) < 8 || brcode[len(brcode)-8:len(brcode)-4] != idCRC+"04" {
+		return c, errors.New("BR Code sem o CRC16 no final")
+	}
+	crc, err := strconv.ParseUint(brcode[len(brcode)-4:], 16, 16)
+	if err != nil || uint16(crc) != CRC16(brcode[:len(brcode)-4]) {
+		return c, errors.New("CRC16 do BR Code não confere")
+	}
+	campos, err := lerCampos(brcode[:len(brcode)-8])
+	if err != nil {
+		return c, err
+	}
+	if campos[idFormato] != "01" {
+		return c, fmt.Errorf("Formato do BR Code não suportado [%s]", campos[idFormato])
+	}
+	conta, err := lerCampos(campos[idConta])
+	if err != nil || !strings.EqualFold(conta[idContaGUI], GUI) {
+		return c, errors.New("BR Code sem a conta PIX (campo 26)")
+	}
+	c.Chave, c.Localizacao = conta[idContaChave], conta[idContaURL]
+	c.Nome, c.Cidade = campos[idNome], campos[idCidade]
+	if v := campos[idValor]; v != "" {
+		if c.Valor, err = centavos(v); err != nil {
+			return c, err
+		}
+	}
+	if adicionais, err := lerCampos(campos[idAdicionais]); err == nil {
+		c.TxID = adicionais[idAdicionalTxID]
+	}
+	return c, nil
+}
+
+// lerCampos: campos TLV do texto, pelo ID
+func lerCampos(texto string) (map[string]string, error) {
+	campos := make(map[string]string)
+	for len(texto) > 0 {
+		if len(texto) < 4 {
+			return nil, fmt.Errorf("Campo incompleto no BR Code [%s]", texto)
+		}
+		tamanho, err := strconv.Atoi(texto[2:4])
+		if err != nil || len(texto) < 4+tamanho {
+			return nil, fmt.Errorf("Tamanho inválido do campo %s do BR Code", texto[:2])
+		}
+		campos[texto[:2]] = texto[4 : 4+tamanho]
+		texto = texto[4+tamanho:]
+	}
+	return campos, nil
+}
+
+// centavos: valor do campo 54 (reais com ponto decimal) em centavos
+func centavos(v string) (int64, error) {
+	inteiro, fracao := v, ""
+	if i := strings.Index(v, "."); i >= 0 {
+		inteiro, fracao = v[:i], v[i+1:]
+	}
+	if len(fracao) > 2 {
+		return 0, fmt.Errorf("Valor inválido no BR Code [%s]", v)
+	}
+	n, err := strconv.ParseInt(inteiro+(fracao + "00")[:2], 10, 64)
+	if err != nil || inteiro == "" {
+		return 0, fmt.Errorf("Valor inválido no BR Code [%s]", v)
+	}
+	return n, nil
+}
@@ -0,0 +1,233 @@
+package pix
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func cobranca() Cobranca {
+	return Cobranca{
+		Recebedor: Recebedor{Chave: "12345678909", Nome: "Cauê Pereira", Cidade: "São Paulo"},
+		TxID:      "f2c4a8b0",
+		Valor:     15000,
+	}
+}
+
+func TestCRC16(t *testing.T) {
+	casos := []struct {
+		texto string
+		crc   uint16
+	}{
+		// valor de verificação do CRC-16/CCITT-FALSE
+		{"123456789", 0x29B1},
+		// exemplo de BR Code dinâmico do manual do Banco Central
+		{"00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***6304", 0x1D3D},
+		{"", 0xFFFF},
+	}
+	for _, c := range casos {
+		if crc := CRC16(c.texto); crc != c.crc {
+			t.Errorf("CRC16(%q) = %04X, esperado %04X", c.texto, crc, c.crc)
+		}
+	}
+}
+
+func TestBRCode(t *testing.T) {
+	dinamica := cobranca()
+	dinamica.Localizacao = "pix.banco.com.br/cob/{txid}"
+
+	casos := []struct {
+		nome     string
+		cobranca Cobranca
+		campos   []string
+	}{
+		{"com a chave", cobranca(), []string{
+			"000201",
+			"010212",
+			"2633" + "0014br.gov.bcb.pix" + "011112345678909",
+			"52040000",
+			"5303986",
+			"5406150.00",
+			"5802BR",
+			"5912Caue Pereira",
+			"6009Sao Paulo",
+			"6212" + "0508f2c4a8b0",
+			"6304" + "7DFF",
+		}},
+		{"com a localização", dinamica, []string{
+			"000201",
+			"010212",
+			"2651" + "0014br.gov.bcb.pix" + "2529pix.banco.com.br/cob/f2c4a8b0",
+			"52040000",
+			"5303986",
+			"5406150.00",
+			"5802BR",
+			"5912Caue Pereira",
+			"6009Sao Paulo",
+			"6207" + "0503***",
+			"6304" + "D164",
+		}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			brcode, err := c.cobranca.BRCode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if esperado := strings.Join(c.campos, ""); brcode != esperado {
+				t.Fatalf("BR Code\n%s\nesperado\n%s", brcode, esperado)
+			}
+
+			lida, err := Decodificar(brcode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lida.Valor != c.cobranca.Valor || lida.Nome != "Caue Pereira" || lida.Cidade != "Sao Paulo" {
+				t.Fatalf("cobrança lida %+v", lida)
+			}
+			if c.cobranca.Localizacao == "" && (lida.Chave != c.cobranca.Chave || lida.TxID != c.cobranca.TxID) {
+				t.Fatalf("cobrança lida %+v", lida)
+			}
+			if c.cobranca.Localizacao != "" && (lida.Localizacao != "pix.banco.com.br/cob/f2c4a8b0" || lida.TxID != "***") {
+				t.Fatalf("cobrança lida %+v", lida)
+			}
+		})
+	}
+}
+
+func TestBRCodeValores(t *testing.T) {
+	for valor, texto := range map[int64]string{1: "0.01", 99: "0.99", 100: "1.00", 123456: "1234.56"} {
+		c := cobranca()
+		c.Valor = valor
+		brcode, err := c.BRCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(brcode, campo(idValor, texto)) {
+			t.Errorf("valor %d fora do campo 54 [%s]: %s", valor, texto, brcode)
+		}
+		if lida, err := Decodificar(brcode); err != nil || lida.Valor != valor {
+			t.Errorf("valor %d lido como %d (%v)", valor, lida.Valor, err)
+		}
+	}
+}
+
+func TestBRCodeInvalido(t *testing.T) {
+	casos := []struct {
+		nome    string
+		alterar func(*Cobranca)
+		erro    string
+	}{
+		{"chave", func(c *Cobranca) { c.Chave = "123.456.789-09" }, "Chave PIX inválida"},
+		{"nome longo", func(c *Cobranca) { c.Nome = strings.Repeat("a", TamanhoMaximoNome+1) }, "excede 25"},
+		{"cidade vazia", func(c *Cobranca) { c.Cidade = " " }, "não foi informado"},
+		{"nome fora do ASCII", func(c *Cobranca) { c.Nome = "Caue ñ" }, "contém o caractere"},
+		{"localização com https", func(c *Cobranca) { c.Localizacao = "https://pix.banco.com.br/{txid}" }, "Localização inválida"},
+		{"localização sem txid", func(c *Cobranca) { c.Localizacao = "pix.banco.com.br/cob" }, "Localização inválida"},
+		{"txid com hífen", func(c *Cobranca) { c.TxID = "f2c4-a8b0" }, "txid inválido"},
+		{"valor zero", func(c *Cobranca) { c.Valor = 0 }, "Valor inválido"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			cob := cobranca()
+			c.alterar(&cob)
+			if _, err := cob.BRCode(); err == nil || !strings.Contains(err.Error(), c.erro) {
+				t.Fatalf("erro = %v, esperado %q", err, c.erro)
+			}
+		})
+	}
+}
+
+func TestDecodificarInvalido(t *testing.T) {
+	brcode, err := cobranca().BRCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// comCRC: texto com o campo 63 e o CRC16 calculado
+	comCRC := func(texto string) string {
+		texto += idCRC + "04"
+		return texto + fmt.Sprintf("%04X", CRC16(texto))
+	}
+
+	casos := []struct {
+		nome   string
+		brcode string
+		erro   string
+	}{
+		{"sem CRC", brcode[:len(brcode)-8], "sem o CRC16"},
+		{"CRC alterado", brcode[:len(brcode)-4] + "0000", "CRC16 do BR Code não confere"},
+		{"valor alterado", strings.Replace(brcode, "5406150.00", "5406140.00", 1), "CRC16 do BR Code não confere"},
+		{"CRC em minúsculas", brcode[:len(brcode)-4] + strings.ToLower(brcode[len(brcode)-4:]), ""},
+		{"campo truncado", comCRC("0002010102122650" + campo(idContaGUI, GUI)), "Tamanho inválido do campo 26"},
+		{"campo incompleto", comCRC("00020101021226"), "Campo incompleto"},
+		{"formato", comCRC("000202"), "Formato do BR Code não suportado"},
+		{"sem a conta PIX", comCRC("000201" + campo(idConta, campo(idContaGUI, "br.gov.bcb.outro"))), "sem a conta PIX"},
+		{"valor com três decimais", comCRC("000201" + campo(idConta, campo(idContaGUI, GUI)) + campo(idValor, "1.005")), "Valor inválido no BR Code"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			_, err := Decodificar(c.brcode)
+			switch {
+			case c.erro == "" && err != nil:
+				t.Fatalf("BR Code recusado: %s", err)
+			case c.erro != "" && (err == nil || !strings.Contains(err.Error(), c.erro)):
+				t.Fatalf("erro = %v, esperado %q", err, c.erro)
+			}
+		})
+	}
+}
+
+func TestValidarTxID(t *testing.T) {
+	casos := []struct {
+		txid     string
+		dinamico bool
+		valido   bool
+	}{
+		{"f2c4a8b0", false, true},
+		{strings.Repeat("a", 25), false, true},
+		{strings.Repeat("a", 26), false, false},
+		{strings.Repeat("a", 35), true, true},
+		{strings.Repeat("a", 36), true, false},
+		{"", false, false},
+		{"f2c4-a8b0", true, false},
+	}
+	for _, c := range casos {
+		if err := ValidarTxID(c.txid, c.dinamico); (err == nil) != c.valido {
+			t.Errorf("ValidarTxID(%q, %v) = %v", c.txid, c.dinamico, err)
+		}
+	}
+}
+
+func TestValidarEndToEndID(t *testing.T) {
+	casos := []struct {
+		id     string
+		valido bool
+	}{
+		{"E00000000202611101430PIXDOJO0001", true},
+		{"E0000000020261110143PIXDOJO00012", false},  // data e hora com 11 dígitos
+		{"E00000000202613101430PIXDOJO0001", false},  // mês 13
+		{"E00000000202611101430PIXDOJO-001", false},  // caractere fora do formato
+		{"D00000000202611101430PIXDOJO0001", false},  // devolução
+		{"E00000000202611101430PIXDOJO00012", false}, // 12 caracteres no final
+	}
+	for _, c := range casos {
+		if err := ValidarEndToEndID(c.id); (err == nil) != c.valido {
+			t.Errorf("ValidarEndToEndID(%q) = %v", c.id, err)
+		}
+	}
+}
+
+func TestValidarChave(t *testing.T) {
+	validas := []string{"12345678909", "12345678000195", "+5511987654321", "pagador@exemplo.com.br", "123e4567-e12b-12d1-a456-426655440000"}
+	invalidas := []string{"", "123.456.789-09", "11987654321@", "+551198765", "123E4567-E12B-12D1-A456-426655440000"}
+	for _, chave := range validas {
+		if err := ValidarChave(chave); err != nil {
+			t.Errorf("chave %q recusada: %s", chave, err)
+		}
+	}
+	for _, chave := range invalidas {
+		if ValidarChave(chave) == nil {
+			t.Errorf("chave %q aceita", chave)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Descrição: desenho dos QR Codes em PNG e SVG
+A escala é a quantidade de pixels (PNG) ou unidades (SVG) por módulo, e a margem é a
+zona de silêncio em módulos (a norma exige ao menos 4).
+*/
+
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// MargemPadrao - zona de silêncio exigida pela norma, em módulos
+const MargemPadrao = 4
+
+// PNG: imagem PNG do QR Code, em preto e branco
+func (c *Codigo) PNG(escala, margem int) ([]byte, error) {
+	if escala < 1 || margem < 0 {
+		return nil, fmt.Errorf("Escala [%d] e margem [%d] inválidas", escala, margem)
+	}
+	lado := (c.Tamanho + 2*margem) * escala
+	img := image.NewPaletted(image.Rect(0, 0, lado, lado), color.Palette{color.White, color.Black})
+	for y := 0; y < lado; y++ {
+		for x := 0; x < lado; x++ {
+			if c.Escuro(x/escala-margem, y/escala-margem) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// SVG: imagem SVG do QR Code, com os módulos escuros em um único path
+func (c *Codigo) SVG(escala, margem int) ([]byte, error) {
+	if escala < 1 || margem < 0 {
+		return nil, fmt.Errorf("Escala [%d] e margem [%d] inválidas", escala, margem)
+	}
+	lado := c.Tamanho + 2*margem
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`+"\n",
+		lado, lado, lado*escala, lado*escala)
+	b.WriteString(`<rect width="100%" height="100%" fill="#FFFFFF"/>` + "\n")
+	b.WriteString(`<path fill="#000000" d="`)
+	primeiro := true
+	for y := 0; y < c.Tamanho; y++ {
+		for x := 0; x < c.Tamanho; x++ {
+			if c.Escuro(x, y) {
+				if !primeiro {
+					b.WriteByte(' ')
+				}
+				fmt.Fprintf(&b, "M%d,%dh1v1h-1z", x+margem, y+margem)
+				primeiro = false
+			}
+		}
+	}
+	b.WriteString(`"/>` + "\n</svg>\n")
+	return b.Bytes(), nil
+}
@@ -0,0 +1,508 @@
+/*
+Descrição: codificação de QR Codes (ISO/IEC 18004) para os BR Codes do PIX
+Os dados são codificados no modo byte, na menor versão (1 a 40) que os comporta com o
+nível de correção de erros pedido. Os blocos de correção são calculados por Reed-Solomon
+sobre GF(256) e a máscara é a de menor penalidade, como recomenda a norma. A geração não
+depende de serviços externos (ver imagem.go para os formatos PNG e SVG).
+*/
+
+// Package qrcode gera QR Codes no modo byte e os desenha em PNG ou SVG, sem dependências
+// externas, para imprimir ou exibir o BR Code das cobranças PIX.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Nivel - nível de correção de erros
+type Nivel int
+
+// Níveis de correção de erros, da menor (7% dos códigos recuperáveis) à maior (30%)
+const (
+	NivelL Nivel = iota
+	NivelM
+	NivelQ
+	NivelH
+)
+
+// NivelPadrao - nível utilizado nos BR Codes (o Banco Central recomenda M)
+const NivelPadrao = NivelM
+
+// ConverterNivel: converte o nome do nível (L, M, Q ou H)
+func ConverterNivel(nome string) (Nivel, error) {
+	switch nome {
+	case "L", "l":
+		return NivelL, nil
+	case "M", "m":
+		return NivelM, nil
+	case "Q", "q":
+		return NivelQ, nil
+	case "H", "h":
+		return NivelH, nil
+	}
+	return 0, fmt.Errorf("Nível de correção desconhecido [%s]. Esperado L, M, Q ou H", nome)
+}
+
+// String: nome do nível
+func (n Nivel) String() string {
+	return [...]string{"L", "M", "Q", "H"}[n]
+}
+
+// bitsFormato: indicador do nível nas informações de formato
+func (n Nivel) bitsFormato() int {
+	return [...]int{1, 0, 3, 2}[n]
+}
+
+// eccPorBloco: códigos de correção por bloco, por nível e versão (índice 0 não utilizado)
+var eccPorBloco = [4][41]int{
+	{0, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{0, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{0, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// blocos: quantidade de blocos de correção, por nível e versão (índice 0 não utilizado)
+var blocos = [4][41]int{
+	{0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{0, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{0, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Codigo - QR Code gerado: a matriz de módulos (true: escuro), sem a margem
+type Codigo struct {
+	Versao  int
+	Nivel   Nivel
+	Mascara int
+	Tamanho int // módulos por lado (17 + 4 * versão)
+
+	modulos [][]bool
+	funcao  [][]bool // módulos dos padrões de função, fora da área de dados
+}
+
+// Codificar: gera o QR Code dos dados, no modo byte, na menor versão possível
+func Codificar(dados []byte, nivel Nivel) (*Codigo, error) {
+	if nivel < NivelL || nivel > NivelH {
+		return nil, fmt.Errorf("Nível de correção inválido [%d]", nivel)
+	}
+	versao := 0
+	for v := 1; v <= 40; v++ {
+		if 4+bitsContagem(v)+8*len(dados) <= 8*codigosDados(v, nivel) {
+			versao = v
+			break
+		}
+	}
+	if versao == 0 {
+		return nil, errors.New("Dados excedem a capacidade do QR Code")
+	}
+
+	// modo byte (0100), contagem de caracteres e dados
+	var bits []bool
+	bits = anexarBits(bits, 0x4, 4)
+	bits = anexarBits(bits, len(dados), bitsContagem(versao))
+	for _, b := range dados {
+		bits = anexarBits(bits, int(b), 8)
+	}
+	capacidade := 8 * codigosDados(versao, nivel)
+	terminador := capacidade - len(bits)
+	if terminador > 4 {
+		terminador = 4
+	}
+	bits = anexarBits(bits, 0, terminador)
+	bits = anexarBits(bits, 0, (8-len(bits)%8)%8)
+	for preenchimento := 0xEC; len(bits) < capacidade; preenchimento ^= 0xEC ^ 0x11 {
+		bits = anexarBits(bits, preenchimento, 8)
+	}
+	codigos := make([]byte, len(bits)/8)
+	for i, b := range bits {
+		if b {
+			codigos[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+
+	c := &Codigo{Versao: versao, Nivel: nivel, Tamanho: 17 + 4*versao}
+	c.modulos = matriz(c.Tamanho)
+	c.funcao = matriz(c.Tamanho)
+	c.desenharPadroes()
+	c.desenharCodigos(intercalar(codigos, versao, nivel))
+
+	// máscara de menor penalidade
+	menor := -1
+	for m := 0; m < 8; m++ {
+		c.aplicarMascara(m)
+		c.desenharFormato(m)
+		if p := c.penalidade(); menor < 0 || p < menor {
+			menor, c.Mascara = p, m
+		}
+		c.aplicarMascara(m) // desfaz (XOR)
+	}
+	c.aplicarMascara(c.Mascara)
+	c.desenharFormato(c.Mascara)
+	c.funcao = nil
+	return c, nil
+}
+
+// Escuro: indica se o módulo da coluna x e da linha y é escuro
+func (c *Codigo) Escuro(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.Tamanho && y < c.Tamanho && c.modulos[y][x]
+}
+
+// bitsContagem: tamanho da contagem de caracteres no modo byte
+func bitsContagem(versao int) int {
+	if versao < 10 {
+		return 8
+	}
+	return 16
+}
+
+// modulosDados: módulos disponíveis para os códigos (dados e correção) na versão
+func modulosDados(versao int) int {
+	n := (16*versao+128)*versao + 64
+	if versao >= 2 {
+		alinhamentos := versao/7 + 2
+		n -= (25*alinhamentos-10)*alinhamentos - 55
+		if versao >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// codigosDados: códigos de dados (sem a correção) na versão e nível
+func codigosDados(versao int, nivel Nivel) int {
+	return modulosDados(versao)/8 - eccPorBloco[nivel][versao]*blocos[nivel][versao]
+}
+
+// anexarBits: acrescenta os n bits menos significativos do valor, do mais significativo
+func anexarBits(bits []bool, valor, n int) []bool {
+	for i := n - 1; i >= 0; i-- {
+		bits = append(bits, valor>>uint(i)&1 != 0)
+	}
+	return bits
+}
+
+// matriz: matriz quadrada de módulos
+func matriz(tamanho int) [][]bool {
+	m := make([][]bool, tamanho)
+	for i := range m {
+		m[i] = make([]bool, tamanho)
+	}
+	return m
+}
+
+// intercalar: divide os códigos de dados nos blocos, calcula a correção de cada bloco e
+// intercala os códigos dos blocos (os blocos curtos têm um código de dados a menos)
+func intercalar(dados []byte, versao int, nivel Nivel) []byte {
+	quantidade, ecc := blocos[nivel][versao], eccPorBloco[nivel][versao]
+	total := modulosDados(versao) / 8
+	curtos := quantidade - total%quantidade
+	tamanhoCurto := total / quantidade // dados e correção de um bloco curto
+
+	divisor := divisorRS(ecc)
+	var bs [][]byte
+	for i, k := 0, 0; i < quantidade; i++ {
+		n := tamanhoCurto - ecc
+		if i >= curtos {
+			n++
+		}
+		bloco := append([]byte{}, dados[k:k+n]...)
+		k += n
+		bloco = append(bloco, restoRS(bloco, divisor)...)
+		if i < curtos {
+			// posição vazia para alinhar a correção dos blocos curtos e longos
+			bloco = append(bloco[:n], append([]byte{0}, bloco[n:]...)...)
+		}
+		bs = append(bs, bloco)
+	}
+
+	resultado := make([]byte, 0, total)
+	for i := 0; i <= tamanhoCurto; i++ {
+		for j, bloco := range bs {
+			if i != tamanhoCurto-ecc || j >= curtos {
+				resultado = append(resultado, bloco[i])
+			}
+		}
+	}
+	return resultado
+}
+
+// multiplicarGF: produto em GF(256) com o polinômio x^8 + x^4 + x^3 + x^2 + 1
+func multiplicarGF(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// divisorRS: polinômio gerador de Reed-Solomon do grau informado (sem o coeficiente do
+// termo de maior grau)
+func divisorRS(grau int) []byte {
+	d := make([]byte, grau)
+	d[grau-1] = 1
+	raiz := byte(1)
+	for i := 0; i < grau; i++ {
+		for j := range d {
+			d[j] = multiplicarGF(d[j], raiz)
+			if j+1 < grau {
+				d[j] ^= d[j+1]
+			}
+		}
+		raiz = multiplicarGF(raiz, 0x02)
+	}
+	return d
+}
+
+// restoRS: códigos de correção dos dados (resto da divisão pelo gerador)
+func restoRS(dados, divisor []byte) []byte {
+	r := make([]byte, len(divisor))
+	for _, b := range dados {
+		fator := b ^ r[0]
+		copy(r, r[1:])
+		r[len(r)-1] = 0
+		for i := range r {
+			r[i] ^= multiplicarGF(divisor[i], fator)
+		}
+	}
+	return r
+}
+
+// definir: define um módulo de um padrão de função
+func (c *Codigo) definir(x, y int, escuro bool) {
+	c.modulos[y][x] = escuro
+	c.funcao[y][x] = true
+}
+
+// desenharPadroes: temporização, localizadores, alinhamentos, área reservada ao formato
+// e informação de versão
+func (c *Codigo) desenharPadroes() {
+	t := c.Tamanho
+	for i := 0; i < t; i++ {
+		c.definir(6, i, i%2 == 0)
+		c.definir(i, 6, i%2 == 0)
+	}
+	for _, p := range [][2]int{{3, 3}, {t - 4, 3}, {3, t - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x >= 0 && y >= 0 && x < t && y < t {
+					d := maximo(abs(dx), abs(dy))
+					c.definir(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	posicoes := posicoesAlinhamento(c.Versao)
+	n := len(posicoes)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			// os cantos ocupados pelos localizadores
+			if i == 0 && j == 0 || i == 0 && j == n-1 || i == n-1 && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.definir(posicoes[i]+dx, posicoes[j]+dy, maximo(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	c.desenharFormato(0) // reserva a área, reescrita com a máscara escolhida
+	if c.Versao >= 7 {
+		resto := c.Versao
+		for i := 0; i < 12; i++ {
+			resto = resto<<1 ^ (resto>>11)*0x1F25
+		}
+		bits := c.Versao<<12 | resto
+		for i := 0; i < 18; i++ {
+			escuro := bits>>uint(i)&1 != 0
+			a, b := t-11+i%3, i/3
+			c.definir(a, b, escuro)
+			c.definir(b, a, escuro)
+		}
+	}
+}
+
+// posicoesAlinhamento: centros dos padrões de alinhamento em cada eixo
+func posicoesAlinhamento(versao int) []int {
+	if versao == 1 {
+		return nil
+	}
+	n := versao/7 + 2
+	passo := (versao*4 + n*2 + 1) / (n*2 - 2) * 2
+	if versao == 32 {
+		passo = 26
+	}
+	p := make([]int, n)
+	p[0] = 6
+	for i, pos := n-1, 17+4*versao-7; i >= 1; i, pos = i-1, pos-passo {
+		p[i] = pos
+	}
+	return p
+}
+
+// desenharFormato: informações de formato (nível e máscara, com BCH), nas duas cópias,
+// e o módulo escuro fixo
+func (c *Codigo) desenharFormato(mascara int) {
+	dados := c.Nivel.bitsFormato()<<3 | mascara
+	resto := dados
+	for i := 0; i < 10; i++ {
+		resto = resto<<1 ^ (resto>>9)*0x537
+	}
+	bits := (dados<<10 | resto) ^ 0x5412
+	bit := func(i int) bool { return bits>>uint(i)&1 != 0 }
+
+	t := c.Tamanho
+	for i := 0; i <= 5; i++ {
+		c.definir(8, i, bit(i))
+	}
+	c.definir(8, 7, bit(6))
+	c.definir(8, 8, bit(7))
+	c.definir(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.definir(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		c.definir(t-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.definir(8, t-15+i, bit(i))
+	}
+	c.definir(8, t-8, true)
+}
+
+// desenharCodigos: posiciona os bits dos códigos nas colunas duplas, em ziguezague da
+// direita para a esquerda, pulando a coluna da temporização vertical
+func (c *Codigo) desenharCodigos(codigos []byte) {
+	t := c.Tamanho
+	i := 0
+	for direita := t - 1; direita >= 1; direita -= 2 {
+		if direita == 6 {
+			direita = 5
+		}
+		for vert := 0; vert < t; vert++ {
+			for j := 0; j < 2; j++ {
+				x := direita - j
+				y := vert
+				if (direita+1)&2 == 0 { // subindo
+					y = t - 1 - vert
+				}
+				if !c.funcao[y][x] && i < len(codigos)*8 {
+					c.modulos[y][x] = codigos[i/8]>>uint(7-i%8)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// aplicarMascara: inverte os módulos de dados selecionados pela máscara
+func (c *Codigo) aplicarMascara(mascara int) {
+	for y := 0; y < c.Tamanho; y++ {
+		for x := 0; x < c.Tamanho; x++ {
+			var inverter bool
+			switch mascara {
+			case 0:
+				inverter = (x+y)%2 == 0
+			case 1:
+				inverter = y%2 == 0
+			case 2:
+				inverter = x%3 == 0
+			case 3:
+				inverter = (x+y)%3 == 0
+			case 4:
+				inverter = (x/3+y/2)%2 == 0
+			case 5:
+				inverter = x*y%2+x*y%3 == 0
+			case 6:
+				inverter = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				inverter = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if inverter && !c.funcao[y][x] {
+				c.modulos[y][x] = !c.modulos[y][x]
+			}
+		}
+	}
+}
+
+// padrões semelhantes ao localizador, penalizados na regra 3
+var (
+	padraoLocalizador1 = []bool{true, false, true, true, true, false, true, false, false, false, false}
+	padraoLocalizador2 = []bool{false, false, false, false, true, false, true, true, true, false, true}
+)
+
+// penalidade: soma das quatro regras de penalidade da norma (sequências da mesma cor,
+// blocos 2x2, padrões semelhantes ao localizador e proporção de módulos escuros)
+func (c *Codigo) penalidade() int {
+	t := c.Tamanho
+	p := 0
+	linha := make([]bool, t)
+	for eixo := 0; eixo < 2; eixo++ {
+		for i := 0; i < t; i++ {
+			for j := 0; j < t; j++ {
+				if eixo == 0 {
+					linha[j] = c.modulos[i][j]
+				} else {
+					linha[j] = c.modulos[j][i]
+				}
+			}
+			for j := 0; j < t; {
+				k := j
+				for k < t && linha[k] == linha[j] {
+					k++
+				}
+				if k-j >= 5 {
+					p += 3 + k - j - 5
+				}
+				j = k
+			}
+			for j := 0; j+11 <= t; j++ {
+				if igual(linha[j:j+11], padraoLocalizador1) || igual(linha[j:j+11], padraoLocalizador2) {
+					p += 40
+				}
+			}
+		}
+	}
+	escuros := 0
+	for y := 0; y < t; y++ {
+		for x := 0; x < t; x++ {
+			if c.modulos[y][x] {
+				escuros++
+			}
+			if x+1 < t && y+1 < t {
+				m := c.modulos[y][x]
+				if c.modulos[y][x+1] == m && c.modulos[y+1][x] == m && c.modulos[y+1][x+1] == m {
+					p += 3
+				}
+			}
+		}
+	}
+	p += abs(escuros*100/(t*t)-50) / 5 * 10
+	return p
+}
+
+func igual(a, b []bool) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func maximo(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,435 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// brCode: exemplo de BR Code dinâmico do manual do Banco Central
+const brCode = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+// linhas: matriz do QR Code, uma linha por string ('#': módulo escuro)
+func linhas(c *Codigo) []string {
+	m := make([]string, c.Tamanho)
+	for y := range m {
+		var b strings.Builder
+		for x := 0; x < c.Tamanho; x++ {
+			if c.Escuro(x, y) {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		m[y] = b.String()
+	}
+	return m
+}
+
+func TestMatriz(t *testing.T) {
+	// matrizes conferidas com um decodificador independente (ZXing)
+	casos := []struct {
+		dados   string
+		nivel   Nivel
+		mascara int
+		matriz  []string
+	}{
+		{"PIX", NivelM, 2, []string{
+			"#######.......#######",
+			"#.....#..##...#.....#",
+			"#.###.#.#..##.#.###.#",
+			"#.###.#.#..##.#.###.#",
+			"#.###.#.#.#.#.#.###.#",
+			"#.....#.#.##..#.....#",
+			"#######.#.#.#.#######",
+			"........###..........",
+			"#.#####...##..#####..",
+			"...##..########......",
+			"...##.###...#.##.###.",
+			"####.#..#..####..##..",
+			"#######..#..#..#.##..",
+			"........#...#..#...#.",
+			"#######..#.#.#..#.##.",
+			"#.....#.#......##.###",
+			"#.###.#.####.#..#.#..",
+			"#.###.#.##.####..#...",
+			"#.###.#.#...#.##.....",
+			"#.....#...#####..#...",
+			"#######.#...#..#..##.",
+		}},
+		{"https://pix.bcb.gov.br", NivelQ, 3, []string{
+			"#######...#########.#.#######",
+			"#.....#.###..#.###.##.#.....#",
+			"#.###.#.##..###.....#.#.###.#",
+			"#.###.#...#..#..#####.#.###.#",
+			"#.###.#..#...##.##.#..#.###.#",
+			"#.....#..##.#####..##.#.....#",
+			"#######.#.#.#.#.#.#.#.#######",
+			".........#...#..###.#........",
+			".###.##.....#.#.....#.....##.",
+			".###.#.###.#..#..#...#..###.#",
+			".#.##.##.##.###.#.#...####.#.",
+			"#..#.#.##..##..#...#........#",
+			"#..#####.#.#..#.##...#.#.####",
+			"..##.#.....#.#.##..#..##.##.#",
+			"...#.####.##..######.#.###.##",
+			"..##.#.###.#.##..##..##.##..#",
+			".#.####..###.##.###.###.##.#.",
+			".##..#.#..#..##.#..##.##.....",
+			"#...#.##.#.#...#####..#...#..",
+			"..#.....###.###..#.#.###..#.#",
+			".###.####..##.#.###.#########",
+			"........#..####...###...##.##",
+			"#######....####..#.##.#.#.##.",
+			"#.....#.##...#.##.#.#...#....",
+			"#.###.#...##...#..#.#######.#",
+			"#.###.#.###...#.####....##.#.",
+			"#.###.#.#..#.#.###..#..#.##.#",
+			"#.....#.##.#.#..#..#.#..##.#.",
+			"#######.....#..#..#.##.#...#.",
+		}},
+	}
+	for _, c := range casos {
+		codigo, err := Codificar([]byte(c.dados), c.nivel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codigo.Mascara != c.mascara {
+			t.Errorf("%q: máscara %d, esperada %d", c.dados, codigo.Mascara, c.mascara)
+		}
+		obtida := linhas(codigo)
+		if strings.Join(obtida, "\n") != strings.Join(c.matriz, "\n") {
+			t.Errorf("%q: matriz\n%s\nesperada\n%s", c.dados, strings.Join(obtida, "\n"), strings.Join(c.matriz, "\n"))
+		}
+	}
+}
+
+func TestVersao(t *testing.T) {
+	// capacidades do modo byte na tabela 7 da ISO/IEC 18004: o limite da versão e um byte a mais
+	casos := []struct {
+		nivel      Nivel
+		capacidade int
+		versao     int
+	}{
+		{NivelL, 17, 1},
+		{NivelM, 14, 1},
+		{NivelQ, 11, 1},
+		{NivelH, 7, 1},
+		{NivelL, 78, 4},
+		{NivelH, 58, 6},
+		{NivelM, 180, 9}, // a contagem passa a ter 16 bits na versão 10
+		{NivelL, 271, 10},
+		{NivelM, 213, 10},
+		{NivelQ, 151, 10},
+		{NivelH, 119, 10},
+		{NivelL, 2953, 40},
+		{NivelM, 2331, 40},
+		{NivelQ, 1663, 40},
+		{NivelH, 1273, 40},
+	}
+	for _, c := range casos {
+		t.Run(fmt.Sprintf("%s %d", c.nivel, c.capacidade), func(t *testing.T) {
+			codigo, err := Codificar(bytes.Repeat([]byte{'a'}, c.capacidade), c.nivel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if codigo.Versao != c.versao || codigo.Nivel != c.nivel || codigo.Tamanho != 17+4*c.versao {
+				t.Fatalf("versão %d (%d módulos, nível %s), esperada %d", codigo.Versao, codigo.Tamanho, codigo.Nivel, c.versao)
+			}
+			codigo, err = Codificar(bytes.Repeat([]byte{'a'}, c.capacidade+1), c.nivel)
+			if c.versao == 40 {
+				if err == nil {
+					t.Fatalf("versão %d gerada acima da capacidade", codigo.Versao)
+				}
+				return
+			}
+			if err != nil || codigo.Versao != c.versao+1 {
+				t.Fatalf("com um byte a mais: %v, esperada a versão %d", err, c.versao+1)
+			}
+		})
+	}
+
+	if _, err := Codificar([]byte("PIX"), Nivel(4)); err == nil {
+		t.Fatal("nível inválido aceito")
+	}
+	for nome, nivel := range map[string]Nivel{"L": NivelL, "m": NivelM, "Q": NivelQ, "h": NivelH} {
+		if n, err := ConverterNivel(nome); err != nil || n != nivel {
+			t.Errorf("ConverterNivel(%q) = %s, %v", nome, n, err)
+		}
+	}
+	if _, err := ConverterNivel("X"); err == nil {
+		t.Error("ConverterNivel aceitou um nível desconhecido")
+	}
+}
+
+func TestDecodificar(t *testing.T) {
+	casos := []string{
+		brCode,
+		strings.Repeat(brCode, 3), // versões com informação de versão e vários blocos
+		strings.Repeat(brCode, 9), // contagem de 16 bits, até a versão 40
+	}
+	for _, dados := range casos {
+		for nivel := NivelL; nivel <= NivelH; nivel++ {
+			codigo, err := Codificar([]byte(dados), nivel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lidos, n, mascara, err := decodificar(codigo)
+			if err != nil {
+				t.Fatalf("versão %d, nível %s: %s", codigo.Versao, nivel, err)
+			}
+			if string(lidos) != dados || n != nivel || mascara != codigo.Mascara {
+				t.Fatalf("versão %d: lidos %q, nível %s, máscara %d; esperados %q, %s, %d",
+					codigo.Versao, lidos, n, mascara, dados, nivel, codigo.Mascara)
+			}
+		}
+	}
+
+	// um módulo de dados trocado é detectado pela correção
+	codigo, err := Codificar([]byte(brCode), NivelPadrao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fim := codigo.Tamanho - 1
+	codigo.modulos[fim][fim] = !codigo.modulos[fim][fim]
+	if _, _, _, err := decodificar(codigo); err == nil || !strings.Contains(err.Error(), "síndrome") {
+		t.Fatalf("erro %v, esperada a síndrome diferente de zero", err)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	codigo, err := Codificar([]byte(brCode), NivelPadrao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := codigo.PNG(3, MargemPadrao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lado := (codigo.Tamanho + 2*MargemPadrao) * 3
+	if img.Bounds().Dx() != lado || img.Bounds().Dy() != lado {
+		t.Fatalf("imagem %v, esperado o lado %d", img.Bounds(), lado)
+	}
+	for y := 0; y < lado; y++ {
+		for x := 0; x < lado; x++ {
+			r, _, _, _ := img.At(x, y).RGBA()
+			if escuro := codigo.Escuro(x/3-MargemPadrao, y/3-MargemPadrao); escuro != (r == 0) {
+				t.Fatalf("pixel (%d, %d) escuro=%t, esperado %t", x, y, r == 0, escuro)
+			}
+		}
+	}
+	if _, err := codigo.PNG(0, MargemPadrao); err == nil {
+		t.Fatal("escala 0 aceita")
+	}
+
+	svg, err := codigo.SVG(3, MargemPadrao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	escuros := strings.Count(strings.Join(linhas(codigo), ""), "#")
+	if n := strings.Count(string(svg), "h1v1h-1z"); n != escuros {
+		t.Fatalf("SVG com %d módulos, esperados %d", n, escuros)
+	}
+}
+
+// decodificar: lê os dados, o nível e a máscara da matriz, como um leitor de QR Codes:
+// confere o BCH das informações de formato e de versão e a correção Reed-Solomon de
+// cada bloco, e interpreta o segmento no modo byte
+func decodificar(c *Codigo) ([]byte, Nivel, int, error) {
+	t := c.Tamanho
+	bit := func(x, y int) int {
+		if c.Escuro(x, y) {
+			return 1
+		}
+		return 0
+	}
+
+	// informações de formato, nas duas cópias
+	var formato1, formato2 int
+	for i := 0; i < 15; i++ {
+		var x1, y1, x2, y2 int
+		switch {
+		case i <= 5:
+			x1, y1 = 8, i
+		case i <= 7:
+			x1, y1 = 8, i+1
+		case i == 8:
+			x1, y1 = 7, 8
+		default:
+			x1, y1 = 14-i, 8
+		}
+		if i < 8 {
+			x2, y2 = t-1-i, 8
+		} else {
+			x2, y2 = 8, t-15+i
+		}
+		formato1 |= bit(x1, y1) << uint(i)
+		formato2 |= bit(x2, y2) << uint(i)
+	}
+	if formato1 != formato2 {
+		return nil, 0, 0, fmt.Errorf("Cópias do formato divergentes: %015b, %015b", formato1, formato2)
+	}
+	formato := formato1 ^ 0x5412
+	if restoBCH(formato, 0x537) != 0 {
+		return nil, 0, 0, fmt.Errorf("BCH do formato inválido: %015b", formato1)
+	}
+	nivel := [...]Nivel{NivelM, NivelL, NivelH, NivelQ}[formato>>13]
+	mascara := formato >> 10 & 7
+	if bit(8, t-8) != 1 {
+		return nil, 0, 0, errors.New("Módulo escuro fixo ausente")
+	}
+
+	// versão: pelo tamanho e, a partir da 7, pelas informações de versão nas duas cópias
+	versao := (t - 17) / 4
+	if versao >= 7 {
+		var v1, v2 int
+		for i := 0; i < 18; i++ {
+			v1 |= bit(t-11+i%3, i/3) << uint(i)
+			v2 |= bit(i/3, t-11+i%3) << uint(i)
+		}
+		if v1 != v2 || restoBCH(v1, 0x1F25) != 0 || v1>>12 != versao {
+			return nil, 0, 0, fmt.Errorf("Informação de versão inválida: %018b, %018b", v1, v2)
+		}
+	}
+
+	// módulos de dados, em ziguezague, sem a máscara
+	padroes := &Codigo{Versao: versao, Nivel: nivel, Tamanho: t, modulos: matriz(t), funcao: matriz(t)}
+	padroes.desenharPadroes()
+	mascarado := [...]func(x, y int) bool{
+		func(x, y int) bool { return (y+x)%2 == 0 },
+		func(x, y int) bool { return y%2 == 0 },
+		func(x, y int) bool { return x%3 == 0 },
+		func(x, y int) bool { return (y+x)%3 == 0 },
+		func(x, y int) bool { return (y/2+x/3)%2 == 0 },
+		func(x, y int) bool { return y*x%2+y*x%3 == 0 },
+		func(x, y int) bool { return (y*x%2+y*x%3)%2 == 0 },
+		func(x, y int) bool { return ((y+x)%2+y*x%3)%2 == 0 },
+	}[mascara]
+	var codigos []byte
+	var atual byte
+	n := 0
+	subindo := true
+	for x := t - 1; x > 0; x -= 2 {
+		if x == 6 {
+			x--
+		}
+		for k := 0; k < t; k++ {
+			y := k
+			if subindo {
+				y = t - 1 - k
+			}
+			for _, xx := range []int{x, x - 1} {
+				if padroes.funcao[y][xx] {
+					continue
+				}
+				atual = atual<<1 | byte(bit(xx, y))
+				if mascarado(xx, y) {
+					atual ^= 1
+				}
+				if n++; n%8 == 0 {
+					codigos = append(codigos, atual)
+					atual = 0
+				}
+			}
+		}
+		subindo = !subindo
+	}
+
+	// separa os blocos e confere as síndromes de Reed-Solomon
+	quantidade, ecc := blocos[nivel][versao], eccPorBloco[nivel][versao]
+	totalDados := len(codigos) - quantidade*ecc
+	longos := totalDados % quantidade
+	bs := make([][]byte, quantidade)
+	k := 0
+	for i := 0; i <= totalDados/quantidade; i++ {
+		for j := range bs {
+			if i < totalDados/quantidade || j >= quantidade-longos {
+				bs[j] = append(bs[j], codigos[k])
+				k++
+			}
+		}
+	}
+	var dados []byte
+	for _, b := range bs {
+		dados = append(dados, b...)
+	}
+	for i := 0; i < ecc; i++ {
+		for j := range bs {
+			bs[j] = append(bs[j], codigos[k])
+			k++
+		}
+	}
+	for j, b := range bs {
+		raiz := byte(1)
+		for i := 0; i < ecc; i++ {
+			var s byte
+			for _, v := range b {
+				s = multiplicarGF(s, raiz) ^ v
+			}
+			if s != 0 {
+				return nil, 0, 0, fmt.Errorf("Bloco %d com a síndrome %d diferente de zero", j, i)
+			}
+			raiz = multiplicarGF(raiz, 2)
+		}
+	}
+
+	// segmento no modo byte, terminador e preenchimento
+	lerBits := func(inicio, n int) int {
+		v := 0
+		for i := inicio; i < inicio+n; i++ {
+			v = v<<1 | int(dados[i/8]>>uint(7-i%8)&1)
+		}
+		return v
+	}
+	if modo := lerBits(0, 4); modo != 0x4 {
+		return nil, 0, 0, fmt.Errorf("Modo %04b, esperado o modo byte", modo)
+	}
+	bitsContagem := 8
+	if versao >= 10 {
+		bitsContagem = 16
+	}
+	tamanho := lerBits(4, bitsContagem)
+	inicio := 4 + bitsContagem
+	lidos := make([]byte, tamanho)
+	for i := range lidos {
+		lidos[i] = byte(lerBits(inicio+8*i, 8))
+	}
+	fim := inicio + 8*tamanho
+	if terminador := 8*len(dados) - fim; terminador > 0 && lerBits(fim, minimo(terminador, 4)) != 0 {
+		return nil, 0, 0, errors.New("Terminador inválido")
+	}
+	for i, p := (fim+4+7)/8, byte(0xEC); i < len(dados); i, p = i+1, p^0xEC^0x11 {
+		if dados[i] != p {
+			return nil, 0, 0, fmt.Errorf("Preenchimento %02X, esperado %02X", dados[i], p)
+		}
+	}
+	return lidos, nivel, mascara, nil
+}
+
+// restoBCH: resto da divisão polinomial, sobre GF(2), do valor pelo gerador
+func restoBCH(valor, gerador int) int {
+	grau := 0
+	for g := gerador; g > 1; g >>= 1 {
+		grau++
+	}
+	for i := 31; i >= grau; i-- {
+		if valor>>uint(i)&1 != 0 {
+			valor ^= gerador << uint(i-grau)
+		}
+	}
+	return valor
+}
+
+func minimo(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
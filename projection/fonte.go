@@ -0,0 +1,101 @@
+package projection
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/CaueP/BlockchainDojo/events"
+)
+
+// EventoBloco - evento do chaincode com a sua posição na blockchain
+type EventoBloco struct {
+	Bloco  uint64 // número do bloco
+	Indice int    // posição do evento dentro do bloco
+	Evento events.Evento
+}
+
+// Fonte - origem dos eventos do chaincode
+type Fonte interface {
+	// Ler entrega a fn, em ordem, os eventos a partir do bloco informado (inclusive)
+	// até o último bloco disponível
+	Ler(aPartirDoBloco uint64, fn func(EventoBloco) error) error
+}
+
+// FonteREST - lê os eventos dos blocos pela API REST do peer (/chain e /chain/blocks/{n})
+type FonteREST struct {
+	URL         string // endereço da API REST do peer, ex.: http://localhost:7050
+	ChaincodeID string // se preenchido, considera apenas os eventos deste chaincode
+	Cliente     *http.Client
+}
+
+// bloco - trecho do JSON de /chain/blocks/{n} utilizado pela projeção
+type bloco struct {
+	NonHashData struct {
+		ChaincodeEvents []struct {
+			ChaincodeID string `json:"chaincodeID"`
+			TxID        string `json:"txID"`
+			EventName   string `json:"eventName"`
+			Payload     string `json:"payload"` // base64
+		} `json:"chaincodeEvents"`
+	} `json:"nonHashData"`
+}
+
+// Ler - implementação de Fonte
+func (f FonteREST) Ler(aPartirDoBloco uint64, fn func(EventoBloco) error) error {
+	var chain struct {
+		Height uint64 `json:"height"`
+	}
+	if err := f.obter("/chain", &chain); err != nil {
+		return err
+	}
+
+	for n := aPartirDoBloco; n < chain.Height; n++ {
+		var b bloco
+		if err := f.obter(fmt.Sprintf("/chain/blocks/%d", n), &b); err != nil {
+			return err
+		}
+		for i, ev := range b.NonHashData.ChaincodeEvents {
+			if f.ChaincodeID != "" && ev.ChaincodeID != f.ChaincodeID {
+				continue
+			}
+			if !events.TipoValido(events.Tipo(ev.EventName)) {
+				continue
+			}
+			payload, err := base64.StdEncoding.DecodeString(ev.Payload)
+			if err != nil {
+				return fmt.Errorf("Payload do evento %s no bloco %d inválido: %s", ev.EventName, n, err)
+			}
+			evento, err := events.Decodificar(payload)
+			if err != nil {
+				return fmt.Errorf("Bloco %d, evento %d: %s", n, i, err)
+			}
+			if err := fn(EventoBloco{Bloco: n, Indice: i, Evento: evento}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// obter: GET na API REST do peer, decodificando a resposta JSON em destino
+func (f FonteREST) obter(caminho string, destino interface{}) error {
+	cliente := f.Cliente
+	if cliente == nil {
+		cliente = http.DefaultClient
+	}
+	resp, err := cliente.Get(strings.TrimSuffix(f.URL, "/") + caminho)
+	if err != nil {
+		return fmt.Errorf("Falha ao consultar %s: %s", caminho, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Falha ao consultar %s: %s", caminho, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(destino); err != nil {
+		return fmt.Errorf("Resposta inválida de %s: %s", caminho, err)
+	}
+	return nil
+}
@@ -0,0 +1,420 @@
+/*
+Descrição: projeção de leitura das propostas a partir dos eventos do chaincode
+Os eventos (ver pacote events) são aplicados, em ordem, a um banco BoltDB local com
+as propostas, os pagadores e os pagamentos. A posição do último evento processado é
+gravada na mesma transação, permitindo retomar a leitura após uma reinicialização.
+*/
+
+// Package projection mantém uma projeção local das propostas, para relatórios
+// que não devem consultar o chaincode diretamente.
+package projection
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/boltdb/bolt"
+
+	"github.com/CaueP/BlockchainDojo/events"
+)
+
+// buckets da projeção
+var (
+	bucketPropostas  = []byte("propostas")
+	bucketPagadores  = []byte("pagadores")
+	bucketPagamentos = []byte("pagamentos")
+	bucketMeta       = []byte("meta")
+
+	chavePosicao = []byte("posicao")
+)
+
+// Proposta - mesmos campos da Proposta do chaincode, com o status derivado e
+// a última transação que alterou a proposta
+type Proposta struct {
+	ID                  string `json:"id_proposta"`
+	CpfPagador          string `json:"cpf_pagador"`
+	PagadorAceitou      bool   `json:"pagador_aceitou"`
+	BeneficiarioAceitou bool   `json:"beneficiario_aceitou"`
+	BoletoPago          bool   `json:"boleto_pago"`
+	NossoNumero         string `json:"nosso_numero"`
+	Valor               int64  `json:"valor"`
+	DataPagamento       string `json:"data_pagamento"`
+	Cancelada           bool   `json:"cancelada"`
+	DataVencimento      string `json:"data_vencimento,omitempty"`
+	Beneficiario        string `json:"beneficiario,omitempty"`
+	FormaPagamento      string `json:"forma_pagamento,omitempty"`
+	EndToEndID          string `json:"end_to_end_id,omitempty"`
+	Status              string `json:"status"`
+	CriadaEm            string `json:"criada_em,omitempty"`
+	AtualizadaEm        string `json:"atualizada_em,omitempty"`
+	UltimaTx            string `json:"ultima_tx"`
+}
+
+// Pagador - propostas associadas a um CPF
+type Pagador struct {
+	CpfPagador string   `json:"cpf_pagador"`
+	Propostas  []string `json:"propostas"`
+}
+
+// Pagamento - pagamento registrado para uma proposta
+type Pagamento struct {
+	IDProposta     string `json:"id_proposta"`
+	CodigoBanco    string `json:"codigo_banco,omitempty"`
+	NossoNumero    string `json:"nosso_numero"`
+	FormaPagamento string `json:"forma_pagamento,omitempty"`
+	EndToEndID     string `json:"end_to_end_id,omitempty"`
+	Valor          int64  `json:"valor"`
+	DataPagamento  string `json:"data_pagamento"`
+	TxID           string `json:"tx_id"`
+	Bloco          uint64 `json:"bloco"`
+}
+
+// Posicao - último evento aplicado à projeção
+type Posicao struct {
+	Bloco  uint64
+	Indice int
+	Vazia  bool // nenhum evento aplicado
+}
+
+// Projecao - projeção das propostas em um arquivo BoltDB
+type Projecao struct {
+	db *bolt.DB
+}
+
+// Abrir: abre (ou cria) a projeção no arquivo informado
+func Abrir(arquivo string) (*Projecao, error) {
+	db, err := bolt.Open(arquivo, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao abrir a projeção [%s]: %s", arquivo, err)
+	}
+	p := &Projecao{db: db}
+	if err := db.Update(criarBuckets); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Fechar: fecha o arquivo da projeção
+func (p *Projecao) Fechar() error {
+	return p.db.Close()
+}
+
+// criarBuckets: cria os buckets da projeção, caso não existam
+func criarBuckets(tx *bolt.Tx) error {
+	for _, nome := range [][]byte{bucketPropostas, bucketPagadores, bucketPagamentos, bucketMeta} {
+		if _, err := tx.CreateBucketIfNotExists(nome); err != nil {
+			return fmt.Errorf("Falha ao criar o bucket %s: %s", nome, err)
+		}
+	}
+	return nil
+}
+
+// Sincronizar: aplica os eventos da fonte a partir da última posição processada
+func (p *Projecao) Sincronizar(fonte Fonte) (int, error) {
+	pos, err := p.Posicao()
+	if err != nil {
+		return 0, err
+	}
+	aplicados := 0
+	err = fonte.Ler(pos.Bloco, func(ev EventoBloco) error {
+		ok, err := p.Aplicar(ev)
+		if ok {
+			aplicados++
+		}
+		return err
+	})
+	return aplicados, err
+}
+
+// Reconstruir: descarta a projeção e aplica novamente todos os eventos da fonte
+func (p *Projecao) Reconstruir(fonte Fonte) (int, error) {
+	err := p.db.Update(func(tx *bolt.Tx) error {
+		for _, nome := range [][]byte{bucketPropostas, bucketPagadores, bucketPagamentos, bucketMeta} {
+			if err := tx.DeleteBucket(nome); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		return criarBuckets(tx)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Falha ao limpar a projeção: %s", err)
+	}
+	return p.Sincronizar(fonte)
+}
+
+// Posicao: último evento aplicado à projeção
+func (p *Projecao) Posicao() (Posicao, error) {
+	pos := Posicao{Vazia: true}
+	err := p.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketMeta).Get(chavePosicao)
+		if len(v) == 16 {
+			pos = Posicao{
+				Bloco:  binary.BigEndian.Uint64(v[:8]),
+				Indice: int(binary.BigEndian.Uint64(v[8:])),
+			}
+		}
+		return nil
+	})
+	return pos, err
+}
+
+// Aplicar: aplica um evento à projeção. Eventos em posições já processadas são
+// ignorados (retorna false), o que torna seguro reler o último bloco.
+func (p *Projecao) Aplicar(ev EventoBloco) (bool, error) {
+	aplicado := false
+	err := p.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+		if v := meta.Get(chavePosicao); len(v) == 16 {
+			bloco := binary.BigEndian.Uint64(v[:8])
+			indice := int(binary.BigEndian.Uint64(v[8:]))
+			if ev.Bloco < bloco || (ev.Bloco == bloco && ev.Indice <= indice) {
+				return nil
+			}
+		}
+
+		if err := aplicarEvento(tx, ev); err != nil {
+			return err
+		}
+
+		pos := make([]byte, 16)
+		binary.BigEndian.PutUint64(pos[:8], ev.Bloco)
+		binary.BigEndian.PutUint64(pos[8:], uint64(ev.Indice))
+		aplicado = true
+		return meta.Put(chavePosicao, pos)
+	})
+	if err != nil {
+		return false, fmt.Errorf("Falha ao aplicar o evento %s da tx %s: %s", ev.Evento.Tipo, ev.Evento.TxID, err)
+	}
+	return aplicado, nil
+}
+
+// aplicarEvento: aplica os eventos de proposta contidos no evento do bloco (os itens
+// de um LoteExecutado são aplicados na mesma transação do banco)
+func aplicarEvento(tx *bolt.Tx, ev EventoBloco) error {
+	for _, e := range ev.Evento.Eventos() {
+		if err := aplicarEventoProposta(tx, e, ev.Bloco); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aplicarEventoProposta: incorpora os campos alterados do evento à proposta e atualiza
+// os índices de pagadores e pagamentos
+func aplicarEventoProposta(tx *bolt.Tx, e events.Evento, bloco uint64) error {
+	propostas := tx.Bucket(bucketPropostas)
+
+	var prop Proposta
+	if v := propostas.Get([]byte(e.IDProposta)); v != nil && e.Tipo != events.PropostaCriada {
+		if err := json.Unmarshal(v, &prop); err != nil {
+			return err
+		}
+	}
+	cpfAnterior := prop.CpfPagador
+	pagaAnterior := prop.BoletoPago
+
+	prop.ID = e.IDProposta
+	c := e.Alterados
+	if c.CpfPagador != nil {
+		prop.CpfPagador = *c.CpfPagador
+	}
+	if c.PagadorAceitou != nil {
+		prop.PagadorAceitou = *c.PagadorAceitou
+	}
+	if c.BeneficiarioAceitou != nil {
+		prop.BeneficiarioAceitou = *c.BeneficiarioAceitou
+	}
+	if c.BoletoPago != nil {
+		prop.BoletoPago = *c.BoletoPago
+	}
+	if c.NossoNumero != nil {
+		prop.NossoNumero = *c.NossoNumero
+	}
+	if c.Valor != nil {
+		prop.Valor = *c.Valor
+	}
+	if c.DataPagamento != nil {
+		prop.DataPagamento = *c.DataPagamento
+	}
+	if c.Cancelada != nil {
+		prop.Cancelada = *c.Cancelada
+	}
+	if c.DataVencimento != nil {
+		prop.DataVencimento = *c.DataVencimento
+	}
+	if c.Beneficiario != nil {
+		prop.Beneficiario = *c.Beneficiario
+	}
+	if c.FormaPagamento != nil {
+		prop.FormaPagamento = *c.FormaPagamento
+	}
+	if c.EndToEndID != nil {
+		prop.EndToEndID = *c.EndToEndID
+	}
+	prop.Status = events.DerivarStatus(prop.PagadorAceitou, prop.BeneficiarioAceitou, prop.NossoNumero, prop.BoletoPago, prop.Cancelada)
+	if e.Tipo == events.PropostaCriada {
+		prop.CriadaEm = e.Horario
+	}
+	prop.AtualizadaEm = e.Horario
+	prop.UltimaTx = e.TxID
+
+	if err := gravar(propostas, prop.ID, prop); err != nil {
+		return err
+	}
+
+	// Índice de propostas por pagador
+	if cpfAnterior != prop.CpfPagador {
+		if cpfAnterior != "" {
+			if err := atualizarPagador(tx, cpfAnterior, prop.ID, false); err != nil {
+				return err
+			}
+		}
+		if err := atualizarPagador(tx, prop.CpfPagador, prop.ID, true); err != nil {
+			return err
+		}
+	}
+
+	// Pagamentos
+	pagamentos := tx.Bucket(bucketPagamentos)
+	if prop.BoletoPago && !pagaAnterior {
+		pag := Pagamento{
+			IDProposta:     prop.ID,
+			NossoNumero:    prop.NossoNumero,
+			FormaPagamento: prop.FormaPagamento,
+			EndToEndID:     prop.EndToEndID,
+			Valor:          prop.Valor,
+			DataPagamento:  prop.DataPagamento,
+			TxID:           e.TxID,
+			Bloco:          bloco,
+		}
+		if c.CodigoBanco != nil {
+			pag.CodigoBanco = *c.CodigoBanco
+		}
+		return gravar(pagamentos, prop.ID, pag)
+	}
+	if !prop.BoletoPago && pagaAnterior {
+		return pagamentos.Delete([]byte(prop.ID))
+	}
+	return nil
+}
+
+// atualizarPagador: inclui ou remove a proposta da lista do pagador
+func atualizarPagador(tx *bolt.Tx, cpf, idProposta string, incluir bool) error {
+	pagadores := tx.Bucket(bucketPagadores)
+	pag := Pagador{CpfPagador: cpf}
+	if v := pagadores.Get([]byte(cpf)); v != nil {
+		if err := json.Unmarshal(v, &pag); err != nil {
+			return err
+		}
+	}
+
+	var ids []string
+	for _, id := range pag.Propostas {
+		if id != idProposta {
+			ids = append(ids, id)
+		}
+	}
+	if incluir {
+		ids = append(ids, idProposta)
+		sort.Strings(ids)
+	}
+	pag.Propostas = ids
+
+	if len(pag.Propostas) == 0 {
+		return pagadores.Delete([]byte(cpf))
+	}
+	return gravar(pagadores, cpf, pag)
+}
+
+// gravar: grava o valor em JSON no bucket
+func gravar(b *bolt.Bucket, chave string, valor interface{}) error {
+	v, err := json.Marshal(valor)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(chave), v)
+}
+
+// ============================================================================================================================
+// Consultas
+// ============================================================================================================================
+
+// Proposta: consulta uma proposta pelo Id. Retorna false se ela não existir na projeção.
+func (p *Projecao) Proposta(id string) (Proposta, bool, error) {
+	var prop Proposta
+	encontrada := false
+	err := p.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketPropostas).Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+		encontrada = true
+		return json.Unmarshal(v, &prop)
+	})
+	return prop, encontrada, err
+}
+
+// Propostas: todas as propostas da projeção, ordenadas pelo Id
+func (p *Projecao) Propostas() ([]Proposta, error) {
+	var lista []Proposta
+	err := p.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPropostas).ForEach(func(k, v []byte) error {
+			var prop Proposta
+			if err := json.Unmarshal(v, &prop); err != nil {
+				return err
+			}
+			lista = append(lista, prop)
+			return nil
+		})
+	})
+	return lista, err
+}
+
+// Pagador: propostas associadas ao CPF informado
+func (p *Projecao) Pagador(cpf string) (Pagador, error) {
+	pag := Pagador{CpfPagador: cpf}
+	err := p.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketPagadores).Get([]byte(cpf))
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(v, &pag)
+	})
+	return pag, err
+}
+
+// Pagadores: todos os pagadores da projeção, ordenados pelo CPF
+func (p *Projecao) Pagadores() ([]Pagador, error) {
+	var lista []Pagador
+	err := p.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPagadores).ForEach(func(k, v []byte) error {
+			var pag Pagador
+			if err := json.Unmarshal(v, &pag); err != nil {
+				return err
+			}
+			lista = append(lista, pag)
+			return nil
+		})
+	})
+	return lista, err
+}
+
+// Pagamentos: todos os pagamentos registrados, ordenados pelo Id da proposta
+func (p *Projecao) Pagamentos() ([]Pagamento, error) {
+	var lista []Pagamento
+	err := p.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPagamentos).ForEach(func(k, v []byte) error {
+			var pag Pagamento
+			if err := json.Unmarshal(v, &pag); err != nil {
+				return err
+			}
+			lista = append(lista, pag)
+			return nil
+		})
+	})
+	return lista, err
+}
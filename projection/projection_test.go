@@ -0,0 +1,210 @@
+package projection
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/CaueP/BlockchainDojo/events"
+)
+
+// fonteMemoria - eventos em memória, já ordenados por bloco e índice
+type fonteMemoria []EventoBloco
+
+func (f fonteMemoria) Ler(aPartirDoBloco uint64, fn func(EventoBloco) error) error {
+	for _, ev := range f {
+		if ev.Bloco < aPartirDoBloco {
+			continue
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// abrir: projeção em um arquivo temporário, fechada ao final do teste
+func abrir(t *testing.T) *Projecao {
+	t.Helper()
+	p, err := Abrir(filepath.Join(t.TempDir(), "projecao.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Fechar() })
+	return p
+}
+
+// evento: evento de proposta na posição (bloco, indice)
+func evento(bloco uint64, indice int, tipo events.Tipo, id string, c events.Campos) EventoBloco {
+	return EventoBloco{Bloco: bloco, Indice: indice, Evento: events.Evento{
+		Versao: 1, Tipo: tipo, TxID: id + "-" + string(tipo), Horario: "2026-11-02T10:00:00Z", IDProposta: id, Alterados: c,
+	}}
+}
+
+// cicloDeVida: p1 criada, transferida de pagador e paga; p2 criada e cancelada
+var cicloDeVida = fonteMemoria{
+	evento(1, 0, events.PropostaCriada, "p1", events.Campos{CpfPagador: events.String("111"), PagadorAceitou: events.Bool(true)}),
+	evento(1, 1, events.PropostaCriada, "p2", events.Campos{CpfPagador: events.String("111")}),
+	evento(2, 0, events.PropostaAtualizada, "p1", events.Campos{CpfPagador: events.String("222")}),
+	evento(2, 1, events.PropostaAceita, "p1", events.Campos{BeneficiarioAceitou: events.Bool(true)}),
+	evento(3, 0, events.BoletoEmitido, "p1", events.Campos{NossoNumero: events.String("00000000001"), Valor: events.Int64(15000)}),
+	evento(4, 0, events.PagamentoRegistrado, "p1", events.Campos{BoletoPago: events.Bool(true), DataPagamento: events.String("2026-11-20"), CodigoBanco: events.String("001")}),
+	evento(4, 1, events.PropostaCancelada, "p2", events.Campos{Cancelada: events.Bool(true), Motivo: events.String("desistência")}),
+}
+
+// conteudo: propostas, pagadores e pagamentos da projeção, para comparação
+func conteudo(t *testing.T, p *Projecao) ([]Proposta, []Pagador, []Pagamento) {
+	t.Helper()
+	propostas, err := p.Propostas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagadores, err := p.Pagadores()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagamentos, err := p.Pagamentos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return propostas, pagadores, pagamentos
+}
+
+func TestSincronizar(t *testing.T) {
+	p := abrir(t)
+	if pos, err := p.Posicao(); err != nil || !pos.Vazia {
+		t.Fatalf("posição inicial %+v, %v", pos, err)
+	}
+
+	n, err := p.Sincronizar(cicloDeVida)
+	if err != nil || n != len(cicloDeVida) {
+		t.Fatalf("%d eventos aplicados, %v", n, err)
+	}
+
+	p1, ok, err := p.Proposta("p1")
+	if err != nil || !ok {
+		t.Fatalf("p1 não encontrada: %v", err)
+	}
+	if p1.CpfPagador != "222" || p1.Status != events.StatusPaga || p1.Valor != 15000 || p1.UltimaTx != "p1-PagamentoRegistrado" {
+		t.Fatalf("p1 %+v", p1)
+	}
+	p2, _, _ := p.Proposta("p2")
+	if p2.Status != events.StatusCancelada {
+		t.Fatalf("p2 com status %s", p2.Status)
+	}
+	if _, ok, _ := p.Proposta("p3"); ok {
+		t.Fatal("p3 encontrada")
+	}
+
+	_, pagadores, pagamentos := conteudo(t, p)
+	esperados := []Pagador{{"111", []string{"p2"}}, {"222", []string{"p1"}}}
+	if !reflect.DeepEqual(pagadores, esperados) {
+		t.Fatalf("pagadores %+v, esperados %+v", pagadores, esperados)
+	}
+	if len(pagamentos) != 1 || pagamentos[0].CodigoBanco != "001" || pagamentos[0].Bloco != 4 || pagamentos[0].Valor != 15000 {
+		t.Fatalf("pagamentos %+v", pagamentos)
+	}
+
+	if pos, _ := p.Posicao(); pos != (Posicao{Bloco: 4, Indice: 1}) {
+		t.Fatalf("posição %+v, esperada bloco 4, índice 1", pos)
+	}
+}
+
+func TestPosicao(t *testing.T) {
+	casos := []struct {
+		nome      string
+		aplicados int // eventos do ciclo de vida aplicados antes
+		ev        EventoBloco
+		aplicado  bool
+		posicao   Posicao
+	}{
+		{"primeiro evento", 0, cicloDeVida[0], true, Posicao{Bloco: 1, Indice: 0}},
+		{"mesmo evento", 1, cicloDeVida[0], false, Posicao{Bloco: 1, Indice: 0}},
+		{"índice seguinte", 1, cicloDeVida[1], true, Posicao{Bloco: 1, Indice: 1}},
+		{"bloco anterior", 3, cicloDeVida[1], false, Posicao{Bloco: 2, Indice: 0}},
+		{"índice anterior no mesmo bloco", 4, cicloDeVida[2], false, Posicao{Bloco: 2, Indice: 1}},
+		{"bloco seguinte com índice menor", 4, cicloDeVida[4], true, Posicao{Bloco: 3, Indice: 0}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			p := abrir(t)
+			if _, err := p.Sincronizar(cicloDeVida[:c.aplicados]); err != nil {
+				t.Fatal(err)
+			}
+			aplicado, err := p.Aplicar(c.ev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if aplicado != c.aplicado {
+				t.Fatalf("aplicado = %v, esperado %v", aplicado, c.aplicado)
+			}
+			if pos, _ := p.Posicao(); pos != c.posicao {
+				t.Fatalf("posição %+v, esperada %+v", pos, c.posicao)
+			}
+		})
+	}
+}
+
+func TestReler(t *testing.T) {
+	casos := []struct {
+		nome  string
+		lidos int // eventos lidos na primeira sincronização
+	}{
+		{"tudo", len(cicloDeVida)},
+		{"até o meio de um bloco", 3},
+		{"até o fim de um bloco", 4},
+	}
+	referencia := abrir(t)
+	if _, err := referencia.Sincronizar(cicloDeVida); err != nil {
+		t.Fatal(err)
+	}
+	propostas, pagadores, pagamentos := conteudo(t, referencia)
+
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			p := abrir(t)
+			if _, err := p.Sincronizar(cicloDeVida[:c.lidos]); err != nil {
+				t.Fatal(err)
+			}
+			// a fonte relê o último bloco processado; os eventos já aplicados são ignorados
+			n, err := p.Sincronizar(cicloDeVida)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(cicloDeVida)-c.lidos {
+				t.Fatalf("%d eventos reaplicados, esperados %d", n, len(cicloDeVida)-c.lidos)
+			}
+			props, pags, pagtos := conteudo(t, p)
+			if !reflect.DeepEqual(props, propostas) || !reflect.DeepEqual(pags, pagadores) || !reflect.DeepEqual(pagtos, pagamentos) {
+				t.Fatal("projeção diferente da sincronizada de uma vez")
+			}
+
+			n, err = p.Reconstruir(cicloDeVida)
+			if err != nil || n != len(cicloDeVida) {
+				t.Fatalf("Reconstruir aplicou %d eventos, %v", n, err)
+			}
+			props, pags, pagtos = conteudo(t, p)
+			if !reflect.DeepEqual(props, propostas) || !reflect.DeepEqual(pags, pagadores) || !reflect.DeepEqual(pagtos, pagamentos) {
+				t.Fatal("projeção reconstruída diferente da sincronizada de uma vez")
+			}
+		})
+	}
+}
+
+func TestLoteNaMesmaTransacao(t *testing.T) {
+	p := abrir(t)
+	lote := EventoBloco{Bloco: 5, Evento: events.Evento{Versao: 1, Tipo: events.LoteExecutado, TxID: "lote", Itens: []events.Evento{
+		{Versao: 1, Tipo: events.PropostaCriada, TxID: "lote", IDProposta: "p1", Alterados: events.Campos{CpfPagador: events.String("111")}},
+		{Versao: 1, Tipo: events.PropostaCriada, TxID: "lote", IDProposta: "p2", Alterados: events.Campos{CpfPagador: events.String("111")}},
+	}}}
+	if ok, err := p.Aplicar(lote); err != nil || !ok {
+		t.Fatalf("lote não aplicado: %v", err)
+	}
+	pag, err := p.Pagador("111")
+	if err != nil || !reflect.DeepEqual(pag.Propostas, []string{"p1", "p2"}) {
+		t.Fatalf("pagador %+v, %v", pag, err)
+	}
+	if pos, _ := p.Posicao(); pos != (Posicao{Bloco: 5}) {
+		t.Fatalf("posição %+v depois do lote", pos)
+	}
+}
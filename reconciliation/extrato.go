@@ -0,0 +1,291 @@
+/*
+Descrição: leitura dos extratos bancários (OFX e CSV)
+O OFX é lido nas duas versões (1.x em SGML, com tags sem fechamento, e 2.x em XML):
+cada STMTTRN é um lançamento, e o período vem de DTSTART e DTEND. O CSV tem uma linha
+de cabeçalho com os nomes das colunas, separadas por vírgula ou ponto e vírgula, e os
+valores em reais (1500.00 ou 1.500,00).
+*/
+
+package reconciliation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/oracle"
+)
+
+// Formatos de extrato
+const (
+	FormatoOFX = "ofx"
+	FormatoCSV = "csv"
+)
+
+// Lancamento - lançamento do extrato. O valor é em centavos (negativo para os débitos) e
+// a data no formato AAAA-MM-DD.
+type Lancamento struct {
+	ID          string `json:"id_lancamento,omitempty"` // FITID do OFX ou coluna id do CSV
+	Data        string `json:"data"`
+	Valor       int64  `json:"valor"`
+	Descricao   string `json:"descricao,omitempty"`
+	NossoNumero string `json:"nosso_numero,omitempty"` // coluna nosso_numero do CSV
+	IDProposta  string `json:"id_proposta,omitempty"`  // coluna id_proposta do CSV
+	Arquivo     string `json:"arquivo,omitempty"`
+	Linha       int    `json:"linha,omitempty"` // linha do CSV
+}
+
+// Extrato - extrato lido, com o período coberto (AAAA-MM-DD)
+type Extrato struct {
+	Arquivo     string       `json:"arquivo"`
+	Formato     string       `json:"formato"`
+	Inicio      string       `json:"inicio"`
+	Fim         string       `json:"fim"`
+	Lancamentos []Lancamento `json:"lancamentos"`
+}
+
+// LerExtrato: lê o extrato em OFX ou CSV, identificado pelo conteúdo (cabeçalho OFXHEADER
+// ou tag <OFX>). O nome do arquivo é registrado nos lançamentos.
+func LerExtrato(arquivo string, conteudo []byte) (Extrato, error) {
+	var e Extrato
+	var err error
+	inicio := conteudo
+	if len(inicio) > 1024 {
+		inicio = inicio[:1024]
+	}
+	inicio = bytes.ToUpper(inicio)
+	if bytes.Contains(inicio, []byte("OFXHEADER")) || bytes.Contains(inicio, []byte("<OFX>")) {
+		e, err = LerOFX(conteudo)
+	} else {
+		e, err = LerCSV(conteudo)
+	}
+	if err != nil {
+		return e, fmt.Errorf("%s: %s", filepath.Base(arquivo), err)
+	}
+	e.Arquivo = filepath.Base(arquivo)
+	for i := range e.Lancamentos {
+		e.Lancamentos[i].Arquivo = e.Arquivo
+	}
+	return e, nil
+}
+
+// LerOFX: lançamentos (STMTTRN) e período (DTSTART e DTEND) do extrato OFX
+func LerOFX(conteudo []byte) (Extrato, error) {
+	e := Extrato{Formato: FormatoOFX}
+	var atual *Lancamento
+	// cada trecho é "TAG>valor" ou "/TAG>"; no SGML o valor vai até a próxima tag
+	for _, trecho := range strings.Split(string(conteudo), "<")[1:] {
+		fim := strings.Index(trecho, ">")
+		if fim < 0 {
+			continue
+		}
+		tag := strings.ToUpper(strings.TrimSpace(trecho[:fim]))
+		valor := entidadesXML.Replace(strings.TrimSpace(trecho[fim+1:]))
+
+		var err error
+		switch tag {
+		case "STMTTRN":
+			e.Lancamentos = append(e.Lancamentos, Lancamento{})
+			atual = &e.Lancamentos[len(e.Lancamentos)-1]
+		case "/STMTTRN":
+			if atual == nil {
+				return e, fmt.Errorf("</STMTTRN> sem o <STMTTRN> correspondente")
+			}
+			if atual.Data == "" {
+				return e, fmt.Errorf("Lançamento %d sem DTPOSTED", len(e.Lancamentos))
+			}
+			atual = nil
+		case "DTSTART":
+			e.Inicio, err = dataOFX(valor)
+		case "DTEND":
+			e.Fim, err = dataOFX(valor)
+		}
+		if err != nil {
+			return e, err
+		}
+		if atual == nil {
+			continue
+		}
+		switch tag {
+		case "FITID":
+			atual.ID = valor
+		case "DTPOSTED":
+			atual.Data, err = dataOFX(valor)
+		case "TRNAMT":
+			atual.Valor, err = Centavos(valor)
+		case "MEMO":
+			atual.Descricao = strings.TrimSpace(atual.Descricao + " " + valor)
+		case "NAME", "REFNUM", "CHECKNUM":
+			// informados antes ou depois do MEMO, conforme o banco
+			atual.Descricao = strings.TrimSpace(valor + " " + atual.Descricao)
+		}
+		if err != nil {
+			return e, fmt.Errorf("Lançamento %d: %s", len(e.Lancamentos), err)
+		}
+	}
+	if atual != nil {
+		return e, fmt.Errorf("Lançamento %d sem </STMTTRN>", len(e.Lancamentos))
+	}
+	periodo(&e)
+	return e, nil
+}
+
+// entidadesXML: entidades do OFX 2.x nos valores das tags
+var entidadesXML = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'")
+
+// dataOFX: data do OFX (AAAAMMDD, seguida ou não de hora e fuso) no formato AAAA-MM-DD
+func dataOFX(s string) (string, error) {
+	if len(s) >= 8 {
+		if t, err := time.Parse("20060102", s[:8]); err == nil {
+			return t.Format(oracle.LayoutData), nil
+		}
+	}
+	return "", fmt.Errorf("Data inválida: %q", s)
+}
+
+// colunasCSV: nomes aceitos para cada coluna do CSV (sem acentos, em minúsculas)
+var colunasCSV = map[string][]string{
+	"data":         {"data", "data_lancamento", "data_movimento"},
+	"valor":        {"valor", "valor_lancamento"},
+	"descricao":    {"descricao", "historico", "memo"},
+	"id":           {"id", "id_lancamento", "documento", "fitid"},
+	"nosso_numero": {"nosso_numero"},
+	"id_proposta":  {"id_proposta"},
+}
+
+// LerCSV: lançamentos do extrato em CSV. As colunas data e valor são obrigatórias; as
+// datas são DD/MM/AAAA ou AAAA-MM-DD. O período é o das datas dos lançamentos.
+func LerCSV(conteudo []byte) (Extrato, error) {
+	e := Extrato{Formato: FormatoCSV}
+	conteudo = bytes.TrimPrefix(conteudo, []byte("\xef\xbb\xbf"))
+	primeira := conteudo
+	if i := bytes.IndexByte(conteudo, '\n'); i >= 0 {
+		primeira = conteudo[:i]
+	}
+	r := csv.NewReader(bytes.NewReader(conteudo))
+	if bytes.Count(primeira, []byte(";")) > bytes.Count(primeira, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.TrimLeadingSpace = true
+	linhas, err := r.ReadAll()
+	if err != nil {
+		return e, fmt.Errorf("CSV inválido: %s", err)
+	}
+	if len(linhas) == 0 {
+		return e, fmt.Errorf("CSV vazio")
+	}
+
+	posicao := make(map[string]int)
+	for i, nome := range linhas[0] {
+		nome = strings.Replace(semAcentos.Replace(strings.ToLower(strings.TrimSpace(nome))), " ", "_", -1)
+		for coluna, nomes := range colunasCSV {
+			for _, n := range nomes {
+				if nome == n {
+					posicao[coluna] = i
+				}
+			}
+		}
+	}
+	for _, obrigatoria := range []string{"data", "valor"} {
+		if _, ok := posicao[obrigatoria]; !ok {
+			return e, fmt.Errorf("CSV sem a coluna %s", obrigatoria)
+		}
+	}
+	campo := func(linha []string, coluna string) string {
+		if i, ok := posicao[coluna]; ok && i < len(linha) {
+			return strings.TrimSpace(linha[i])
+		}
+		return ""
+	}
+
+	for n, linha := range linhas[1:] {
+		if len(linha) == 1 && strings.TrimSpace(linha[0]) == "" {
+			continue
+		}
+		l := Lancamento{
+			ID:          campo(linha, "id"),
+			Descricao:   campo(linha, "descricao"),
+			NossoNumero: campo(linha, "nosso_numero"),
+			IDProposta:  campo(linha, "id_proposta"),
+			Linha:       n + 2,
+		}
+		if l.Data, err = dataCSV(campo(linha, "data")); err != nil {
+			return e, fmt.Errorf("Linha %d: %s", l.Linha, err)
+		}
+		if l.Valor, err = Centavos(campo(linha, "valor")); err != nil {
+			return e, fmt.Errorf("Linha %d: %s", l.Linha, err)
+		}
+		e.Lancamentos = append(e.Lancamentos, l)
+	}
+	periodo(&e)
+	return e, nil
+}
+
+// semAcentos: letras acentuadas dos nomes das colunas
+var semAcentos = strings.NewReplacer("á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e",
+	"í", "i", "ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c")
+
+// dataCSV: data DD/MM/AAAA ou AAAA-MM-DD no formato AAAA-MM-DD
+func dataCSV(s string) (string, error) {
+	for _, layout := range []string{oracle.LayoutData, "02/01/2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(oracle.LayoutData), nil
+		}
+	}
+	return "", fmt.Errorf("Data inválida: %q", s)
+}
+
+// periodo: completa o período não informado com a menor e a maior data dos lançamentos
+func periodo(e *Extrato) {
+	var inicio, fim string
+	for _, l := range e.Lancamentos {
+		if inicio == "" || l.Data < inicio {
+			inicio = l.Data
+		}
+		if l.Data > fim {
+			fim = l.Data
+		}
+	}
+	if e.Inicio == "" {
+		e.Inicio = inicio
+	}
+	if e.Fim == "" {
+		e.Fim = fim
+	}
+}
+
+// Centavos: converte um valor em reais (150, 150.5, -1500.00, 1.500,00 ou 1500,00) para centavos
+func Centavos(s string) (int64, error) {
+	texto := strings.Replace(strings.TrimSpace(s), " ", "", -1)
+	if strings.Contains(texto, ",") {
+		// vírgula decimal: os pontos são separadores de milhar
+		texto = strings.Replace(strings.Replace(texto, ".", "", -1), ",", ".", 1)
+	}
+	negativo := strings.HasPrefix(texto, "-")
+	texto = strings.TrimLeft(texto, "+-")
+	inteiro, fracao := texto, ""
+	if i := strings.Index(texto, "."); i >= 0 {
+		inteiro, fracao = texto[:i], texto[i+1:]
+	}
+	if inteiro == "" && fracao == "" {
+		return 0, fmt.Errorf("Valor inválido: %q", s)
+	}
+	if inteiro == "" {
+		inteiro = "0"
+	}
+	if len(fracao) > 2 || strings.Trim(inteiro+fracao, "0123456789") != "" {
+		return 0, fmt.Errorf("Valor inválido: %q", s)
+	}
+	v, err := strconv.ParseInt(inteiro+(fracao + "00")[:2], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Valor inválido: %q", s)
+	}
+	if negativo {
+		v = -v
+	}
+	return v, nil
+}
@@ -0,0 +1,412 @@
+/*
+Descrição: conciliação dos boletos pagos no ledger com os extratos bancários
+Os créditos dos extratos, dentro do período conciliado, são associados aos boletos:
+primeiro pela referência (colunas nosso_numero e id_proposta do CSV, o nosso número
+ou o ID da proposta na descrição do lançamento ou, nos pagamentos PIX, o ID fim a fim
+no ID ou na descrição do lançamento) e, para os créditos restantes, pelo
+valor, quando um único boleto pago ainda não conciliado tem o mesmo valor e a data de
+pagamento dentro da tolerância. Cada lançamento e cada boleto pago sem lançamento gera
+um item com a situação da conciliação, gravado no ledger pelo invoke
+registrarConciliacao (ver chaincode/propostas/conciliacao.go).
+*/
+
+// Package reconciliation concilia os boletos pagos no ledger com os extratos bancários
+// (OFX e CSV), apontando os pagamentos presentes em um só lado, as divergências de valor
+// e de data e os lançamentos duplicados.
+package reconciliation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/oracle"
+)
+
+// ToleranciaDiasPadrao - diferença aceita entre a data de pagamento no ledger e a data do
+// crédito no extrato (o banco credita a cobrança em D+1 ou D+2)
+const ToleranciaDiasPadrao = 2
+
+// Situações da conciliação
+const (
+	SituacaoConciliado      = "conciliado"       // crédito do extrato confere com o boleto pago
+	SituacaoSomenteLedger   = "somente_ledger"   // boleto pago no ledger sem crédito no extrato
+	SituacaoSomenteExtrato  = "somente_extrato"  // crédito sem boleto pago no ledger
+	SituacaoValorDivergente = "valor_divergente" // valor do crédito diferente do boleto
+	SituacaoDataDivergente  = "data_divergente"  // data do crédito fora da tolerância
+	SituacaoDuplicado       = "duplicado"        // lançamento repetido ou segundo crédito do mesmo boleto
+	// SituacaoNaoConciliado: boleto sem conciliação registrada (apenas na query
+	// listarStatusConciliacao)
+	SituacaoNaoConciliado = "nao_conciliado"
+)
+
+// Situacoes - situações dos itens de uma conciliação, em ordem de gravidade decrescente
+var Situacoes = []string{
+	SituacaoValorDivergente, SituacaoSomenteExtrato, SituacaoSomenteLedger,
+	SituacaoDataDivergente, SituacaoDuplicado, SituacaoConciliado,
+}
+
+// Critérios da associação entre o lançamento e o boleto
+const (
+	CriterioNossoNumero = "nosso_numero"
+	CriterioIDProposta  = "id_proposta"
+	CriterioEndToEndID  = "end_to_end_id" // ID fim a fim do pagamento PIX
+	CriterioValor       = "valor"
+)
+
+// Boleto - boleto emitido ou proposta paga por PIX no ledger, considerados na conciliação
+type Boleto struct {
+	IDProposta    string
+	NossoNumero   string
+	EndToEndID    string // ID fim a fim do pagamento PIX
+	Valor         int64  // em centavos
+	Status        string // status da proposta (ver events.Status)
+	DataPagamento string // AAAA-MM-DD (vazio: não informada no pagamento)
+}
+
+// Item - resultado da conciliação de um lançamento ou de um boleto pago sem lançamento.
+// Os campos _ledger vêm do boleto e os campos _extrato do lançamento.
+type Item struct {
+	Situacao     string `json:"situacao"`
+	IDProposta   string `json:"id_proposta,omitempty"`
+	NossoNumero  string `json:"nosso_numero,omitempty"`
+	EndToEndID   string `json:"end_to_end_id,omitempty"`
+	ValorLedger  int64  `json:"valor_ledger,omitempty"`
+	DataLedger   string `json:"data_ledger,omitempty"`
+	IDLancamento string `json:"id_lancamento,omitempty"`
+	ValorExtrato int64  `json:"valor_extrato,omitempty"`
+	DataExtrato  string `json:"data_extrato,omitempty"`
+	Descricao    string `json:"descricao,omitempty"`
+	Arquivo      string `json:"arquivo,omitempty"`
+	Criterio     string `json:"criterio,omitempty"`
+	Detalhe      string `json:"detalhe,omitempty"`
+}
+
+// Divergente: indica se o item exige análise (qualquer situação exceto conciliado)
+func (i Item) Divergente() bool {
+	return i.Situacao != SituacaoConciliado
+}
+
+// Gravidade: posição da situação em Situacoes (menor: mais grave)
+func Gravidade(situacao string) int {
+	for i, s := range Situacoes {
+		if s == situacao {
+			return i
+		}
+	}
+	return len(Situacoes)
+}
+
+// Opcoes - parâmetros da conciliação
+type Opcoes struct {
+	// Inicio e Fim: período conciliado (AAAA-MM-DD); vazio: período dos extratos
+	Inicio, Fim    string
+	ToleranciaDias int
+}
+
+// Resultado - itens da conciliação e a quantidade por situação
+type Resultado struct {
+	Inicio    string         `json:"inicio"`
+	Fim       string         `json:"fim"`
+	Extratos  []string       `json:"extratos"`
+	Itens     []Item         `json:"itens"`
+	Resumo    map[string]int `json:"resumo"`
+	Ignorados int            `json:"ignorados"` // débitos e lançamentos fora do período
+}
+
+// Conciliar: concilia os boletos pagos com os créditos dos extratos no período. Os boletos
+// pagos com data de pagamento fora do período não geram somente_ledger; os sem data de
+// pagamento sempre são considerados.
+func Conciliar(boletos []Boleto, extratos []Extrato, op Opcoes) Resultado {
+	r := Resultado{Inicio: op.Inicio, Fim: op.Fim, Resumo: make(map[string]int)}
+	for _, e := range extratos {
+		r.Extratos = append(r.Extratos, e.Arquivo)
+		if op.Inicio == "" && (r.Inicio == "" || e.Inicio < r.Inicio) {
+			r.Inicio = e.Inicio
+		}
+		if op.Fim == "" && e.Fim > r.Fim {
+			r.Fim = e.Fim
+		}
+	}
+
+	porNossoNumero := make(map[string]int)
+	porID := make(map[string]int)
+	porEndToEndID := make(map[string]int)
+	for i, b := range boletos {
+		if b.NossoNumero != "" {
+			porNossoNumero[normalizarNossoNumero(b.NossoNumero)] = i
+		}
+		if b.EndToEndID != "" {
+			porEndToEndID[strings.ToUpper(b.EndToEndID)] = i
+		}
+		porID[strings.ToLower(b.IDProposta)] = i
+	}
+
+	// créditos do período, sem os lançamentos repetidos (mesmo ID)
+	type credito struct {
+		Lancamento
+		boleto    int // índice do boleto associado (-1: nenhum)
+		criterio  string
+		repetido  string // arquivo do lançamento com o mesmo ID
+		candidato int    // boletos com o mesmo valor, quando nenhum é associado
+	}
+	var creditos []*credito
+	vistos := make(map[string]string)
+	for _, e := range extratos {
+		for _, l := range e.Lancamentos {
+			if l.Valor <= 0 || l.Data < r.Inicio || (r.Fim != "" && l.Data > r.Fim) {
+				r.Ignorados++
+				continue
+			}
+			c := &credito{Lancamento: l, boleto: -1}
+			if l.ID != "" {
+				if arquivo, ok := vistos[l.ID]; ok {
+					c.repetido = arquivo
+				}
+				vistos[l.ID] = l.Arquivo
+			}
+			creditos = append(creditos, c)
+		}
+	}
+
+	// associação pela referência
+	associado := make(map[int]bool)
+	for _, c := range creditos {
+		if c.repetido != "" {
+			continue
+		}
+		c.boleto, c.criterio = referencia(c.Lancamento, porNossoNumero, porID, porEndToEndID)
+		if c.boleto >= 0 && boletos[c.boleto].Status == events.StatusPaga {
+			associado[c.boleto] = true
+		}
+	}
+	// associação pelo valor: um único boleto pago ainda não associado
+	for _, c := range creditos {
+		if c.repetido != "" || c.boleto >= 0 {
+			continue
+		}
+		var candidatos []int
+		for i, b := range boletos {
+			if b.Status == events.StatusPaga && !associado[i] && b.Valor == c.Valor &&
+				(b.DataPagamento == "" || diasEntre(b.DataPagamento, c.Data) <= op.ToleranciaDias) {
+				candidatos = append(candidatos, i)
+			}
+		}
+		c.candidato = len(candidatos)
+		if len(candidatos) == 1 {
+			c.boleto, c.criterio = candidatos[0], CriterioValor
+			associado[candidatos[0]] = true
+		}
+	}
+
+	conciliados := make(map[int]bool)
+	for _, c := range creditos {
+		item := Item{
+			IDLancamento: c.ID,
+			ValorExtrato: c.Valor,
+			DataExtrato:  c.Data,
+			Descricao:    c.Descricao,
+			Arquivo:      c.Arquivo,
+			Criterio:     c.criterio,
+		}
+		if c.boleto >= 0 {
+			b := boletos[c.boleto]
+			item.IDProposta, item.NossoNumero, item.EndToEndID = b.IDProposta, b.NossoNumero, b.EndToEndID
+			item.ValorLedger, item.DataLedger = b.Valor, b.DataPagamento
+		}
+
+		switch {
+		case c.repetido != "":
+			item.Situacao, item.Detalhe = SituacaoDuplicado, "lançamento repetido (mesmo ID em "+c.repetido+")"
+		case c.boleto < 0:
+			item.Situacao = SituacaoSomenteExtrato
+			if c.candidato > 1 {
+				item.Detalhe = fmt.Sprintf("%d boletos pagos com o mesmo valor", c.candidato)
+			}
+		case conciliados[c.boleto]:
+			item.Situacao, item.Detalhe = SituacaoDuplicado, "segundo crédito do boleto"
+		case boletos[c.boleto].Status != events.StatusPaga:
+			item.Situacao, item.Detalhe = SituacaoSomenteExtrato, "proposta com status "+boletos[c.boleto].Status+" no ledger"
+		case c.Valor != item.ValorLedger:
+			item.Situacao = SituacaoValorDivergente
+			item.Detalhe = fmt.Sprintf("extrato %d, boleto %d", c.Valor, item.ValorLedger)
+		case item.DataLedger != "" && diasEntre(item.DataLedger, c.Data) > op.ToleranciaDias:
+			item.Situacao = SituacaoDataDivergente
+			item.Detalhe = fmt.Sprintf("%d dias entre o pagamento e o crédito", diasEntre(item.DataLedger, c.Data))
+		default:
+			item.Situacao = SituacaoConciliado
+		}
+		if c.boleto >= 0 && boletos[c.boleto].Status == events.StatusPaga && c.repetido == "" {
+			conciliados[c.boleto] = true
+		}
+		r.Itens = append(r.Itens, item)
+	}
+
+	// boletos pagos no período sem crédito
+	var semCredito []Item
+	for i, b := range boletos {
+		if b.Status != events.StatusPaga || conciliados[i] {
+			continue
+		}
+		if b.DataPagamento != "" && (b.DataPagamento < r.Inicio || (r.Fim != "" && b.DataPagamento > r.Fim)) {
+			continue
+		}
+		semCredito = append(semCredito, Item{
+			Situacao:    SituacaoSomenteLedger,
+			IDProposta:  b.IDProposta,
+			NossoNumero: b.NossoNumero,
+			EndToEndID:  b.EndToEndID,
+			ValorLedger: b.Valor,
+			DataLedger:  b.DataPagamento,
+		})
+	}
+	sort.Slice(semCredito, func(i, j int) bool { return semCredito[i].IDProposta < semCredito[j].IDProposta })
+	r.Itens = append(r.Itens, semCredito...)
+
+	for _, i := range r.Itens {
+		r.Resumo[i.Situacao]++
+	}
+	return r
+}
+
+// referencia: boleto indicado pelo lançamento (colunas do CSV, ID do lançamento com o ID
+// fim a fim do PIX ou trechos da descrição com o nosso número, o ID da proposta ou o ID
+// fim a fim); -1 se nenhum
+func referencia(l Lancamento, porNossoNumero, porID, porEndToEndID map[string]int) (int, string) {
+	if i, ok := porEndToEndID[strings.ToUpper(l.ID)]; ok && l.ID != "" {
+		return i, CriterioEndToEndID
+	}
+	if l.NossoNumero != "" {
+		if i, ok := porNossoNumero[normalizarNossoNumero(l.NossoNumero)]; ok {
+			return i, CriterioNossoNumero
+		}
+	}
+	if l.IDProposta != "" {
+		if i, ok := porID[strings.ToLower(l.IDProposta)]; ok {
+			return i, CriterioIDProposta
+		}
+	}
+	trechos := strings.FieldsFunc(l.Descricao, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	for _, t := range trechos {
+		// trechos curtos (datas, parcelas) não identificam o boleto
+		if len(t) < 6 {
+			continue
+		}
+		if i, ok := porEndToEndID[strings.ToUpper(t)]; ok {
+			return i, CriterioEndToEndID
+		}
+		if i, ok := porNossoNumero[normalizarNossoNumero(t)]; ok && strings.Trim(t, "0123456789") == "" {
+			return i, CriterioNossoNumero
+		}
+		if i, ok := porID[strings.ToLower(t)]; ok {
+			return i, CriterioIDProposta
+		}
+	}
+	return -1, ""
+}
+
+// normalizarNossoNumero: nosso número sem os zeros à esquerda
+func normalizarNossoNumero(s string) string {
+	s = strings.TrimLeft(strings.TrimSpace(s), "0")
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// diasEntre: dias entre as datas AAAA-MM-DD, em valor absoluto
+func diasEntre(a, b string) int {
+	ta, errA := time.Parse(oracle.LayoutData, a)
+	tb, errB := time.Parse(oracle.LayoutData, b)
+	if errA != nil || errB != nil {
+		return 0
+	}
+	d := int(ta.Sub(tb).Hours() / 24)
+	if d < 0 {
+		d = -d
+	}
+	return d
+}
+
+// Documento: documento do invoke registrarConciliacao com os itens do resultado. Os
+// campos do ledger não são enviados: o chaincode os preenche com o estado da proposta.
+func (r Resultado) Documento(idConciliacao string) map[string]interface{} {
+	itens := make([]map[string]interface{}, 0, len(r.Itens))
+	for _, i := range r.Itens {
+		item := map[string]interface{}{"situacao": i.Situacao}
+		campos := map[string]string{
+			"id_proposta":   i.IDProposta,
+			"id_lancamento": i.IDLancamento,
+			"data_extrato":  i.DataExtrato,
+			"descricao":     i.Descricao,
+			"arquivo":       i.Arquivo,
+			"criterio":      i.Criterio,
+			"detalhe":       i.Detalhe,
+		}
+		for nome, valor := range campos {
+			if valor != "" {
+				item[nome] = valor
+			}
+		}
+		if i.DataExtrato != "" {
+			item["valor_extrato"] = i.ValorExtrato
+		}
+		itens = append(itens, item)
+	}
+	documento := map[string]interface{}{
+		"id_conciliacao": idConciliacao,
+		"itens":          itens,
+	}
+	if r.Inicio != "" {
+		documento["inicio"] = r.Inicio
+	}
+	if r.Fim != "" {
+		documento["fim"] = r.Fim
+	}
+	if len(r.Extratos) > 0 {
+		documento["extratos"] = r.Extratos
+	}
+	return documento
+}
+
+// colunasConciliacaoCSV: cabeçalho do relatório da conciliação em CSV
+var colunasConciliacaoCSV = []string{
+	"situacao", "id_proposta", "nosso_numero", "end_to_end_id", "valor_ledger", "data_ledger", "id_lancamento",
+	"valor_extrato", "data_extrato", "descricao", "arquivo", "criterio", "detalhe",
+}
+
+// CSV: relatório em CSV, com uma linha por item. Com apenasDivergencias, apenas os itens
+// que exigem análise.
+func (r Resultado) CSV(apenasDivergencias bool) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	linhas := [][]string{colunasConciliacaoCSV}
+	for _, i := range r.Itens {
+		if apenasDivergencias && !i.Divergente() {
+			continue
+		}
+		valorExtrato := ""
+		if i.DataExtrato != "" {
+			valorExtrato = strconv.FormatInt(i.ValorExtrato, 10)
+		}
+		valorLedger := ""
+		if i.IDProposta != "" {
+			valorLedger = strconv.FormatInt(i.ValorLedger, 10)
+		}
+		linhas = append(linhas, []string{
+			i.Situacao, i.IDProposta, i.NossoNumero, i.EndToEndID, valorLedger, i.DataLedger, i.IDLancamento,
+			valorExtrato, i.DataExtrato, i.Descricao, i.Arquivo, i.Criterio, i.Detalhe,
+		})
+	}
+	if err := w.WriteAll(linhas); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,224 @@
+package reconciliation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/CaueP/BlockchainDojo/events"
+)
+
+// extrato: extrato de teste com os lançamentos informados, de 2026-11-01 a 2026-11-30
+func extrato(lancamentos ...Lancamento) []Extrato {
+	for i := range lancamentos {
+		lancamentos[i].Arquivo = "extrato.csv"
+	}
+	return []Extrato{{Arquivo: "extrato.csv", Formato: FormatoCSV, Inicio: "2026-11-01", Fim: "2026-11-30", Lancamentos: lancamentos}}
+}
+
+func boletoPago(id, nossoNumero string, valor int64, data string) Boleto {
+	return Boleto{IDProposta: id, NossoNumero: nossoNumero, Valor: valor, Status: events.StatusPaga, DataPagamento: data}
+}
+
+// situacao: situação, proposta e critério do item ("conciliado a1 nosso_numero")
+func situacao(i Item) string {
+	return strings.TrimSpace(i.Situacao + " " + i.IDProposta + " " + i.Criterio)
+}
+
+func TestConciliar(t *testing.T) {
+	boletos := []Boleto{
+		boletoPago("a1", "00000101", 15000, "2026-11-10"),
+		boletoPago("b2", "00000102", 8990, "2026-11-12"),
+		{IDProposta: "c3", EndToEndID: "E00000000202611141030PIXDOJO0001", Valor: 5000, Status: events.StatusPaga, DataPagamento: "2026-11-14"},
+		{IDProposta: "d4", NossoNumero: "00000104", Valor: 6400, Status: events.StatusBoletoEmitido},
+	}
+
+	casos := []struct {
+		nome        string
+		boletos     []Boleto
+		lancamentos []Lancamento
+		situacoes   []string
+	}{
+		{"nosso número na descrição", boletos[:1],
+			[]Lancamento{{ID: "L1", Data: "2026-11-11", Valor: 15000, Descricao: "LIQUIDACAO COBRANCA 101"}},
+			// trechos com menos de 6 caracteres não identificam o boleto; associado pelo valor
+			[]string{"conciliado a1 valor"}},
+		{"nosso número com zeros", boletos[:1],
+			[]Lancamento{{ID: "L1", Data: "2026-11-11", Valor: 15000, Descricao: "LIQUIDACAO COBRANCA 0000000101"}},
+			[]string{"conciliado a1 nosso_numero"}},
+		{"coluna nosso_numero", boletos[:1],
+			[]Lancamento{{Data: "2026-11-11", Valor: 15000, NossoNumero: "101"}},
+			[]string{"conciliado a1 nosso_numero"}},
+		{"coluna id_proposta", boletos[:1],
+			[]Lancamento{{Data: "2026-11-11", Valor: 15000, IDProposta: "A1"}},
+			[]string{"conciliado a1 id_proposta"}},
+		{"ID fim a fim no lançamento", boletos[2:3],
+			[]Lancamento{{ID: "e00000000202611141030pixdojo0001", Data: "2026-11-14", Valor: 5000}},
+			[]string{"conciliado c3 end_to_end_id"}},
+		{"ID fim a fim na descrição", boletos[2:3],
+			[]Lancamento{{ID: "L1", Data: "2026-11-14", Valor: 5000, Descricao: "PIX E00000000202611141030PIXDOJO0001"}},
+			[]string{"conciliado c3 end_to_end_id"}},
+		{"valor divergente", boletos[:1],
+			[]Lancamento{{Data: "2026-11-11", Valor: 14990, NossoNumero: "101"}},
+			[]string{"valor_divergente a1 nosso_numero"}},
+		{"data na tolerância", boletos[:1],
+			[]Lancamento{{Data: "2026-11-12", Valor: 15000, NossoNumero: "101"}},
+			[]string{"conciliado a1 nosso_numero"}},
+		{"data divergente", boletos[:1],
+			[]Lancamento{{Data: "2026-11-13", Valor: 15000, NossoNumero: "101"}},
+			[]string{"data_divergente a1 nosso_numero"}},
+		{"lançamento repetido", boletos[:1],
+			[]Lancamento{{ID: "L1", Data: "2026-11-11", Valor: 15000, NossoNumero: "101"}, {ID: "L1", Data: "2026-11-11", Valor: 15000, NossoNumero: "101"}},
+			[]string{"conciliado a1 nosso_numero", "duplicado"}},
+		{"segundo crédito do boleto", boletos[:1],
+			[]Lancamento{{ID: "L1", Data: "2026-11-11", Valor: 15000, NossoNumero: "101"}, {ID: "L2", Data: "2026-11-12", Valor: 15000, NossoNumero: "101"}},
+			[]string{"conciliado a1 nosso_numero", "duplicado a1 nosso_numero"}},
+		{"boleto não pago", boletos[3:],
+			[]Lancamento{{Data: "2026-11-20", Valor: 6400, NossoNumero: "104"}},
+			[]string{"somente_extrato d4 nosso_numero"}},
+		{"crédito sem boleto", boletos[:1],
+			[]Lancamento{{Data: "2026-11-11", Valor: 99}},
+			[]string{"somente_extrato", "somente_ledger a1"}},
+		{"valor fora da tolerância", boletos[:1],
+			[]Lancamento{{Data: "2026-11-20", Valor: 15000}},
+			[]string{"somente_extrato", "somente_ledger a1"}},
+		{"valor de dois boletos", []Boleto{boletoPago("a1", "101", 15000, "2026-11-10"), boletoPago("a2", "201", 15000, "2026-11-11")},
+			[]Lancamento{{Data: "2026-11-11", Valor: 15000}},
+			[]string{"somente_extrato", "somente_ledger a1", "somente_ledger a2"}},
+		{"débito e fora do período", boletos[:1],
+			[]Lancamento{{Data: "2026-11-11", Valor: -15000, NossoNumero: "101"}, {Data: "2026-10-31", Valor: 15000, NossoNumero: "101"}},
+			[]string{"somente_ledger a1"}},
+		{"pagamento fora do período", []Boleto{boletoPago("a1", "101", 15000, "2026-10-31")},
+			nil, nil},
+		{"pagamento sem data", []Boleto{boletoPago("a1", "101", 15000, "")},
+			nil, []string{"somente_ledger a1"}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			r := Conciliar(c.boletos, extrato(c.lancamentos...), Opcoes{ToleranciaDias: ToleranciaDiasPadrao})
+			var situacoes []string
+			for _, i := range r.Itens {
+				situacoes = append(situacoes, situacao(i))
+			}
+			if !reflect.DeepEqual(situacoes, c.situacoes) {
+				t.Fatalf("itens %q, esperados %q", situacoes, c.situacoes)
+			}
+		})
+	}
+}
+
+func TestConciliarFixtures(t *testing.T) {
+	b, err := ioutil.ReadFile("fixtures/propostas.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var propostas []struct {
+		ID            string `json:"id_proposta"`
+		NossoNumero   string `json:"nosso_numero"`
+		Valor         int64  `json:"valor"`
+		Status        string `json:"status"`
+		DataPagamento string `json:"data_pagamento"`
+	}
+	if err := json.Unmarshal(b, &propostas); err != nil {
+		t.Fatal(err)
+	}
+	var boletos []Boleto
+	for _, p := range propostas {
+		boletos = append(boletos, Boleto{IDProposta: p.ID, NossoNumero: p.NossoNumero, Valor: p.Valor, Status: p.Status, DataPagamento: p.DataPagamento})
+	}
+	var extratos []Extrato
+	for _, arquivo := range []string{"fixtures/extrato.ofx", "fixtures/extrato_conta2.csv"} {
+		conteudo, err := ioutil.ReadFile(arquivo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := LerExtrato(arquivo, conteudo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		extratos = append(extratos, e)
+	}
+
+	r := Conciliar(boletos, extratos, Opcoes{ToleranciaDias: ToleranciaDiasPadrao})
+	if r.Inicio != "2026-11-01" || r.Fim != "2026-11-30" || r.Ignorados != 2 {
+		t.Fatalf("período %s a %s, %d ignorados", r.Inicio, r.Fim, r.Ignorados)
+	}
+	esperados := []string{
+		"conciliado a1f3c9e2 nosso_numero",       // OFX 202611110001
+		"duplicado",                              // mesmo FITID
+		"valor_divergente b7d2e4f1 nosso_numero", // 80,00 de 89,90
+		"conciliado 5d9e7b21 valor",              // TED sem referência
+		"data_divergente e9a6f310 nosso_numero",  // 15 dias após o pagamento
+		"somente_extrato",                        // PIX sem cobrança
+		"somente_extrato 7c3a0f94 nosso_numero",  // boleto emitido, não pago
+		"conciliado f2c4a8b0 nosso_numero",       // CSV
+		"duplicado a1f3c9e2 nosso_numero",        // segundo crédito em outra conta
+		"somente_ledger 0ab3d6e1",
+	}
+	var situacoes []string
+	for _, i := range r.Itens {
+		situacoes = append(situacoes, situacao(i))
+	}
+	if !reflect.DeepEqual(situacoes, esperados) {
+		t.Fatalf("itens\n%q\nesperados\n%q", situacoes, esperados)
+	}
+	resumo := map[string]int{
+		SituacaoConciliado: 3, SituacaoDuplicado: 2, SituacaoValorDivergente: 1,
+		SituacaoDataDivergente: 1, SituacaoSomenteExtrato: 2, SituacaoSomenteLedger: 1,
+	}
+	if !reflect.DeepEqual(r.Resumo, resumo) {
+		t.Fatalf("resumo %v, esperado %v", r.Resumo, resumo)
+	}
+}
+
+func TestGravidade(t *testing.T) {
+	ordem := []string{
+		SituacaoValorDivergente, SituacaoSomenteExtrato, SituacaoSomenteLedger,
+		SituacaoDataDivergente, SituacaoDuplicado, SituacaoConciliado,
+	}
+	for i := 1; i < len(ordem); i++ {
+		if Gravidade(ordem[i-1]) >= Gravidade(ordem[i]) {
+			t.Errorf("%s não é mais grave que %s", ordem[i-1], ordem[i])
+		}
+	}
+	if g := Gravidade(SituacaoNaoConciliado); g != len(Situacoes) {
+		t.Errorf("gravidade de %s = %d, esperada %d", SituacaoNaoConciliado, g, len(Situacoes))
+	}
+	for _, s := range Situacoes {
+		if (Item{Situacao: s}).Divergente() != (s != SituacaoConciliado) {
+			t.Errorf("Divergente de %s", s)
+		}
+	}
+}
+
+func TestDocumento(t *testing.T) {
+	r := Conciliar([]Boleto{boletoPago("a1", "101", 15000, "2026-11-10")},
+		extrato(Lancamento{ID: "L1", Data: "2026-11-11", Valor: 14990, NossoNumero: "101"}), Opcoes{ToleranciaDias: ToleranciaDiasPadrao})
+	doc := r.Documento("c1")
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperado := `{"extratos":["extrato.csv"],"fim":"2026-11-30","id_conciliacao":"c1","inicio":"2026-11-01","itens":[` +
+		`{"arquivo":"extrato.csv","criterio":"nosso_numero","data_extrato":"2026-11-11","detalhe":"extrato 14990, boleto 15000",` +
+		`"id_lancamento":"L1","id_proposta":"a1","situacao":"valor_divergente","valor_extrato":14990}]}`
+	if string(b) != esperado {
+		t.Fatalf("documento\n%s\nesperado\n%s", b, esperado)
+	}
+}
+
+func TestCentavos(t *testing.T) {
+	casos := map[string]int64{"150": 15000, "150.5": 15050, "-1500.00": -150000, "1.500,00": 150000, "1500,00": 150000, ",5": 50}
+	for texto, valor := range casos {
+		if v, err := Centavos(texto); err != nil || v != valor {
+			t.Errorf("Centavos(%q) = %d, %v; esperado %d", texto, v, err, valor)
+		}
+	}
+	for _, texto := range []string{"", "1.505", "R$ 10", "1,2,3"} {
+		if _, err := Centavos(texto); err == nil {
+			t.Errorf("Centavos(%q) aceito", texto)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Descrição: cenários de ponta a ponta das propostas descritos em YAML
+Cada cenário declara as identidades, os oráculos dos bancos e o servidor de teste da
+API /atualizar utilizados, e uma sequência de passos (invokes, queries, confirmações de
+pagamento, blocos concorrentes e verificações das notificações) com as respostas e os
+eventos esperados. Ver exemplos/ para cenários completos.
+*/
+
+// Package scenario executa cenários YAML contra o simulador e o servidor de teste
+// da API /atualizar, gerando um relatório no formato JUnit.
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Cenario - cenário lido de um arquivo YAML
+type Cenario struct {
+	Nome        string                `yaml:"nome"`
+	Descricao   string                `yaml:"descricao"`
+	Identidades map[string]Identidade `yaml:"identidades"`
+	// Oraculos: fixtures de oráculos locais (ver oracle/local), relativas ao arquivo do cenário
+	Oraculos []string `yaml:"oraculos"`
+	// Deploy: se omitido, o Init é executado com as chaves públicas dos oráculos
+	Deploy *Deploy `yaml:"deploy"`
+	// API: inicia o servidor de teste de /atualizar e o relay que o notifica a cada evento
+	API    *API    `yaml:"api"`
+	Passos []Passo `yaml:"passos"`
+
+	arquivo string
+}
+
+// Identidade - usuário simulado (ver simulator.NovaIdentidade)
+type Identidade struct {
+	Metadata  string            `yaml:"metadata"`
+	Atributos map[string]string `yaml:"atributos"`
+	Assinar   bool              `yaml:"assinar"` // metadata com a assinatura da transação
+}
+
+// Deploy - argumentos do Init do chaincode: os informados em Args ou a Configuracao
+// (JSON) seguida das chaves públicas dos oráculos declarados
+type Deploy struct {
+	Como         string   `yaml:"como"`
+	Args         []string `yaml:"args"`
+	Configuracao string   `yaml:"configuracao"`
+}
+
+// API - configuração do servidor de teste de /atualizar (ver mockapi)
+type API struct {
+	Segredo   string        `yaml:"segredo"`   // segredo HMAC do cabeçalho X-Assinatura
+	Respostas []RespostaAPI `yaml:"respostas"` // respostas programadas para as primeiras notificações
+}
+
+// RespostaAPI - resposta programada do servidor de teste
+type RespostaAPI struct {
+	Status int    `yaml:"status"`
+	Corpo  string `yaml:"corpo"`
+}
+
+// Passo - uma ação do cenário e os resultados esperados. Cada passo tem exatamente
+// uma ação: invoke, query, confirmar_pagamento, bloco ou notificacoes.
+type Passo struct {
+	Nome string `yaml:"nome"`
+	Como string `yaml:"como"` // identidade que submete a transação (vazio: sem identidade)
+
+	Invoke             string        `yaml:"invoke"`
+	Query              string        `yaml:"query"`
+	Args               []string      `yaml:"args"`
+	ConfirmarPagamento *Pagamento    `yaml:"confirmar_pagamento"`
+	Bloco              []Passo       `yaml:"bloco"` // invokes concorrentes em um único bloco
+	Notificacoes       *Notificacoes `yaml:"notificacoes"`
+
+	// Resposta esperada. Objetos são comparados apenas nas chaves informadas.
+	Resposta interface{} `yaml:"resposta"`
+	// Erro: trecho esperado no erro da transação (código do envelope ou trecho da mensagem)
+	Erro string `yaml:"erro"`
+	// Eventos emitidos pela transação; se informado, a quantidade também é verificada
+	Eventos *[]map[string]interface{} `yaml:"eventos"`
+	// Validacao: resultado da validação no bloco (apenas nos passos de um bloco)
+	Validacao string `yaml:"validacao"`
+}
+
+// Pagamento - confirmação de pagamento com o atestado assinado pelo oráculo do banco,
+// do boleto (nosso_numero) ou do PIX (end_to_end_id)
+type Pagamento struct {
+	Proposta    string `yaml:"proposta"`
+	Banco       string `yaml:"banco"`
+	NossoNumero string `yaml:"nosso_numero"`
+	EndToEndID  string `yaml:"end_to_end_id"`
+}
+
+// Notificacoes - verificação das requisições recebidas pelo servidor de teste
+type Notificacoes struct {
+	Total *int `yaml:"total"` // quantidade de requisições recebidas
+	// Propostas: cada item deve corresponder ao corpo de alguma requisição aceita
+	Propostas []map[string]interface{} `yaml:"propostas"`
+}
+
+// Carregar: lê e valida o cenário do arquivo informado
+func Carregar(arquivo string) (*Cenario, error) {
+	conteudo, err := ioutil.ReadFile(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Decodificar(conteudo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", arquivo, err)
+	}
+	c.arquivo = arquivo
+	if c.Nome == "" {
+		c.Nome = strings.TrimSuffix(filepath.Base(arquivo), filepath.Ext(arquivo))
+	}
+	return c, nil
+}
+
+// Decodificar: converte e valida o YAML de um cenário. Campos desconhecidos são
+// rejeitados, para que erros de digitação não passem despercebidos.
+func Decodificar(conteudo []byte) (*Cenario, error) {
+	var c Cenario
+	dec := yaml.NewDecoder(bytes.NewReader(conteudo))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("YAML inválido: %s", err)
+	}
+	if err := c.Validar(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Arquivo: arquivo de onde o cenário foi carregado (vazio se decodificado da memória)
+func (c *Cenario) Arquivo() string {
+	return c.arquivo
+}
+
+// Validar: verifica as referências às identidades e a ação de cada passo
+func (c *Cenario) Validar() error {
+	if len(c.Passos) == 0 {
+		return errors.New("Cenário sem passos")
+	}
+	if c.Deploy != nil {
+		if err := c.validarIdentidade(c.Deploy.Como); err != nil {
+			return fmt.Errorf("deploy: %s", err)
+		}
+		if c.Deploy.Configuracao != "" && c.Deploy.Args != nil {
+			return errors.New("deploy: informe args ou configuracao, não ambos")
+		}
+	}
+	for i, p := range c.Passos {
+		if err := c.validarPasso(p, false); err != nil {
+			return fmt.Errorf("passo %d (%s): %s", i+1, p.Titulo(), err)
+		}
+	}
+	return nil
+}
+
+func (c *Cenario) validarPasso(p Passo, emBloco bool) error {
+	acoes := 0
+	for _, definida := range []bool{p.Invoke != "", p.Query != "", p.ConfirmarPagamento != nil, p.Bloco != nil, p.Notificacoes != nil} {
+		if definida {
+			acoes++
+		}
+	}
+	if acoes != 1 {
+		return errors.New("informe exatamente uma ação: invoke, query, confirmar_pagamento, bloco ou notificacoes")
+	}
+	if err := c.validarIdentidade(p.Como); err != nil {
+		return err
+	}
+	if p.Erro != "" && p.Resposta != nil {
+		return errors.New("resposta e erro não podem ser esperados no mesmo passo")
+	}
+	if p.Validacao != "" && !emBloco {
+		return errors.New("validacao só pode ser verificada nos passos de um bloco")
+	}
+
+	switch {
+	case p.Query != "":
+		if p.Eventos != nil {
+			return errors.New("queries não emitem eventos")
+		}
+	case p.ConfirmarPagamento != nil:
+		pg := p.ConfirmarPagamento
+		if pg.Proposta == "" || pg.Banco == "" || (pg.NossoNumero == "") == (pg.EndToEndID == "") {
+			return errors.New("confirmar_pagamento requer proposta, banco e nosso_numero (boleto) ou end_to_end_id (PIX)")
+		}
+	case p.Bloco != nil:
+		if emBloco {
+			return errors.New("blocos não podem ser aninhados")
+		}
+		if len(p.Bloco) == 0 {
+			return errors.New("bloco sem transações")
+		}
+		if p.Resposta != nil || p.Erro != "" || p.Eventos != nil {
+			return errors.New("as expectativas de um bloco são informadas em cada transação")
+		}
+		for i, sub := range p.Bloco {
+			if sub.Invoke == "" && sub.ConfirmarPagamento == nil {
+				return fmt.Errorf("transação %d do bloco: apenas invoke e confirmar_pagamento", i+1)
+			}
+			if err := c.validarPasso(sub, true); err != nil {
+				return fmt.Errorf("transação %d do bloco: %s", i+1, err)
+			}
+		}
+	case p.Notificacoes != nil:
+		if c.API == nil {
+			return errors.New("notificacoes requer a seção api no cenário")
+		}
+		if p.Resposta != nil || p.Erro != "" || p.Eventos != nil {
+			return errors.New("notificacoes não tem resposta, erro nem eventos")
+		}
+	}
+	return nil
+}
+
+func (c *Cenario) validarIdentidade(nome string) error {
+	if nome == "" {
+		return nil
+	}
+	if _, ok := c.Identidades[nome]; !ok {
+		return fmt.Errorf("identidade não declarada: %s", nome)
+	}
+	return nil
+}
+
+// Titulo: nome do passo ou, se não informado, a descrição da ação
+func (p Passo) Titulo() string {
+	if p.Nome != "" {
+		return p.Nome
+	}
+	switch {
+	case p.Invoke != "":
+		return "invoke " + p.Invoke
+	case p.Query != "":
+		return "query " + p.Query
+	case p.ConfirmarPagamento != nil:
+		return "confirmar pagamento da proposta " + p.ConfirmarPagamento.Proposta
+	case p.Bloco != nil:
+		return fmt.Sprintf("bloco com %d transações", len(p.Bloco))
+	case p.Notificacoes != nil:
+		return "notificações"
+	}
+	return "passo"
+}
@@ -0,0 +1,69 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodificar(t *testing.T) {
+	const identidades = "identidades: {pagador: {metadata: pagador}}\n"
+	casos := []struct {
+		nome string
+		yaml string
+		erro string // "" se o cenário for válido
+	}{
+		{"invoke", identidades + "passos: [{como: pagador, invoke: aceitarProposta, args: [p1, pagador]}]", ""},
+		{"sem passos", "nome: vazio", "Cenário sem passos"},
+		{"campo desconhecido", "passos: [{invoke: f, respota: {}}]", "YAML inválido"},
+		{"duas ações", "passos: [{invoke: f, query: g}]", "informe exatamente uma ação"},
+		{"nenhuma ação", "passos: [{nome: nada}]", "informe exatamente uma ação"},
+		{"identidade não declarada", "passos: [{como: pagador, invoke: f}]", "passo 1 (invoke f): identidade não declarada: pagador"},
+		{"identidade do deploy", "deploy: {como: admin}\npassos: [{invoke: f}]", "deploy: identidade não declarada: admin"},
+		{"args e configuracao no deploy", "deploy: {args: [a], configuracao: '{}'}\npassos: [{invoke: f}]", "informe args ou configuracao"},
+		{"resposta e erro", "passos: [{invoke: f, resposta: ok, erro: X}]", "resposta e erro não podem ser esperados"},
+		{"eventos de query", "passos: [{query: g, eventos: []}]", "queries não emitem eventos"},
+		{"validacao fora do bloco", "passos: [{invoke: f, validacao: VALID}]", "validacao só pode ser verificada nos passos de um bloco"},
+		{"pagamento sem identificação", "passos: [{confirmar_pagamento: {proposta: p1, banco: '001'}}]", "confirmar_pagamento requer"},
+		{"pagamento com boleto e PIX", "passos: [{confirmar_pagamento: {proposta: p1, banco: '001', nosso_numero: '1', end_to_end_id: E1}}]", "confirmar_pagamento requer"},
+		{"bloco vazio", "passos: [{bloco: []}]", "bloco sem transações"},
+		{"query no bloco", "passos: [{bloco: [{query: g}]}]", "transação 1 do bloco: apenas invoke e confirmar_pagamento"},
+		{"bloco aninhado", "passos: [{bloco: [{invoke: f}, {bloco: [{invoke: f}]}]}]", "transação 2 do bloco: apenas invoke"},
+		{"expectativa no bloco", "passos: [{bloco: [{invoke: f}], erro: X}]", "as expectativas de um bloco são informadas em cada transação"},
+		{"validacao no bloco", "passos: [{bloco: [{invoke: f, validacao: MVCC_READ_CONFLICT}]}]", ""},
+		{"notificacoes sem api", "passos: [{notificacoes: {total: 1}}]", "notificacoes requer a seção api"},
+		{"notificacoes com api", "api: {segredo: s}\npassos: [{notificacoes: {total: 1}}]", ""},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			_, err := Decodificar([]byte(c.yaml))
+			if c.erro == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.erro) {
+				t.Fatalf("erro = %v, esperado %q", err, c.erro)
+			}
+		})
+	}
+}
+
+func TestTitulo(t *testing.T) {
+	casos := []struct {
+		passo  Passo
+		titulo string
+	}{
+		{Passo{Nome: "aceite", Invoke: "aceitarProposta"}, "aceite"},
+		{Passo{Invoke: "aceitarProposta"}, "invoke aceitarProposta"},
+		{Passo{Query: "consultarProposta"}, "query consultarProposta"},
+		{Passo{ConfirmarPagamento: &Pagamento{Proposta: "p1"}}, "confirmar pagamento da proposta p1"},
+		{Passo{Bloco: make([]Passo, 2)}, "bloco com 2 transações"},
+		{Passo{Notificacoes: &Notificacoes{}}, "notificações"},
+	}
+	for _, c := range casos {
+		if got := c.passo.Titulo(); got != c.titulo {
+			t.Fatalf("título %q, esperado %q", got, c.titulo)
+		}
+	}
+}
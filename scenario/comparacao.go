@@ -0,0 +1,103 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// comparar: verifica se o valor obtido contém o esperado e retorna uma linha por
+// diferença. Objetos são comparados apenas nas chaves esperadas, listas elemento a
+// elemento (com o mesmo tamanho) e valores simples pelo texto, para que "true" e true
+// sejam equivalentes (o chaincode responde booleanos como strings).
+func comparar(esperado, obtido interface{}, caminho string) []string {
+	esperado, obtido = normalizar(esperado), normalizar(obtido)
+
+	switch e := esperado.(type) {
+	case map[string]interface{}:
+		o, ok := obtido.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: esperado um objeto, obtido %s", caminho, texto(obtido))}
+		}
+		var dif []string
+		chaves := make([]string, 0, len(e))
+		for k := range e {
+			chaves = append(chaves, k)
+		}
+		sort.Strings(chaves)
+		for _, k := range chaves {
+			v, existe := o[k]
+			if !existe {
+				dif = append(dif, fmt.Sprintf("%s.%s: ausente", caminho, k))
+				continue
+			}
+			dif = append(dif, comparar(e[k], v, caminho+"."+k)...)
+		}
+		return dif
+	case []interface{}:
+		o, ok := obtido.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: esperada uma lista, obtido %s", caminho, texto(obtido))}
+		}
+		if len(e) != len(o) {
+			return []string{fmt.Sprintf("%s: esperados %d itens, obtidos %d", caminho, len(e), len(o))}
+		}
+		var dif []string
+		for i := range e {
+			dif = append(dif, comparar(e[i], o[i], fmt.Sprintf("%s[%d]", caminho, i))...)
+		}
+		return dif
+	case nil:
+		if obtido != nil {
+			return []string{fmt.Sprintf("%s: esperado null, obtido %s", caminho, texto(obtido))}
+		}
+		return nil
+	}
+
+	switch obtido.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return []string{fmt.Sprintf("%s: esperado %s, obtido %s", caminho, texto(esperado), texto(obtido))}
+	}
+	if fmt.Sprint(esperado) != fmt.Sprint(obtido) {
+		return []string{fmt.Sprintf("%s: esperado %s, obtido %s", caminho, texto(esperado), texto(obtido))}
+	}
+	return nil
+}
+
+// contem: indica se o obtido contém o esperado (ver comparar)
+func contem(esperado, obtido interface{}) bool {
+	return len(comparar(esperado, obtido, "")) == 0
+}
+
+// normalizar: converte o valor (lido do YAML ou do JSON) para os tipos do encoding/json
+func normalizar(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var n interface{}
+	if err := json.Unmarshal(b, &n); err != nil {
+		return v
+	}
+	return n
+}
+
+// decodificarResposta: resposta do chaincode em JSON ou, se não for JSON, como texto
+func decodificarResposta(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	return v
+}
+
+func texto(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
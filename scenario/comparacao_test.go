@@ -0,0 +1,64 @@
+package scenario
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestComparar(t *testing.T) {
+	obtido := decodificarResposta([]byte(`{"id_proposta": "p1", "valor": 15000, "pago": "true", "tags": ["a", "b"], "boleto": null}`))
+	casos := []struct {
+		nome       string
+		esperado   interface{}
+		diferencas []string
+	}{
+		{"chaves informadas", map[string]interface{}{"id_proposta": "p1"}, nil},
+		{"número do YAML", map[string]interface{}{"valor": 15000}, nil},
+		{"booleano como texto", map[string]interface{}{"pago": true}, nil},
+		{"nulo", map[string]interface{}{"boleto": nil}, nil},
+		{"lista", map[string]interface{}{"tags": []interface{}{"a", "b"}}, nil},
+		{"valor diferente", map[string]interface{}{"id_proposta": "p2"},
+			[]string{`resposta.id_proposta: esperado "p2", obtido "p1"`}},
+		{"chave ausente", map[string]interface{}{"status": "criada", "valor": 1},
+			[]string{`resposta.status: ausente`, `resposta.valor: esperado 1, obtido 15000`}},
+		{"tamanho da lista", map[string]interface{}{"tags": []interface{}{"a"}},
+			[]string{`resposta.tags: esperados 1 itens, obtidos 2`}},
+		{"item da lista", map[string]interface{}{"tags": []interface{}{"a", "c"}},
+			[]string{`resposta.tags[1]: esperado "c", obtido "b"`}},
+		{"objeto em vez de valor", map[string]interface{}{"valor": map[string]interface{}{"centavos": 1}},
+			[]string{`resposta.valor: esperado um objeto, obtido 15000`}},
+		{"valor em vez de objeto", map[string]interface{}{"tags": "a"},
+			[]string{`resposta.tags: esperado "a", obtido ["a","b"]`}},
+		{"nulo esperado", map[string]interface{}{"pago": nil},
+			[]string{`resposta.pago: esperado null, obtido "true"`}},
+		{"lista em vez de objeto", []interface{}{}, []string{`resposta: esperada uma lista, obtido {"boleto":null,"id_proposta":"p1","pago":"true","tags":["a","b"],"valor":15000}`}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			dif := comparar(c.esperado, obtido, "resposta")
+			if fmt.Sprintf("%q", dif) != fmt.Sprintf("%q", c.diferencas) {
+				t.Fatalf("diferenças %q, esperadas %q", dif, c.diferencas)
+			}
+			if contem(c.esperado, obtido) != (len(c.diferencas) == 0) {
+				t.Fatalf("contem = %v", !(len(c.diferencas) == 0))
+			}
+		})
+	}
+}
+
+func TestDecodificarResposta(t *testing.T) {
+	casos := []struct {
+		resposta string
+		valor    interface{}
+	}{
+		{``, nil},
+		{`texto`, "texto"},
+		{`42`, float64(42)},
+		{`{"a": 1}`, map[string]interface{}{"a": float64(1)}},
+	}
+	for _, c := range casos {
+		if v := decodificarResposta([]byte(c.resposta)); fmt.Sprintf("%#v", v) != fmt.Sprintf("%#v", c.valor) {
+			t.Fatalf("%q: %#v, esperado %#v", c.resposta, v, c.valor)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/mockapi"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/oracle/local"
+	"github.com/CaueP/BlockchainDojo/projection"
+	"github.com/CaueP/BlockchainDojo/simulator"
+)
+
+// InicioRelogio - horário da primeira transação de cada cenário. O relógio avança um
+// segundo por transação e os IDs são tx-1, tx-2..., para que os eventos esperados
+// possam ser escritos com valores fixos.
+var InicioRelogio = time.Date(2017, 1, 2, 10, 0, 0, 0, time.UTC)
+
+// Resultado - resultado da execução de um cenário
+type Resultado struct {
+	Cenario string
+	Arquivo string
+	Erro    error // falha na preparação (identidades, oráculos ou deploy)
+	Passos  []ResultadoPasso
+	Duracao time.Duration
+}
+
+// ResultadoPasso - resultado de um passo. Após a primeira falha, os passos seguintes
+// não são executados (Ignorado), pois dependem do estado produzido pelos anteriores.
+type ResultadoPasso struct {
+	Nome     string
+	TxIDs    []string
+	Falhas   []string
+	Ignorado bool
+	Duracao  time.Duration
+}
+
+// Sucesso: indica se a preparação e todos os passos foram executados sem falhas
+func (r Resultado) Sucesso() bool {
+	if r.Erro != nil {
+		return false
+	}
+	for _, p := range r.Passos {
+		if len(p.Falhas) > 0 || p.Ignorado {
+			return false
+		}
+	}
+	return true
+}
+
+// execucao - estado de um cenário em execução
+type execucao struct {
+	cenario     *Cenario
+	sim         *simulator.Simulador
+	identidades map[string]*simulator.Identidade
+	oraculos    map[string]*local.Oraculo
+	api         *mockapi.Servidor
+	segredo     string
+
+	// posição do último evento repassado pelo relay
+	blocoRelay  uint64
+	indiceRelay int
+}
+
+// Executar: executa o cenário em um simulador novo, com o chaincode criado por fabrica
+// (uma instância por endossante)
+func Executar(c *Cenario, fabrica func() shim.Chaincode) Resultado {
+	inicio := time.Now()
+	res := Resultado{Cenario: c.Nome, Arquivo: c.arquivo}
+
+	e, err := preparar(c, fabrica)
+	if e != nil && e.api != nil {
+		defer e.api.Fechar()
+	}
+	if err != nil {
+		res.Erro = err
+		res.Duracao = time.Since(inicio)
+		return res
+	}
+
+	falhou := false
+	for _, p := range c.Passos {
+		rp := ResultadoPasso{Nome: p.Titulo()}
+		if falhou {
+			rp.Ignorado = true
+			res.Passos = append(res.Passos, rp)
+			continue
+		}
+		t := time.Now()
+		rp.TxIDs, rp.Falhas = e.executarPasso(p)
+		rp.Duracao = time.Since(t)
+		falhou = len(rp.Falhas) > 0
+		res.Passos = append(res.Passos, rp)
+	}
+	res.Duracao = time.Since(inicio)
+	return res
+}
+
+// preparar: cria o simulador, as identidades, os oráculos e o servidor de teste, e
+// executa o deploy
+func preparar(c *Cenario, fabrica func() shim.Chaincode) (*execucao, error) {
+	sim := simulator.Novo(fabrica())
+	sim.Fabrica = fabrica
+	sim.Relogio = simulator.RelogioSequencial(InicioRelogio, time.Second)
+	sim.GeradorTxID = simulator.TxIDSequencial("tx")
+
+	e := &execucao{
+		cenario:     c,
+		sim:         sim,
+		identidades: make(map[string]*simulator.Identidade),
+		oraculos:    make(map[string]*local.Oraculo),
+		indiceRelay: -1,
+	}
+
+	for nome, id := range c.Identidades {
+		identidade, err := simulator.NovaIdentidade(nome, []byte(id.Metadata), id.Atributos)
+		if err != nil {
+			return e, err
+		}
+		identidade.AssinarTransacoes = id.Assinar
+		e.identidades[nome] = identidade
+	}
+
+	var argsDeploy []string
+	for _, fixture := range c.Oraculos {
+		if !filepath.IsAbs(fixture) && c.arquivo != "" {
+			fixture = filepath.Join(filepath.Dir(c.arquivo), fixture)
+		}
+		o, err := local.Carregar(fixture)
+		if err != nil {
+			return e, err
+		}
+		chave, err := o.ChavePublica()
+		if err != nil {
+			return e, err
+		}
+		e.oraculos[o.CodigoBanco()] = o
+		argsDeploy = append(argsDeploy, o.CodigoBanco(), string(chave))
+	}
+
+	if c.API != nil {
+		e.segredo = c.API.Segredo
+		e.api = mockapi.Novo(c.API.Segredo)
+		for _, r := range c.API.Respostas {
+			e.api.Programar(mockapi.Resposta{Status: r.Status, Corpo: r.Corpo})
+		}
+		if _, err := e.api.Iniciar(); err != nil {
+			return e, fmt.Errorf("Falha ao iniciar a API de teste: %s", err)
+		}
+	}
+
+	como := ""
+	if c.Deploy != nil {
+		como = c.Deploy.Como
+		if c.Deploy.Configuracao != "" {
+			argsDeploy = append([]string{c.Deploy.Configuracao}, argsDeploy...)
+		} else {
+			argsDeploy = c.Deploy.Args
+		}
+	}
+	if _, err := sim.Como(e.identidades[como]).Implantar("init", argsDeploy); err != nil {
+		return e, fmt.Errorf("Falha no deploy: %s", err)
+	}
+	e.blocoRelay = sim.Altura()
+	return e, nil
+}
+
+// executarPasso: executa a ação do passo e retorna os IDs das transações e as falhas
+func (e *execucao) executarPasso(p Passo) ([]string, []string) {
+	cliente := e.sim.Como(e.identidades[p.Como])
+
+	switch {
+	case p.Query != "":
+		resposta, err := cliente.Query(p.Query, p.Args)
+		return nil, verificarResultado(p, resposta, err)
+
+	case p.Invoke != "" || p.ConfirmarPagamento != nil:
+		funcao, args, err := e.argumentos(p)
+		if err != nil {
+			return nil, []string{err.Error()}
+		}
+		res, err := cliente.Invoke(funcao, args)
+		falhas := verificarResultado(p, res.Payload, err)
+		falhas = append(falhas, e.verificarEventos(p, res.TxID, err == nil)...)
+		return txIDs(res.TxID), append(falhas, e.relay()...)
+
+	case p.Bloco != nil:
+		return e.executarBloco(p.Bloco)
+
+	case p.Notificacoes != nil:
+		return nil, e.verificarNotificacoes(*p.Notificacoes)
+	}
+	return nil, []string{"passo sem ação"}
+}
+
+// executarBloco: submete as transações concorrentemente em um único bloco
+func (e *execucao) executarBloco(passos []Passo) ([]string, []string) {
+	var reqs []simulator.Requisicao
+	for i, p := range passos {
+		funcao, args, err := e.argumentos(p)
+		if err != nil {
+			return nil, []string{fmt.Sprintf("transação %d: %s", i+1, err)}
+		}
+		reqs = append(reqs, simulator.Requisicao{Identidade: e.identidades[p.Como], Funcao: funcao, Args: args})
+	}
+
+	var ids, falhas []string
+	for i, r := range e.sim.ExecutarBloco(reqs) {
+		p := passos[i]
+		ids = append(ids, r.TxID)
+
+		var dif []string
+		switch {
+		case p.Validacao != "":
+			if r.Validacao != p.Validacao {
+				dif = append(dif, fmt.Sprintf("validação: esperada %s, obtida %s", p.Validacao, r.Validacao))
+			}
+			if p.Erro != "" || p.Resposta != nil {
+				dif = append(dif, verificarResultado(p, r.Resposta, r.Erro)...)
+			}
+		default:
+			dif = verificarResultado(p, r.Resposta, r.Erro)
+		}
+		dif = append(dif, e.verificarEventos(p, r.TxID, r.Validacao == simulator.Valida)...)
+
+		for _, d := range dif {
+			falhas = append(falhas, fmt.Sprintf("transação %d (%s): %s", i+1, p.Titulo(), d))
+		}
+	}
+	return ids, append(falhas, e.relay()...)
+}
+
+// argumentos: função e argumentos do invoke. Em confirmar_pagamento, o atestado é
+// obtido do oráculo do banco e enviado a confirmarPagamento.
+func (e *execucao) argumentos(p Passo) (string, []string, error) {
+	if p.ConfirmarPagamento == nil {
+		return p.Invoke, p.Args, nil
+	}
+	pg := p.ConfirmarPagamento
+	o, ok := e.oraculos[pg.Banco]
+	if !ok {
+		return "", nil, fmt.Errorf("Oráculo do banco %s não declarado em oraculos", pg.Banco)
+	}
+	var atestado oracle.Atestado
+	var err error
+	if pg.EndToEndID != "" {
+		atestado, err = o.AtestarPix(pg.EndToEndID)
+	} else {
+		atestado, err = o.Atestar(pg.NossoNumero)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	b, err := json.Marshal(atestado)
+	if err != nil {
+		return "", nil, err
+	}
+	return "confirmarPagamento", []string{pg.Proposta, string(b)}, nil
+}
+
+// verificarResultado: compara a resposta ou o erro da transação com o esperado
+func verificarResultado(p Passo, resposta []byte, err error) []string {
+	if p.Erro != "" {
+		if err == nil {
+			return []string{fmt.Sprintf("esperado erro contendo %q, mas a transação foi executada com sucesso", p.Erro)}
+		}
+		if !strings.Contains(err.Error(), p.Erro) {
+			return []string{fmt.Sprintf("esperado erro contendo %q, obtido %q", p.Erro, err.Error())}
+		}
+		return nil
+	}
+	if err != nil {
+		if e, ok := err.(*ledger.ErroChaincode); ok {
+			return []string{"erro do chaincode: " + e.Mensagem}
+		}
+		return []string{"erro: " + err.Error()}
+	}
+	if p.Resposta == nil {
+		return nil
+	}
+	return comparar(p.Resposta, decodificarResposta(resposta), "resposta")
+}
+
+// verificarEventos: compara os eventos emitidos pela transação com os esperados
+func (e *execucao) verificarEventos(p Passo, txID string, confirmada bool) []string {
+	if p.Eventos == nil {
+		return nil
+	}
+	var obtidos []interface{}
+	if confirmada {
+		for _, b := range e.sim.Blocos() {
+			for _, tx := range b.Transacoes {
+				if tx.TxID == txID && tx.Evento != nil {
+					obtidos = append(obtidos, decodificarResposta(tx.Evento.Payload))
+				}
+			}
+		}
+	}
+
+	esperados := *p.Eventos
+	if len(esperados) != len(obtidos) {
+		return []string{fmt.Sprintf("eventos: esperados %d, emitidos %d %s", len(esperados), len(obtidos), texto(obtidos))}
+	}
+	var falhas []string
+	for i := range esperados {
+		falhas = append(falhas, comparar(esperados[i], obtidos[i], fmt.Sprintf("eventos[%d]", i))...)
+	}
+	return falhas
+}
+
+// relay: repassa a /atualizar cada evento confirmado desde a última chamada, com a
+// proposta consultada no chaincode, no mesmo formato enviado pelo chaincode apicall
+func (e *execucao) relay() []string {
+	if e.api == nil {
+		return nil
+	}
+	var pendentes []projection.EventoBloco
+	err := e.sim.Ler(e.blocoRelay, func(ev projection.EventoBloco) error {
+		if ev.Bloco == e.blocoRelay && ev.Indice <= e.indiceRelay {
+			return nil
+		}
+		pendentes = append(pendentes, ev)
+		return nil
+	})
+	if err != nil {
+		return []string{"relay: " + err.Error()}
+	}
+
+	var falhas []string
+	for _, ev := range pendentes {
+		e.blocoRelay, e.indiceRelay = ev.Bloco, ev.Indice
+		for _, evento := range ev.Evento.Eventos() {
+			if err := e.notificar(evento.IDProposta); err != nil {
+				falhas = append(falhas, fmt.Sprintf("relay do evento %s da proposta %s: %s", evento.Tipo, evento.IDProposta, err))
+			}
+		}
+	}
+	return falhas
+}
+
+// notificar: POST /atualizar com a proposta atual. Respostas de erro da API não são
+// falhas do relay; elas ficam registradas no servidor e são verificadas em notificacoes.
+func (e *execucao) notificar(idProposta string) error {
+	payload, err := e.sim.Query("consultarProposta", []string{idProposta})
+	if err != nil {
+		return err
+	}
+	var p struct {
+		ID                  string `json:"id_proposta"`
+		CpfPagador          string `json:"cpf_pagador"`
+		PagadorAceitou      bool   `json:"pagador_aceitou"`
+		BeneficiarioAceitou bool   `json:"beneficiario_aceitou"`
+		BoletoPago          bool   `json:"boleto_pago"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	corpo, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", e.api.URL()+"/atualizar", bytes.NewReader(corpo))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.segredo != "" {
+		req.Header.Set(mockapi.CabecalhoAssinatura, mockapi.Assinar(e.segredo, corpo))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// verificarNotificacoes: compara as requisições recebidas pelo servidor de teste
+func (e *execucao) verificarNotificacoes(n Notificacoes) []string {
+	requisicoes := e.api.Requisicoes()
+	var falhas []string
+	if n.Total != nil && len(requisicoes) != *n.Total {
+		falhas = append(falhas, fmt.Sprintf("notificações: esperadas %d, recebidas %d", *n.Total, len(requisicoes)))
+	}
+
+	var aceitas []interface{}
+	for _, r := range requisicoes {
+		if r.Erro == nil {
+			aceitas = append(aceitas, decodificarResposta(r.Corpo))
+		}
+	}
+	for i, esperada := range n.Propostas {
+		encontrada := false
+		for _, a := range aceitas {
+			if contem(esperada, a) {
+				encontrada = true
+				break
+			}
+		}
+		if !encontrada {
+			falhas = append(falhas, fmt.Sprintf("propostas[%d]: %s não recebida em /atualizar (aceitas: %s)", i, texto(esperada), texto(aceitas)))
+		}
+	}
+	return falhas
+}
+
+func txIDs(id string) []string {
+	if id == "" {
+		return nil
+	}
+	return []string{id}
+}
@@ -0,0 +1,193 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/chaincode/propostas"
+)
+
+func fabrica() shim.Chaincode { return new(propostas.BoletoPropostaChaincode) }
+
+// executar: decodifica e executa o cenário
+func executar(t *testing.T, conteudo string) Resultado {
+	t.Helper()
+	c, err := Decodificar([]byte(conteudo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Executar(c, fabrica)
+}
+
+// falhas: falhas de cada passo, ou "ignorado"
+func falhas(r Resultado) []string {
+	var f []string
+	for _, p := range r.Passos {
+		switch {
+		case p.Ignorado:
+			f = append(f, "ignorado")
+		default:
+			f = append(f, strings.Join(p.Falhas, "; "))
+		}
+	}
+	return f
+}
+
+func TestExemplos(t *testing.T) {
+	arquivos, err := filepath.Glob("exemplos/*.yaml")
+	if err != nil || len(arquivos) == 0 {
+		t.Fatalf("exemplos: %v, %v", arquivos, err)
+	}
+	for _, arquivo := range arquivos {
+		t.Run(filepath.Base(arquivo), func(t *testing.T) {
+			c, err := Carregar(arquivo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r := Executar(c, fabrica); !r.Sucesso() {
+				t.Fatalf("cenário com falhas:\n%s", Resumo([]Resultado{r}))
+			}
+		})
+	}
+}
+
+func TestPassos(t *testing.T) {
+	const registrar = "  - {invoke: registrarProposta, args: [p1, 111, 'false', 'false', 'false']}\n"
+	casos := []struct {
+		nome   string
+		passos string
+		falhas []string
+	}{
+		{"sucesso", registrar +
+			"  - {query: consultarProposta, args: [p1], resposta: {id_proposta: p1, status: criada}}\n",
+			[]string{"", ""}},
+		{"resposta diferente", registrar +
+			"  - {query: consultarProposta, args: [p1], resposta: {status: aceita}}\n" +
+			"  - {query: consultarProposta, args: [p1]}\n",
+			[]string{"", `resposta.status: esperado "aceita", obtido "criada"`, "ignorado"}},
+		{"erro esperado não ocorre", registrar +
+			"  - {query: consultarProposta, args: [p1], erro: PROPOSTA_NAO_ENCONTRADA}\n",
+			[]string{"", `esperado erro contendo "PROPOSTA_NAO_ENCONTRADA", mas a transação foi executada com sucesso`}},
+		{"outro erro", "  - {query: consultarProposta, args: [p9], erro: ARGUMENTO_INVALIDO}\n",
+			[]string{`esperado erro contendo "ARGUMENTO_INVALIDO", obtido`}},
+		{"erro inesperado", "  - {query: consultarProposta, args: [p9]}\n",
+			[]string{"erro do chaincode: "}},
+		{"eventos", "  - {invoke: registrarProposta, args: [p1, 111, 'false', 'false', 'false'], eventos: [{tipo: PropostaCriada, tx_id: tx-2}]}\n" +
+			"  - {invoke: aceitarProposta, args: [p1, pagador], eventos: []}\n",
+			[]string{"", "eventos: esperados 0, emitidos 1"}},
+		{"validação no bloco", "  - bloco:\n" +
+			"    - {invoke: registrarProposta, args: [p1, 111, 'false', 'false', 'false']}\n" +
+			"    - {invoke: registrarProposta, args: [p1, 222, 'false', 'false', 'false'], validacao: VALID}\n",
+			[]string{"transação 2 (invoke registrarProposta): validação: esperada VALID, obtida MVCC_READ_CONFLICT"}},
+		{"oráculo não declarado", "  - {confirmar_pagamento: {proposta: p1, banco: '001', nosso_numero: '1'}}\n",
+			[]string{"Oráculo do banco 001 não declarado em oraculos"}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			r := executar(t, "passos:\n"+c.passos)
+			if r.Erro != nil {
+				t.Fatal(r.Erro)
+			}
+			obtidas := falhas(r)
+			if len(obtidas) != len(c.falhas) {
+				t.Fatalf("falhas %q, esperadas %q", obtidas, c.falhas)
+			}
+			for i := range c.falhas {
+				if (c.falhas[i] == "") != (obtidas[i] == "") || !strings.Contains(obtidas[i], c.falhas[i]) {
+					t.Fatalf("passo %d: falhas %q, esperado %q", i+1, obtidas[i], c.falhas[i])
+				}
+			}
+			if r.Sucesso() != (strings.Join(c.falhas, "") == "") {
+				t.Fatalf("sucesso = %v com as falhas %q", r.Sucesso(), obtidas)
+			}
+		})
+	}
+}
+
+func TestPreparacao(t *testing.T) {
+	casos := []struct {
+		nome    string
+		cenario string
+		erro    string
+	}{
+		{"fixture inexistente", "oraculos: [inexistente.json]\npassos: [{query: versao}]", "inexistente.json"},
+		{"deploy recusado", "deploy: {configuracao: '{\"tabela\": \"outra\"}'}\npassos: [{query: versao}]", "Falha no deploy"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			r := executar(t, c.cenario)
+			if r.Erro == nil || !strings.Contains(r.Erro.Error(), c.erro) {
+				t.Fatalf("erro = %v, esperado %q", r.Erro, c.erro)
+			}
+			if r.Sucesso() || len(r.Passos) != 0 {
+				t.Fatalf("passos executados depois da falha na preparação: %+v", r.Passos)
+			}
+		})
+	}
+}
+
+func TestNotificacoes(t *testing.T) {
+	r := executar(t, `
+api:
+  segredo: s
+  respostas: [{status: 500, corpo: indisponível}]
+passos:
+  - {invoke: registrarProposta, args: [p1, 111, 'false', 'false', 'false']}
+  - {invoke: aceitarProposta, args: [p1, pagador]}
+  - notificacoes:
+      total: 2
+      propostas:
+        - {id_proposta: p1, pagador_aceitou: false}
+        - {id_proposta: p1, pagador_aceitou: true}
+  - notificacoes:
+      propostas:
+        - {id_proposta: p1, boleto_pago: true}
+`)
+	// a resposta 500 da API não é uma falha do relay; a proposta não enviada é
+	obtidas := falhas(r)
+	if strings.Join(obtidas[:3], "") != "" || !strings.HasPrefix(obtidas[3], `propostas[0]: {"boleto_pago":true,"id_proposta":"p1"} não recebida`) {
+		t.Fatalf("falhas %q", obtidas)
+	}
+}
+
+func TestRelatorioJUnit(t *testing.T) {
+	resultados := []Resultado{
+		executar(t, "nome: ok\npassos: [{query: versao}]"),
+		executar(t, "nome: falha\npassos: [{query: consultarProposta, args: [p9]}, {query: versao}]"),
+		{Cenario: "preparação", Erro: erroPreparacao{}},
+	}
+	var buf bytes.Buffer
+	if err := RelatorioJUnit(&buf, resultados); err != nil {
+		t.Fatal(err)
+	}
+	var rel suitesJUnit
+	if err := xml.Unmarshal(buf.Bytes(), &rel); err != nil {
+		t.Fatal(err)
+	}
+	if rel.Testes != 4 || rel.Falhas != 1 || rel.Erros != 1 || len(rel.Suites) != 3 {
+		t.Fatalf("relatório: %d testes, %d falhas, %d erros, %d suites\n%s", rel.Testes, rel.Falhas, rel.Erros, len(rel.Suites), buf.String())
+	}
+	falha := rel.Suites[1]
+	if falha.Ignorados != 1 || falha.Casos[0].Falha == nil || falha.Casos[1].Ignorado == nil || falha.Casos[0].Nome != "01 query consultarProposta" {
+		t.Fatalf("suite com falha: %+v", falha)
+	}
+	if erro := rel.Suites[2].Casos[0]; erro.Nome != "preparação" || erro.Erro == nil || erro.Erro.Mensagem != "sem deploy" {
+		t.Fatalf("suite com erro: %+v", erro)
+	}
+
+	resumo := Resumo(resultados)
+	for _, trecho := range []string{"OK ok", "FALHOU falha", "  01 FALHA query consultarProposta", "  02 -      query versao", "erro na preparação: sem deploy\ndetalhes"} {
+		if !strings.Contains(resumo, trecho) {
+			t.Fatalf("resumo sem %q:\n%s", trecho, resumo)
+		}
+	}
+}
+
+type erroPreparacao struct{}
+
+func (erroPreparacao) Error() string { return "sem deploy\ndetalhes" }
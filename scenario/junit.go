@@ -0,0 +1,139 @@
+package scenario
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Relatório JUnit: um testsuite por cenário e um testcase por passo, no formato
+// lido pelos servidores de integração contínua
+type suitesJUnit struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Testes  int          `xml:"tests,attr"`
+	Falhas  int          `xml:"failures,attr"`
+	Erros   int          `xml:"errors,attr"`
+	Tempo   string       `xml:"time,attr"`
+	Suites  []suiteJUnit `xml:"testsuite"`
+}
+
+type suiteJUnit struct {
+	Nome      string      `xml:"name,attr"`
+	Testes    int         `xml:"tests,attr"`
+	Falhas    int         `xml:"failures,attr"`
+	Erros     int         `xml:"errors,attr"`
+	Ignorados int         `xml:"skipped,attr"`
+	Tempo     string      `xml:"time,attr"`
+	Arquivo   string      `xml:"file,attr,omitempty"`
+	Casos     []casoJUnit `xml:"testcase"`
+}
+
+type casoJUnit struct {
+	Nome     string      `xml:"name,attr"`
+	Classe   string      `xml:"classname,attr"`
+	Tempo    string      `xml:"time,attr"`
+	Falha    *falhaJUnit `xml:"failure,omitempty"`
+	Erro     *falhaJUnit `xml:"error,omitempty"`
+	Ignorado *struct{}   `xml:"skipped,omitempty"`
+	Saida    string      `xml:"system-out,omitempty"`
+}
+
+type falhaJUnit struct {
+	Mensagem string `xml:"message,attr"`
+	Texto    string `xml:",chardata"`
+}
+
+// RelatorioJUnit: grava o relatório JUnit dos resultados
+func RelatorioJUnit(w io.Writer, resultados []Resultado) error {
+	var total suitesJUnit
+	var tempo time.Duration
+	for _, r := range resultados {
+		s := suiteJUnit{Nome: r.Cenario, Tempo: segundos(r.Duracao), Arquivo: r.Arquivo}
+		if r.Erro != nil {
+			s.Erros++
+			s.Casos = append(s.Casos, casoJUnit{
+				Nome:   "preparação",
+				Classe: r.Cenario,
+				Tempo:  segundos(r.Duracao),
+				Erro:   &falhaJUnit{Mensagem: primeiraLinha(r.Erro.Error()), Texto: r.Erro.Error()},
+			})
+		}
+		for i, p := range r.Passos {
+			caso := casoJUnit{
+				Nome:   fmt.Sprintf("%02d %s", i+1, p.Nome),
+				Classe: r.Cenario,
+				Tempo:  segundos(p.Duracao),
+			}
+			if len(p.TxIDs) > 0 {
+				caso.Saida = "transações: " + strings.Join(p.TxIDs, ", ")
+			}
+			switch {
+			case p.Ignorado:
+				s.Ignorados++
+				caso.Ignorado = &struct{}{}
+			case len(p.Falhas) > 0:
+				s.Falhas++
+				caso.Falha = &falhaJUnit{Mensagem: primeiraLinha(p.Falhas[0]), Texto: strings.Join(p.Falhas, "\n")}
+			}
+			s.Casos = append(s.Casos, caso)
+		}
+		s.Testes = len(s.Casos)
+
+		total.Testes += s.Testes
+		total.Falhas += s.Falhas
+		total.Erros += s.Erros
+		tempo += r.Duracao
+		total.Suites = append(total.Suites, s)
+	}
+	total.Tempo = segundos(tempo)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(total); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Resumo: uma linha por passo, no formato exibido no console
+func Resumo(resultados []Resultado) string {
+	var b strings.Builder
+	for _, r := range resultados {
+		situacao := "OK"
+		if !r.Sucesso() {
+			situacao = "FALHOU"
+		}
+		fmt.Fprintf(&b, "%s %s (%s)\n", situacao, r.Cenario, r.Duracao.Round(time.Millisecond))
+		if r.Erro != nil {
+			fmt.Fprintf(&b, "  erro na preparação: %s\n", r.Erro)
+		}
+		for i, p := range r.Passos {
+			switch {
+			case p.Ignorado:
+				fmt.Fprintf(&b, "  %02d -      %s\n", i+1, p.Nome)
+			case len(p.Falhas) > 0:
+				fmt.Fprintf(&b, "  %02d FALHA %s\n", i+1, p.Nome)
+				for _, f := range p.Falhas {
+					fmt.Fprintf(&b, "           %s\n", f)
+				}
+			default:
+				fmt.Fprintf(&b, "  %02d ok    %s\n", i+1, p.Nome)
+			}
+		}
+	}
+	return b.String()
+}
+
+func segundos(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func primeiraLinha(s string) string {
+	return strings.SplitN(s, "\n", 2)[0]
+}
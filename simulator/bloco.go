@@ -0,0 +1,214 @@
+package simulator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/CaueP/BlockchainDojo/ledger"
+)
+
+// Validação das transações de um bloco. O peer v0.6 executa as transações em ordem,
+// uma de cada vez; em uma rede com endosso antes da ordenação (fabric 1.x), transações
+// concorrentes são executadas sobre o mesmo estado e a validação do bloco invalida as
+// que leram versões que deixaram de ser as atuais.
+const (
+	Valida           = "VALID"
+	ConflitoLeitura  = "MVCC_READ_CONFLICT"    // uma chave lida foi alterada antes da confirmação
+	ConflitoFantasma = "PHANTOM_READ_CONFLICT" // o resultado de um RangeQueryState foi alterado
+	FalhaEndosso     = "ENDORSEMENT_FAILURE"   // erro do chaincode ou divergência: não incluída no bloco
+)
+
+// Requisicao - transação submetida em ExecutarBloco
+type Requisicao struct {
+	Identidade *Identidade // nil: transação sem certificado nem metadata
+	Funcao     string
+	Args       []string
+}
+
+// ResultadoBloco - resultado de uma transação submetida em ExecutarBloco
+type ResultadoBloco struct {
+	TxID      string
+	Resposta  []byte
+	Validacao string
+	Conflitos []Conflito
+	Erro      error // *ledger.ErroChaincode, *ErroDivergencia ou *ErroConflito
+}
+
+// Conflito - leitura invalidada por uma escrita confirmada antes da transação
+type Conflito struct {
+	Chave      string `json:"chave"`
+	VersaoLida Versao `json:"versao_lida"`
+	Atual      Versao `json:"versao_atual"`
+	GravadaPor string `json:"gravada_por,omitempty"` // transação do mesmo bloco que alterou a chave
+	Fantasma   bool   `json:"fantasma,omitempty"`    // chave incluída ou removida do intervalo lido
+}
+
+// ErroConflito - transação invalidada na validação do bloco
+type ErroConflito struct {
+	TxID      string
+	Validacao string
+	Conflitos []Conflito
+}
+
+func (e *ErroConflito) Error() string {
+	var chaves []string
+	for _, c := range e.Conflitos {
+		desc := fmt.Sprintf("%q (lida na versão %d.%d, atual %d.%d", c.Chave, c.VersaoLida.Bloco, c.VersaoLida.Transacao, c.Atual.Bloco, c.Atual.Transacao)
+		if c.GravadaPor != "" {
+			desc += ", gravada por " + c.GravadaPor
+		}
+		chaves = append(chaves, desc+")")
+	}
+	return fmt.Sprintf("Transação %s invalidada (%s): %s", e.TxID, e.Validacao, strings.Join(chaves, "; "))
+}
+
+// ExecutarBloco: submete as transações (invokes) concorrentemente. Todas são endossadas
+// sobre o estado atual e ordenadas na ordem informada em um único bloco; na validação,
+// cada transação que leu uma versão alterada por uma transação anterior do bloco é
+// invalidada e as suas escritas descartadas. Transações com erro no endosso não são
+// incluídas no bloco.
+func (s *Simulador) ExecutarBloco(reqs []Requisicao) []ResultadoBloco {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.executarBloco("invoke", reqs)
+}
+
+func (s *Simulador) executarBloco(tipo string, reqs []Requisicao) []ResultadoBloco {
+	numero := uint64(len(s.blocos))
+	resultados := make([]ResultadoBloco, len(reqs))
+
+	// endosso: todas as transações sobre o estado confirmado antes do bloco
+	var ordenadas []*Stub
+	var indices []int
+	for i, req := range reqs {
+		stub, err := s.novoStub(req.Identidade, s.GeradorTxID(), s.Relogio(), req.Funcao, req.Args)
+		if err != nil {
+			resultados[i] = ResultadoBloco{Validacao: FalhaEndosso, Erro: err}
+			continue
+		}
+		resultados[i].TxID = stub.txID
+
+		endossos := s.endossar(stub, tipo)
+		if err := compararEndossos(stub.txID, req.Funcao, endossos); err != nil {
+			resultados[i].Validacao, resultados[i].Erro = FalhaEndosso, err
+			continue
+		}
+		if endossos[0].erro != nil {
+			resultados[i].Validacao = FalhaEndosso
+			resultados[i].Erro = &ledger.ErroChaincode{Mensagem: endossos[0].erro.Error()}
+			continue
+		}
+		resultados[i].Resposta = endossos[0].resposta
+		ordenadas = append(ordenadas, endossos[0].stub)
+		indices = append(indices, i)
+	}
+	if len(ordenadas) == 0 {
+		return resultados
+	}
+
+	// validação e confirmação, na ordem do bloco
+	bloco := Bloco{Numero: numero}
+	for pos, st := range ordenadas {
+		versao := Versao{Bloco: numero, Transacao: pos}
+		tx := Transacao{
+			TxID:           st.txID,
+			Tipo:           tipo,
+			Funcao:         st.funcao,
+			Args:           append([]string(nil), st.args...),
+			Horario:        st.horario,
+			Chamador:       nomeIdentidade(st.identidade),
+			Evento:         st.evento,
+			LeituraEscrita: st.leituraEscrita(),
+			Validacao:      Valida,
+		}
+
+		tx.Conflitos = s.validar(tx.LeituraEscrita, bloco.Transacoes)
+		r := &resultados[indices[pos]]
+		if len(tx.Conflitos) > 0 {
+			tx.Validacao = ConflitoLeitura
+			for _, c := range tx.Conflitos {
+				if c.Fantasma {
+					tx.Validacao = ConflitoFantasma
+					break
+				}
+			}
+			r.Resposta = nil
+			r.Erro = &ErroConflito{TxID: tx.TxID, Validacao: tx.Validacao, Conflitos: tx.Conflitos}
+		} else {
+			st.confirmar(versao)
+		}
+		r.Validacao, r.Conflitos = tx.Validacao, tx.Conflitos
+		bloco.Transacoes = append(bloco.Transacoes, tx)
+	}
+	s.blocos = append(s.blocos, bloco)
+	return resultados
+}
+
+// validar: compara as versões lidas pela transação com as versões atuais do estado
+// (já com as escritas das transações válidas anteriores do bloco)
+func (s *Simulador) validar(rw ConjuntoLeituraEscrita, anteriores []Transacao) []Conflito {
+	var conflitos []Conflito
+	for _, k := range chavesVersao(rw.Leituras) {
+		lida, atual := rw.Leituras[k], s.estado[k].versao
+		if lida != atual {
+			conflitos = append(conflitos, Conflito{Chave: k, VersaoLida: lida, Atual: atual, GravadaPor: gravadaPor(k, anteriores)})
+		}
+	}
+
+	for _, intervalo := range rw.Intervalos {
+		atuais := make(map[string]Versao)
+		for k, r := range s.estado {
+			if k >= intervalo.Inicio && (intervalo.Fim == "" || k < intervalo.Fim) {
+				atuais[k] = r.versao
+			}
+		}
+		for _, k := range uniao(chavesVersao(intervalo.Chaves), chavesVersao(atuais)) {
+			lida, estavaNaLeitura := intervalo.Chaves[k]
+			atual, existe := atuais[k]
+			if estavaNaLeitura != existe {
+				conflitos = append(conflitos, Conflito{Chave: k, VersaoLida: lida, Atual: atual, GravadaPor: gravadaPor(k, anteriores), Fantasma: true})
+			}
+		}
+	}
+
+	// ordem determinística para o relatório
+	ordenarConflitos(conflitos)
+	return conflitos
+}
+
+// gravadaPor: transação válida do mesmo bloco que gravou a chave por último
+func gravadaPor(chave string, anteriores []Transacao) string {
+	for i := len(anteriores) - 1; i >= 0; i-- {
+		tx := anteriores[i]
+		if tx.Validacao != Valida {
+			continue
+		}
+		if _, ok := tx.LeituraEscrita.Escritas[chave]; ok {
+			return tx.TxID
+		}
+	}
+	return ""
+}
+
+func ordenarConflitos(c []Conflito) {
+	sort.SliceStable(c, func(i, j int) bool {
+		if c[i].Chave != c[j].Chave {
+			return c[i].Chave < c[j].Chave
+		}
+		return !c[i].Fantasma && c[j].Fantasma
+	})
+}
+
+// RelatorioBloco: uma linha por transação com o resultado da validação
+func RelatorioBloco(resultados []ResultadoBloco) string {
+	var linhas []string
+	for i, r := range resultados {
+		linha := fmt.Sprintf("%d %s %s", i+1, r.TxID, r.Validacao)
+		if r.Erro != nil && r.Validacao != Valida {
+			linha += ": " + r.Erro.Error()
+		}
+		linhas = append(linhas, linha)
+	}
+	return strings.Join(linhas, "\n")
+}
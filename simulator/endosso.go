@@ -0,0 +1,189 @@
+package simulator
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// endosso - resultado da execução de uma transação por um endossante
+type endosso struct {
+	stub     *Stub
+	resposta []byte
+	erro     error
+}
+
+// ErroDivergencia - os endossantes produziram resultados diferentes para a mesma
+// transação (chaincode não determinístico). A transação não é confirmada.
+type ErroDivergencia struct {
+	TxID       string
+	Funcao     string
+	Diferencas []string // uma linha por diferença encontrada
+}
+
+func (e *ErroDivergencia) Error() string {
+	return fmt.Sprintf("Divergência entre os endossantes na transação %s (%s):\n  %s",
+		e.TxID, e.Funcao, strings.Join(e.Diferencas, "\n  "))
+}
+
+// endossar: executa a transação em cada endossante, sobre o mesmo estado confirmado.
+// Todos recebem o mesmo ID, horário, payload, binding e metadata da transação.
+func (s *Simulador) endossar(base *Stub, tipo string) []endosso {
+	n := s.Endossantes
+	if n < 1 {
+		n = 1
+	}
+
+	endossos := make([]endosso, n)
+	for i := 0; i < n; i++ {
+		st := base
+		if i > 0 {
+			copia := *base
+			copia.leituras = make(map[string]Versao)
+			copia.intervalos = nil
+			copia.escritas = make(map[string][]byte)
+			copia.evento = nil
+			st = &copia
+		}
+
+		cc := s.instancia(i)
+		var e endosso
+		e.stub = st
+		if tipo == "deploy" {
+			e.resposta, e.erro = cc.Init(st, st.funcao, st.args)
+		} else {
+			e.resposta, e.erro = cc.Invoke(st, st.funcao, st.args)
+		}
+		endossos[i] = e
+	}
+	return endossos
+}
+
+// instancia: chaincode executado pelo endossante i
+func (s *Simulador) instancia(i int) shim.Chaincode {
+	if s.Fabrica == nil {
+		return s.chaincode
+	}
+	for len(s.instancias) <= i {
+		s.instancias = append(s.instancias, s.Fabrica())
+	}
+	return s.instancias[i]
+}
+
+// compararEndossos: compara o resultado de cada endossante com o do primeiro
+func compararEndossos(txID, funcao string, endossos []endosso) error {
+	var dif []string
+	ref := endossos[0]
+	for i, e := range endossos[1:] {
+		outro := fmt.Sprintf("endossante %d", i+2)
+
+		if textoErro(ref.erro) != textoErro(e.erro) {
+			dif = append(dif, fmt.Sprintf("erro: endossante 1 = %s; %s = %s", textoErro(ref.erro), outro, textoErro(e.erro)))
+		}
+		if !bytes.Equal(ref.resposta, e.resposta) {
+			dif = append(dif, fmt.Sprintf("resposta: endossante 1 = %s; %s = %s", textoValor(ref.resposta), outro, textoValor(e.resposta)))
+		}
+		dif = append(dif, compararLeituras(ref.stub.leituras, e.stub.leituras, outro)...)
+		dif = append(dif, compararEscritas(ref.stub.escritas, e.stub.escritas, outro)...)
+		if textoEvento(ref.stub.evento) != textoEvento(e.stub.evento) {
+			dif = append(dif, fmt.Sprintf("evento: endossante 1 = %s; %s = %s", textoEvento(ref.stub.evento), outro, textoEvento(e.stub.evento)))
+		}
+	}
+	if len(dif) == 0 {
+		return nil
+	}
+	return &ErroDivergencia{TxID: txID, Funcao: funcao, Diferencas: dif}
+}
+
+func compararLeituras(a, b map[string]Versao, outro string) []string {
+	var dif []string
+	for _, k := range uniao(chavesVersao(a), chavesVersao(b)) {
+		_, emA := a[k]
+		_, emB := b[k]
+		if emA && !emB {
+			dif = append(dif, fmt.Sprintf("leitura %q: apenas o endossante 1", k))
+		} else if emB && !emA {
+			dif = append(dif, fmt.Sprintf("leitura %q: apenas o %s", k, outro))
+		}
+	}
+	return dif
+}
+
+func compararEscritas(a, b map[string][]byte, outro string) []string {
+	var dif []string
+	for _, k := range uniao(chavesBytes(a), chavesBytes(b)) {
+		va, emA := a[k]
+		vb, emB := b[k]
+		if emA == emB && bytes.Equal(va, vb) && (va == nil) == (vb == nil) {
+			continue
+		}
+		dif = append(dif, fmt.Sprintf("escrita %q: endossante 1 = %s; %s = %s", k, textoEscrita(va, emA), outro, textoEscrita(vb, emB)))
+	}
+	return dif
+}
+
+func textoEscrita(v []byte, escrita bool) string {
+	if !escrita {
+		return "(não gravada)"
+	}
+	if v == nil {
+		return "(excluída)"
+	}
+	return textoValor(v)
+}
+
+func textoEvento(ev *Evento) string {
+	if ev == nil {
+		return "(nenhum)"
+	}
+	return ev.Nome + " " + textoValor(ev.Payload)
+}
+
+func textoErro(err error) string {
+	if err == nil {
+		return "(nenhum)"
+	}
+	return err.Error()
+}
+
+// textoValor: valor legível e limitado para a mensagem de divergência
+func textoValor(v []byte) string {
+	const limite = 200
+	if len(v) > limite {
+		return fmt.Sprintf("%q...", v[:limite])
+	}
+	return fmt.Sprintf("%q", v)
+}
+
+func chavesVersao(m map[string]Versao) []string {
+	var chaves []string
+	for k := range m {
+		chaves = append(chaves, k)
+	}
+	return chaves
+}
+
+func chavesBytes(m map[string][]byte) []string {
+	var chaves []string
+	for k := range m {
+		chaves = append(chaves, k)
+	}
+	return chaves
+}
+
+// uniao: chaves das duas listas, sem repetição e em ordem
+func uniao(a, b []string) []string {
+	vistas := make(map[string]bool)
+	var todas []string
+	for _, k := range append(a, b...) {
+		if !vistas[k] {
+			vistas[k] = true
+			todas = append(todas, k)
+		}
+	}
+	sort.Strings(todas)
+	return todas
+}
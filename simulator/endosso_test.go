@@ -0,0 +1,156 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// funcaoTeste - função do chaincode de teste
+type funcaoTeste func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error)
+
+// chaincodeTeste - chaincode que despacha Init, Invoke e Query para as funções pelo nome
+type chaincodeTeste map[string]funcaoTeste
+
+func (cc chaincodeTeste) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return cc.executar(stub, function, args)
+}
+
+func (cc chaincodeTeste) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return cc.executar(stub, function, args)
+}
+
+func (cc chaincodeTeste) Query(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return cc.executar(stub, function, args)
+}
+
+func (cc chaincodeTeste) executar(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	f, ok := cc[function]
+	if !ok {
+		return nil, fmt.Errorf("função desconhecida: %s", function)
+	}
+	return f(stub, args)
+}
+
+// gravar: grava args[1] na chave args[0]
+func gravar(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+	return nil, stub.PutState(args[0], []byte(args[1]))
+}
+
+func TestDivergenciaEntreEndossantes(t *testing.T) {
+	inicio := time.Date(2026, 11, 2, 10, 0, 0, 0, time.UTC)
+
+	// cada caso recebe a posição da instância criada pela Fabrica (0 para a primeira),
+	// simulando o estado local ou o relógio de cada peer
+	casos := []struct {
+		nome       string
+		funcao     func(instancia int) funcaoTeste
+		diferencas []string // trechos esperados em ErroDivergencia.Diferencas; nil se a transação for válida
+	}{
+		{"determinístico", func(int) funcaoTeste {
+			return gravar
+		}, nil},
+		{"estado local", func(instancia int) funcaoTeste {
+			contador := 10 * instancia
+			return func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+				contador++
+				return nil, stub.PutState(args[0], []byte(fmt.Sprint(contador)))
+			}
+		}, []string{`escrita "k": endossante 1 = "1"; endossante 2 = "11"`, `endossante 3 = "21"`}},
+		{"relógio local", func(instancia int) funcaoTeste {
+			agora := inicio.Add(time.Duration(instancia) * time.Second)
+			return func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+				return []byte(agora.Format(time.RFC3339)), nil
+			}
+		}, []string{`resposta: endossante 1 = "2026-11-02T10:00:00Z"; endossante 2 = "2026-11-02T10:00:01Z"`}},
+		{"leitura a mais", func(instancia int) funcaoTeste {
+			return func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+				if instancia == 2 {
+					stub.GetState("cache")
+				}
+				return gravar(stub, args)
+			}
+		}, []string{`leitura "cache": apenas o endossante 3`}},
+		{"evento", func(instancia int) funcaoTeste {
+			return func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+				return nil, stub.SetEvent("Gravado", []byte(fmt.Sprint(instancia)))
+			}
+		}, []string{`evento: endossante 1 = Gravado "0"; endossante 2 = Gravado "1"`}},
+		{"erro em um endossante", func(instancia int) funcaoTeste {
+			return func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+				if instancia == 1 {
+					return nil, errors.New("sem conexão")
+				}
+				return gravar(stub, args)
+			}
+		}, []string{`erro: endossante 1 = (nenhum); endossante 2 = sem conexão`, `escrita "k": endossante 1 = "v"; endossante 2 = (não gravada)`}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			instancias := 0
+			sim := Novo(nil)
+			sim.Endossantes = 3
+			sim.Fabrica = func() shim.Chaincode {
+				f := c.funcao(instancias)
+				instancias++
+				return chaincodeTeste{"gravar": f}
+			}
+
+			_, err := sim.Invoke("gravar", []string{"k", "v"})
+			if c.diferencas == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sim.Altura() != 2 {
+					t.Fatalf("altura %d, esperada 2", sim.Altura())
+				}
+				return
+			}
+
+			div, ok := err.(*ErroDivergencia)
+			if !ok {
+				t.Fatalf("erro = %v, esperado ErroDivergencia", err)
+			}
+			if div.Funcao != "gravar" || div.TxID == "" {
+				t.Fatalf("divergência %+v", div)
+			}
+			texto := strings.Join(div.Diferencas, "\n")
+			for _, d := range c.diferencas {
+				if !strings.Contains(texto, d) {
+					t.Fatalf("diferenças:\n%s\nesperado o trecho %s", texto, d)
+				}
+			}
+			if sim.Altura() != 1 || len(sim.Estado()) != 0 {
+				t.Fatalf("transação divergente confirmada: altura %d, estado %q", sim.Altura(), sim.Estado())
+			}
+			if instancias != 3 {
+				t.Fatalf("%d instâncias criadas, esperadas 3", instancias)
+			}
+		})
+	}
+}
+
+func TestEndossanteUnico(t *testing.T) {
+	// com um endossante, o chaincode não determinístico não é detectado
+	sim := Novo(nil)
+	n := 0
+	sim.Fabrica = func() shim.Chaincode {
+		n++
+		local := n
+		return chaincodeTeste{"gravar": func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+			return nil, stub.PutState(args[0], []byte(fmt.Sprint(local)))
+		}}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := sim.Invoke("gravar", []string{"k"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n != 1 {
+		t.Fatalf("%d instâncias criadas, esperada 1 (reutilizada entre as transações)", n)
+	}
+}
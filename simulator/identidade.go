@@ -0,0 +1,86 @@
+package simulator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Identidade - usuário simulado que assina as transações, no lugar do secureContext
+// registrado no peer. O certificado é autoassinado (ECDSA P-256) e os atributos são
+// os que o TCert carregaria, lidos pelo chaincode com ReadCertAttribute.
+type Identidade struct {
+	Nome        string
+	Certificado []byte            // DER, retornado por GetCallerCertificate
+	Metadata    []byte            // metadata enviado nas transações (GetCallerMetadata)
+	Atributos   map[string][]byte // atributos do certificado
+	// AssinarTransacoes substitui o Metadata pela assinatura de payload||binding da
+	// transação, como o cliente faz para o controle de acesso com VerifySignature
+	AssinarTransacoes bool
+
+	chave *ecdsa.PrivateKey
+}
+
+// NovaIdentidade: cria a identidade com um novo par de chaves e certificado
+func NovaIdentidade(nome string, metadata []byte, atributos map[string]string) (*Identidade, error) {
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao gerar a chave da identidade %s: %s", nome, err)
+	}
+
+	modelo := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: nome},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, modelo, modelo, &chave.PublicKey, chave)
+	if err != nil {
+		return nil, fmt.Errorf("Falha ao gerar o certificado da identidade %s: %s", nome, err)
+	}
+
+	id := &Identidade{
+		Nome:        nome,
+		Certificado: cert,
+		Metadata:    copiar(metadata),
+		Atributos:   make(map[string][]byte),
+		chave:       chave,
+	}
+	for k, v := range atributos {
+		id.Atributos[k] = []byte(v)
+	}
+	return id, nil
+}
+
+// Assinar: assinatura ECDSA (ASN.1) do SHA-256 da mensagem, verificável por
+// VerifySignature com o certificado da identidade
+func (id *Identidade) Assinar(mensagem []byte) ([]byte, error) {
+	if id.chave == nil {
+		return nil, errors.New("Identidade " + id.Nome + " sem chave privada")
+	}
+	hash := sha256.Sum256(mensagem)
+	return ecdsa.SignASN1(rand.Reader, id.chave, hash[:])
+}
+
+// verificarAssinatura: VerifySignature do simulador. O peer v0.6 utiliza o hash da
+// configuração de segurança (SHA3); o simulador utiliza SHA-256, como Assinar.
+func verificarAssinatura(certificado, assinatura, mensagem []byte) (bool, error) {
+	cert, err := x509.ParseCertificate(certificado)
+	if err != nil {
+		return false, fmt.Errorf("Failed parsing certificate: %s", err)
+	}
+	chave, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return false, errors.New("Certificate does not contain an ECDSA public key")
+	}
+	hash := sha256.Sum256(mensagem)
+	return ecdsa.VerifyASN1(chave, hash[:], assinatura), nil
+}
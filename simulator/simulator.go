@@ -0,0 +1,338 @@
+/*
+Descrição: ledger em memória que executa um chaincode v0.6 no próprio processo
+Cada deploy ou invoke bem-sucedido gera um bloco com uma transação e, se houver, o
+evento emitido por ela. Invokes com erro não alteram o estado, como no peer.
+ExecutarBloco submete várias transações concorrentes em um único bloco (ver bloco.go).
+O histórico pode ser salvo e carregado, e consultado em qualquer transação (ver snapshot.go).
+As transações são executadas com a Identidade padrão do simulador ou, com Como,
+com qualquer outra identidade simulada (certificado, metadata e atributos).
+*/
+
+// Package simulator implementa em memória a interface shim.ChaincodeStubInterface
+// utilizada pelos chaincodes deste repositório, permitindo executá-los sem um peer.
+package simulator
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/CaueP/BlockchainDojo/events"
+	"github.com/CaueP/BlockchainDojo/ledger"
+	"github.com/CaueP/BlockchainDojo/projection"
+)
+
+// Simulador - estado e blocos de um chaincode executado em memória
+type Simulador struct {
+	// Relogio fornece o horário das transações (padrão: time.Now). As queries não são
+	// transações: são executadas no horário da última transação do histórico.
+	Relogio func() time.Time
+	// GeradorTxID fornece os IDs das transações (padrão: UUID aleatório). As queries
+	// recebem IDs próprios (query-1, query-2...), sem consumir o gerador.
+	GeradorTxID func() string
+	// Identidade utilizada por Implantar, Invoke e Query (nil: transações sem certificado
+	// nem metadata, como em um peer com a segurança desabilitada)
+	Identidade *Identidade
+	// Endossantes: quantidade de peers simulados que executam cada deploy e invoke
+	// (padrão: 1). Com mais de um, a transação só é confirmada se todos produzirem a
+	// mesma resposta, leituras, escritas e evento (ver ErroDivergencia).
+	Endossantes int
+	// Fabrica cria a instância do chaincode de cada endossante. Se nil, todos os
+	// endossantes executam a instância informada em Novo.
+	Fabrica func() shim.Chaincode
+
+	mu         sync.Mutex
+	chaincode  shim.Chaincode
+	instancias []shim.Chaincode
+	estado     map[string]registro
+	blocos     []Bloco
+	consultas  int // queries executadas, para os IDs das queries
+}
+
+// registro - valor de uma chave do estado e a versão da transação que o gravou
+type registro struct {
+	valor  []byte
+	versao Versao
+}
+
+// Versao - posição (bloco e transação no bloco) da última escrita de uma chave.
+// A versão zero indica uma chave inexistente.
+type Versao struct {
+	Bloco     uint64 `json:"bloco"`
+	Transacao int    `json:"transacao"`
+}
+
+// ConjuntoLeituraEscrita - chaves lidas (com a versão lida) e gravadas por uma transação
+type ConjuntoLeituraEscrita struct {
+	Leituras   map[string]Versao  `json:"leituras"`
+	Intervalos []LeituraIntervalo `json:"intervalos,omitempty"` // RangeQueryState
+	Escritas   map[string][]byte  `json:"escritas"`             // valor nil: chave excluída
+}
+
+// LeituraIntervalo - chaves confirmadas (com as versões) retornadas por um RangeQueryState
+type LeituraIntervalo struct {
+	Inicio string            `json:"inicio"`
+	Fim    string            `json:"fim"`
+	Chaves map[string]Versao `json:"chaves"`
+}
+
+// Bloco - bloco com as transações ordenadas, válidas ou invalidadas na validação
+type Bloco struct {
+	Numero     uint64      `json:"numero"`
+	Transacoes []Transacao `json:"transacoes"` // vazio no bloco 0 (gênese)
+}
+
+// Transacao - deploy ou invoke incluído em um bloco
+type Transacao struct {
+	TxID     string    `json:"tx_id"`
+	Tipo     string    `json:"tipo"` // deploy ou invoke
+	Funcao   string    `json:"funcao"`
+	Args     []string  `json:"args"`
+	Horario  time.Time `json:"horario"`
+	Chamador string    `json:"chamador,omitempty"` // nome da identidade que submeteu a transação
+	Evento   *Evento   `json:"evento,omitempty"`
+	// LeituraEscrita: leituras e escritas da transação, aplicadas ao estado na confirmação
+	LeituraEscrita ConjuntoLeituraEscrita `json:"leitura_escrita"`
+	// Validacao: Valida ou o motivo da invalidação (ConflitoLeitura, ConflitoFantasma)
+	Validacao string     `json:"validacao"`
+	Conflitos []Conflito `json:"conflitos,omitempty"`
+}
+
+// Evento - evento emitido pelo chaincode com SetEvent
+type Evento struct {
+	Nome    string `json:"nome"`
+	Payload []byte `json:"payload"`
+}
+
+// Novo: cria o simulador do chaincode informado, com o bloco gênese
+func Novo(cc shim.Chaincode) *Simulador {
+	return &Simulador{
+		Relogio:     time.Now,
+		GeradorTxID: novoTxID,
+		chaincode:   cc,
+		estado:      make(map[string]registro),
+		blocos:      []Bloco{{Numero: 0}},
+	}
+}
+
+// Cliente - acesso ao simulador com uma identidade. Implementa ledger.Ledger.
+type Cliente struct {
+	sim        *Simulador
+	identidade *Identidade
+}
+
+// Como: cliente que submete as transações com a identidade informada
+func (s *Simulador) Como(id *Identidade) *Cliente {
+	return &Cliente{sim: s, identidade: id}
+}
+
+// Implantar: executa o Init do chaincode (deploy) com a identidade padrão
+func (s *Simulador) Implantar(funcao string, args []string) (ledger.Resultado, error) {
+	return s.Como(s.Identidade).Implantar(funcao, args)
+}
+
+// Invoke - implementação de ledger.Ledger com a identidade padrão
+func (s *Simulador) Invoke(funcao string, args []string) (ledger.Resultado, error) {
+	return s.Como(s.Identidade).Invoke(funcao, args)
+}
+
+// Query - implementação de ledger.Ledger com a identidade padrão
+func (s *Simulador) Query(funcao string, args []string) ([]byte, error) {
+	return s.Como(s.Identidade).Query(funcao, args)
+}
+
+// Implantar: executa o Init do chaincode (deploy)
+func (c *Cliente) Implantar(funcao string, args []string) (ledger.Resultado, error) {
+	return c.sim.executar(c.identidade, "deploy", funcao, args)
+}
+
+// Invoke - implementação de ledger.Ledger. A transação é confirmada imediatamente,
+// portanto o resultado nunca é Pendente.
+func (c *Cliente) Invoke(funcao string, args []string) (ledger.Resultado, error) {
+	return c.sim.executar(c.identidade, "invoke", funcao, args)
+}
+
+// Query - implementação de ledger.Ledger. Alterações de estado feitas pela query são descartadas.
+// A query não avança o GeradorTxID nem o Relogio, para não alterar os IDs e os horários
+// das transações seguintes.
+func (c *Cliente) Query(funcao string, args []string) ([]byte, error) {
+	s := c.sim
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.consultas++
+	stub, err := s.novoStub(c.identidade, fmt.Sprintf("query-%d", s.consultas), s.ultimoHorario(), funcao, args)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := s.chaincode.Query(stub, funcao, args)
+	if err != nil {
+		return nil, &ledger.ErroChaincode{Mensagem: err.Error()}
+	}
+	return payload, nil
+}
+
+// Ler - implementação de projection.Fonte sobre os blocos do simulador
+func (s *Simulador) Ler(aPartirDoBloco uint64, fn func(projection.EventoBloco) error) error {
+	s.mu.Lock()
+	blocos := append([]Bloco(nil), s.blocos...)
+	s.mu.Unlock()
+
+	for _, b := range blocos {
+		if b.Numero < aPartirDoBloco {
+			continue
+		}
+		// como em nonHashData.chaincodeEvents, apenas as transações válidas têm evento
+		indice := -1
+		for _, tx := range b.Transacoes {
+			if tx.Validacao != Valida || tx.Evento == nil {
+				continue
+			}
+			indice++
+			if !events.TipoValido(events.Tipo(tx.Evento.Nome)) {
+				continue
+			}
+			evento, err := events.Decodificar(tx.Evento.Payload)
+			if err != nil {
+				return fmt.Errorf("Bloco %d, evento %d: %s", b.Numero, indice, err)
+			}
+			if err := fn(projection.EventoBloco{Bloco: b.Numero, Indice: indice, Evento: evento}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Altura: quantidade de blocos, incluindo o gênese (equivalente a /chain do peer)
+func (s *Simulador) Altura() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return uint64(len(s.blocos))
+}
+
+// Blocos: cópia dos blocos gerados até o momento
+func (s *Simulador) Blocos() []Bloco {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Bloco(nil), s.blocos...)
+}
+
+// Estado: chaves do estado do chaincode em ordem, para inspeção
+func (s *Simulador) Estado() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chaves := make([]string, 0, len(s.estado))
+	for k := range s.estado {
+		chaves = append(chaves, k)
+	}
+	sort.Strings(chaves)
+	return chaves
+}
+
+// executar: executa Init (deploy) ou Invoke em um bloco com uma única transação
+func (s *Simulador) executar(id *Identidade, tipo, funcao string, args []string) (ledger.Resultado, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.executarBloco(tipo, []Requisicao{{Identidade: id, Funcao: funcao, Args: args}})[0]
+	if r.Erro != nil {
+		return ledger.Resultado{TxID: r.TxID}, r.Erro
+	}
+	return ledger.Resultado{TxID: r.TxID, Payload: r.Resposta}, nil
+}
+
+// ultimoHorario: horário da última transação do histórico (zero antes da primeira)
+func (s *Simulador) ultimoHorario() time.Time {
+	for i := len(s.blocos) - 1; i >= 0; i-- {
+		if txs := s.blocos[i].Transacoes; len(txs) > 0 {
+			return txs[len(txs)-1].Horario
+		}
+	}
+	return time.Time{}
+}
+
+// novoStub: stub de uma nova transação sobre o estado atual. O payload é a função com
+// os argumentos e o binding vincula a transação ao certificado do chamador.
+func (s *Simulador) novoStub(id *Identidade, txID string, horario time.Time, funcao string, args []string) (*Stub, error) {
+	st := &Stub{
+		sim:        s,
+		txID:       txID,
+		horario:    horario,
+		funcao:     funcao,
+		args:       args,
+		identidade: id,
+		leituras:   make(map[string]Versao),
+		escritas:   make(map[string][]byte),
+	}
+
+	payload, err := json.Marshal(struct {
+		Function string   `json:"function"`
+		Args     []string `json:"args"`
+	}{funcao, args})
+	if err != nil {
+		return nil, err
+	}
+	st.payload = payload
+	binding := sha256.Sum256(append([]byte(st.txID), certificado(id)...))
+	st.binding = binding[:]
+
+	if id != nil {
+		st.metadata = copiar(id.Metadata)
+		if id.AssinarTransacoes {
+			st.metadata, err = id.Assinar(append(copiar(st.payload), st.binding...))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return st, nil
+}
+
+// RelogioSequencial: relógio determinístico que avança passo a cada transação
+func RelogioSequencial(inicio time.Time, passo time.Duration) func() time.Time {
+	proximo := inicio
+	return func() time.Time {
+		t := proximo
+		proximo = proximo.Add(passo)
+		return t
+	}
+}
+
+// TxIDSequencial: IDs de transação determinísticos (prefixo-1, prefixo-2, ...)
+func TxIDSequencial(prefixo string) func() string {
+	n := 0
+	return func() string {
+		n++
+		return fmt.Sprintf("%s-%d", prefixo, n)
+	}
+}
+
+func certificado(id *Identidade) []byte {
+	if id == nil {
+		return nil
+	}
+	return id.Certificado
+}
+
+func nomeIdentidade(id *Identidade) string {
+	if id == nil {
+		return ""
+	}
+	return id.Nome
+}
+
+// novoTxID: UUID aleatório, no formato dos IDs de transação do fabric v0.6
+func novoTxID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,243 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// contador: chaincode de teste com leituras, escritas e consultas por intervalo
+var contador = chaincodeTeste{
+	"gravar": gravar,
+	// incrementar: lê a chave args[0] e grava o valor somado de 1
+	"incrementar": func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+		v, err := stub.GetState(args[0])
+		if err != nil {
+			return nil, err
+		}
+		n, _ := strconv.Atoi(string(v))
+		return []byte(strconv.Itoa(n + 1)), stub.PutState(args[0], []byte(strconv.Itoa(n+1)))
+	},
+	// copiar: lê a chave args[0] e grava o valor em args[1]
+	"copiar": func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+		v, err := stub.GetState(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return nil, stub.PutState(args[1], v)
+	},
+	// contar: grava em "total" a quantidade de chaves com o prefixo args[0]
+	"contar": func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+		it, err := stub.RangeQueryState(args[0], args[0]+"\xff")
+		if err != nil {
+			return nil, err
+		}
+		n := 0
+		for it.HasNext() {
+			it.Next()
+			n++
+		}
+		return nil, stub.PutState("total", []byte(strconv.Itoa(n)))
+	},
+	"excluir": func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+		return nil, stub.DelState(args[0])
+	},
+	"falhar": func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+		stub.PutState("falha", []byte("x"))
+		return nil, errors.New("falha do chaincode")
+	},
+	"ler": func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+		return stub.GetState(args[0])
+	},
+	// transacao: ID e horário da transação
+	"transacao": func(stub shim.ChaincodeStubInterface, args []string) ([]byte, error) {
+		ts, err := stub.GetTxTimestamp()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(stub.GetTxID() + " " + time.Unix(ts.Seconds, int64(ts.Nanos)).UTC().Format(time.RFC3339)), nil
+	},
+}
+
+// novoContador: simulador do chaincode contador com as chaves iniciais gravadas no bloco 1
+func novoContador(t *testing.T, iniciais ...string) *Simulador {
+	t.Helper()
+	sim := Novo(contador)
+	sim.GeradorTxID = TxIDSequencial("tx")
+	var reqs []Requisicao
+	for i := 0; i+1 < len(iniciais); i += 2 {
+		reqs = append(reqs, Requisicao{Funcao: "gravar", Args: []string{iniciais[i], iniciais[i+1]}})
+	}
+	for _, r := range sim.ExecutarBloco(reqs) {
+		if r.Erro != nil {
+			t.Fatal(r.Erro)
+		}
+	}
+	return sim
+}
+
+// requisicoes: "funcao arg1 arg2..." de cada transação do bloco
+func requisicoes(linhas ...string) []Requisicao {
+	reqs := make([]Requisicao, len(linhas))
+	for i, l := range linhas {
+		campos := strings.Fields(l)
+		reqs[i] = Requisicao{Funcao: campos[0], Args: campos[1:]}
+	}
+	return reqs
+}
+
+// valor: valor confirmado da chave
+func valor(t *testing.T, sim *Simulador, chave string) string {
+	t.Helper()
+	v, err := sim.Query("ler", []string{chave})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(v)
+}
+
+func TestConflitoLeitura(t *testing.T) {
+	sim := novoContador(t, "c", "0")
+	resultados := sim.ExecutarBloco(requisicoes("incrementar c", "incrementar c"))
+
+	if resultados[0].Validacao != Valida || string(resultados[0].Resposta) != "1" {
+		t.Fatalf("primeiro incremento %+v", resultados[0])
+	}
+	r := resultados[1]
+	if r.Validacao != ConflitoLeitura || r.Resposta != nil {
+		t.Fatalf("segundo incremento %+v, esperado %s", r, ConflitoLeitura)
+	}
+	esperado := []Conflito{{Chave: "c", VersaoLida: Versao{Bloco: 1}, Atual: Versao{Bloco: 2}, GravadaPor: resultados[0].TxID}}
+	if !reflect.DeepEqual(r.Conflitos, esperado) {
+		t.Fatalf("conflitos %+v, esperados %+v", r.Conflitos, esperado)
+	}
+	if e, ok := r.Erro.(*ErroConflito); !ok || e.TxID != r.TxID || e.Validacao != ConflitoLeitura {
+		t.Fatalf("erro %v", r.Erro)
+	}
+	if v := valor(t, sim, "c"); v != "1" {
+		t.Fatalf("c = %s depois do bloco, esperado 1 (escrita do segundo incremento descartada)", v)
+	}
+
+	// a transação invalidada é mantida no bloco, com o motivo
+	bloco := sim.Blocos()[2]
+	if len(bloco.Transacoes) != 2 || bloco.Transacoes[1].Validacao != ConflitoLeitura || len(bloco.Transacoes[1].Conflitos) != 1 {
+		t.Fatalf("bloco %+v", bloco)
+	}
+	relatorio := RelatorioBloco(resultados)
+	if !strings.HasPrefix(relatorio, "1 tx-2 VALID\n2 tx-3 MVCC_READ_CONFLICT: Transação tx-3 invalidada") {
+		t.Fatalf("relatório:\n%s", relatorio)
+	}
+}
+
+func TestConflitoFantasma(t *testing.T) {
+	casos := []struct {
+		nome      string
+		bloco     []string
+		validacao []string
+		conflitos []Conflito // conflitos da última transação
+		total     string
+	}{
+		{"inclusão no intervalo", []string{"gravar item/2 b", "contar item/"},
+			[]string{Valida, ConflitoFantasma},
+			[]Conflito{{Chave: "item/2", Atual: Versao{Bloco: 2}, GravadaPor: "tx-3", Fantasma: true}}, "1"},
+		{"exclusão no intervalo", []string{"excluir item/1", "contar item/"},
+			[]string{Valida, ConflitoFantasma},
+			// a chave excluída também foi lida pelo intervalo
+			[]Conflito{
+				{Chave: "item/1", VersaoLida: Versao{Bloco: 1}, GravadaPor: "tx-3"},
+				{Chave: "item/1", VersaoLida: Versao{Bloco: 1}, GravadaPor: "tx-3", Fantasma: true},
+			}, "1"},
+		{"inclusão fora do intervalo", []string{"gravar outro/2 b", "contar item/"},
+			[]string{Valida, Valida}, nil, "1"},
+		{"contagem antes da inclusão", []string{"contar item/", "gravar item/2 b"},
+			[]string{Valida, Valida}, nil, "1"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			sim := novoContador(t, "item/1", "a", "total", "1")
+			resultados := sim.ExecutarBloco(requisicoes(c.bloco...))
+			for i, r := range resultados {
+				if r.Validacao != c.validacao[i] {
+					t.Fatalf("transação %d: %s, esperado %s (%v)", i+1, r.Validacao, c.validacao[i], r.Erro)
+				}
+			}
+			ultimo := resultados[len(resultados)-1]
+			if !reflect.DeepEqual(ultimo.Conflitos, c.conflitos) {
+				t.Fatalf("conflitos %+v, esperados %+v", ultimo.Conflitos, c.conflitos)
+			}
+			if v := valor(t, sim, "total"); v != c.total {
+				t.Fatalf("total = %s, esperado %s", v, c.total)
+			}
+		})
+	}
+}
+
+func TestOrdemNoBloco(t *testing.T) {
+	casos := []struct {
+		nome      string
+		bloco     []string
+		validacao []string
+		estado    map[string]string // valores confirmados depois do bloco
+	}{
+		{"escritas sem leitura: a última prevalece", []string{"gravar a 1", "gravar a 2"},
+			[]string{Valida, Valida}, map[string]string{"a": "2"}},
+		{"leitura antes da escrita", []string{"incrementar c", "gravar c 5"},
+			[]string{Valida, Valida}, map[string]string{"c": "5"}},
+		{"leitura depois da escrita", []string{"gravar c 5", "incrementar c"},
+			[]string{Valida, ConflitoLeitura}, map[string]string{"c": "5"}},
+		{"escrita de transação invalidada não invalida as seguintes", []string{"gravar a 1", "copiar a b", "copiar b d"},
+			[]string{Valida, ConflitoLeitura, Valida}, map[string]string{"a": "1", "b": "", "d": ""}},
+		{"falha no endosso fora do bloco", []string{"gravar a 1", "falhar", "incrementar c"},
+			[]string{Valida, FalhaEndosso, Valida}, map[string]string{"a": "1", "c": "1", "falha": ""}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			sim := novoContador(t, "c", "0")
+			resultados := sim.ExecutarBloco(requisicoes(c.bloco...))
+
+			var endossadas []string
+			for i, r := range resultados {
+				if r.Validacao != c.validacao[i] {
+					t.Fatalf("transação %d: %s, esperado %s (%v)", i+1, r.Validacao, c.validacao[i], r.Erro)
+				}
+				if r.Validacao != FalhaEndosso {
+					endossadas = append(endossadas, r.TxID)
+				}
+			}
+
+			// as transações endossadas entram no bloco na ordem de submissão
+			bloco := sim.Blocos()[len(sim.Blocos())-1]
+			var ordem []string
+			for _, tx := range bloco.Transacoes {
+				ordem = append(ordem, tx.TxID)
+			}
+			if fmt.Sprint(ordem) != fmt.Sprint(endossadas) {
+				t.Fatalf("ordem no bloco %v, esperada %v", ordem, endossadas)
+			}
+			for k, v := range c.estado {
+				if got := valor(t, sim, k); got != v {
+					t.Fatalf("%s = %q, esperado %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestVersaoDaEscrita(t *testing.T) {
+	sim := novoContador(t)
+	sim.ExecutarBloco(requisicoes("gravar a 1", "gravar b 1", "gravar a 2"))
+	if _, err := sim.Invoke("incrementar", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	// a leitura registra a versão (bloco, posição no bloco) da última escrita confirmada
+	tx := sim.Blocos()[2].Transacoes[0]
+	if lida := tx.LeituraEscrita.Leituras["a"]; lida != (Versao{Bloco: 1, Transacao: 2}) {
+		t.Fatalf("versão lida %+v, esperada 1.2", lida)
+	}
+}
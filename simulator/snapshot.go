@@ -0,0 +1,278 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// VersaoSnapshot - versão do formato gravado por Salvar. Carregar recusa arquivos de
+// versões posteriores.
+const VersaoSnapshot = 1
+
+// Snapshot - estado completo do simulador. O histórico (Blocos, com as leituras, escritas e
+// eventos de cada transação) é a fonte da carga; o Estado é conferido contra o histórico
+// reexecutado e as Tabelas são apenas uma visão legível das linhas gravadas no estado.
+type Snapshot struct {
+	Versao  int              `json:"versao"`
+	Criado  time.Time        `json:"criado"`
+	Altura  uint64           `json:"altura"`
+	Estado  []EntradaEstado  `json:"estado"`
+	Tabelas []TabelaSnapshot `json:"tabelas,omitempty"`
+	Blocos  []Bloco          `json:"blocos"`
+}
+
+// EntradaEstado - chave do estado com o valor e a versão da última escrita
+type EntradaEstado struct {
+	Chave  string `json:"chave"`
+	Valor  []byte `json:"valor"`
+	Versao Versao `json:"versao"`
+}
+
+// TabelaSnapshot - definição e linhas de uma tabela (informativo, ignorado na carga)
+type TabelaSnapshot struct {
+	Nome    string              `json:"nome"`
+	Colunas []string            `json:"colunas"`
+	Linhas  [][]json.RawMessage `json:"linhas"`
+}
+
+// Snapshot: cópia do estado e do histórico do simulador
+func (s *Simulador) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := Snapshot{
+		Versao: VersaoSnapshot,
+		Criado: s.Relogio(),
+		Altura: uint64(len(s.blocos)),
+		Blocos: append([]Bloco(nil), s.blocos...),
+	}
+	chaves := make([]string, 0, len(s.estado))
+	for k := range s.estado {
+		chaves = append(chaves, k)
+	}
+	sort.Strings(chaves)
+	for _, k := range chaves {
+		r := s.estado[k]
+		snap.Estado = append(snap.Estado, EntradaEstado{Chave: k, Valor: r.valor, Versao: r.versao})
+	}
+	snap.Tabelas = tabelasDoEstado(snap.Estado)
+	return snap
+}
+
+// Salvar: grava o snapshot do simulador em JSON
+func (s *Simulador) Salvar(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.Snapshot())
+}
+
+// SalvarArquivo: grava o snapshot do simulador no arquivo informado
+func (s *Simulador) SalvarArquivo(caminho string) error {
+	f, err := os.Create(caminho)
+	if err != nil {
+		return err
+	}
+	if err := s.Salvar(f); err != nil {
+		f.Close()
+		return fmt.Errorf("Falha ao gravar o snapshot %s: %s", caminho, err)
+	}
+	return f.Close()
+}
+
+// Carregar: cria o simulador do chaincode informado a partir de um snapshot gravado por
+// Salvar. O estado é reconstruído aplicando as escritas das transações válidas de cada
+// bloco, e o resultado precisa ser igual ao estado gravado. As identidades das transações
+// não são gravadas (apenas o nome do chamador); a Identidade do simulador carregado é nil.
+func Carregar(r io.Reader, cc shim.Chaincode) (*Simulador, error) {
+	var snap Snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("Snapshot inválido: %s", err)
+	}
+	if snap.Versao < 1 || snap.Versao > VersaoSnapshot {
+		return nil, fmt.Errorf("Versão do snapshot não suportada: %d (suportada até %d)", snap.Versao, VersaoSnapshot)
+	}
+	if len(snap.Blocos) == 0 || uint64(len(snap.Blocos)) != snap.Altura {
+		return nil, fmt.Errorf("Snapshot inválido: altura %d com %d blocos", snap.Altura, len(snap.Blocos))
+	}
+	for i, b := range snap.Blocos {
+		if b.Numero != uint64(i) {
+			return nil, fmt.Errorf("Snapshot inválido: bloco %d na posição %d", b.Numero, i)
+		}
+	}
+
+	s := Novo(cc)
+	s.blocos = snap.Blocos
+	s.estado = reexecutar(s.blocos)
+
+	if err := conferirEstado(s.estado, snap.Estado); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// CarregarArquivo: Carregar a partir do arquivo informado
+func CarregarArquivo(caminho string, cc shim.Chaincode) (*Simulador, error) {
+	f, err := os.Open(caminho)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := Carregar(f, cc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", caminho, err)
+	}
+	return s, nil
+}
+
+// Transacoes: quantidade de transações no histórico, válidas ou invalidadas
+func (s *Simulador) Transacoes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, b := range s.blocos {
+		n += len(b.Transacoes)
+	}
+	return n
+}
+
+// NaTransacao: simulador com o histórico até a transação de número n (1 é a primeira
+// transação do histórico, normalmente o deploy), para consultar o estado naquele ponto.
+// O bloco da transação n é mantido apenas até ela. Invokes no simulador retornado não
+// alteram o original.
+func (s *Simulador) NaTransacao(n int) (*Simulador, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := 0
+	for _, b := range s.blocos {
+		total += len(b.Transacoes)
+	}
+	if n < 1 || n > total {
+		return nil, fmt.Errorf("Transação %d inexistente: o histórico tem %d transações", n, total)
+	}
+
+	var blocos []Bloco
+	restantes := n
+	for _, b := range s.blocos {
+		if restantes == 0 {
+			break
+		}
+		if len(b.Transacoes) > restantes {
+			b.Transacoes = b.Transacoes[:restantes]
+		}
+		restantes -= len(b.Transacoes)
+		blocos = append(blocos, b)
+	}
+
+	copia := &Simulador{
+		Relogio:     s.Relogio,
+		GeradorTxID: s.GeradorTxID,
+		Identidade:  s.Identidade,
+		Endossantes: s.Endossantes,
+		Fabrica:     s.Fabrica,
+		chaincode:   s.chaincode,
+		blocos:      blocos,
+	}
+	copia.estado = reexecutar(blocos)
+	return copia, nil
+}
+
+// reexecutar: estado resultante das escritas das transações válidas dos blocos, com as
+// versões atribuídas na confirmação
+func reexecutar(blocos []Bloco) map[string]registro {
+	estado := make(map[string]registro)
+	for _, b := range blocos {
+		for pos, tx := range b.Transacoes {
+			if tx.Validacao != Valida {
+				continue
+			}
+			for k, v := range tx.LeituraEscrita.Escritas {
+				if v == nil {
+					delete(estado, k)
+				} else {
+					estado[k] = registro{valor: copiar(v), versao: Versao{Bloco: b.Numero, Transacao: pos}}
+				}
+			}
+		}
+	}
+	return estado
+}
+
+// conferirEstado: compara o estado reconstruído com o estado gravado no snapshot
+func conferirEstado(estado map[string]registro, gravado []EntradaEstado) error {
+	var dif []string
+	vistas := make(map[string]bool)
+	for _, e := range gravado {
+		vistas[e.Chave] = true
+		r, ok := estado[e.Chave]
+		switch {
+		case !ok:
+			dif = append(dif, fmt.Sprintf("%q: não gravada por nenhuma transação", e.Chave))
+		case !bytes.Equal(r.valor, e.Valor):
+			dif = append(dif, fmt.Sprintf("%q: valor %s, histórico %s", e.Chave, textoValor(e.Valor), textoValor(r.valor)))
+		case r.versao != e.Versao:
+			dif = append(dif, fmt.Sprintf("%q: versão %d.%d, histórico %d.%d", e.Chave, e.Versao.Bloco, e.Versao.Transacao, r.versao.Bloco, r.versao.Transacao))
+		}
+	}
+	for k := range estado {
+		if !vistas[k] {
+			dif = append(dif, fmt.Sprintf("%q: ausente do estado gravado", k))
+		}
+	}
+	if len(dif) == 0 {
+		return nil
+	}
+	sort.Strings(dif)
+	return fmt.Errorf("Estado do snapshot diferente do histórico:\n  %s", strings.Join(dif, "\n  "))
+}
+
+// tabelasDoEstado: definição e linhas de cada tabela gravada no estado
+func tabelasDoEstado(estado []EntradaEstado) []TabelaSnapshot {
+	var tabelas []TabelaSnapshot
+	indice := make(map[string]int)
+	for _, e := range estado {
+		if !strings.HasPrefix(e.Chave, prefixoTabela) {
+			continue
+		}
+		var def shim.Table
+		if err := json.Unmarshal(e.Valor, &def); err != nil {
+			continue
+		}
+		t := TabelaSnapshot{Nome: def.Name}
+		for _, c := range def.ColumnDefinitions {
+			t.Colunas = append(t.Colunas, c.Name)
+		}
+		indice[def.Name] = len(tabelas)
+		tabelas = append(tabelas, t)
+	}
+
+	// as chaves estão em ordem, portanto as linhas também ficam em ordem de chave
+	for _, e := range estado {
+		if !strings.HasPrefix(e.Chave, prefixoLinha) {
+			continue
+		}
+		nome := strings.SplitN(strings.TrimPrefix(e.Chave, prefixoLinha), "\x00", 2)[0]
+		i, ok := indice[nome]
+		if !ok {
+			continue
+		}
+		var colunas []colunaArmazenada
+		if err := json.Unmarshal(e.Valor, &colunas); err != nil {
+			continue
+		}
+		linha := make([]json.RawMessage, len(colunas))
+		for j, c := range colunas {
+			linha[j] = c.Valor
+		}
+		tabelas[i].Linhas = append(tabelas[i].Linhas, linha)
+	}
+	return tabelas
+}
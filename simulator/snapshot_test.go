@@ -0,0 +1,156 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var inicio = time.Date(2026, 11, 2, 10, 0, 0, 0, time.UTC)
+
+// historico: contador com quatro transações em três blocos, uma por minuto a partir de inicio:
+// a=1 | a=2, b=1 | a=3
+func historico(t *testing.T) *Simulador {
+	t.Helper()
+	sim := Novo(contador)
+	sim.Relogio = RelogioSequencial(inicio, time.Minute)
+	sim.GeradorTxID = TxIDSequencial("tx")
+	if _, err := sim.Invoke("gravar", []string{"a", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range sim.ExecutarBloco(requisicoes("gravar a 2", "gravar b 1")) {
+		if r.Erro != nil {
+			t.Fatal(r.Erro)
+		}
+	}
+	if _, err := sim.Invoke("incrementar", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	return sim
+}
+
+// transacoes: "txID horário" de cada transação do histórico
+func transacoes(sim *Simulador) string {
+	var txs []string
+	for _, b := range sim.Blocos() {
+		for _, tx := range b.Transacoes {
+			txs = append(txs, tx.TxID+" "+tx.Horario.Format("15:04"))
+		}
+	}
+	return strings.Join(txs, ", ")
+}
+
+func TestQueryNaoAvancaTransacoes(t *testing.T) {
+	sim := Novo(contador)
+	sim.Relogio = RelogioSequencial(inicio, time.Minute)
+	sim.GeradorTxID = TxIDSequencial("tx")
+
+	if _, err := sim.Query("transacao", nil); err == nil {
+		t.Fatal("query com horário antes da primeira transação")
+	}
+	if _, err := sim.Invoke("gravar", []string{"a", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if v := valor(t, sim, "a"); v != "1" {
+			t.Fatalf("a = %s", v)
+		}
+	}
+	resposta, err := sim.Query("transacao", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a query tem o próprio ID e o horário da última transação
+	if string(resposta) != "query-5 2026-11-02T10:00:00Z" {
+		t.Fatalf("query em %s", resposta)
+	}
+	if _, err := sim.Invoke("incrementar", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := transacoes(sim); got != "tx-1 10:00, tx-2 10:01" {
+		t.Fatalf("transações %s, esperadas tx-1 10:00, tx-2 10:01", got)
+	}
+}
+
+func TestSnapshotNaTransacao(t *testing.T) {
+	original := historico(t)
+	var buf bytes.Buffer
+	if err := original.Salvar(&buf); err != nil {
+		t.Fatal(err)
+	}
+	sim, err := Carregar(&buf, contador)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.Altura() != original.Altura() || transacoes(sim) != transacoes(original) {
+		t.Fatalf("histórico carregado %s, esperado %s", transacoes(sim), transacoes(original))
+	}
+
+	casos := []struct {
+		n       int
+		a, b    string
+		horario string // horário das queries na transação n
+	}{
+		{1, "1", "", "10:00"},
+		{2, "2", "", "10:01"},
+		{3, "2", "1", "10:02"},
+		{4, "3", "1", "10:03"},
+	}
+	for _, c := range casos {
+		passado, err := sim.NaTransacao(c.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a, b := valor(t, passado, "a"), valor(t, passado, "b"); a != c.a || b != c.b {
+			t.Fatalf("transação %d: a = %q, b = %q; esperados %q e %q", c.n, a, b, c.a, c.b)
+		}
+		resposta, err := passado.Query("transacao", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(resposta), "T"+c.horario+":00Z") {
+			t.Fatalf("transação %d: query em %s, esperado %s", c.n, resposta, c.horario)
+		}
+	}
+	for _, n := range []int{0, 5} {
+		if _, err := sim.NaTransacao(n); err == nil {
+			t.Fatalf("NaTransacao(%d) sem erro", n)
+		}
+	}
+
+	// invokes no passado não alteram o simulador carregado
+	passado, _ := sim.NaTransacao(1)
+	if _, err := passado.Invoke("incrementar", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if a := valor(t, passado, "a"); a != "2" {
+		t.Fatalf("a = %s no passado depois do incremento", a)
+	}
+	if a := valor(t, sim, "a"); a != "3" || sim.Transacoes() != 4 {
+		t.Fatalf("a = %s com %d transações no simulador carregado", a, sim.Transacoes())
+	}
+}
+
+func TestCarregarEstadoDivergente(t *testing.T) {
+	snap := historico(t).Snapshot()
+	for i, e := range snap.Estado {
+		if e.Chave == "a" {
+			snap.Estado[i].Valor = []byte("4")
+		}
+	}
+	b, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Carregar(bytes.NewReader(b), contador); err == nil {
+		t.Fatal("snapshot carregado com o estado diferente do histórico")
+	}
+
+	snap.Versao = VersaoSnapshot + 1
+	b, _ = json.Marshal(snap)
+	if _, err := Carregar(bytes.NewReader(b), contador); err == nil || !strings.Contains(err.Error(), "Versão do snapshot não suportada") {
+		t.Fatalf("erro = %v, esperado versão não suportada", err)
+	}
+}
@@ -0,0 +1,272 @@
+package simulator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/crypto/attr"
+)
+
+// Stub - implementação de shim.ChaincodeStubInterface para uma transação do simulador.
+// As escritas ficam pendentes até a transação ser confirmada.
+type Stub struct {
+	sim        *Simulador
+	txID       string
+	horario    time.Time
+	funcao     string
+	args       []string
+	identidade *Identidade
+	metadata   []byte
+	payload    []byte
+	binding    []byte
+	leituras   map[string]Versao // versão de cada chave lida do estado confirmado
+	intervalos []LeituraIntervalo
+	escritas   map[string][]byte // valor nil: chave excluída
+	evento     *Evento
+}
+
+// errNaoSuportado: funções da interface não utilizadas pelos chaincodes deste repositório
+func errNaoSuportado(funcao string) error {
+	return fmt.Errorf("%s não suportado pelo simulador", funcao)
+}
+
+// confirmar: aplica as escritas da transação ao estado do simulador, com a versão informada
+func (st *Stub) confirmar(versao Versao) {
+	for k, v := range st.escritas {
+		if v == nil {
+			delete(st.sim.estado, k)
+		} else {
+			st.sim.estado[k] = registro{valor: v, versao: versao}
+		}
+	}
+}
+
+// leituraEscrita: cópia das leituras e escritas da transação
+func (st *Stub) leituraEscrita() ConjuntoLeituraEscrita {
+	c := ConjuntoLeituraEscrita{
+		Leituras: make(map[string]Versao, len(st.leituras)),
+		Escritas: make(map[string][]byte, len(st.escritas)),
+	}
+	for k, v := range st.leituras {
+		c.Leituras[k] = v
+	}
+	for k, v := range st.escritas {
+		c.Escritas[k] = copiar(v)
+	}
+	for _, i := range st.intervalos {
+		copia := LeituraIntervalo{Inicio: i.Inicio, Fim: i.Fim, Chaves: make(map[string]Versao, len(i.Chaves))}
+		for k, v := range i.Chaves {
+			copia.Chaves[k] = v
+		}
+		c.Intervalos = append(c.Intervalos, copia)
+	}
+	return c
+}
+
+// GetArgs - função e argumentos da transação, como no fabric v0.6
+func (st *Stub) GetArgs() [][]byte {
+	args := [][]byte{[]byte(st.funcao)}
+	for _, a := range st.args {
+		args = append(args, []byte(a))
+	}
+	return args
+}
+
+// GetStringArgs - função e argumentos da transação
+func (st *Stub) GetStringArgs() []string {
+	return append([]string{st.funcao}, st.args...)
+}
+
+// GetTxID - ID da transação
+func (st *Stub) GetTxID() string {
+	return st.txID
+}
+
+// GetTxTimestamp - horário da transação, obtido do Relogio do simulador (nas queries, o
+// horário da última transação; indisponível antes da primeira)
+func (st *Stub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	if st.horario.IsZero() {
+		return nil, errors.New("Horário da transação indisponível")
+	}
+	return &timestamp.Timestamp{Seconds: st.horario.Unix(), Nanos: int32(st.horario.Nanosecond())}, nil
+}
+
+// GetState - valor da chave, considerando as escritas da própria transação
+func (st *Stub) GetState(key string) ([]byte, error) {
+	if v, ok := st.escritas[key]; ok {
+		return copiar(v), nil
+	}
+	r := st.sim.estado[key]
+	st.leituras[key] = r.versao
+	return copiar(r.valor), nil
+}
+
+// PutState - grava a chave ao confirmar a transação
+func (st *Stub) PutState(key string, value []byte) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+	v := copiar(value)
+	if v == nil {
+		v = []byte{}
+	}
+	st.escritas[key] = v
+	return nil
+}
+
+// DelState - exclui a chave ao confirmar a transação
+func (st *Stub) DelState(key string) error {
+	st.escritas[key] = nil
+	return nil
+}
+
+// RangeQueryState - chaves entre startKey (inclusive) e endKey (exclusive), em ordem
+func (st *Stub) RangeQueryState(startKey, endKey string) (shim.StateRangeQueryIteratorInterface, error) {
+	chaves := make(map[string]bool)
+	for k := range st.sim.estado {
+		chaves[k] = true
+	}
+	for k := range st.escritas {
+		chaves[k] = true
+	}
+
+	// chaves confirmadas no intervalo, para a validação de leituras fantasmas (ver bloco.go)
+	intervalo := LeituraIntervalo{Inicio: startKey, Fim: endKey, Chaves: make(map[string]Versao)}
+	for k, r := range st.sim.estado {
+		if k >= startKey && (endKey == "" || k < endKey) {
+			intervalo.Chaves[k] = r.versao
+		}
+	}
+	st.intervalos = append(st.intervalos, intervalo)
+
+	it := &iterador{}
+	for k := range chaves {
+		if k < startKey || (endKey != "" && k >= endKey) {
+			continue
+		}
+		v, _ := st.GetState(k)
+		if v == nil {
+			continue
+		}
+		it.chaves = append(it.chaves, k)
+	}
+	sort.Strings(it.chaves)
+	for _, k := range it.chaves {
+		v, _ := st.GetState(k)
+		it.valores = append(it.valores, v)
+	}
+	return it, nil
+}
+
+// SetEvent - evento da transação. Como no fabric v0.6, apenas o último evento é mantido.
+func (st *Stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("Event name can not be nil string.")
+	}
+	st.evento = &Evento{Nome: name, Payload: copiar(payload)}
+	return nil
+}
+
+// InvokeChaincode - não suportado
+func (st *Stub) InvokeChaincode(chaincodeName string, args [][]byte) ([]byte, error) {
+	return nil, errNaoSuportado("InvokeChaincode")
+}
+
+// QueryChaincode - não suportado
+func (st *Stub) QueryChaincode(chaincodeName string, args [][]byte) ([]byte, error) {
+	return nil, errNaoSuportado("QueryChaincode")
+}
+
+// ReadCertAttribute - atributo do certificado do chamador
+func (st *Stub) ReadCertAttribute(attributeName string) ([]byte, error) {
+	if st.identidade == nil {
+		return nil, errors.New("Failed getting caller certificate. Transaction without identity")
+	}
+	v, ok := st.identidade.Atributos[attributeName]
+	if !ok {
+		return nil, fmt.Errorf("Attribute %s not found", attributeName)
+	}
+	return copiar(v), nil
+}
+
+// VerifyAttribute - verifica se o atributo do certificado do chamador tem o valor informado
+func (st *Stub) VerifyAttribute(attributeName string, attributeValue []byte) (bool, error) {
+	v, err := st.ReadCertAttribute(attributeName)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(v, attributeValue), nil
+}
+
+// VerifyAttributes - verifica todos os atributos informados
+func (st *Stub) VerifyAttributes(attrs ...*attr.Attribute) (bool, error) {
+	for _, a := range attrs {
+		ok, err := st.VerifyAttribute(a.Name, a.Value)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// VerifySignature - verifica a assinatura ECDSA da mensagem com a chave do certificado
+// (ver Identidade.Assinar)
+func (st *Stub) VerifySignature(certificate, signature, message []byte) (bool, error) {
+	return verificarAssinatura(certificate, signature, message)
+}
+
+// GetCallerCertificate - certificado (DER) da identidade que submeteu a transação
+func (st *Stub) GetCallerCertificate() ([]byte, error) {
+	return copiar(certificado(st.identidade)), nil
+}
+
+// GetCallerMetadata - metadata enviado pela identidade que submeteu a transação
+func (st *Stub) GetCallerMetadata() ([]byte, error) {
+	return copiar(st.metadata), nil
+}
+
+// GetBinding - vínculo entre a transação e o certificado do chamador
+func (st *Stub) GetBinding() ([]byte, error) {
+	return copiar(st.binding), nil
+}
+
+// GetPayload - função e argumentos da transação (JSON)
+func (st *Stub) GetPayload() ([]byte, error) {
+	return copiar(st.payload), nil
+}
+
+// iterador - resultado de RangeQueryState
+type iterador struct {
+	chaves  []string
+	valores [][]byte
+	pos     int
+}
+
+func (it *iterador) HasNext() bool {
+	return it.pos < len(it.chaves)
+}
+
+func (it *iterador) Next() (string, []byte, error) {
+	if !it.HasNext() {
+		return "", nil, errors.New("Não há mais chaves no intervalo")
+	}
+	k, v := it.chaves[it.pos], it.valores[it.pos]
+	it.pos++
+	return k, v, nil
+}
+
+func (it *iterador) Close() error {
+	return nil
+}
+
+func copiar(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
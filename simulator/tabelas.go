@@ -0,0 +1,367 @@
+package simulator
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// As tabelas são gravadas no estado, como no fabric v0.6: a definição na chave
+// prefixoTabela+nome e cada linha em prefixoLinha+nome+chave, com as colunas de chave
+// codificadas com o tamanho à frente para que chaves parciais formem um intervalo.
+const (
+	prefixoTabela = "\x00tabela\x00"
+	prefixoLinha  = "\x00linha\x00"
+	fimIntervalo  = "\xff" // maior que qualquer byte de uma string UTF-8
+)
+
+// ErrTableNotFound - mesma mensagem do shim v0.6
+var ErrTableNotFound = errors.New("chaincode: Table not found")
+
+// colunaArmazenada - coluna de uma linha gravada no estado
+type colunaArmazenada struct {
+	Tipo  shim.ColumnDefinition_Type `json:"tipo"`
+	Valor json.RawMessage            `json:"valor"`
+}
+
+// CreateTable - cria a tabela, falhando se ela já existir
+func (st *Stub) CreateTable(name string, columnDefinitions []*shim.ColumnDefinition) error {
+	if name == "" {
+		return errors.New("Table name must not be empty")
+	}
+	if _, err := st.GetTable(name); err == nil {
+		return fmt.Errorf("CreateTable operation failed. Table %s already exists.", name)
+	}
+	if len(columnDefinitions) == 0 {
+		return errors.New("Invalid column definitions. Tables must contain at least one column.")
+	}
+
+	temChave := false
+	nomes := make(map[string]bool)
+	for _, def := range columnDefinitions {
+		if def == nil || def.Name == "" {
+			return errors.New("Column name must not be empty")
+		}
+		if nomes[def.Name] {
+			return fmt.Errorf("Invalid table. Table contains duplicate column name: %s", def.Name)
+		}
+		nomes[def.Name] = true
+		temChave = temChave || def.Key
+	}
+	if !temChave {
+		return errors.New("Invalid table. One or more columns must be a key.")
+	}
+
+	tabela, err := json.Marshal(shim.Table{Name: name, ColumnDefinitions: columnDefinitions})
+	if err != nil {
+		return err
+	}
+	return st.PutState(prefixoTabela+name, tabela)
+}
+
+// GetTable - definição da tabela
+func (st *Stub) GetTable(tableName string) (*shim.Table, error) {
+	b, err := st.GetState(prefixoTabela + tableName)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, ErrTableNotFound
+	}
+	var tabela shim.Table
+	if err := json.Unmarshal(b, &tabela); err != nil {
+		return nil, fmt.Errorf("Definição da tabela %s inválida: %s", tableName, err)
+	}
+	return &tabela, nil
+}
+
+// DeleteTable - exclui a tabela e todas as suas linhas
+func (st *Stub) DeleteTable(tableName string) error {
+	if _, err := st.GetTable(tableName); err != nil {
+		return err
+	}
+	it, err := st.RangeQueryState(prefixoLinha+tableName+"\x00", prefixoLinha+tableName+"\x00"+fimIntervalo)
+	if err != nil {
+		return err
+	}
+	for it.HasNext() {
+		k, _, _ := it.Next()
+		st.DelState(k)
+	}
+	return st.DelState(prefixoTabela + tableName)
+}
+
+// InsertRow - insere a linha, retornando false (sem erro) se a chave já existir
+func (st *Stub) InsertRow(tableName string, row shim.Row) (bool, error) {
+	return st.gravarLinha(tableName, row, false)
+}
+
+// ReplaceRow - substitui a linha, retornando false (sem erro) se a chave não existir
+func (st *Stub) ReplaceRow(tableName string, row shim.Row) (bool, error) {
+	return st.gravarLinha(tableName, row, true)
+}
+
+// GetRow - linha correspondente à chave completa, ou uma linha vazia se não existir
+func (st *Stub) GetRow(tableName string, key []shim.Column) (shim.Row, error) {
+	tabela, err := st.GetTable(tableName)
+	if err != nil {
+		return shim.Row{}, err
+	}
+	chave, err := chaveLinha(tabela, key)
+	if err != nil {
+		return shim.Row{}, err
+	}
+	b, err := st.GetState(chave)
+	if err != nil || b == nil {
+		return shim.Row{}, err
+	}
+	return decodificarLinha(b)
+}
+
+// GetRows - linhas cuja chave começa com a chave parcial informada, em ordem de chave
+func (st *Stub) GetRows(tableName string, key []shim.Column) (<-chan shim.Row, error) {
+	tabela, err := st.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) >= colunasChave(tabela) {
+		return nil, errors.New("GetRows should be used with a partial key. Use GetRow for a complete key.")
+	}
+	prefixo, err := chaveLinha(tabela, key)
+	if err != nil {
+		return nil, err
+	}
+	it, err := st.RangeQueryState(prefixo, prefixo+fimIntervalo)
+	if err != nil {
+		return nil, err
+	}
+
+	var linhas []shim.Row
+	for it.HasNext() {
+		_, b, _ := it.Next()
+		linha, err := decodificarLinha(b)
+		if err != nil {
+			return nil, err
+		}
+		linhas = append(linhas, linha)
+	}
+
+	ch := make(chan shim.Row, len(linhas))
+	for _, l := range linhas {
+		ch <- l
+	}
+	close(ch)
+	return ch, nil
+}
+
+// DeleteRow - exclui a linha correspondente à chave completa
+func (st *Stub) DeleteRow(tableName string, key []shim.Column) error {
+	tabela, err := st.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+	chave, err := chaveLinha(tabela, key)
+	if err != nil {
+		return err
+	}
+	return st.DelState(chave)
+}
+
+// gravarLinha: InsertRow (substituir false) e ReplaceRow (substituir true)
+func (st *Stub) gravarLinha(tableName string, row shim.Row, substituir bool) (bool, error) {
+	tabela, err := st.GetTable(tableName)
+	if err != nil {
+		return false, err
+	}
+	if len(row.Columns) != len(tabela.ColumnDefinitions) {
+		return false, fmt.Errorf("The number of columns in the row (%d) does not match the number of columns in the table definition (%d)", len(row.Columns), len(tabela.ColumnDefinitions))
+	}
+
+	var key []shim.Column
+	for i, def := range tabela.ColumnDefinitions {
+		if tipoColuna(row.Columns[i]) != def.Type {
+			return false, fmt.Errorf("The type of column %s does not match the table definition", def.Name)
+		}
+		if def.Key {
+			key = append(key, *row.Columns[i])
+		}
+	}
+	chave, err := chaveLinha(tabela, key)
+	if err != nil {
+		return false, err
+	}
+
+	existente, err := st.GetState(chave)
+	if err != nil {
+		return false, err
+	}
+	if substituir != (existente != nil) {
+		return false, nil
+	}
+
+	b, err := codificarLinha(row)
+	if err != nil {
+		return false, err
+	}
+	return true, st.PutState(chave, b)
+}
+
+// chaveLinha: chave de estado da linha (ou prefixo, para uma chave parcial)
+func chaveLinha(tabela *shim.Table, key []shim.Column) (string, error) {
+	if len(key) > colunasChave(tabela) {
+		return "", fmt.Errorf("Too many key values. The table %s has %d key columns", tabela.Name, colunasChave(tabela))
+	}
+	partes := []string{prefixoLinha + tabela.Name}
+	i := 0
+	for _, def := range tabela.ColumnDefinitions {
+		if !def.Key || i >= len(key) {
+			continue
+		}
+		if tipoColuna(&key[i]) != def.Type {
+			return "", fmt.Errorf("The type of key column %s does not match the table definition", def.Name)
+		}
+		v := textoColuna(&key[i])
+		partes = append(partes, strconv.Itoa(len(v))+":"+v)
+		i++
+	}
+	return strings.Join(partes, "\x00") + "\x00", nil
+}
+
+func colunasChave(tabela *shim.Table) int {
+	n := 0
+	for _, def := range tabela.ColumnDefinitions {
+		if def.Key {
+			n++
+		}
+	}
+	return n
+}
+
+// tipoColuna: tipo correspondente ao valor da coluna (-1 se vazio)
+func tipoColuna(c *shim.Column) shim.ColumnDefinition_Type {
+	if c == nil {
+		return -1
+	}
+	switch c.GetValue().(type) {
+	case *shim.Column_String_:
+		return shim.ColumnDefinition_STRING
+	case *shim.Column_Int32:
+		return shim.ColumnDefinition_INT32
+	case *shim.Column_Int64:
+		return shim.ColumnDefinition_INT64
+	case *shim.Column_Uint32:
+		return shim.ColumnDefinition_UINT32
+	case *shim.Column_Uint64:
+		return shim.ColumnDefinition_UINT64
+	case *shim.Column_Bytes:
+		return shim.ColumnDefinition_BYTES
+	case *shim.Column_Bool:
+		return shim.ColumnDefinition_BOOL
+	}
+	return -1
+}
+
+// textoColuna: representação da coluna de chave utilizada na chave de estado
+func textoColuna(c *shim.Column) string {
+	switch v := c.GetValue().(type) {
+	case *shim.Column_String_:
+		return v.String_
+	case *shim.Column_Int32:
+		return strconv.FormatInt(int64(v.Int32), 10)
+	case *shim.Column_Int64:
+		return strconv.FormatInt(v.Int64, 10)
+	case *shim.Column_Uint32:
+		return strconv.FormatUint(uint64(v.Uint32), 10)
+	case *shim.Column_Uint64:
+		return strconv.FormatUint(v.Uint64, 10)
+	case *shim.Column_Bytes:
+		return hex.EncodeToString(v.Bytes)
+	case *shim.Column_Bool:
+		return strconv.FormatBool(v.Bool)
+	}
+	return ""
+}
+
+func codificarLinha(row shim.Row) ([]byte, error) {
+	colunas := make([]colunaArmazenada, len(row.Columns))
+	for i, c := range row.Columns {
+		var valor interface{}
+		switch v := c.GetValue().(type) {
+		case *shim.Column_String_:
+			valor = v.String_
+		case *shim.Column_Int32:
+			valor = v.Int32
+		case *shim.Column_Int64:
+			valor = v.Int64
+		case *shim.Column_Uint32:
+			valor = v.Uint32
+		case *shim.Column_Uint64:
+			valor = v.Uint64
+		case *shim.Column_Bytes:
+			valor = v.Bytes
+		case *shim.Column_Bool:
+			valor = v.Bool
+		}
+		b, err := json.Marshal(valor)
+		if err != nil {
+			return nil, err
+		}
+		colunas[i] = colunaArmazenada{Tipo: tipoColuna(c), Valor: b}
+	}
+	return json.Marshal(colunas)
+}
+
+func decodificarLinha(b []byte) (shim.Row, error) {
+	var colunas []colunaArmazenada
+	if err := json.Unmarshal(b, &colunas); err != nil {
+		return shim.Row{}, fmt.Errorf("Linha inválida: %s", err)
+	}
+
+	row := shim.Row{Columns: make([]*shim.Column, len(colunas))}
+	for i, c := range colunas {
+		var err error
+		col := &shim.Column{}
+		switch c.Tipo {
+		case shim.ColumnDefinition_STRING:
+			v := &shim.Column_String_{}
+			err = json.Unmarshal(c.Valor, &v.String_)
+			col.Value = v
+		case shim.ColumnDefinition_INT32:
+			v := &shim.Column_Int32{}
+			err = json.Unmarshal(c.Valor, &v.Int32)
+			col.Value = v
+		case shim.ColumnDefinition_INT64:
+			v := &shim.Column_Int64{}
+			err = json.Unmarshal(c.Valor, &v.Int64)
+			col.Value = v
+		case shim.ColumnDefinition_UINT32:
+			v := &shim.Column_Uint32{}
+			err = json.Unmarshal(c.Valor, &v.Uint32)
+			col.Value = v
+		case shim.ColumnDefinition_UINT64:
+			v := &shim.Column_Uint64{}
+			err = json.Unmarshal(c.Valor, &v.Uint64)
+			col.Value = v
+		case shim.ColumnDefinition_BYTES:
+			v := &shim.Column_Bytes{}
+			err = json.Unmarshal(c.Valor, &v.Bytes)
+			col.Value = v
+		case shim.ColumnDefinition_BOOL:
+			v := &shim.Column_Bool{}
+			err = json.Unmarshal(c.Valor, &v.Bool)
+			col.Value = v
+		default:
+			col = nil
+		}
+		if err != nil {
+			return shim.Row{}, fmt.Errorf("Linha inválida: %s", err)
+		}
+		row.Columns[i] = col
+	}
+	return row, nil
+}
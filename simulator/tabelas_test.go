@@ -0,0 +1,199 @@
+package simulator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// transacao: stub de uma nova transação sobre o estado confirmado do simulador
+func transacao(t *testing.T, s *Simulador) *Stub {
+	t.Helper()
+	st, err := s.novoStub(nil, "tx-teste", time.Time{}, "teste", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func texto(v string) *shim.Column  { return &shim.Column{Value: &shim.Column_String_{String_: v}} }
+func inteiro(v int64) *shim.Column { return &shim.Column{Value: &shim.Column_Int64{Int64: v}} }
+
+// criarPagamentos: tabela com a chave (banco, numero) e a coluna valor
+func criarPagamentos(t *testing.T, st *Stub, nome string) {
+	t.Helper()
+	err := st.CreateTable(nome, []*shim.ColumnDefinition{
+		{Name: "banco", Type: shim.ColumnDefinition_STRING, Key: true},
+		{Name: "numero", Type: shim.ColumnDefinition_STRING, Key: true},
+		{Name: "valor", Type: shim.ColumnDefinition_INT64},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// inserir: insere as linhas (banco, numero, valor), falhando se alguma já existir
+func inserir(t *testing.T, st *Stub, tabela string, linhas ...[3]string) {
+	t.Helper()
+	for _, l := range linhas {
+		var valor int64
+		fmt.Sscan(l[2], &valor)
+		ok, err := st.InsertRow(tabela, shim.Row{Columns: []*shim.Column{texto(l[0]), texto(l[1]), inteiro(valor)}})
+		if err != nil || !ok {
+			t.Fatalf("InsertRow %v: %v, %v", l, ok, err)
+		}
+	}
+}
+
+// chavesLinhas: "banco/numero" de cada linha, na ordem recebida
+func chavesLinhas(t *testing.T, ch <-chan shim.Row) []string {
+	t.Helper()
+	var chaves []string
+	for r := range ch {
+		chaves = append(chaves, r.Columns[0].GetString_()+"/"+r.Columns[1].GetString_())
+	}
+	return chaves
+}
+
+func TestGetRowsChaveParcial(t *testing.T) {
+	s := Novo(nil)
+	st := transacao(t, s)
+	criarPagamentos(t, st, "Pagamento")
+	inserir(t, st, "Pagamento",
+		[3]string{"001", "2", "200"},
+		[3]string{"0011", "1", "300"}, // banco que começa com o mesmo texto
+		[3]string{"001", "1", "100"},
+		[3]string{"002", "1", "400"},
+	)
+
+	casos := []struct {
+		nome   string
+		chave  []shim.Column
+		chaves string
+		erro   string
+	}{
+		{"sem chave", nil, "001/1 001/2 002/1 0011/1", ""},
+		{"primeira coluna", []shim.Column{*texto("001")}, "001/1 001/2", ""},
+		{"prefixo de outro valor", []shim.Column{*texto("00")}, "", ""},
+		{"valor mais longo", []shim.Column{*texto("0011")}, "0011/1", ""},
+		{"chave completa", []shim.Column{*texto("001"), *texto("1")}, "", "GetRows should be used with a partial key"},
+		{"tipo da chave", []shim.Column{*inteiro(1)}, "", "does not match the table definition"},
+	}
+	// as linhas pendentes da transação e, depois da confirmação, as do estado
+	for _, confirmada := range []bool{false, true} {
+		if confirmada {
+			st.confirmar(Versao{Bloco: 1})
+			st = transacao(t, s)
+		}
+		for _, c := range casos {
+			t.Run(fmt.Sprintf("%s (confirmada %v)", c.nome, confirmada), func(t *testing.T) {
+				ch, err := st.GetRows("Pagamento", c.chave)
+				if c.erro != "" {
+					if err == nil || !strings.Contains(err.Error(), c.erro) {
+						t.Fatalf("erro = %v, esperado %q", err, c.erro)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := strings.Join(chavesLinhas(t, ch), " "); got != c.chaves {
+					t.Fatalf("linhas %q, esperadas %q", got, c.chaves)
+				}
+			})
+		}
+	}
+
+	if _, err := st.GetRows("Recebimento", nil); err != ErrTableNotFound {
+		t.Fatalf("erro = %v, esperado %v", err, ErrTableNotFound)
+	}
+}
+
+func TestDeleteTable(t *testing.T) {
+	s := Novo(nil)
+	st := transacao(t, s)
+	for _, nome := range []string{"Pagamento", "PagamentoHistorico", "Pagamento2"} {
+		criarPagamentos(t, st, nome)
+		inserir(t, st, nome, [3]string{"001", "1", "100"}, [3]string{"002", "1", "200"})
+	}
+	st.confirmar(Versao{Bloco: 1})
+
+	// uma linha confirmada e outra pendente na mesma transação da exclusão
+	st = transacao(t, s)
+	inserir(t, st, "Pagamento", [3]string{"003", "1", "300"})
+	if err := st.DeleteTable("Pagamento"); err != nil {
+		t.Fatal(err)
+	}
+	st.confirmar(Versao{Bloco: 2})
+
+	st = transacao(t, s)
+	if _, err := st.GetTable("Pagamento"); err != ErrTableNotFound {
+		t.Fatalf("GetTable depois de DeleteTable: %v", err)
+	}
+	for _, k := range s.Estado() {
+		if strings.HasPrefix(k, prefixoLinha+"Pagamento\x00") {
+			t.Fatalf("linha %q mantida depois de DeleteTable", k)
+		}
+	}
+	for _, nome := range []string{"PagamentoHistorico", "Pagamento2"} {
+		ch, err := st.GetRows(nome, nil)
+		if err != nil {
+			t.Fatalf("%s: %s", nome, err)
+		}
+		if got := strings.Join(chavesLinhas(t, ch), " "); got != "001/1 002/1" {
+			t.Fatalf("%s com as linhas %q depois de excluir Pagamento", nome, got)
+		}
+	}
+	if err := st.DeleteTable("Pagamento"); err != ErrTableNotFound {
+		t.Fatalf("segundo DeleteTable: %v", err)
+	}
+}
+
+func TestGravarLinha(t *testing.T) {
+	s := Novo(nil)
+	st := transacao(t, s)
+	criarPagamentos(t, st, "Pagamento")
+	inserir(t, st, "Pagamento", [3]string{"001", "1", "100"})
+
+	linha := func(banco string, valor *shim.Column) shim.Row {
+		return shim.Row{Columns: []*shim.Column{texto(banco), texto("1"), valor}}
+	}
+	casos := []struct {
+		nome       string
+		substituir bool
+		linha      shim.Row
+		ok         bool
+		erro       string
+	}{
+		{"inserir existente", false, linha("001", inteiro(150)), false, ""},
+		{"substituir existente", true, linha("001", inteiro(150)), true, ""},
+		{"substituir inexistente", true, linha("002", inteiro(200)), false, ""},
+		{"tipo da coluna", false, linha("002", texto("200")), false, "The type of column valor"},
+		{"quantidade de colunas", false, shim.Row{Columns: []*shim.Column{texto("002")}}, false, "The number of columns"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			ok, err := st.gravarLinha("Pagamento", c.linha, c.substituir)
+			if c.erro != "" {
+				if err == nil || !strings.Contains(err.Error(), c.erro) {
+					t.Fatalf("erro = %v, esperado %q", err, c.erro)
+				}
+				return
+			}
+			if err != nil || ok != c.ok {
+				t.Fatalf("gravada = %v, %v; esperado %v", ok, err, c.ok)
+			}
+		})
+	}
+
+	r, err := st.GetRow("Pagamento", []shim.Column{*texto("001"), *texto("1")})
+	if err != nil || r.Columns[2].GetInt64() != 150 {
+		t.Fatalf("linha %v, %v", r, err)
+	}
+	if err := st.CreateTable("Pagamento", []*shim.ColumnDefinition{{Name: "id", Type: shim.ColumnDefinition_STRING, Key: true}}); err == nil {
+		t.Fatal("CreateTable de uma tabela existente")
+	}
+}
@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+)
+
+// EhDocumento: indica se o argumento é um documento JSON (objeto) em vez de um argumento posicional
+func EhDocumento(arg string) bool {
+	return strings.HasPrefix(strings.TrimSpace(arg), "{")
+}
+
+// argumentosDocumento: se a função receber um único documento JSON e possuir esquema,
+// valida o documento e o converte nos argumentos posicionais equivalentes. Os demais
+// argumentos são retornados sem alterações.
+func argumentosDocumento(funcao string, args []string) ([]string, error) {
+	if _, ok := esquemas[funcao]; !ok || len(args) != 1 || !EhDocumento(args[0]) {
+		return args, nil
+	}
+	d, err := decodificarDocumento(funcao, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	switch funcao {
+	case "registrarProposta":
+		args = []string{
+			texto(d["id_proposta"]),
+			texto(d["cpf_pagador"]),
+			texto(d["pagador_aceitou"]),
+			texto(d["beneficiario_aceitou"]),
+			texto(d["boleto_pago"]),
+		}
+		if _, ok := d["nosso_numero"]; ok {
+			args = append(args, texto(d["nosso_numero"]), texto(d["valor"]))
+		}
+	case "aceitarProposta":
+		args = []string{texto(d["id_proposta"]), texto(d["parte"])}
+	case "emitirBoleto":
+		args = []string{texto(d["id_proposta"]), texto(d["nosso_numero"]), texto(d["valor"])}
+		if _, ok := d["data_vencimento"]; ok {
+			args = append(args, texto(d["data_vencimento"]))
+		}
+		if _, ok := d["beneficiario"]; ok {
+			if len(args) == 3 {
+				args = append(args, "")
+			}
+			args = append(args, texto(d["beneficiario"]))
+		}
+	case "confirmarPagamento":
+		// o atestado é repassado em JSON; a assinatura é verificada sobre os seus campos
+		atestado, err := json.Marshal(d["atestado"])
+		if err != nil {
+			return nil, envelope.Interno(err)
+		}
+		args = []string{texto(d["id_proposta"]), string(atestado)}
+	case "cancelarProposta":
+		args = []string{texto(d["id_proposta"]), texto(d["motivo"])}
+	case "registrarCobrancaPix":
+		args = []string{texto(d["id_proposta"])}
+		if _, ok := d["valor"]; ok {
+			args = append(args, texto(d["valor"]))
+		}
+	}
+	return args, nil
+}
+
+// decodificarDocumento: decodifica o documento JSON da função e o valida pelo esquema
+func decodificarDocumento(funcao, documentoJSON string) (map[string]interface{}, error) {
+	var documento interface{}
+	dec := json.NewDecoder(strings.NewReader(documentoJSON))
+	dec.UseNumber()
+	err := dec.Decode(&documento)
+	if err == nil && dec.More() {
+		err = errConteudoAposDocumento
+	}
+	if err != nil {
+		campo := envelope.Campo{Caminho: "/", Regra: "json", Parametros: map[string]string{"detalhe": err.Error()}}
+		return nil, envelope.Documento(funcao, []envelope.Campo{campo})
+	}
+	if campos := esquemas[funcao].Validar(documento); len(campos) > 0 {
+		return nil, envelope.Documento(funcao, campos)
+	}
+	return documento.(map[string]interface{}), nil
+}
+
+var errConteudoAposDocumento = errors.New("conteúdo após o documento")
+
+// texto: valor do documento já validado no formato do argumento posicional
+func texto(valor interface{}) string {
+	switch v := valor.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
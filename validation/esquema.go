@@ -0,0 +1,225 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+)
+
+// Esquema - subconjunto do JSON Schema (2020-12) utilizado pelos esquemas publicados:
+// type, properties, required, additionalProperties, dependentRequired, enum,
+// minLength, maxLength, pattern, minimum, exclusiveMinimum, items e minItems. As demais palavras-chave
+// (title, description, $id...) são apenas documentação.
+type Esquema struct {
+	Tipo                   string              `json:"type,omitempty"`
+	Propriedades           map[string]*Esquema `json:"properties,omitempty"`
+	Obrigatorias           []string            `json:"required,omitempty"`
+	PropriedadesAdicionais *bool               `json:"additionalProperties,omitempty"`
+	Dependentes            map[string][]string `json:"dependentRequired,omitempty"`
+	Enum                   []interface{}       `json:"enum,omitempty"`
+	TamanhoMinimo          *int                `json:"minLength,omitempty"`
+	TamanhoMaximo          *int                `json:"maxLength,omitempty"`
+	Padrao                 string              `json:"pattern,omitempty"`
+	Minimo                 *json.Number        `json:"minimum,omitempty"`
+	MinimoExclusivo        *json.Number        `json:"exclusiveMinimum,omitempty"`
+	Itens                  *Esquema            `json:"items,omitempty"`
+	MinimoItens            *int                `json:"minItems,omitempty"`
+	padrao                 *regexp.Regexp
+}
+
+// CompilarEsquema: converte o JSON do esquema, compilando os padrões
+func CompilarEsquema(esquemaJSON []byte) (*Esquema, error) {
+	var e Esquema
+	if err := json.Unmarshal(esquemaJSON, &e); err != nil {
+		return nil, fmt.Errorf("Esquema inválido: %s", err)
+	}
+	if err := e.compilar(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (e *Esquema) compilar() error {
+	if e.Padrao != "" {
+		re, err := regexp.Compile(e.Padrao)
+		if err != nil {
+			return fmt.Errorf("Esquema inválido: pattern [%s]: %s", e.Padrao, err)
+		}
+		e.padrao = re
+	}
+	for _, p := range e.Propriedades {
+		if err := p.compilar(); err != nil {
+			return err
+		}
+	}
+	if e.Itens != nil {
+		return e.Itens.compilar()
+	}
+	return nil
+}
+
+// Validar: valida o documento (decodificado com json.Decoder.UseNumber) e retorna
+// todas as violações encontradas, ordenadas pelo caminho. A mensagem de cada violação
+// é preenchida pelo envelope, no idioma do chamador.
+func (e *Esquema) Validar(documento interface{}) []envelope.Campo {
+	var campos []envelope.Campo
+	e.validar(documento, "", &campos)
+	sort.SliceStable(campos, func(i, j int) bool { return campos[i].Caminho < campos[j].Caminho })
+	return campos
+}
+
+func (e *Esquema) validar(valor interface{}, caminho string, campos *[]envelope.Campo) {
+	violacao := func(caminho, regra string, parametros ...string) {
+		if caminho == "" {
+			caminho = "/"
+		}
+		c := envelope.Campo{Caminho: caminho, Regra: regra}
+		if len(parametros) > 0 {
+			c.Parametros = make(map[string]string)
+			for i := 0; i+1 < len(parametros); i += 2 {
+				c.Parametros[parametros[i]] = parametros[i+1]
+			}
+		}
+		*campos = append(*campos, c)
+	}
+
+	if e.Tipo != "" && !tipoCompativel(e.Tipo, valor) {
+		violacao(caminho, "type", "esperado", e.Tipo, "recebido", tipoJSON(valor))
+		return
+	}
+	if len(e.Enum) > 0 && !contido(valor, e.Enum) {
+		violacao(caminho, "enum", "valor", textoJSON(valor), "permitidos", textoEnum(e.Enum))
+	}
+
+	switch v := valor.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if e.TamanhoMinimo != nil && n < *e.TamanhoMinimo {
+			violacao(caminho, "minLength", "limite", strconv.Itoa(*e.TamanhoMinimo))
+		}
+		if e.TamanhoMaximo != nil && n > *e.TamanhoMaximo {
+			violacao(caminho, "maxLength", "limite", strconv.Itoa(*e.TamanhoMaximo))
+		}
+		if e.padrao != nil && !e.padrao.MatchString(v) {
+			violacao(caminho, "pattern", "padrao", e.Padrao)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if e.Minimo != nil {
+			if m, _ := e.Minimo.Float64(); f < m {
+				violacao(caminho, "minimum", "limite", e.Minimo.String())
+			}
+		}
+		if e.MinimoExclusivo != nil {
+			if m, _ := e.MinimoExclusivo.Float64(); f <= m {
+				violacao(caminho, "exclusiveMinimum", "limite", e.MinimoExclusivo.String())
+			}
+		}
+	case map[string]interface{}:
+		for _, nome := range e.Obrigatorias {
+			if _, ok := v[nome]; !ok {
+				violacao(caminho+"/"+ponteiro(nome), "required")
+			}
+		}
+		for nome, dependentes := range e.Dependentes {
+			if _, ok := v[nome]; !ok {
+				continue
+			}
+			for _, d := range dependentes {
+				if _, ok := v[d]; !ok {
+					violacao(caminho+"/"+ponteiro(d), "dependentRequired", "campo", nome)
+				}
+			}
+		}
+		for nome, campo := range v {
+			p, ok := e.Propriedades[nome]
+			if !ok {
+				if e.PropriedadesAdicionais != nil && !*e.PropriedadesAdicionais {
+					violacao(caminho+"/"+ponteiro(nome), "additionalProperties")
+				}
+				continue
+			}
+			p.validar(campo, caminho+"/"+ponteiro(nome), campos)
+		}
+	case []interface{}:
+		if e.MinimoItens != nil && len(v) < *e.MinimoItens {
+			violacao(caminho, "minItems", "limite", strconv.Itoa(*e.MinimoItens))
+		}
+		if e.Itens != nil {
+			for i, item := range v {
+				e.Itens.validar(item, caminho+"/"+strconv.Itoa(i), campos)
+			}
+		}
+	}
+}
+
+// tipoCompativel: verifica o tipo JSON do valor; integer aceita apenas números sem parte fracionária
+func tipoCompativel(tipo string, valor interface{}) bool {
+	switch tipo {
+	case "integer":
+		n, ok := valor.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "number":
+		_, ok := valor.(json.Number)
+		return ok
+	}
+	return tipoJSON(valor) == tipo
+}
+
+func tipoJSON(valor interface{}) string {
+	switch valor.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", valor)
+}
+
+func contido(valor interface{}, enum []interface{}) bool {
+	for _, e := range enum {
+		if textoJSON(e) == textoJSON(valor) {
+			return true
+		}
+	}
+	return false
+}
+
+func textoEnum(enum []interface{}) string {
+	textos := make([]string, len(enum))
+	for i, e := range enum {
+		textos[i] = textoJSON(e)
+	}
+	return "[" + strings.Join(textos, ", ") + "]"
+}
+
+func textoJSON(valor interface{}) string {
+	b, err := json.Marshal(valor)
+	if err != nil {
+		return fmt.Sprint(valor)
+	}
+	return string(b)
+}
+
+// ponteiro: escapa o nome do campo para o JSON Pointer (RFC 6901)
+func ponteiro(nome string) string {
+	return strings.Replace(strings.Replace(nome, "~", "~0", -1), "/", "~1", -1)
+}
@@ -0,0 +1,108 @@
+package validation
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// regras: caminho e regra de cada violação ("/valor:type")
+func regras(t *testing.T, esquema *Esquema, documentoJSON string) []string {
+	var documento interface{}
+	dec := json.NewDecoder(strings.NewReader(documentoJSON))
+	dec.UseNumber()
+	if err := dec.Decode(&documento); err != nil {
+		t.Fatal(err)
+	}
+	var r []string
+	for _, c := range esquema.Validar(documento) {
+		r = append(r, c.Caminho+":"+c.Regra)
+	}
+	return r
+}
+
+func TestEsquemaPalavrasChave(t *testing.T) {
+	esquema, err := CompilarEsquema([]byte(`{
+  "type": "object",
+  "properties": {
+    "id": { "type": "string", "minLength": 1, "maxLength": 8, "pattern": "^[a-z0-9]+$" },
+    "parte": { "type": "string", "enum": ["pagador", "beneficiario"] },
+    "valor": { "type": "integer", "exclusiveMinimum": 0 },
+    "juros": { "type": "integer", "minimum": 0 },
+    "nosso_numero": { "type": "string" },
+    "itens": { "type": "array", "minItems": 1, "items": { "type": "boolean" } }
+  },
+  "required": ["id"],
+  "dependentRequired": { "nosso_numero": ["valor"] },
+  "additionalProperties": false
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct {
+		nome      string
+		documento string
+		regras    []string
+	}{
+		{"válido", `{"id": "a1", "parte": "pagador", "valor": 1, "juros": 0, "itens": [true]}`, nil},
+		{"tipo do documento", `[]`, []string{"/:type"}},
+		{"required", `{}`, []string{"/id:required"}},
+		{"additionalProperties", `{"id": "a1", "extra": 1}`, []string{"/extra:additionalProperties"}},
+		{"minLength", `{"id": ""}`, []string{"/id:minLength", "/id:pattern"}},
+		{"maxLength", `{"id": "abcdefghi"}`, []string{"/id:maxLength"}},
+		{"pattern", `{"id": "A-1"}`, []string{"/id:pattern"}},
+		{"enum", `{"id": "a1", "parte": "banco"}`, []string{"/parte:enum"}},
+		{"integer com fração", `{"id": "a1", "valor": 1.5}`, []string{"/valor:type"}},
+		{"integer em string", `{"id": "a1", "valor": "1"}`, []string{"/valor:type"}},
+		{"exclusiveMinimum", `{"id": "a1", "valor": 0}`, []string{"/valor:exclusiveMinimum"}},
+		{"minimum", `{"id": "a1", "juros": -1}`, []string{"/juros:minimum"}},
+		{"dependentRequired", `{"id": "a1", "nosso_numero": "1"}`, []string{"/valor:dependentRequired"}},
+		{"minItems", `{"id": "a1", "itens": []}`, []string{"/itens:minItems"}},
+		{"items", `{"id": "a1", "itens": [true, "sim"]}`, []string{"/itens/1:type"}},
+		{"ordem por caminho", `{"valor": 0, "parte": "x"}`, []string{"/id:required", "/parte:enum", "/valor:exclusiveMinimum"}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if r := regras(t, esquema, c.documento); !reflect.DeepEqual(r, c.regras) {
+				t.Fatalf("violações %v, esperadas %v", r, c.regras)
+			}
+		})
+	}
+}
+
+func TestEsquemaPonteiro(t *testing.T) {
+	esquema, err := CompilarEsquema([]byte(`{"type": "object", "required": ["a/b", "c~d"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := regras(t, esquema, `{}`)
+	if esperadas := []string{"/a~1b:required", "/c~0d:required"}; !reflect.DeepEqual(r, esperadas) {
+		t.Fatalf("violações %v, esperadas %v", r, esperadas)
+	}
+}
+
+func TestCompilarEsquemaInvalido(t *testing.T) {
+	for _, esquemaJSON := range []string{`{`, `{"pattern": "("}`} {
+		if _, err := CompilarEsquema([]byte(esquemaJSON)); err == nil {
+			t.Errorf("esquema %s aceito", esquemaJSON)
+		}
+	}
+}
+
+func TestEsquemasPublicados(t *testing.T) {
+	for _, funcao := range FuncoesComEsquema() {
+		esquemaJSON, _ := EsquemaJSON(funcao)
+		var e struct {
+			ID    string `json:"$id"`
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal(esquemaJSON, &e); err != nil {
+			t.Fatalf("%s: %s", funcao, err)
+		}
+		if e.ID != URLEsquemas+funcao+".json" || e.Title != funcao {
+			t.Errorf("%s: $id %q, title %q", funcao, e.ID, e.Title)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package validation
+
+import (
+	"sort"
+)
+
+// URLEsquemas - base do $id dos esquemas, publicados pelo gateway em GET /esquemas/{funcao}.json
+const URLEsquemas = "https://github.com/CaueP/BlockchainDojo/esquemas/"
+
+// Esquemas JSON dos documentos aceitos pelas funções Invoke, como alternativa aos
+// argumentos posicionais. Os nomes dos campos são os mesmos do gateway REST.
+var esquemasJSON = map[string]string{
+	"registrarProposta": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "` + URLEsquemas + `registrarProposta.json",
+  "title": "registrarProposta",
+  "description": "Registra uma nova proposta ou atualiza uma existente, ainda não paga nem cancelada. nosso_numero e valor são informados juntos; ao atualizar sem eles, os valores já registrados são mantidos, e com o boleto já emitido eles são recusados.",
+  "type": "object",
+  "properties": {
+    "id_requisicao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$", "description": "ID da requisição do cliente; repetições com o mesmo ID retornam a resposta original" },
+    "id_proposta": { "type": "string", "minLength": 1, "description": "Hash que identifica a proposta" },
+    "cpf_pagador": { "type": "string", "minLength": 1, "description": "CPF do pagador" },
+    "pagador_aceitou": { "type": "boolean" },
+    "beneficiario_aceitou": { "type": "boolean" },
+    "boleto_pago": { "type": "boolean", "description": "false com a tabela completa, em que o pagamento é registrado por confirmarPagamento" },
+    "nosso_numero": { "type": "string", "minLength": 1, "description": "Nosso número do boleto" },
+    "valor": { "type": "integer", "minimum": 0, "description": "Valor do boleto em centavos" }
+  },
+  "required": ["id_proposta", "cpf_pagador", "pagador_aceitou", "beneficiario_aceitou", "boleto_pago"],
+  "dependentRequired": { "nosso_numero": ["valor"], "valor": ["nosso_numero"] },
+  "additionalProperties": false
+}`,
+	"aceitarProposta": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "` + URLEsquemas + `aceitarProposta.json",
+  "title": "aceitarProposta",
+  "description": "Registra o aceite do pagador ou do beneficiário.",
+  "type": "object",
+  "properties": {
+    "id_requisicao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$", "description": "ID da requisição do cliente; repetições com o mesmo ID retornam a resposta original" },
+    "id_proposta": { "type": "string", "minLength": 1 },
+    "parte": { "type": "string", "enum": ["pagador", "beneficiario"] }
+  },
+  "required": ["id_proposta", "parte"],
+  "additionalProperties": false
+}`,
+	"emitirBoleto": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "` + URLEsquemas + `emitirBoleto.json",
+  "title": "emitirBoleto",
+  "description": "Registra o boleto emitido para a proposta.",
+  "type": "object",
+  "properties": {
+    "id_requisicao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$", "description": "ID da requisição do cliente; repetições com o mesmo ID retornam a resposta original" },
+    "id_proposta": { "type": "string", "minLength": 1 },
+    "nosso_numero": { "type": "string", "minLength": 1 },
+    "valor": { "type": "integer", "exclusiveMinimum": 0, "description": "Valor do boleto em centavos" },
+    "data_vencimento": { "type": "string", "pattern": "^[0-9]{4}-[0-9]{2}-[0-9]{2}$", "description": "Data de vencimento do boleto (AAAA-MM-DD)" },
+    "beneficiario": { "type": "string", "minLength": 1, "description": "CPF ou CNPJ do beneficiário" }
+  },
+  "required": ["id_proposta", "nosso_numero", "valor"],
+  "additionalProperties": false
+}`,
+	"confirmarPagamento": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "` + URLEsquemas + `confirmarPagamento.json",
+  "title": "confirmarPagamento",
+  "description": "Liquida a proposta com o atestado de pagamento assinado pelo oráculo do banco: do boleto, com o nosso_numero, ou PIX, com o end_to_end_id e o txid (ID da proposta).",
+  "type": "object",
+  "properties": {
+    "id_requisicao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$", "description": "ID da requisição do cliente; repetições com o mesmo ID retornam a resposta original" },
+    "id_proposta": { "type": "string", "minLength": 1 },
+    "atestado": {
+      "type": "object",
+      "properties": {
+        "codigo_banco": { "type": "string", "pattern": "^[0-9]{3}$" },
+        "nosso_numero": { "type": "string", "minLength": 1, "description": "Nosso número do boleto pago (vazio no PIX)" },
+        "end_to_end_id": { "type": "string", "pattern": "^E[0-9]{20}[A-Za-z0-9]{11}$", "description": "ID fim a fim do pagamento PIX" },
+        "txid": { "type": "string", "pattern": "^[A-Za-z0-9]{1,35}$", "description": "txid da cobrança PIX paga (ID da proposta)" },
+        "valor": { "type": "integer", "exclusiveMinimum": 0 },
+        "data_pagamento": { "type": "string", "pattern": "^[0-9]{4}-[0-9]{2}-[0-9]{2}$" },
+        "assinatura": { "type": "string", "minLength": 1, "description": "Assinatura ECDSA (DER em base64) do oráculo" }
+      },
+      "required": ["codigo_banco", "valor", "data_pagamento", "assinatura"],
+      "dependentRequired": { "end_to_end_id": ["txid"], "txid": ["end_to_end_id"] },
+      "additionalProperties": false
+    }
+  },
+  "required": ["id_proposta", "atestado"],
+  "additionalProperties": false
+}`,
+	"cancelarProposta": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "` + URLEsquemas + `cancelarProposta.json",
+  "title": "cancelarProposta",
+  "description": "Cancela uma proposta ainda não paga.",
+  "type": "object",
+  "properties": {
+    "id_requisicao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$", "description": "ID da requisição do cliente; repetições com o mesmo ID retornam a resposta original" },
+    "id_proposta": { "type": "string", "minLength": 1 },
+    "motivo": { "type": "string" }
+  },
+  "required": ["id_proposta", "motivo"],
+  "additionalProperties": false
+}`,
+	"registrarCobrancaPix": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "` + URLEsquemas + `registrarCobrancaPix.json",
+  "title": "registrarCobrancaPix",
+  "description": "Registra uma única vez a cobrança PIX da proposta aceita, com o valor da proposta (do boleto emitido ou de registrarProposta), e retorna o BR Code com o ID da proposta como txid. O pagamento PIX é aceito apenas com o valor registrado.",
+  "type": "object",
+  "properties": {
+    "id_requisicao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$", "description": "ID da requisição do cliente; repetições com o mesmo ID retornam a resposta original" },
+    "id_proposta": { "type": "string", "minLength": 1 },
+    "valor": { "type": "integer", "exclusiveMinimum": 0, "description": "Valor em centavos, conferido com o valor da proposta" }
+  },
+  "required": ["id_proposta"],
+  "additionalProperties": false
+}`,
+	"executarLote": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "` + URLEsquemas + `executarLote.json",
+  "title": "executarLote",
+  "description": "Executa as operações em ordem, na mesma transação: todas são aplicadas ou nenhuma. O máximo de operações é definido pela configuração do chaincode (lote_maximo).",
+  "type": "object",
+  "properties": {
+    "id_requisicao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$", "description": "ID da requisição do cliente; repetições com o mesmo ID retornam a resposta original" },
+    "operacoes": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "properties": {
+          "funcao": { "type": "string", "enum": ["registrarProposta", "aceitarProposta", "confirmarPagamento", "cancelarProposta"] },
+          "documento": { "type": "object", "description": "Documento da função, validado pelo esquema da função; o id_requisicao do documento é ignorado" }
+        },
+        "required": ["funcao", "documento"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["operacoes"],
+  "additionalProperties": false
+}`,
+	"registrarConciliacao": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "` + URLEsquemas + `registrarConciliacao.json",
+  "title": "registrarConciliacao",
+  "description": "Registra a conciliação dos boletos pagos com os extratos bancários. Os dados do ledger de cada item (nosso número, valor e data de pagamento) são preenchidos pelo chaincode.",
+  "type": "object",
+  "properties": {
+    "id_requisicao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$", "description": "ID da requisição do cliente; repetições com o mesmo ID retornam a resposta original" },
+    "id_conciliacao": { "type": "string", "pattern": "^[A-Za-z0-9._:-]{1,128}$", "description": "Identificador da conciliação, único no ledger" },
+    "inicio": { "type": "string", "pattern": "^[0-9]{4}-[0-9]{2}-[0-9]{2}$", "description": "Início do período conciliado (AAAA-MM-DD)" },
+    "fim": { "type": "string", "pattern": "^[0-9]{4}-[0-9]{2}-[0-9]{2}$", "description": "Fim do período conciliado (AAAA-MM-DD)" },
+    "extratos": { "type": "array", "items": { "type": "string", "minLength": 1 }, "description": "Arquivos dos extratos conciliados" },
+    "itens": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "situacao": { "type": "string", "enum": ["conciliado", "somente_ledger", "somente_extrato", "valor_divergente", "data_divergente", "duplicado"] },
+          "id_proposta": { "type": "string", "minLength": 1, "description": "Proposta do boleto; obrigatório exceto em somente_extrato e duplicado" },
+          "id_lancamento": { "type": "string", "minLength": 1, "description": "ID do lançamento no extrato (FITID)" },
+          "valor_extrato": { "type": "integer", "description": "Valor do lançamento em centavos" },
+          "data_extrato": { "type": "string", "pattern": "^[0-9]{4}-[0-9]{2}-[0-9]{2}$", "description": "Data do lançamento (AAAA-MM-DD)" },
+          "descricao": { "type": "string" },
+          "arquivo": { "type": "string" },
+          "criterio": { "type": "string", "enum": ["nosso_numero", "id_proposta", "end_to_end_id", "valor"], "description": "Critério da associação entre o lançamento e o boleto" },
+          "detalhe": { "type": "string" }
+        },
+        "required": ["situacao"],
+        "dependentRequired": { "valor_extrato": ["data_extrato"], "data_extrato": ["valor_extrato"] },
+        "additionalProperties": false
+      }
+    }
+  },
+  "required": ["id_conciliacao", "itens"],
+  "additionalProperties": false
+}`,
+}
+
+// esquemas compilados, por função
+var esquemas = make(map[string]*Esquema)
+
+func init() {
+	for funcao, esquemaJSON := range esquemasJSON {
+		e, err := CompilarEsquema([]byte(esquemaJSON))
+		if err != nil {
+			panic("esquema de " + funcao + ": " + err.Error())
+		}
+		esquemas[funcao] = e
+	}
+}
+
+// EsquemaJSON: esquema publicado do documento aceito pela função
+func EsquemaJSON(funcao string) ([]byte, bool) {
+	e, ok := esquemasJSON[funcao]
+	return []byte(e), ok
+}
+
+// FuncoesComEsquema: funções que aceitam um documento JSON, em ordem alfabética
+func FuncoesComEsquema() []string {
+	var funcoes []string
+	for funcao := range esquemasJSON {
+		funcoes = append(funcoes, funcao)
+	}
+	sort.Strings(funcoes)
+	return funcoes
+}
@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+)
+
+// PrefixoIDRequisicao - prefixo do argumento opcional com o ID da requisição do cliente,
+// informado antes dos argumentos posicionais de uma função Invoke
+// (ex.: "id_requisicao=3f9c", "p1", "pagador"). Nos documentos JSON, o ID é o campo id_requisicao.
+const PrefixoIDRequisicao = "id_requisicao="
+
+// formato do ID da requisição: sem separadores de chave, até 128 caracteres
+var reIDRequisicao = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// IDRequisicao: separa o ID da requisição dos argumentos da função Invoke. Retorna
+// o ID vazio se a requisição não informar nenhum. O documento JSON é retornado sem
+// alterações, pois o campo id_requisicao faz parte do esquema.
+func IDRequisicao(args []string) (string, []string, error) {
+	if len(args) > 0 && strings.HasPrefix(args[0], PrefixoIDRequisicao) {
+		id := strings.TrimPrefix(args[0], PrefixoIDRequisicao)
+		if !reIDRequisicao.MatchString(id) {
+			return "", nil, envelope.Novo(envelope.ArgumentoInvalido, "campo", "id_requisicao", "valor", id)
+		}
+		return id, args[1:], nil
+	}
+	if len(args) == 1 && EhDocumento(args[0]) {
+		var d struct {
+			IDRequisicao *string `json:"id_requisicao"`
+		}
+		// documentos inválidos são rejeitados depois, pela validação do esquema
+		if json.Unmarshal([]byte(args[0]), &d) != nil || d.IDRequisicao == nil {
+			return "", args, nil
+		}
+		if !reIDRequisicao.MatchString(*d.IDRequisicao) {
+			return "", nil, envelope.Novo(envelope.ArgumentoInvalido, "campo", "id_requisicao", "valor", *d.IDRequisicao)
+		}
+		return *d.IDRequisicao, args, nil
+	}
+	return "", args, nil
+}
+
+// ComIDRequisicao: argumentos da função Invoke com o ID da requisição informado
+// (utilizado pelos clientes, como o gateway)
+func ComIDRequisicao(id string, args []string) []string {
+	return append([]string{PrefixoIDRequisicao + id}, args...)
+}
@@ -0,0 +1,466 @@
+/*
+Descrição: validação dos argumentos das funções do chaincode de propostas
+Cada função Invoke também aceita um único documento JSON, validado pelo esquema
+publicado da função (ver esquemas.go) e convertido nos argumentos posicionais.
+As mesmas funções são utilizadas pelo chaincode e pelos pontos de entrada fora da
+blockchain (gateway REST, gRPC), para que uma requisição inválida receba o mesmo
+erro (ver pacote envelope) qualquer que seja o caminho utilizado.
+*/
+
+// Package validation converte e valida os argumentos posicionais das funções do
+// chaincode de propostas, ou os documentos JSON equivalentes.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+	"github.com/CaueP/BlockchainDojo/oracle"
+	"github.com/CaueP/BlockchainDojo/reconciliation"
+)
+
+// Registro - argumentos de registrarProposta
+type Registro struct {
+	ID                  string
+	CpfPagador          string
+	PagadorAceitou      bool
+	BeneficiarioAceitou bool
+	BoletoPago          bool
+	NossoNumero         string
+	Valor               int64
+	InformouBoleto      bool // true se nossoNumero e valor foram informados (7 argumentos)
+}
+
+// Aceite - argumentos de aceitarProposta
+type Aceite struct {
+	ID    string
+	Parte string
+}
+
+// Boleto - argumentos de emitirBoleto
+type Boleto struct {
+	ID             string
+	NossoNumero    string
+	Valor          int64
+	DataVencimento string // AAAA-MM-DD (opcional)
+	Beneficiario   string // CPF ou CNPJ do beneficiário (opcional)
+}
+
+// Pagamento - argumentos de confirmarPagamento
+type Pagamento struct {
+	ID       string
+	Atestado oracle.Atestado
+}
+
+// Cancelamento - argumentos de cancelarProposta
+type Cancelamento struct {
+	ID     string
+	Motivo string
+}
+
+// Lote - operações de executarLote, na ordem de execução
+type Lote struct {
+	Operacoes []OperacaoLote
+}
+
+// OperacaoLote - operação do lote: a função e o seu argumento (o documento JSON da função)
+type OperacaoLote struct {
+	Funcao     string
+	Argumentos []string
+}
+
+// Conciliacao - documento de registrarConciliacao
+type Conciliacao struct {
+	ID       string                `json:"id_conciliacao"`
+	Inicio   string                `json:"inicio"`
+	Fim      string                `json:"fim"`
+	Extratos []string              `json:"extratos"`
+	Itens    []reconciliation.Item `json:"itens"`
+}
+
+// RegistrarProposta: valida os argumentos de registrarProposta
+// (Id, cpfPagador, pagadorAceitou, beneficiarioAceitou, boletoPago[, nossoNumero, valor])
+func RegistrarProposta(args []string) (Registro, error) {
+	var r Registro
+	args, err := argumentosDocumento("registrarProposta", args)
+	if err != nil {
+		return r, err
+	}
+
+	if len(args) != 5 && len(args) != 7 {
+		return r, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "5 ou 7")
+	}
+
+	r.ID = args[0]
+	r.CpfPagador = args[1]
+	r.PagadorAceitou, err = strconv.ParseBool(args[2])
+	if err != nil {
+		return r, envelope.Novo(envelope.ArgumentoInvalido, "campo", "pagadorAceitou", "valor", args[2])
+	}
+	r.BeneficiarioAceitou, err = strconv.ParseBool(args[3])
+	if err != nil {
+		return r, envelope.Novo(envelope.ArgumentoInvalido, "campo", "beneficiarioAceitou", "valor", args[3])
+	}
+	r.BoletoPago, err = strconv.ParseBool(args[4])
+	if err != nil {
+		return r, envelope.Novo(envelope.ArgumentoInvalido, "campo", "boletoPago", "valor", args[4])
+	}
+	if len(args) == 7 {
+		r.InformouBoleto = true
+		r.NossoNumero = args[5]
+		r.Valor, err = strconv.ParseInt(args[6], 10, 64)
+		if err != nil || r.Valor < 0 {
+			return r, envelope.Novo(envelope.ArgumentoInvalido, "campo", "valor", "valor", args[6])
+		}
+	}
+	return r, nil
+}
+
+// ConsultarProposta: valida os argumentos de consultarProposta (Id)
+func ConsultarProposta(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "1")
+	}
+	return args[0], nil
+}
+
+// ListarPropostas: valida os argumentos de listarPropostas (nenhum)
+func ListarPropostas(args []string) error {
+	if len(args) != 0 {
+		return envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "0")
+	}
+	return nil
+}
+
+// ListarFuncoes: valida os argumentos de listarFuncoes (nenhum)
+func ListarFuncoes(args []string) error {
+	if len(args) != 0 {
+		return envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "0")
+	}
+	return nil
+}
+
+// Versao: valida os argumentos de versao (nenhum)
+func Versao(args []string) error {
+	if len(args) != 0 {
+		return envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "0")
+	}
+	return nil
+}
+
+// Aging - argumentos de agingRecebiveis
+type Aging struct {
+	Formato        string // json ou csv
+	DataReferencia string // AAAA-MM-DD (vazio: data da transação)
+}
+
+// AgingRecebiveis: valida os argumentos de agingRecebiveis ([formato[, dataReferencia]])
+func AgingRecebiveis(args []string) (Aging, error) {
+	if len(args) > 2 {
+		return Aging{}, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "0 a 2")
+	}
+	a := Aging{Formato: "json"}
+	if len(args) > 0 && args[0] != "" {
+		a.Formato = args[0]
+	}
+	if a.Formato != "json" && a.Formato != "csv" {
+		return a, envelope.Novo(envelope.ArgumentoInvalido, "campo", "formato", "valor", a.Formato)
+	}
+	if len(args) > 1 && args[1] != "" {
+		if _, err := time.Parse(oracle.LayoutData, args[1]); err != nil {
+			return a, envelope.Novo(envelope.ArgumentoInvalido, "campo", "dataReferencia", "valor", args[1])
+		}
+		a.DataReferencia = args[1]
+	}
+	return a, nil
+}
+
+// ExpurgarRequisicoes: valida os argumentos de expurgarRequisicoes (nenhum)
+func ExpurgarRequisicoes(args []string) error {
+	if len(args) != 0 {
+		return envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "0")
+	}
+	return nil
+}
+
+// Oraculo - argumentos de registrarOraculo
+type Oraculo struct {
+	CodigoBanco  string
+	ChavePublica string // PEM
+}
+
+// RegistrarOraculo: valida os argumentos de registrarOraculo (codigoBanco, chavePublica)
+func RegistrarOraculo(args []string) (Oraculo, error) {
+	if len(args) != 2 {
+		return Oraculo{}, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "2")
+	}
+	o := Oraculo{CodigoBanco: args[0], ChavePublica: args[1]}
+	if err := oracle.ValidarCodigoBanco(o.CodigoBanco); err != nil {
+		return o, envelope.Novo(envelope.ArgumentoInvalido, "campo", "codigoBanco", "valor", o.CodigoBanco)
+	}
+	if _, err := oracle.DecodificarChavePublica([]byte(o.ChavePublica)); err != nil {
+		return o, envelope.Novo(envelope.ArgumentoInvalido, "campo", "chavePublica", "valor", err.Error())
+	}
+	return o, nil
+}
+
+// AceitarProposta: valida os argumentos de aceitarProposta (Id, parte)
+func AceitarProposta(args []string) (Aceite, error) {
+	args, err := argumentosDocumento("aceitarProposta", args)
+	if err != nil {
+		return Aceite{}, err
+	}
+	if len(args) != 2 {
+		return Aceite{}, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "2")
+	}
+	a := Aceite{ID: args[0], Parte: args[1]}
+	if a.Parte != "pagador" && a.Parte != "beneficiario" {
+		return a, envelope.Novo(envelope.ArgumentoInvalido, "campo", "parte", "valor", a.Parte)
+	}
+	return a, nil
+}
+
+// EmitirBoleto: valida os argumentos de emitirBoleto (Id, nossoNumero, valor[, dataVencimento[, beneficiario]])
+func EmitirBoleto(args []string) (Boleto, error) {
+	args, err := argumentosDocumento("emitirBoleto", args)
+	if err != nil {
+		return Boleto{}, err
+	}
+	if len(args) < 3 || len(args) > 5 {
+		return Boleto{}, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "3 a 5")
+	}
+	b := Boleto{ID: args[0], NossoNumero: args[1]}
+	if len(args) > 3 && args[3] != "" {
+		if _, err := time.Parse(oracle.LayoutData, args[3]); err != nil {
+			return b, envelope.Novo(envelope.ArgumentoInvalido, "campo", "dataVencimento", "valor", args[3])
+		}
+		b.DataVencimento = args[3]
+	}
+	if len(args) > 4 {
+		b.Beneficiario = args[4]
+	}
+	if b.NossoNumero == "" {
+		return b, envelope.Novo(envelope.CampoObrigatorio, "campo", "nossoNumero")
+	}
+	valor, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || valor <= 0 {
+		return b, envelope.Novo(envelope.ArgumentoInvalido, "campo", "valor", "valor", args[2])
+	}
+	b.Valor = valor
+	return b, nil
+}
+
+// ConfirmarPagamento: valida os argumentos de confirmarPagamento (Id, atestado).
+// A assinatura do atestado é verificada pelo chaincode, com a chave do oráculo registrada.
+func ConfirmarPagamento(args []string) (Pagamento, error) {
+	args, err := argumentosDocumento("confirmarPagamento", args)
+	if err != nil {
+		return Pagamento{}, err
+	}
+	if len(args) != 2 {
+		return Pagamento{}, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "2")
+	}
+	p := Pagamento{ID: args[0]}
+	atestado, err := oracle.Decodificar([]byte(args[1]))
+	if err != nil {
+		return p, envelope.Novo(envelope.AtestadoInvalido, "detalhe", strings.TrimPrefix(err.Error(), "Atestado inválido: "))
+	}
+	if err := atestado.Validar(); err != nil {
+		return p, envelope.Novo(envelope.AtestadoInvalido, "detalhe", err.Error())
+	}
+	p.Atestado = atestado
+	return p, nil
+}
+
+// CancelarProposta: valida os argumentos de cancelarProposta (Id, motivo)
+func CancelarProposta(args []string) (Cancelamento, error) {
+	args, err := argumentosDocumento("cancelarProposta", args)
+	if err != nil {
+		return Cancelamento{}, err
+	}
+	if len(args) != 2 {
+		return Cancelamento{}, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "2")
+	}
+	return Cancelamento{ID: args[0], Motivo: args[1]}, nil
+}
+
+// ExecutarLote: valida o documento de executarLote (único argumento). Os documentos das
+// operações são validados pelas próprias funções, na execução do lote.
+func ExecutarLote(args []string) (Lote, error) {
+	if len(args) != 1 || !EhDocumento(args[0]) {
+		return Lote{}, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "1")
+	}
+	d, err := decodificarDocumento("executarLote", args[0])
+	if err != nil {
+		return Lote{}, err
+	}
+
+	var lote Lote
+	for _, op := range d["operacoes"].([]interface{}) {
+		o := op.(map[string]interface{})
+		documento, err := json.Marshal(o["documento"])
+		if err != nil {
+			return Lote{}, envelope.Interno(err)
+		}
+		lote.Operacoes = append(lote.Operacoes, OperacaoLote{
+			Funcao:     texto(o["funcao"]),
+			Argumentos: []string{string(documento)},
+		})
+	}
+	return lote, nil
+}
+
+// RegistrarConciliacao: valida o documento de registrarConciliacao (único argumento). Os
+// itens sem id_proposta são aceitos apenas nas situações somente_extrato e duplicado
+// (créditos sem boleto associado).
+func RegistrarConciliacao(args []string) (Conciliacao, error) {
+	var c Conciliacao
+	if len(args) != 1 || !EhDocumento(args[0]) {
+		return c, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "1")
+	}
+	if _, err := decodificarDocumento("registrarConciliacao", args[0]); err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal([]byte(args[0]), &c); err != nil {
+		return c, envelope.Interno(err)
+	}
+
+	for _, campo := range []struct{ nome, valor string }{{"inicio", c.Inicio}, {"fim", c.Fim}} {
+		if _, err := time.Parse(oracle.LayoutData, campo.valor); campo.valor != "" && err != nil {
+			return c, envelope.Novo(envelope.ArgumentoInvalido, "campo", campo.nome, "valor", campo.valor)
+		}
+	}
+	if c.Inicio != "" && c.Fim != "" && c.Fim < c.Inicio {
+		return c, envelope.Novo(envelope.ArgumentoInvalido, "campo", "fim", "valor", c.Fim)
+	}
+	for i, item := range c.Itens {
+		semBoleto := item.Situacao == reconciliation.SituacaoSomenteExtrato || item.Situacao == reconciliation.SituacaoDuplicado
+		if item.IDProposta == "" && !semBoleto {
+			return c, envelope.Novo(envelope.CampoObrigatorio, "campo", fmt.Sprintf("itens[%d].id_proposta", i))
+		}
+		// os campos do ledger são preenchidos pelo chaincode
+		c.Itens[i].NossoNumero, c.Itens[i].ValorLedger, c.Itens[i].DataLedger = "", 0, ""
+	}
+	return c, nil
+}
+
+// ConsultarConciliacao: valida os argumentos de consultarConciliacao (idConciliacao)
+func ConsultarConciliacao(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "1")
+	}
+	if args[0] == "" {
+		return "", envelope.Novo(envelope.CampoObrigatorio, "campo", "idConciliacao")
+	}
+	return args[0], nil
+}
+
+// ListarStatusConciliacao: valida os argumentos de listarStatusConciliacao ([situacao]).
+// Retorna a situação vazia para listar todos os boletos.
+func ListarStatusConciliacao(args []string) (string, error) {
+	if len(args) > 1 {
+		return "", envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "0 a 1")
+	}
+	if len(args) == 0 || args[0] == "" {
+		return "", nil
+	}
+	if args[0] == reconciliation.SituacaoNaoConciliado || reconciliation.Gravidade(args[0]) < len(reconciliation.Situacoes) {
+		return args[0], nil
+	}
+	return "", envelope.Novo(envelope.ArgumentoInvalido, "campo", "situacao", "valor", args[0])
+}
+
+// CobrancaPix - argumentos de registrarCobrancaPix
+type CobrancaPix struct {
+	ID    string
+	Valor int64 // em centavos, conferido com o valor da proposta (0: não informado)
+}
+
+// RegistrarCobrancaPix: valida os argumentos de registrarCobrancaPix (Id[, valor])
+func RegistrarCobrancaPix(args []string) (CobrancaPix, error) {
+	args, err := argumentosDocumento("registrarCobrancaPix", args)
+	if err != nil {
+		return CobrancaPix{}, err
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return CobrancaPix{}, envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "1 a 2")
+	}
+	c := CobrancaPix{ID: args[0]}
+	if c.ID == "" {
+		return c, envelope.Novo(envelope.CampoObrigatorio, "campo", "Id")
+	}
+	if len(args) > 1 && args[1] != "" {
+		v, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || v <= 0 {
+			return c, envelope.Novo(envelope.ArgumentoInvalido, "campo", "valor", "valor", args[1])
+		}
+		c.Valor = v
+	}
+	return c, nil
+}
+
+// GerarBRCode: valida os argumentos de gerarBRCode (Id)
+func GerarBRCode(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", envelope.Novo(envelope.ArgumentosInvalidos, "esperado", "1")
+	}
+	if args[0] == "" {
+		return "", envelope.Novo(envelope.CampoObrigatorio, "campo", "Id")
+	}
+	return args[0], nil
+}
+
+// Validar: valida os argumentos da função informada, incluindo o ID da requisição
+// opcional das funções Invoke. Funções sem validação registrada são aceitas, cabendo
+// ao chaincode rejeitá-las.
+func Validar(funcao string, args []string) error {
+	var err error
+	if _, invoke := esquemas[funcao]; invoke {
+		if _, args, err = IDRequisicao(args); err != nil {
+			return err
+		}
+	}
+	switch funcao {
+	case "registrarProposta":
+		_, err = RegistrarProposta(args)
+	case "consultarProposta":
+		_, err = ConsultarProposta(args)
+	case "listarPropostas":
+		err = ListarPropostas(args)
+	case "listarFuncoes":
+		err = ListarFuncoes(args)
+	case "versao":
+		err = Versao(args)
+	case "agingRecebiveis":
+		_, err = AgingRecebiveis(args)
+	case "aceitarProposta":
+		_, err = AceitarProposta(args)
+	case "emitirBoleto":
+		_, err = EmitirBoleto(args)
+	case "confirmarPagamento":
+		_, err = ConfirmarPagamento(args)
+	case "cancelarProposta":
+		_, err = CancelarProposta(args)
+	case "expurgarRequisicoes":
+		err = ExpurgarRequisicoes(args)
+	case "registrarOraculo":
+		_, err = RegistrarOraculo(args)
+	case "executarLote":
+		_, err = ExecutarLote(args)
+	case "registrarConciliacao":
+		_, err = RegistrarConciliacao(args)
+	case "consultarConciliacao":
+		_, err = ConsultarConciliacao(args)
+	case "listarStatusConciliacao":
+		_, err = ListarStatusConciliacao(args)
+	case "registrarCobrancaPix":
+		_, err = RegistrarCobrancaPix(args)
+	case "gerarBRCode":
+		_, err = GerarBRCode(args)
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/CaueP/BlockchainDojo/envelope"
+)
+
+// codigo: código do envelope do erro ("" se err for nil)
+func codigo(t *testing.T, err error) envelope.Codigo {
+	if err == nil {
+		return ""
+	}
+	e, ok := envelope.Decodificar(err)
+	if !ok {
+		t.Fatalf("erro fora do envelope: %s", err)
+	}
+	return e.Codigo
+}
+
+func TestArgumentosDocumento(t *testing.T) {
+	casos := []struct {
+		funcao    string
+		documento string
+		args      []string
+	}{
+		{"registrarProposta", `{"id_proposta": "a1", "cpf_pagador": "123", "pagador_aceitou": true, "beneficiario_aceitou": false, "boleto_pago": false}`,
+			[]string{"a1", "123", "true", "false", "false"}},
+		{"registrarProposta", `{"id_proposta": "a1", "cpf_pagador": "123", "pagador_aceitou": true, "beneficiario_aceitou": true, "boleto_pago": false, "nosso_numero": "0001", "valor": 15000}`,
+			[]string{"a1", "123", "true", "true", "false", "0001", "15000"}},
+		{"aceitarProposta", `{"id_proposta": "a1", "parte": "pagador"}`, []string{"a1", "pagador"}},
+		{"emitirBoleto", `{"id_proposta": "a1", "nosso_numero": "0001", "valor": 15000}`, []string{"a1", "0001", "15000"}},
+		{"emitirBoleto", `{"id_proposta": "a1", "nosso_numero": "0001", "valor": 15000, "beneficiario": "111"}`, []string{"a1", "0001", "15000", "", "111"}},
+		{"emitirBoleto", `{"id_proposta": "a1", "nosso_numero": "0001", "valor": 15000, "data_vencimento": "2026-11-10", "beneficiario": "111"}`,
+			[]string{"a1", "0001", "15000", "2026-11-10", "111"}},
+		{"cancelarProposta", `{"id_requisicao": "r1", "id_proposta": "a1", "motivo": "desistência"}`, []string{"a1", "desistência"}},
+		{"registrarCobrancaPix", `{"id_proposta": "a1"}`, []string{"a1"}},
+		{"registrarCobrancaPix", `{"id_proposta": "a1", "valor": 15000}`, []string{"a1", "15000"}},
+		// funções sem esquema e argumentos posicionais são repassados sem alterações
+		{"consultarProposta", `{"id_proposta": "a1"}`, []string{`{"id_proposta": "a1"}`}},
+		{"aceitarProposta", `a1`, []string{"a1"}},
+	}
+	for _, c := range casos {
+		t.Run(c.funcao, func(t *testing.T) {
+			args, err := argumentosDocumento(c.funcao, []string{c.documento})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, c.args) {
+				t.Fatalf("argumentos %q, esperados %q", args, c.args)
+			}
+		})
+	}
+}
+
+func TestDocumentoInvalido(t *testing.T) {
+	casos := []struct {
+		nome      string
+		funcao    string
+		documento string
+		campos    []string
+	}{
+		{"JSON mal formado", "aceitarProposta", `{"id_proposta": `, []string{"/:json"}},
+		{"conteúdo após o documento", "aceitarProposta", `{"id_proposta": "a1", "parte": "pagador"} {}`, []string{"/:json"}},
+		{"campo obrigatório", "aceitarProposta", `{"id_proposta": "a1"}`, []string{"/parte:required"}},
+		{"parte fora do enum", "aceitarProposta", `{"id_proposta": "a1", "parte": "banco"}`, []string{"/parte:enum"}},
+		{"campo desconhecido", "cancelarProposta", `{"id_proposta": "a1", "motivo": "", "Motivo": ""}`, []string{"/Motivo:additionalProperties"}},
+		{"nosso número sem valor", "registrarProposta", `{"id_proposta": "a1", "cpf_pagador": "1", "pagador_aceitou": true, "beneficiario_aceitou": true, "boleto_pago": false, "nosso_numero": "1"}`,
+			[]string{"/valor:dependentRequired"}},
+		{"valor do boleto zero", "emitirBoleto", `{"id_proposta": "a1", "nosso_numero": "1", "valor": 0}`, []string{"/valor:exclusiveMinimum"}},
+		{"valor em reais", "emitirBoleto", `{"id_proposta": "a1", "nosso_numero": "1", "valor": 150.5}`, []string{"/valor:type"}},
+		{"data de vencimento", "emitirBoleto", `{"id_proposta": "a1", "nosso_numero": "1", "valor": 1, "data_vencimento": "10/11/2026"}`, []string{"/data_vencimento:pattern"}},
+		{"valor da cobrança PIX", "registrarCobrancaPix", `{"id_proposta": "a1", "valor": -1}`, []string{"/valor:exclusiveMinimum"}},
+		{"atestado sem assinatura", "confirmarPagamento", `{"id_proposta": "a1", "atestado": {"codigo_banco": "001", "nosso_numero": "1", "valor": 1, "data_pagamento": "2026-11-10"}}`,
+			[]string{"/atestado/assinatura:required"}},
+		{"txid sem ID fim a fim", "confirmarPagamento", `{"id_proposta": "a1", "atestado": {"codigo_banco": "001", "txid": "a1", "valor": 1, "data_pagamento": "2026-11-10", "assinatura": "x"}}`,
+			[]string{"/atestado/end_to_end_id:dependentRequired"}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			_, err := argumentosDocumento(c.funcao, []string{c.documento})
+			e, ok := envelope.Decodificar(err)
+			if !ok || e.Codigo != envelope.DocumentoInvalido {
+				t.Fatalf("erro = %v, esperado %s", err, envelope.DocumentoInvalido)
+			}
+			var campos []string
+			for _, campo := range e.Campos {
+				campos = append(campos, campo.Caminho+":"+campo.Regra)
+				if campo.Mensagem == "" {
+					t.Errorf("violação %s sem mensagem", campo.Caminho)
+				}
+			}
+			if !reflect.DeepEqual(campos, c.campos) {
+				t.Fatalf("violações %v, esperadas %v", campos, c.campos)
+			}
+		})
+	}
+}
+
+func TestConfirmarPagamento(t *testing.T) {
+	casos := []struct {
+		nome   string
+		args   []string
+		codigo envelope.Codigo
+	}{
+		{"documento", []string{`{"id_proposta": "a1", "atestado": {"codigo_banco": "001", "nosso_numero": "1", "valor": 15000, "data_pagamento": "2026-11-10", "assinatura": "MEUCIQ=="}}`}, ""},
+		{"posicional", []string{"a1", `{"codigo_banco": "001", "nosso_numero": "1", "valor": 15000, "data_pagamento": "2026-11-10", "assinatura": "MEUCIQ=="}`}, ""},
+		{"quantidade de argumentos", []string{"a1"}, envelope.ArgumentosInvalidos},
+		{"atestado mal formado", []string{"a1", `{"valor": `}, envelope.AtestadoInvalido},
+		{"atestado sem nosso número", []string{"a1", `{"codigo_banco": "001", "valor": 15000, "data_pagamento": "2026-11-10", "assinatura": "MEUCIQ=="}`}, envelope.AtestadoInvalido},
+		{"data do pagamento", []string{"a1", `{"codigo_banco": "001", "nosso_numero": "1", "valor": 15000, "data_pagamento": "2026-11-31", "assinatura": "MEUCIQ=="}`}, envelope.AtestadoInvalido},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			p, err := ConfirmarPagamento(c.args)
+			if cod := codigo(t, err); cod != c.codigo {
+				t.Fatalf("erro = %v, esperado %q", err, c.codigo)
+			}
+			if err == nil && (p.ID != "a1" || p.Atestado.Valor != 15000 || p.Atestado.Assinatura != "MEUCIQ==") {
+				t.Fatalf("pagamento %+v", p)
+			}
+		})
+	}
+}
+
+func TestExecutarLote(t *testing.T) {
+	lote, err := ExecutarLote([]string{`{"id_requisicao": "lote-1", "operacoes": [
+		{"funcao": "aceitarProposta", "documento": {"id_proposta": "a1", "parte": "pagador"}},
+		{"funcao": "confirmarPagamento", "documento": {"id_proposta": "a1"}}]}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperado := Lote{Operacoes: []OperacaoLote{
+		{Funcao: "aceitarProposta", Argumentos: []string{`{"id_proposta":"a1","parte":"pagador"}`}},
+		{Funcao: "confirmarPagamento", Argumentos: []string{`{"id_proposta":"a1"}`}},
+	}}
+	if !reflect.DeepEqual(lote, esperado) {
+		t.Fatalf("lote %+v, esperado %+v", lote, esperado)
+	}
+
+	casos := []struct {
+		nome   string
+		args   []string
+		codigo envelope.Codigo
+	}{
+		{"posicional", []string{"aceitarProposta"}, envelope.ArgumentosInvalidos},
+		{"sem operações", []string{`{"operacoes": []}`}, envelope.DocumentoInvalido},
+		{"função fora do lote", []string{`{"operacoes": [{"funcao": "emitirBoleto", "documento": {}}]}`}, envelope.DocumentoInvalido},
+		{"sem documento", []string{`{"operacoes": [{"funcao": "cancelarProposta"}]}`}, envelope.DocumentoInvalido},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if _, err := ExecutarLote(c.args); codigo(t, err) != c.codigo {
+				t.Fatalf("erro = %v, esperado %s", err, c.codigo)
+			}
+		})
+	}
+}
+
+func TestRegistrarConciliacao(t *testing.T) {
+	c, err := RegistrarConciliacao([]string{`{"id_conciliacao": "c1", "inicio": "2026-11-01", "fim": "2026-11-30", "itens": [
+		{"situacao": "conciliado", "id_proposta": "a1", "id_lancamento": "L1", "valor_extrato": 15000, "data_extrato": "2026-11-10", "criterio": "nosso_numero"},
+		{"situacao": "somente_extrato", "id_lancamento": "L2", "valor_extrato": 100, "data_extrato": "2026-11-11"}]}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != "c1" || len(c.Itens) != 2 || c.Itens[0].IDProposta != "a1" {
+		t.Fatalf("conciliação %+v", c)
+	}
+
+	casos := []struct {
+		nome      string
+		documento string
+		codigo    envelope.Codigo
+	}{
+		{"situação desconhecida", `{"id_conciliacao": "c1", "itens": [{"situacao": "pago"}]}`, envelope.DocumentoInvalido},
+		{"valor sem data", `{"id_conciliacao": "c1", "itens": [{"situacao": "somente_extrato", "valor_extrato": 1}]}`, envelope.DocumentoInvalido},
+		{"divergência sem proposta", `{"id_conciliacao": "c1", "itens": [{"situacao": "valor_divergente"}]}`, envelope.CampoObrigatorio},
+		{"data inexistente", `{"id_conciliacao": "c1", "inicio": "2026-02-30", "itens": []}`, envelope.ArgumentoInvalido},
+		{"fim antes do início", `{"id_conciliacao": "c1", "inicio": "2026-11-30", "fim": "2026-11-01", "itens": []}`, envelope.ArgumentoInvalido},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if _, err := RegistrarConciliacao([]string{c.documento}); codigo(t, err) != c.codigo {
+				t.Fatalf("erro = %v, esperado %s", err, c.codigo)
+			}
+		})
+	}
+}
+
+func TestRegistrarCobrancaPix(t *testing.T) {
+	casos := []struct {
+		nome   string
+		args   []string
+		valor  int64
+		codigo envelope.Codigo
+	}{
+		{"valor da proposta", []string{"a1"}, 0, ""},
+		{"valor informado", []string{"a1", "15000"}, 15000, ""},
+		{"documento", []string{`{"id_proposta": "a1", "valor": 15000}`}, 15000, ""},
+		{"sem proposta", []string{""}, 0, envelope.CampoObrigatorio},
+		{"valor zero", []string{"a1", "0"}, 0, envelope.ArgumentoInvalido},
+		{"valor em reais", []string{"a1", "150,00"}, 0, envelope.ArgumentoInvalido},
+		{"argumentos demais", []string{"a1", "1", "2"}, 0, envelope.ArgumentosInvalidos},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			cobranca, err := RegistrarCobrancaPix(c.args)
+			if cod := codigo(t, err); cod != c.codigo {
+				t.Fatalf("erro = %v, esperado %q", err, c.codigo)
+			}
+			if err == nil && cobranca.Valor != c.valor {
+				t.Fatalf("valor %d, esperado %d", cobranca.Valor, c.valor)
+			}
+		})
+	}
+}
+
+func TestIDRequisicao(t *testing.T) {
+	casos := []struct {
+		nome   string
+		args   []string
+		id     string
+		codigo envelope.Codigo
+	}{
+		{"posicional", ComIDRequisicao("r-1", []string{"a1", "pagador"}), "r-1", ""},
+		{"documento", []string{`{"id_requisicao": "r-2", "id_proposta": "a1"}`}, "r-2", ""},
+		{"sem ID", []string{"a1", "pagador"}, "", ""},
+		{"separador de chave", ComIDRequisicao("r/1", []string{"a1"}), "", envelope.ArgumentoInvalido},
+		{"documento com ID vazio", []string{`{"id_requisicao": "", "id_proposta": "a1"}`}, "", envelope.ArgumentoInvalido},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			id, _, err := IDRequisicao(c.args)
+			if cod := codigo(t, err); cod != c.codigo || id != c.id {
+				t.Fatalf("ID %q, erro = %v; esperado %q, %q", id, err, c.id, c.codigo)
+			}
+		})
+	}
+
+	// Validar separa o ID antes de validar os argumentos da função
+	if err := Validar("aceitarProposta", ComIDRequisicao("r-1", []string{"a1", "pagador"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := Validar("aceitarProposta", []string{`{"id_requisicao": "r-1", "id_proposta": "a1", "parte": "banco"}`}); codigo(t, err) != envelope.DocumentoInvalido {
+		t.Fatalf("erro = %v, esperado %s", err, envelope.DocumentoInvalido)
+	}
+}